@@ -0,0 +1,42 @@
+package common
+
+// 管理权限标识，管理员接口按路由分组校验
+const (
+	PermissionUserRead       = "user.read"       // 查看用户
+	PermissionUserManage     = "user.manage"     // 编辑、封禁、删除用户及处理注销申请
+	PermissionLogRead        = "log.read"        // 查看使用日志与任务记录
+	PermissionLogManage      = "log.manage"      // 清理历史日志
+	PermissionLogContent     = "log.content"     // 查看请求与响应内容日志
+	PermissionDataRead       = "data.read"       // 查看数据看板与利润报表
+	PermissionChannelRead    = "channel.read"    // 查看渠道
+	PermissionChannelManage  = "channel.manage"  // 新增、编辑、测试渠道
+	PermissionModelManage    = "model.manage"    // 模型、供应商、分组与部署
+	PermissionBillingManage  = "billing.manage"  // 兑换码、注册码、充值、订阅及用户额度
+	PermissionFarmManage     = "farm.manage"     // 农场、机器人与赛季管理
+	PermissionFeedbackManage = "feedback.manage" // 留言反馈处理
+)
+
+// AllAdminPermissions 全部权限，按展示顺序排列
+var AllAdminPermissions = []string{
+	PermissionUserRead,
+	PermissionUserManage,
+	PermissionLogRead,
+	PermissionLogManage,
+	PermissionLogContent,
+	PermissionDataRead,
+	PermissionChannelRead,
+	PermissionChannelManage,
+	PermissionModelManage,
+	PermissionBillingManage,
+	PermissionFarmManage,
+	PermissionFeedbackManage,
+}
+
+func IsValidAdminPermission(permission string) bool {
+	for _, p := range AllAdminPermissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
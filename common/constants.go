@@ -76,6 +76,9 @@ var MemoryCacheEnabled bool
 var LogConsumeEnabled = true
 
 var TLSInsecureSkipVerify bool
+
+// MetricsToken 为空时不开放 /metrics
+var MetricsToken string
 var InsecureTLSConfig = &tls.Config{InsecureSkipVerify: true}
 
 var EmailAPIUrl = ""
@@ -0,0 +1,10 @@
+-- 释放一个并发名额，计数归零时删除 key
+-- KEYS[1]: 计数器唯一标识
+
+local key = KEYS[1]
+local current = redis.call('DECR', key)
+if current <= 0 then
+    redis.call('DEL', key)
+    return 0
+end
+return current
@@ -0,0 +1,36 @@
+-- 固定窗口计数器
+-- KEYS[1]: 计数器唯一标识
+-- ARGV[1]: 窗口内允许的上限
+-- ARGV[2]: 窗口长度（毫秒）
+-- ARGV[3]: 本次消耗（为 0 时只检查是否已达上限）
+-- ARGV[4]: 是否强制累加（1 表示不做限制，直接记录实际用量）
+
+local key = KEYS[1]
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local cost = tonumber(ARGV[3])
+local force = tonumber(ARGV[4])
+
+local current = tonumber(redis.call('GET', key) or '0')
+
+local allowed = 1
+if force ~= 1 then
+    if (cost > 0 and current + cost > limit) or (cost == 0 and current >= limit) then
+        allowed = 0
+    end
+end
+
+if allowed == 1 and cost > 0 then
+    current = redis.call('INCRBY', key, cost)
+end
+
+-- 新窗口（或异常丢失过期时间）时设置过期
+local ttl = redis.call('PTTL', key)
+if ttl < 0 and current > 0 then
+    redis.call('PEXPIRE', key, window)
+    ttl = window
+elseif ttl < 0 then
+    ttl = window
+end
+
+return {allowed, current, ttl}
@@ -0,0 +1,177 @@
+package limiter
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/go-redis/redis/v8"
+)
+
+//go:embed lua/window_limit.lua
+var windowLimitLua string
+
+//go:embed lua/concurrency_limit.lua
+var concurrencyLimitLua string
+
+//go:embed lua/concurrency_release.lua
+var concurrencyReleaseLua string
+
+var (
+	windowLimitScript        = redis.NewScript(windowLimitLua)
+	concurrencyLimitScript   = redis.NewScript(concurrencyLimitLua)
+	concurrencyReleaseScript = redis.NewScript(concurrencyReleaseLua)
+)
+
+// WindowResult 固定窗口限流结果
+type WindowResult struct {
+	Allowed   bool
+	Limit     int64
+	Remaining int64
+	Reset     time.Duration // 距离当前窗口重置的时间
+}
+
+func useRedis() bool {
+	return common.RedisEnabled && common.RDB != nil
+}
+
+// AllowWindow 在固定窗口内检查并累加消耗；cost 为 0 时只检查窗口内用量是否已达上限
+func AllowWindow(ctx context.Context, key string, limit int64, window time.Duration, cost int64) (*WindowResult, error) {
+	return evalWindow(ctx, key, limit, window, cost, false)
+}
+
+// AddWindow 不做限制地累加窗口内用量，用于请求结束后记录实际消耗
+func AddWindow(ctx context.Context, key string, window time.Duration, cost int64) error {
+	if cost <= 0 {
+		return nil
+	}
+	_, err := evalWindow(ctx, key, 0, window, cost, true)
+	return err
+}
+
+func evalWindow(ctx context.Context, key string, limit int64, window time.Duration, cost int64, force bool) (*WindowResult, error) {
+	var (
+		allowed bool
+		current int64
+		reset   time.Duration
+	)
+	if useRedis() {
+		forceArg := 0
+		if force {
+			forceArg = 1
+		}
+		values, err := windowLimitScript.Run(ctx, common.RDB, []string{key}, limit, window.Milliseconds(), cost, forceArg).Int64Slice()
+		if err != nil {
+			return nil, fmt.Errorf("window limit failed: %w", err)
+		}
+		if len(values) != 3 {
+			return nil, fmt.Errorf("window limit failed: unexpected result %v", values)
+		}
+		allowed, current, reset = values[0] == 1, values[1], time.Duration(values[2])*time.Millisecond
+	} else {
+		allowed, current, reset = memoryStore.allowWindow(key, limit, window, cost, force)
+	}
+	remaining := limit - current
+	if remaining < 0 {
+		remaining = 0
+	}
+	return &WindowResult{Allowed: allowed, Limit: limit, Remaining: remaining, Reset: reset}, nil
+}
+
+// AcquireConcurrency 占用一个并发名额，返回是否成功以及当前并发数
+func AcquireConcurrency(ctx context.Context, key string, limit int64, ttl time.Duration) (bool, int64, error) {
+	if !useRedis() {
+		ok, current := memoryStore.acquire(key, limit)
+		return ok, current, nil
+	}
+	values, err := concurrencyLimitScript.Run(ctx, common.RDB, []string{key}, limit, ttl.Milliseconds()).Int64Slice()
+	if err != nil {
+		return false, 0, fmt.Errorf("concurrency limit failed: %w", err)
+	}
+	if len(values) != 2 {
+		return false, 0, fmt.Errorf("concurrency limit failed: unexpected result %v", values)
+	}
+	return values[0] == 1, values[1], nil
+}
+
+// ReleaseConcurrency 释放 AcquireConcurrency 占用的名额
+func ReleaseConcurrency(ctx context.Context, key string) error {
+	if !useRedis() {
+		memoryStore.release(key)
+		return nil
+	}
+	return concurrencyReleaseScript.Run(ctx, common.RDB, []string{key}).Err()
+}
+
+type memoryWindow struct {
+	count   int64
+	resetAt time.Time
+}
+
+// memoryLimiter Redis 未启用时的单机实现
+type memoryLimiter struct {
+	mu          sync.Mutex
+	windows     map[string]*memoryWindow
+	concurrency map[string]int64
+	lastSweep   time.Time
+}
+
+var memoryStore = &memoryLimiter{
+	windows:     make(map[string]*memoryWindow),
+	concurrency: make(map[string]int64),
+}
+
+func (m *memoryLimiter) allowWindow(key string, limit int64, window time.Duration, cost int64, force bool) (bool, int64, time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.sweepLocked(now)
+	w, ok := m.windows[key]
+	if !ok || !now.Before(w.resetAt) {
+		w = &memoryWindow{resetAt: now.Add(window)}
+		m.windows[key] = w
+	}
+	if !force && ((cost > 0 && w.count+cost > limit) || (cost == 0 && w.count >= limit)) {
+		return false, w.count, w.resetAt.Sub(now)
+	}
+	w.count += cost
+	return true, w.count, w.resetAt.Sub(now)
+}
+
+// sweepLocked 每分钟清理一次已过期的窗口
+func (m *memoryLimiter) sweepLocked(now time.Time) {
+	if now.Sub(m.lastSweep) < time.Minute {
+		return
+	}
+	m.lastSweep = now
+	for key, w := range m.windows {
+		if !now.Before(w.resetAt) {
+			delete(m.windows, key)
+		}
+	}
+}
+
+func (m *memoryLimiter) acquire(key string, limit int64) (bool, int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	current := m.concurrency[key]
+	if current >= limit {
+		return false, current
+	}
+	m.concurrency[key] = current + 1
+	return true, current + 1
+}
+
+func (m *memoryLimiter) release(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.concurrency[key] <= 1 {
+		delete(m.concurrency, key)
+		return
+	}
+	m.concurrency[key]--
+}
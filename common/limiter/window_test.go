@@ -0,0 +1,63 @@
+package limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllowWindow_Memory(t *testing.T) {
+	ctx := context.Background()
+	key := "test:window:rpm"
+
+	for i := 0; i < 3; i++ {
+		result, err := AllowWindow(ctx, key, 3, time.Minute, 1)
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+		require.Equal(t, int64(2-i), result.Remaining)
+	}
+	result, err := AllowWindow(ctx, key, 3, time.Minute, 1)
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+	require.Greater(t, result.Reset, time.Duration(0))
+	require.LessOrEqual(t, result.Reset, time.Minute)
+}
+
+func TestAllowWindow_MemoryTokensRecordedAfterRequest(t *testing.T) {
+	ctx := context.Background()
+	key := "test:window:tpm"
+
+	result, err := AllowWindow(ctx, key, 100, time.Minute, 0)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+
+	// 实际用量可以超过上限，超出后后续请求被拒绝
+	require.NoError(t, AddWindow(ctx, key, time.Minute, 150))
+	result, err = AllowWindow(ctx, key, 100, time.Minute, 0)
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+	require.Zero(t, result.Remaining)
+}
+
+func TestConcurrency_Memory(t *testing.T) {
+	ctx := context.Background()
+	key := "test:concurrency"
+
+	ok, _, err := AcquireConcurrency(ctx, key, 2, time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, current, err := AcquireConcurrency(ctx, key, 2, time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, int64(2), current)
+	ok, _, err = AcquireConcurrency(ctx, key, 2, time.Minute)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, ReleaseConcurrency(ctx, key))
+	ok, _, err = AcquireConcurrency(ctx, key, 2, time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+}
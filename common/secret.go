@@ -0,0 +1,186 @@
+package common
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// 上游凭据（渠道 key、OAuth client secret、支付密钥等）的信封加密。
+// 每个值使用随机生成的数据密钥（DEK）以 AES-256-GCM 加密，DEK 再由主密钥（KEK）加密后与密文一起保存：
+//
+//	enc:v1:<kek id>:<base64(wrapped dek)>:<base64(ciphertext)>
+//
+// 主密钥来自 SECRET_MASTER_KEY 或 SECRET_MASTER_KEY_FILE；未配置时不加密，保持明文存储。
+// 轮换主密钥时把旧密钥放入 SECRET_MASTER_KEY_OLD（多个用逗号分隔），再执行 --rotate-secret-key。
+
+const secretEnvelopePrefix = "enc:v1:"
+
+type secretMasterKey struct {
+	id  string
+	key []byte
+}
+
+var (
+	secretCurrentKey *secretMasterKey
+	secretKeys       = map[string]*secretMasterKey{}
+)
+
+func newSecretMasterKey(material string) *secretMasterKey {
+	sum := sha256.Sum256([]byte(material))
+	idSum := sha256.Sum256(sum[:])
+	return &secretMasterKey{id: hex.EncodeToString(idSum[:4]), key: sum[:]}
+}
+
+// InitSecretMasterKey 从环境变量读取主密钥
+func InitSecretMasterKey() error {
+	material := strings.TrimSpace(os.Getenv("SECRET_MASTER_KEY"))
+	if material == "" {
+		if path := strings.TrimSpace(os.Getenv("SECRET_MASTER_KEY_FILE")); path != "" {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("failed to read SECRET_MASTER_KEY_FILE: %w", err)
+			}
+			material = strings.TrimSpace(string(data))
+			if material == "" {
+				return errors.New("SECRET_MASTER_KEY_FILE is empty")
+			}
+		}
+	}
+	var oldMaterials []string
+	for _, old := range strings.Split(os.Getenv("SECRET_MASTER_KEY_OLD"), ",") {
+		if old = strings.TrimSpace(old); old != "" {
+			oldMaterials = append(oldMaterials, old)
+		}
+	}
+	SetSecretMasterKeys(material, oldMaterials...)
+	return nil
+}
+
+// SetSecretMasterKeys 设置当前主密钥以及仅用于解密的旧密钥，current 为空表示关闭加密
+func SetSecretMasterKeys(current string, old ...string) {
+	secretCurrentKey = nil
+	secretKeys = map[string]*secretMasterKey{}
+	for _, material := range old {
+		key := newSecretMasterKey(material)
+		secretKeys[key.id] = key
+	}
+	if current != "" {
+		secretCurrentKey = newSecretMasterKey(current)
+		secretKeys[secretCurrentKey.id] = secretCurrentKey
+	}
+}
+
+func SecretEncryptionEnabled() bool {
+	return secretCurrentKey != nil
+}
+
+func IsEncryptedSecret(value string) bool {
+	return strings.HasPrefix(value, secretEnvelopePrefix)
+}
+
+// SecretNeedsRotation 明文值或由非当前主密钥加密的值需要重新加密
+func SecretNeedsRotation(value string) bool {
+	if value == "" || secretCurrentKey == nil {
+		return false
+	}
+	if !IsEncryptedSecret(value) {
+		return true
+	}
+	kid, _, _ := strings.Cut(strings.TrimPrefix(value, secretEnvelopePrefix), ":")
+	return kid != secretCurrentKey.id
+}
+
+func gcmSeal(key []byte, plaintext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func gcmOpen(key []byte, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonceSize := gcm.NonceSize()
+	if len(data) < nonceSize {
+		return nil, errors.New("ciphertext too short")
+	}
+	return gcm.Open(nil, data[:nonceSize], data[nonceSize:], nil)
+}
+
+// EncryptSecret 使用当前主密钥加密；未开启加密、空值或已经加密的值原样返回
+func EncryptSecret(plaintext string) (string, error) {
+	if plaintext == "" || secretCurrentKey == nil || IsEncryptedSecret(plaintext) {
+		return plaintext, nil
+	}
+	dek := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, dek); err != nil {
+		return "", err
+	}
+	wrapped, err := gcmSeal(secretCurrentKey.key, dek)
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := gcmSeal(dek, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+	return secretEnvelopePrefix + secretCurrentKey.id + ":" +
+		base64.StdEncoding.EncodeToString(wrapped) + ":" +
+		base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// DecryptSecret 解密信封格式的值，明文值原样返回
+func DecryptSecret(value string) (string, error) {
+	if !IsEncryptedSecret(value) {
+		return value, nil
+	}
+	parts := strings.Split(strings.TrimPrefix(value, secretEnvelopePrefix), ":")
+	if len(parts) != 3 {
+		return "", errors.New("invalid encrypted secret")
+	}
+	key, ok := secretKeys[parts[0]]
+	if !ok {
+		return "", fmt.Errorf("secret is encrypted with unknown master key %s", parts[0])
+	}
+	wrapped, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", err
+	}
+	dek, err := gcmOpen(key.key, wrapped)
+	if err != nil {
+		return "", fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	plaintext, err := gcmOpen(dek, ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+	return string(plaintext), nil
+}
@@ -0,0 +1,34 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretEnvelopeRotation(t *testing.T) {
+	t.Cleanup(func() { SetSecretMasterKeys("") })
+
+	SetSecretMasterKeys("")
+	plain, err := EncryptSecret("sk-test")
+	require.NoError(t, err)
+	require.Equal(t, "sk-test", plain, "encryption is disabled without a master key")
+
+	SetSecretMasterKeys("old-master-key")
+	encrypted, err := EncryptSecret("sk-test")
+	require.NoError(t, err)
+	require.True(t, IsEncryptedSecret(encrypted))
+	require.NotContains(t, encrypted, "sk-test")
+	require.False(t, SecretNeedsRotation(encrypted))
+	require.True(t, SecretNeedsRotation("sk-plain"))
+
+	SetSecretMasterKeys("new-master-key", "old-master-key")
+	require.True(t, SecretNeedsRotation(encrypted))
+	decrypted, err := DecryptSecret(encrypted)
+	require.NoError(t, err)
+	require.Equal(t, "sk-test", decrypted)
+
+	SetSecretMasterKeys("new-master-key")
+	_, err = DecryptSecret(encrypted)
+	require.Error(t, err)
+}
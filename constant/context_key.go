@@ -9,6 +9,8 @@ const (
 
 	ContextKeyOriginalModel    ContextKey = "original_model"
 	ContextKeyRequestStartTime ContextKey = "request_start_time"
+	// ContextKeyModelFallbackFrom stores the primary model when distribution already fell back to another model
+	ContextKeyModelFallbackFrom ContextKey = "model_fallback_from"
 
 	/* token related keys */
 	ContextKeyTokenUnlimited         ContextKey = "token_unlimited_quota"
@@ -19,6 +21,12 @@ const (
 	ContextKeyTokenModelLimitEnabled ContextKey = "token_model_limit_enabled"
 	ContextKeyTokenModelLimit        ContextKey = "token_model_limit"
 	ContextKeyTokenCrossGroupRetry   ContextKey = "token_cross_group_retry"
+	ContextKeyTokenRpmLimit          ContextKey = "token_rpm_limit"
+	ContextKeyTokenTpmLimit          ContextKey = "token_tpm_limit"
+	ContextKeyTokenConcurrencyLimit  ContextKey = "token_concurrency_limit"
+	ContextKeyTokenContentLog        ContextKey = "token_content_log"
+	ContextKeyTokenModelFallbacks    ContextKey = "token_model_fallbacks"
+	ContextKeyTokenOrgId             ContextKey = "token_org_id"
 
 	/* channel related keys */
 	ContextKeyChannelId                ContextKey = "channel_id"
@@ -40,6 +48,8 @@ const (
 	// ContextKeyChannelTriedKeyIndices stores per-request failed key indices: map[channelId]map[keyIndex]bool
 	// Used to skip previously-failed keys when retrying the same multi-key channel within one request.
 	ContextKeyChannelTriedKeyIndices ContextKey = "channel_tried_key_indices"
+	// ContextKeyChannelKeyLease stores the *model.ChannelKeyLease of the selected multi-key index (in-flight tracking)
+	ContextKeyChannelKeyLease ContextKey = "channel_key_lease"
 
 	ContextKeyAutoGroup           ContextKey = "auto_group"
 	ContextKeyAutoGroupIndex      ContextKey = "auto_group_index"
@@ -68,4 +78,15 @@ const (
 
 	// ContextKeyLanguage stores the user's language preference for i18n
 	ContextKeyLanguage ContextKey = "language"
+
+	// ContextKeyRelayUsage stores the final *dto.Usage of a successful relay, used by the response cache and token TPM limits.
+	ContextKeyRelayUsage ContextKey = "relay_usage"
+
+	// ContextKeyClaudeStructuredOutput marks that response_format was converted to a forced Claude tool call,
+	// so the tool arguments must be restored as the assistant text content.
+	ContextKeyClaudeStructuredOutput ContextKey = "claude_structured_output"
+
+	// ContextKeyAuditRecorded marks that the handler already wrote a detailed audit log,
+	// so the route-level audit middleware skips its request-body fallback entry.
+	ContextKeyAuditRecorded ContextKey = "audit_recorded"
 )
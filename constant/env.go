@@ -17,6 +17,9 @@ var GenerateDefaultToken bool
 var ErrorLogEnabled bool
 var TaskQueryLimit int
 var TaskTimeoutMinutes int
+var FileStoreDir string
+var MaxFileUploadMB int
+var BatchWorkerConcurrency int
 
 // temporary variable for sora patch, will be removed in future
 var TaskPricePatches []string
@@ -3,6 +3,17 @@ package constant
 type MultiKeyMode string
 
 const (
-	MultiKeyModeRandom  MultiKeyMode = "random"  // 随机
-	MultiKeyModePolling MultiKeyMode = "polling" // 轮询
+	MultiKeyModeRandom        MultiKeyMode = "random"          // 随机
+	MultiKeyModePolling       MultiKeyMode = "polling"         // 轮询
+	MultiKeyModeLeastInFlight MultiKeyMode = "least_in_flight" // 在途请求最少
+	MultiKeyModeWeighted      MultiKeyMode = "weighted"        // 按 key 权重随机
+	MultiKeyModeCooldown      MultiKeyMode = "cooldown"        // 随机，429 后按 Retry-After 冷却
 )
+
+func (m MultiKeyMode) IsValid() bool {
+	switch m {
+	case MultiKeyModeRandom, MultiKeyModePolling, MultiKeyModeLeastInFlight, MultiKeyModeWeighted, MultiKeyModeCooldown:
+		return true
+	}
+	return false
+}
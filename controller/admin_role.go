@@ -0,0 +1,142 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/model"
+	"github.com/QuantumNous/new-api/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetAdminRoles 获取管理角色列表
+func GetAdminRoles(c *gin.Context) {
+	roles, err := model.GetAllAdminRoles()
+	if err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	common.ApiSuccess(c, roles)
+}
+
+// GetAdminPermissions 获取可分配的权限标识
+func GetAdminPermissions(c *gin.Context) {
+	common.ApiSuccess(c, common.AllAdminPermissions)
+}
+
+func validateAdminRole(role *model.AdminRole) error {
+	role.Name = strings.TrimSpace(role.Name)
+	if role.Name == "" {
+		return errors.New("角色名称不能为空")
+	}
+	for _, p := range role.Permissions {
+		if !common.IsValidAdminPermission(p) {
+			return fmt.Errorf("未知的权限标识：%s", p)
+		}
+	}
+	dup, err := model.IsAdminRoleNameDuplicated(role.Id, role.Name)
+	if err != nil {
+		return err
+	}
+	if dup {
+		return errors.New("角色名称已存在")
+	}
+	return nil
+}
+
+// CreateAdminRole 创建管理角色
+func CreateAdminRole(c *gin.Context) {
+	var role model.AdminRole
+	if err := c.ShouldBindJSON(&role); err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	role.Id = 0
+	if err := validateAdminRole(&role); err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	if err := role.Insert(); err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	service.RecordAudit(c, model.AuditTargetAdminRole, role.Id, model.AuditActionCreate, nil, &role)
+	common.ApiSuccess(c, &role)
+}
+
+// UpdateAdminRole 更新管理角色
+func UpdateAdminRole(c *gin.Context) {
+	var role model.AdminRole
+	if err := c.ShouldBindJSON(&role); err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	originRole, err := model.GetAdminRoleById(role.Id)
+	if err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	if err := validateAdminRole(&role); err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	if err := role.Update(); err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	service.RecordAudit(c, model.AuditTargetAdminRole, role.Id, model.AuditActionUpdate, originRole, &role)
+	common.ApiSuccess(c, &role)
+}
+
+// DeleteAdminRole 删除管理角色
+func DeleteAdminRole(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	originRole, err := model.GetAdminRoleById(id)
+	if err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	if err := model.DeleteAdminRole(id); err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	service.RecordAudit(c, model.AuditTargetAdminRole, id, model.AuditActionDelete, originRole, nil)
+	common.ApiSuccess(c, nil)
+}
+
+// SetUserAdminRole 为管理员分配管理角色，admin_role_id 为 0 表示恢复全部管理权限
+func SetUserAdminRole(c *gin.Context) {
+	var req struct {
+		UserId      int `json:"user_id"`
+		AdminRoleId int `json:"admin_role_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	user, err := model.GetUserById(req.UserId, false)
+	if err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	if user.Role != common.RoleAdminUser {
+		common.ApiErrorMsg(c, "只能为管理员分配管理角色")
+		return
+	}
+	if err := model.SetUserAdminRole(user.Id, req.AdminRoleId); err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	service.RecordAudit(c, model.AuditTargetUser, user.Id, model.AuditActionUpdate,
+		map[string]int{"admin_role_id": user.AdminRoleId}, map[string]int{"admin_role_id": req.AdminRoleId})
+	model.RecordLog(user.Id, model.LogTypeManage, fmt.Sprintf("超级管理员将管理角色设置为 %d", req.AdminRoleId))
+	common.ApiSuccess(c, nil)
+}
@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// auditLogExportLimit 单次导出的最大条数
+const auditLogExportLimit = 10000
+
+func getAuditLogQuery(c *gin.Context) model.AuditLogQuery {
+	startTimestamp, _ := strconv.ParseInt(c.Query("start_timestamp"), 10, 64)
+	endTimestamp, _ := strconv.ParseInt(c.Query("end_timestamp"), 10, 64)
+	return model.AuditLogQuery{
+		ActorName:      c.Query("actor_name"),
+		TargetType:     c.Query("target_type"),
+		TargetId:       c.Query("target_id"),
+		Action:         c.Query("action"),
+		StartTimestamp: startTimestamp,
+		EndTimestamp:   endTimestamp,
+	}
+}
+
+// GetAuditLogs 分页查询管理操作审计日志
+func GetAuditLogs(c *gin.Context) {
+	pageInfo := common.GetPageQuery(c)
+	logs, total, err := model.GetAuditLogs(getAuditLogQuery(c), pageInfo.GetStartIdx(), pageInfo.GetPageSize())
+	if err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	pageInfo.SetTotal(int(total))
+	pageInfo.SetItems(logs)
+	common.ApiSuccess(c, pageInfo)
+}
+
+// ExportAuditLogs 按筛选条件导出审计日志为 CSV
+func ExportAuditLogs(c *gin.Context) {
+	logs, _, err := model.GetAuditLogs(getAuditLogQuery(c), 0, auditLogExportLimit)
+	if err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	filename := fmt.Sprintf("audit_logs_%s.csv", time.Now().Format("20060102150405"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	// 写入 BOM，便于 Excel 正确识别 UTF-8
+	_, _ = c.Writer.Write([]byte("\xEF\xBB\xBF"))
+	writer := csv.NewWriter(c.Writer)
+	_ = writer.Write([]string{"id", "time", "actor_id", "actor_name", "actor_role", "ip", "target_type", "target_id", "action", "route", "diff"})
+	for _, log := range logs {
+		_ = writer.Write([]string{
+			strconv.Itoa(log.Id),
+			time.Unix(log.CreatedAt, 0).Format(time.RFC3339),
+			strconv.Itoa(log.ActorId),
+			log.ActorName,
+			strconv.Itoa(log.ActorRole),
+			log.Ip,
+			log.TargetType,
+			log.TargetId,
+			log.Action,
+			log.Route,
+			log.Diff,
+		})
+	}
+	writer.Flush()
+}
@@ -0,0 +1,133 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/dto"
+	"github.com/QuantumNous/new-api/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+const batchCompletionWindow = "24h"
+
+func CreateBatch(c *gin.Context) {
+	userId := c.GetInt("id")
+	var req dto.BatchCreateRequest
+	if err := common.UnmarshalBodyReusable(c, &req); err != nil {
+		openAIApiError(c, http.StatusBadRequest, "invalid request body: "+err.Error(), "invalid_request")
+		return
+	}
+	if _, ok := batchEndpointFormats[req.Endpoint]; !ok {
+		openAIApiError(c, http.StatusBadRequest, fmt.Sprintf("unsupported endpoint: '%s'", req.Endpoint), "invalid_endpoint")
+		return
+	}
+	if req.CompletionWindow != batchCompletionWindow {
+		openAIApiError(c, http.StatusBadRequest, "completion_window must be '24h'", "invalid_completion_window")
+		return
+	}
+	if len(req.Metadata) > 16 {
+		openAIApiError(c, http.StatusBadRequest, "metadata can contain at most 16 key-value pairs", "invalid_metadata")
+		return
+	}
+	inputFile, err := model.GetUserFileByFileId(userId, req.InputFileID)
+	if err != nil {
+		openAIApiError(c, http.StatusNotFound, fmt.Sprintf("No such File object: %s", req.InputFileID), "file_not_found")
+		return
+	}
+	if inputFile.Purpose != dto.FilePurposeBatch {
+		openAIApiError(c, http.StatusBadRequest, "input file must be uploaded with purpose 'batch'", "invalid_file_purpose")
+		return
+	}
+
+	now := common.GetTimestamp()
+	batch := &model.Batch{
+		BatchId:          model.NewBatchId(),
+		UserId:           userId,
+		TokenId:          c.GetInt("token_id"),
+		Endpoint:         req.Endpoint,
+		InputFileId:      inputFile.FileId,
+		CompletionWindow: req.CompletionWindow,
+		Status:           dto.BatchStatusValidating,
+		CreatedAt:        now,
+		ExpiresAt:        now + int64((24 * time.Hour).Seconds()),
+	}
+	batch.SetMetadata(req.Metadata)
+	if err := batch.Insert(); err != nil {
+		common.SysError("insert batch failed: " + err.Error())
+		openAIApiError(c, http.StatusInternalServerError, "failed to create batch", "batch_create_error")
+		return
+	}
+	c.JSON(http.StatusOK, batch.ToOpenAIBatch())
+}
+
+func getUserBatchOrAbort(c *gin.Context) *model.Batch {
+	batchId := c.Param("id")
+	batch, err := model.GetUserBatchByBatchId(c.GetInt("id"), batchId)
+	if err != nil {
+		openAIApiError(c, http.StatusNotFound, fmt.Sprintf("No such Batch object: %s", batchId), "batch_not_found")
+		return nil
+	}
+	return batch
+}
+
+func RetrieveBatch(c *gin.Context) {
+	batch := getUserBatchOrAbort(c)
+	if batch == nil {
+		return
+	}
+	c.JSON(http.StatusOK, batch.ToOpenAIBatch())
+}
+
+func CancelBatch(c *gin.Context) {
+	batch := getUserBatchOrAbort(c)
+	if batch == nil {
+		return
+	}
+	if batch.Status != dto.BatchStatusCancelling {
+		ok, err := model.MarkBatchCancelling(batch.Id)
+		if err != nil {
+			openAIApiError(c, http.StatusInternalServerError, err.Error(), "batch_cancel_error")
+			return
+		}
+		if !ok {
+			openAIApiError(c, http.StatusConflict, fmt.Sprintf("Cannot cancel a batch with status '%s'", batch.Status), "batch_not_cancellable")
+			return
+		}
+	}
+	batch, err := model.GetBatchById(batch.Id)
+	if err != nil {
+		openAIApiError(c, http.StatusInternalServerError, err.Error(), "query_error")
+		return
+	}
+	c.JSON(http.StatusOK, batch.ToOpenAIBatch())
+}
+
+func ListBatches(c *gin.Context) {
+	limit := parseOpenAIListLimit(c, 20, 100)
+	batches, err := model.GetUserBatches(c.GetInt("id"), c.Query("after"), limit+1)
+	if err != nil {
+		openAIApiError(c, http.StatusInternalServerError, err.Error(), "query_error")
+		return
+	}
+	hasMore := len(batches) > limit
+	if hasMore {
+		batches = batches[:limit]
+	}
+	res := dto.OpenAIList[*dto.OpenAIBatch]{
+		Object:  "list",
+		Data:    make([]*dto.OpenAIBatch, 0, len(batches)),
+		HasMore: hasMore,
+	}
+	for _, b := range batches {
+		res.Data = append(res.Data, b.ToOpenAIBatch())
+	}
+	if len(batches) > 0 {
+		res.FirstID = batches[0].BatchId
+		res.LastID = batches[len(batches)-1].BatchId
+	}
+	c.JSON(http.StatusOK, res)
+}
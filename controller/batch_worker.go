@@ -0,0 +1,567 @@
+package controller
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
+	"github.com/QuantumNous/new-api/dto"
+	"github.com/QuantumNous/new-api/logger"
+	"github.com/QuantumNous/new-api/middleware"
+	"github.com/QuantumNous/new-api/model"
+	"github.com/QuantumNous/new-api/service"
+	"github.com/QuantumNous/new-api/types"
+
+	"github.com/bytedance/gopkg/util/gopool"
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// batchEndpointFormats 批处理支持的 endpoint 及其对应的 relay 格式
+var batchEndpointFormats = map[string]types.RelayFormat{
+	"/v1/chat/completions": types.RelayFormatOpenAI,
+	"/v1/completions":      types.RelayFormatOpenAI,
+	"/v1/embeddings":       types.RelayFormatEmbedding,
+	"/v1/moderations":      types.RelayFormatOpenAI,
+	"/v1/responses":        types.RelayFormatOpenAIResponses,
+	"/v1/messages":         types.RelayFormatClaude,
+}
+
+const (
+	batchWorkerTickInterval  = 10 * time.Second
+	batchWorkerQueryLimit    = 20
+	batchMaxValidationErrors = 100
+	// 每一轮每个批处理最多执行的行数，多个批处理之间轮流推进，避免大批处理独占 worker
+	batchLinesPerRound = 100
+	// 限流响应未携带 Retry-After 时的默认等待时间
+	batchDefaultRetryAfter = batchWorkerTickInterval
+)
+
+var (
+	batchWorkerOnce      sync.Once
+	batchWorkerRunning   atomic.Bool
+	batchRelayEngine     *gin.Engine
+	batchRelayEngineOnce sync.Once
+	// batchThrottled 被限流的批处理，仅由 worker 协程访问
+	batchThrottled = make(map[int]*batchThrottleState)
+)
+
+// batchThrottleState 批处理被限流后的暂停状态
+type batchThrottleState struct {
+	// retryAt 到达该时间前跳过此批处理
+	retryAt time.Time
+	// results 限流行之后已执行完成的行结果（按行号），恢复时直接写出，避免重复执行与重复计费
+	results map[int]batchLineResult
+}
+
+// getBatchRelayEngine 构造进程内 relay 引擎：批处理的每一行都经过与 /v1 相同的
+// 风控、限流 -> Distribute -> Relay 链路（渠道选择、重试、BillingSession 计费、消费日志）。
+func getBatchRelayEngine() *gin.Engine {
+	batchRelayEngineOnce.Do(func() {
+		engine := gin.New()
+		engine.Use(gin.Recovery())
+		engine.Use(middleware.RequestId())
+		engine.Use(middleware.RouteTag("relay"))
+		engine.Use(middleware.BodyStorageCleanup())
+		engine.Use(middleware.InternalTokenAuth())
+		engine.Use(middleware.RequestRiskControl())
+		engine.Use(middleware.ModelRequestRateLimit())
+		engine.Use(middleware.TokenRateLimit())
+		engine.Use(middleware.Distribute())
+		for endpoint, format := range batchEndpointFormats {
+			relayFormat := format
+			engine.POST(endpoint, func(c *gin.Context) {
+				Relay(c, relayFormat)
+			})
+		}
+		batchRelayEngine = engine
+	})
+	return batchRelayEngine
+}
+
+// StartBatchWorkerTask 启动批处理后台任务，仅在主节点运行
+func StartBatchWorkerTask() {
+	batchWorkerOnce.Do(func() {
+		if !common.IsMasterNode {
+			return
+		}
+		gopool.Go(func() {
+			logger.LogInfo(context.Background(), fmt.Sprintf("batch worker started: tick=%s, concurrency=%d", batchWorkerTickInterval, constant.BatchWorkerConcurrency))
+			ticker := time.NewTicker(batchWorkerTickInterval)
+			defer ticker.Stop()
+			for range ticker.C {
+				runBatchWorkerOnce()
+			}
+		})
+	})
+}
+
+func runBatchWorkerOnce() {
+	if !batchWorkerRunning.CompareAndSwap(false, true) {
+		return
+	}
+	defer batchWorkerRunning.Store(false)
+
+	ctx := context.Background()
+	// 按轮次推进：每轮每个批处理最多执行 batchLinesPerRound 行，直到没有批处理还有待执行的行
+	for {
+		batches, err := model.GetUnfinishedBatches(batchWorkerQueryLimit)
+		if err != nil {
+			logger.LogWarn(ctx, fmt.Sprintf("batch worker query failed: %v", err))
+			return
+		}
+		hasMore := false
+		for _, batch := range batches {
+			if processBatch(ctx, batch) {
+				hasMore = true
+			}
+		}
+		if !hasMore {
+			return
+		}
+	}
+}
+
+// processBatch 推进一个批处理，返回 true 表示本轮未执行完、还有待执行的行
+func processBatch(ctx context.Context, batch *model.Batch) bool {
+	now := common.GetTimestamp()
+	switch batch.Status {
+	case dto.BatchStatusCancelling:
+		finishBatch(ctx, batch, dto.BatchStatusCancelled)
+	case dto.BatchStatusFinalizing:
+		finishBatch(ctx, batch, dto.BatchStatusCompleted)
+	case dto.BatchStatusValidating:
+		if batch.ExpiresAt > 0 && now > batch.ExpiresAt {
+			finishBatch(ctx, batch, dto.BatchStatusExpired)
+			return false
+		}
+		validateBatch(ctx, batch)
+	case dto.BatchStatusInProgress:
+		if batch.ExpiresAt > 0 && now > batch.ExpiresAt {
+			delete(batchThrottled, batch.Id)
+			finishBatch(ctx, batch, dto.BatchStatusExpired)
+			return false
+		}
+		if state, ok := batchThrottled[batch.Id]; ok && time.Now().Before(state.retryAt) {
+			return false
+		}
+		return runBatchRequests(ctx, batch)
+	}
+	delete(batchThrottled, batch.Id)
+	return false
+}
+
+// scanBatchLines 逐行读取 JSONL，跳过空行；fn 返回 false 时停止
+func scanBatchLines(reader io.Reader, fn func(lineNo int, line []byte) bool) error {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), constant.MaxRequestBodyMB<<20)
+	lineNo := 0
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		lineNo++
+		if !fn(lineNo, line) {
+			break
+		}
+	}
+	return scanner.Err()
+}
+
+func failBatch(ctx context.Context, batch *model.Batch, errs []dto.BatchError) {
+	fromStatus := batch.Status
+	batch.Status = dto.BatchStatusFailed
+	batch.FailedAt = common.GetTimestamp()
+	batch.SetErrors(errs)
+	if _, err := batch.UpdateWithStatus(fromStatus); err != nil {
+		logger.LogError(ctx, fmt.Sprintf("batch %s update failed: %v", batch.BatchId, err))
+	}
+}
+
+func validateBatch(ctx context.Context, batch *model.Batch) {
+	inputFile, err := model.GetUserFileByFileId(batch.UserId, batch.InputFileId)
+	if err != nil {
+		failBatch(ctx, batch, []dto.BatchError{{Code: "invalid_input_file", Message: "input file not found"}})
+		return
+	}
+	f, err := service.OpenStoredFile(inputFile.Path)
+	if err != nil {
+		failBatch(ctx, batch, []dto.BatchError{{Code: "invalid_input_file", Message: "input file content is not available"}})
+		return
+	}
+	defer f.Close()
+
+	var errs []dto.BatchError
+	customIds := make(map[string]bool)
+	total := 0
+	scanErr := scanBatchLines(f, func(lineNo int, line []byte) bool {
+		total = lineNo
+		n := lineNo
+		var input dto.BatchInputLine
+		if err := common.Unmarshal(line, &input); err != nil {
+			errs = append(errs, dto.BatchError{Code: "invalid_json_line", Message: "This line is not parseable as valid JSON.", Line: &n})
+		} else if input.CustomID == "" {
+			errs = append(errs, dto.BatchError{Code: "missing_required_parameter", Message: "Missing required parameter: 'custom_id'.", Param: "custom_id", Line: &n})
+		} else if customIds[input.CustomID] {
+			errs = append(errs, dto.BatchError{Code: "duplicate_custom_id", Message: "The custom_id for this request is a duplicate of another request.", Param: "custom_id", Line: &n})
+		} else if !strings.EqualFold(input.Method, http.MethodPost) {
+			errs = append(errs, dto.BatchError{Code: "invalid_method", Message: "Only the POST method is supported.", Param: "method", Line: &n})
+		} else if input.URL != batch.Endpoint {
+			errs = append(errs, dto.BatchError{Code: "mismatched_endpoint", Message: fmt.Sprintf("The url '%s' does not match the batch endpoint '%s'.", input.URL, batch.Endpoint), Param: "url", Line: &n})
+		} else if !gjson.ValidBytes(input.Body) || !gjson.ParseBytes(input.Body).IsObject() {
+			errs = append(errs, dto.BatchError{Code: "invalid_request", Message: "The request body must be a JSON object.", Param: "body", Line: &n})
+		}
+		customIds[input.CustomID] = true
+		return len(errs) < batchMaxValidationErrors
+	})
+	if scanErr != nil {
+		errs = append(errs, dto.BatchError{Code: "invalid_input_file", Message: scanErr.Error()})
+	}
+	if len(errs) == 0 && total == 0 {
+		errs = append(errs, dto.BatchError{Code: "empty_file", Message: "The input file is empty."})
+	}
+	if len(errs) > 0 {
+		failBatch(ctx, batch, errs)
+		return
+	}
+
+	outputFile, err := createBatchResultFile(batch, "output")
+	if err != nil {
+		logger.LogError(ctx, fmt.Sprintf("batch %s create output file failed: %v", batch.BatchId, err))
+		return
+	}
+	errorFile, err := createBatchResultFile(batch, "error")
+	if err != nil {
+		logger.LogError(ctx, fmt.Sprintf("batch %s create error file failed: %v", batch.BatchId, err))
+		return
+	}
+	batch.OutputFileId = outputFile.FileId
+	batch.ErrorFileId = errorFile.FileId
+	batch.TotalCount = total
+	batch.Status = dto.BatchStatusInProgress
+	batch.InProgressAt = common.GetTimestamp()
+	if _, err := batch.UpdateWithStatus(dto.BatchStatusValidating); err != nil {
+		logger.LogError(ctx, fmt.Sprintf("batch %s update failed: %v", batch.BatchId, err))
+	}
+}
+
+func createBatchResultFile(batch *model.Batch, kind string) (*model.File, error) {
+	fileId := model.NewFileId()
+	relPath, f, err := service.CreateStoredFile(batch.UserId, fileId)
+	if err != nil {
+		return nil, err
+	}
+	_ = f.Close()
+	file := &model.File{
+		FileId:   fileId,
+		UserId:   batch.UserId,
+		TokenId:  batch.TokenId,
+		Filename: fmt.Sprintf("%s_%s.jsonl", batch.BatchId, kind),
+		Purpose:  dto.FilePurposeBatchOutput,
+		Status:   model.FileStatusUploaded,
+		Path:     relPath,
+	}
+	if err := file.Insert(); err != nil {
+		service.RemoveStoredFile(relPath)
+		return nil, err
+	}
+	return file, nil
+}
+
+type batchLineResult struct {
+	output  dto.BatchOutputLine
+	success bool
+	// retryAfter 大于 0 表示该行被限流（429），不计入已处理，稍后重试
+	retryAfter time.Duration
+}
+
+type batchPendingLine struct {
+	lineNo int
+	input  dto.BatchInputLine
+}
+
+// batchConcurrency 单个批处理的并发数，不超过令牌的并发限制
+func batchConcurrency(batch *model.Batch) int {
+	concurrency := constant.BatchWorkerConcurrency
+	if token, err := model.GetTokenByIdWithCache(batch.TokenId); err == nil && token.ConcurrencyLimit > 0 && token.ConcurrencyLimit < concurrency {
+		concurrency = token.ConcurrencyLimit
+	}
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	return concurrency
+}
+
+// runBatchRequests 从断点继续执行最多 batchLinesPerRound 行，返回 true 表示还有待执行的行
+func runBatchRequests(ctx context.Context, batch *model.Batch) bool {
+	inputFile, err := model.GetUserFileByFileId(batch.UserId, batch.InputFileId)
+	if err != nil {
+		failBatch(ctx, batch, []dto.BatchError{{Code: "invalid_input_file", Message: "input file not found"}})
+		return false
+	}
+	outputFile, err := model.GetUserFileByFileId(batch.UserId, batch.OutputFileId)
+	if err != nil {
+		failBatch(ctx, batch, []dto.BatchError{{Code: "server_error", Message: "output file not found"}})
+		return false
+	}
+	errorFile, err := model.GetUserFileByFileId(batch.UserId, batch.ErrorFileId)
+	if err != nil {
+		failBatch(ctx, batch, []dto.BatchError{{Code: "server_error", Message: "error file not found"}})
+		return false
+	}
+
+	input, err := service.OpenStoredFile(inputFile.Path)
+	if err != nil {
+		failBatch(ctx, batch, []dto.BatchError{{Code: "invalid_input_file", Message: "input file content is not available"}})
+		return false
+	}
+	defer input.Close()
+	outputWriter, err := service.OpenStoredFileForAppend(outputFile.Path)
+	if err != nil {
+		logger.LogError(ctx, fmt.Sprintf("batch %s open output file failed: %v", batch.BatchId, err))
+		return false
+	}
+	defer outputWriter.Close()
+	errorWriter, err := service.OpenStoredFileForAppend(errorFile.Path)
+	if err != nil {
+		logger.LogError(ctx, fmt.Sprintf("batch %s open error file failed: %v", batch.BatchId, err))
+		return false
+	}
+	defer errorWriter.Close()
+
+	concurrency := batchConcurrency(batch)
+	// 已处理的行数即断点，重启后从此处继续
+	processed := batch.CompletedCount + batch.FailedCount
+	pending := make([]batchPendingLine, 0, concurrency)
+	var carried map[int]batchLineResult
+	if state, ok := batchThrottled[batch.Id]; ok {
+		carried = state.results
+		delete(batchThrottled, batch.Id)
+	}
+	stopped := false
+	executed := 0
+	yielded := false
+
+	flush := func() bool {
+		if len(pending) == 0 {
+			return true
+		}
+		status, err := model.GetBatchStatus(batch.Id)
+		if err != nil || status != dto.BatchStatusInProgress {
+			return false
+		}
+		if batch.ExpiresAt > 0 && common.GetTimestamp() > batch.ExpiresAt {
+			finishBatch(ctx, batch, dto.BatchStatusExpired)
+			return false
+		}
+		results := make([]batchLineResult, len(pending))
+		var wg sync.WaitGroup
+		for i := range pending {
+			if result, ok := carried[pending[i].lineNo]; ok {
+				results[i] = result
+				continue
+			}
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				results[i] = executeBatchLine(batch, pending[i].input)
+			}(i)
+		}
+		wg.Wait()
+		// 断点按行数推进，遇到被限流的行即停止写出，该行及其后的行留到 Retry-After 之后重试
+		throttledAt := -1
+		for i, result := range results {
+			if result.retryAfter > 0 {
+				throttledAt = i
+				break
+			}
+			data, _ := common.Marshal(result.output)
+			data = append(data, '\n')
+			if result.success {
+				_, err = outputWriter.Write(data)
+				batch.CompletedCount++
+			} else {
+				_, err = errorWriter.Write(data)
+				batch.FailedCount++
+			}
+			if err != nil {
+				logger.LogError(ctx, fmt.Sprintf("batch %s write result failed: %v", batch.BatchId, err))
+			}
+		}
+		if err := batch.UpdateProgress(); err != nil {
+			logger.LogError(ctx, fmt.Sprintf("batch %s update progress failed: %v", batch.BatchId, err))
+		}
+		if throttledAt >= 0 {
+			state := &batchThrottleState{
+				retryAt: time.Now().Add(results[throttledAt].retryAfter),
+				results: make(map[int]batchLineResult),
+			}
+			for i := throttledAt + 1; i < len(results); i++ {
+				if results[i].retryAfter == 0 {
+					state.results[pending[i].lineNo] = results[i]
+				}
+			}
+			batchThrottled[batch.Id] = state
+			pending = pending[:0]
+			return false
+		}
+		pending = pending[:0]
+		return true
+	}
+
+	scanErr := scanBatchLines(input, func(lineNo int, line []byte) bool {
+		if lineNo <= processed {
+			return true
+		}
+		var inputLine dto.BatchInputLine
+		if err := common.Unmarshal(line, &inputLine); err != nil {
+			return true
+		}
+		if executed >= batchLinesPerRound {
+			// 本轮配额已用完，剩余行留到下一轮
+			yielded = true
+			return false
+		}
+		executed++
+		pending = append(pending, batchPendingLine{lineNo: lineNo, input: inputLine})
+		if len(pending) >= concurrency {
+			if !flush() {
+				stopped = true
+				return false
+			}
+		}
+		return true
+	})
+	if !stopped && !flush() {
+		stopped = true
+	}
+	if stopped {
+		return false
+	}
+	if yielded {
+		return true
+	}
+	if scanErr != nil {
+		logger.LogError(ctx, fmt.Sprintf("batch %s read input failed: %v", batch.BatchId, scanErr))
+		return false
+	}
+
+	batch.Status = dto.BatchStatusFinalizing
+	batch.FinalizingAt = common.GetTimestamp()
+	won, err := batch.UpdateWithStatus(dto.BatchStatusInProgress)
+	if err != nil || !won {
+		return false
+	}
+	finishBatch(ctx, batch, dto.BatchStatusCompleted)
+	return false
+}
+
+// executeBatchLine 通过进程内 relay 引擎执行单行请求
+func executeBatchLine(batch *model.Batch, line dto.BatchInputLine) batchLineResult {
+	output := dto.BatchOutputLine{
+		ID:       "batch_req_" + common.GetUUID(),
+		CustomID: line.CustomID,
+	}
+	body := []byte(line.Body)
+	// 批处理不支持流式响应
+	if gjson.GetBytes(body, "stream").Exists() {
+		if stripped, err := sjson.DeleteBytes(body, "stream"); err == nil {
+			body = stripped
+		}
+	}
+	ctx := middleware.WithInternalToken(context.Background(), batch.TokenId)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, line.URL, bytes.NewReader(body))
+	if err != nil {
+		output.Error = &dto.BatchError{Code: "invalid_request", Message: err.Error()}
+		return batchLineResult{output: output}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	getBatchRelayEngine().ServeHTTP(recorder, req)
+
+	if recorder.Code == http.StatusTooManyRequests {
+		return batchLineResult{output: output, retryAfter: parseBatchRetryAfter(recorder.Header().Get("Retry-After"))}
+	}
+
+	respBody := recorder.Body.Bytes()
+	if !json.Valid(respBody) {
+		respBody, _ = common.Marshal(string(respBody))
+	}
+	output.Response = &dto.BatchOutputResponse{
+		StatusCode: recorder.Code,
+		RequestID:  recorder.Header().Get(common.RequestIdKey),
+		Body:       respBody,
+	}
+	return batchLineResult{
+		output:  output,
+		success: recorder.Code >= 200 && recorder.Code < 300,
+	}
+}
+
+// parseBatchRetryAfter 解析 Retry-After 秒数，缺省或无效时使用默认等待时间
+func parseBatchRetryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || seconds <= 0 {
+		return batchDefaultRetryAfter
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// finishBatch 结束批处理：回填结果文件大小，移除空的结果文件并写入终态
+func finishBatch(ctx context.Context, batch *model.Batch, status string) {
+	fromStatus := batch.Status
+	batch.OutputFileId = finalizeBatchResultFile(ctx, batch, batch.OutputFileId)
+	batch.ErrorFileId = finalizeBatchResultFile(ctx, batch, batch.ErrorFileId)
+	now := common.GetTimestamp()
+	batch.Status = status
+	switch status {
+	case dto.BatchStatusCompleted:
+		batch.CompletedAt = now
+	case dto.BatchStatusCancelled:
+		batch.CancelledAt = now
+	case dto.BatchStatusExpired:
+		batch.ExpiredAt = now
+	}
+	if _, err := batch.UpdateWithStatus(fromStatus); err != nil {
+		logger.LogError(ctx, fmt.Sprintf("batch %s finish failed: %v", batch.BatchId, err))
+		return
+	}
+	logger.LogInfo(ctx, fmt.Sprintf("batch %s finished: status=%s, completed=%d, failed=%d, total=%d",
+		batch.BatchId, status, batch.CompletedCount, batch.FailedCount, batch.TotalCount))
+}
+
+func finalizeBatchResultFile(ctx context.Context, batch *model.Batch, fileId string) string {
+	if fileId == "" {
+		return ""
+	}
+	file, err := model.GetUserFileByFileId(batch.UserId, fileId)
+	if err != nil {
+		return ""
+	}
+	size, err := service.StoredFileSize(file.Path)
+	if err != nil || size == 0 {
+		if err := model.DeleteFileById(file.Id); err != nil {
+			logger.LogError(ctx, fmt.Sprintf("batch %s delete empty result file failed: %v", batch.BatchId, err))
+		}
+		service.RemoveStoredFile(file.Path)
+		return ""
+	}
+	if err := model.UpdateFileContentInfo(file.Id, size, model.FileStatusProcessed); err != nil {
+		logger.LogError(ctx, fmt.Sprintf("batch %s update result file failed: %v", batch.BatchId, err))
+	}
+	return fileId
+}
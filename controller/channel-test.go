@@ -159,6 +159,7 @@ func testChannel(channel *model.Channel, testModel string, endpointType string,
 	c.Set("group", group)
 
 	newAPIError := middleware.SetupContextForSelectedChannel(c, channel, testModel)
+	defer middleware.ReleaseChannelKeyLease(c)
 	if newAPIError != nil {
 		return testResult{
 			context:     c,
@@ -836,7 +837,7 @@ func testAllChannels(notify bool) error {
 
 			// enable channel
 			if !isChannelEnabled && service.ShouldEnableChannel(newAPIError, channel.Status) {
-				service.EnableChannel(channel.Id, common.GetContextKeyString(result.context, constant.ContextKeyChannelKey), channel.Name)
+				service.EnableChannel(channel.Id, common.GetContextKeyString(result.context, constant.ContextKeyChannelKey), channel.Name, "通道测试成功")
 			}
 
 			channel.UpdateResponseTime(milliseconds)
@@ -64,6 +64,8 @@ func clearChannelInfo(channel *model.Channel) {
 	if channel.ChannelInfo.IsMultiKey {
 		channel.ChannelInfo.MultiKeyDisabledReason = nil
 		channel.ChannelInfo.MultiKeyDisabledTime = nil
+		channel.ChannelInfo.MultiKeyPrevDisabledReason = nil
+		channel.ChannelInfo.MultiKeyPrevDisabledTime = nil
 	}
 }
 
@@ -145,6 +147,7 @@ func GetAllChannels(c *gin.Context) {
 
 	for _, datum := range channelData {
 		clearChannelInfo(datum)
+		datum.Health = model.GetChannelHealthSummary(datum.Id)
 	}
 
 	countQuery := model.DB.Model(&model.Channel{})
@@ -483,6 +486,7 @@ func SearchChannels(c *gin.Context) {
 
 	for _, datum := range pagedData {
 		clearChannelInfo(datum)
+		datum.Health = model.GetChannelHealthSummary(datum.Id)
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -543,6 +547,7 @@ func GetChannelKey(c *gin.Context) {
 
 	// 记录操作日志
 	model.RecordLog(userId, model.LogTypeSystem, fmt.Sprintf("查看渠道密钥信息 (渠道ID: %d)", channelId))
+	service.RecordAudit(c, model.AuditTargetChannel, channelId, model.AuditActionViewKey, nil, nil)
 
 	// 返回渠道密钥
 	c.JSON(http.StatusOK, gin.H{
@@ -824,6 +829,11 @@ func AddChannel(c *gin.Context) {
 		common.ApiError(c, err)
 		return
 	}
+	// 批量添加时渠道 ID 由数据库分批生成，这里记录提交的渠道配置与数量
+	service.RecordAudit(c, model.AuditTargetChannel, "", model.AuditActionCreate, nil, map[string]any{
+		"channel": addChannelRequest.Channel,
+		"count":   len(channels),
+	})
 	service.ResetProxyClientCache()
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -834,12 +844,14 @@ func AddChannel(c *gin.Context) {
 
 func DeleteChannel(c *gin.Context) {
 	id, _ := strconv.Atoi(c.Param("id"))
+	originChannel, _ := model.GetChannelById(id, true)
 	channel := model.Channel{Id: id}
 	err := channel.Delete()
 	if err != nil {
 		common.ApiError(c, err)
 		return
 	}
+	service.RecordAudit(c, model.AuditTargetChannel, id, model.AuditActionDelete, originChannel, nil)
 	model.InitChannelCache()
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -1127,6 +1139,9 @@ func UpdateChannel(c *gin.Context) {
 		common.ApiError(c, err)
 		return
 	}
+	if updatedChannel, err := model.GetChannelById(channel.Id, true); err == nil {
+		service.RecordAudit(c, model.AuditTargetChannel, channel.Id, model.AuditActionUpdate, originChannel, updatedChannel)
+	}
 	model.InitChannelCache()
 	service.ResetProxyClientCache()
 	channel.Key = ""
@@ -1379,11 +1394,13 @@ func CopyChannel(c *gin.Context) {
 // MultiKeyManageRequest represents the request for multi-key management operations
 type MultiKeyManageRequest struct {
 	ChannelId int    `json:"channel_id"`
-	Action    string `json:"action"`              // "disable_key", "enable_key", "delete_key", "delete_disabled_keys", "get_key_status"
-	KeyIndex  *int   `json:"key_index,omitempty"` // for disable_key, enable_key, and delete_key actions
+	Action    string `json:"action"`              // "disable_key", "enable_key", "delete_key", "delete_disabled_keys", "get_key_status", "set_key_weight", "set_multi_key_mode"
+	KeyIndex  *int   `json:"key_index,omitempty"` // for disable_key, enable_key, delete_key and set_key_weight actions
 	Page      int    `json:"page,omitempty"`      // for get_key_status pagination
 	PageSize  int    `json:"page_size,omitempty"` // for get_key_status pagination
 	Status    *int   `json:"status,omitempty"`    // for get_key_status filtering: 1=enabled, 2=manual_disabled, 3=auto_disabled, nil=all
+	Weight    *int   `json:"weight,omitempty"`    // for set_key_weight
+	Mode      string `json:"mode,omitempty"`      // for set_multi_key_mode
 }
 
 // MultiKeyStatusResponse represents the response for key status query
@@ -1405,6 +1422,11 @@ type KeyStatus struct {
 	DisabledTime int64  `json:"disabled_time,omitempty"`
 	Reason       string `json:"reason,omitempty"`
 	KeyPreview   string `json:"key_preview"` // first 10 chars of key for identification
+	Weight       int    `json:"weight"`      // used by weighted mode
+	// 上一次的禁用原因与时间
+	PrevReason       string `json:"prev_reason,omitempty"`
+	PrevDisabledTime int64  `json:"prev_disabled_time,omitempty"`
+	model.ChannelKeyStats
 }
 
 // ManageMultiKeys handles multi-key management operations
@@ -1458,8 +1480,8 @@ func ManageMultiKeys(c *gin.Context) {
 		var allKeyStatusList []KeyStatus
 		for i, key := range keys {
 			status := 1 // default enabled
-			var disabledTime int64
-			var reason string
+			var disabledTime, prevDisabledTime int64
+			var reason, prevReason string
 
 			if channel.ChannelInfo.MultiKeyStatusList != nil {
 				if s, exists := channel.ChannelInfo.MultiKeyStatusList[i]; exists {
@@ -1484,6 +1506,8 @@ func ManageMultiKeys(c *gin.Context) {
 				if channel.ChannelInfo.MultiKeyDisabledReason != nil {
 					reason = channel.ChannelInfo.MultiKeyDisabledReason[i]
 				}
+				prevReason = channel.ChannelInfo.MultiKeyPrevDisabledReason[i]
+				prevDisabledTime = channel.ChannelInfo.MultiKeyPrevDisabledTime[i]
 			}
 
 			// Create key preview (first 10 chars)
@@ -1493,11 +1517,15 @@ func ManageMultiKeys(c *gin.Context) {
 			}
 
 			allKeyStatusList = append(allKeyStatusList, KeyStatus{
-				Index:        i,
-				Status:       status,
-				DisabledTime: disabledTime,
-				Reason:       reason,
-				KeyPreview:   keyPreview,
+				Index:            i,
+				Status:           status,
+				DisabledTime:     disabledTime,
+				Reason:           reason,
+				PrevReason:       prevReason,
+				PrevDisabledTime: prevDisabledTime,
+				KeyPreview:       keyPreview,
+				Weight:           channel.ChannelInfo.KeyWeight(i),
+				ChannelKeyStats:  model.GetChannelKeyStats(channel.Id, i),
 			})
 		}
 
@@ -1623,6 +1651,8 @@ func ManageMultiKeys(c *gin.Context) {
 		if channel.ChannelInfo.MultiKeyDisabledReason != nil {
 			delete(channel.ChannelInfo.MultiKeyDisabledReason, keyIndex)
 		}
+		delete(channel.ChannelInfo.MultiKeyPrevDisabledReason, keyIndex)
+		delete(channel.ChannelInfo.MultiKeyPrevDisabledTime, keyIndex)
 
 		err = channel.Update()
 		if err != nil {
@@ -1647,6 +1677,8 @@ func ManageMultiKeys(c *gin.Context) {
 		channel.ChannelInfo.MultiKeyStatusList = make(map[int]int)
 		channel.ChannelInfo.MultiKeyDisabledTime = make(map[int]int64)
 		channel.ChannelInfo.MultiKeyDisabledReason = make(map[int]string)
+		channel.ChannelInfo.MultiKeyPrevDisabledReason = nil
+		channel.ChannelInfo.MultiKeyPrevDisabledTime = nil
 
 		err = channel.Update()
 		if err != nil {
@@ -1731,6 +1763,9 @@ func ManageMultiKeys(c *gin.Context) {
 		var newStatusList = make(map[int]int)
 		var newDisabledTime = make(map[int]int64)
 		var newDisabledReason = make(map[int]string)
+		var newPrevDisabledReason = make(map[int]string)
+		var newPrevDisabledTime = make(map[int]int64)
+		var newWeights = make(map[int]int)
 
 		newIndex := 0
 		for i, key := range keys {
@@ -1757,6 +1792,13 @@ func ManageMultiKeys(c *gin.Context) {
 					newDisabledReason[newIndex] = r
 				}
 			}
+			if r, exists := channel.ChannelInfo.MultiKeyPrevDisabledReason[i]; exists {
+				newPrevDisabledReason[newIndex] = r
+				newPrevDisabledTime[newIndex] = channel.ChannelInfo.MultiKeyPrevDisabledTime[i]
+			}
+			if w, exists := channel.ChannelInfo.MultiKeyWeights[i]; exists {
+				newWeights[newIndex] = w
+			}
 			newIndex++
 		}
 
@@ -1774,6 +1816,9 @@ func ManageMultiKeys(c *gin.Context) {
 		channel.ChannelInfo.MultiKeyStatusList = newStatusList
 		channel.ChannelInfo.MultiKeyDisabledTime = newDisabledTime
 		channel.ChannelInfo.MultiKeyDisabledReason = newDisabledReason
+		channel.ChannelInfo.MultiKeyPrevDisabledReason = newPrevDisabledReason
+		channel.ChannelInfo.MultiKeyPrevDisabledTime = newPrevDisabledTime
+		channel.ChannelInfo.MultiKeyWeights = newWeights
 
 		err = channel.Update()
 		if err != nil {
@@ -1781,6 +1826,8 @@ func ManageMultiKeys(c *gin.Context) {
 			return
 		}
 
+		// 删除后索引发生变化，清除旧的 key 统计
+		model.ResetChannelKeyStats(channel.Id)
 		model.InitChannelCache()
 		c.JSON(http.StatusOK, gin.H{
 			"success": true,
@@ -1795,6 +1842,9 @@ func ManageMultiKeys(c *gin.Context) {
 		var newStatusList = make(map[int]int)
 		var newDisabledTime = make(map[int]int64)
 		var newDisabledReason = make(map[int]string)
+		var newPrevDisabledReason = make(map[int]string)
+		var newPrevDisabledTime = make(map[int]int64)
+		var newWeights = make(map[int]int)
 
 		newIndex := 0
 		for i, key := range keys {
@@ -1823,6 +1873,13 @@ func ManageMultiKeys(c *gin.Context) {
 							newDisabledReason[newIndex] = r
 						}
 					}
+					if r, exists := channel.ChannelInfo.MultiKeyPrevDisabledReason[i]; exists {
+						newPrevDisabledReason[newIndex] = r
+						newPrevDisabledTime[newIndex] = channel.ChannelInfo.MultiKeyPrevDisabledTime[i]
+					}
+				}
+				if w, exists := channel.ChannelInfo.MultiKeyWeights[i]; exists {
+					newWeights[newIndex] = w
 				}
 				newIndex++
 			}
@@ -1842,6 +1899,9 @@ func ManageMultiKeys(c *gin.Context) {
 		channel.ChannelInfo.MultiKeyStatusList = newStatusList
 		channel.ChannelInfo.MultiKeyDisabledTime = newDisabledTime
 		channel.ChannelInfo.MultiKeyDisabledReason = newDisabledReason
+		channel.ChannelInfo.MultiKeyPrevDisabledReason = newPrevDisabledReason
+		channel.ChannelInfo.MultiKeyPrevDisabledTime = newPrevDisabledTime
+		channel.ChannelInfo.MultiKeyWeights = newWeights
 
 		err = channel.Update()
 		if err != nil {
@@ -1849,6 +1909,8 @@ func ManageMultiKeys(c *gin.Context) {
 			return
 		}
 
+		// 删除后索引发生变化，清除旧的 key 统计
+		model.ResetChannelKeyStats(channel.Id)
 		model.InitChannelCache()
 		c.JSON(http.StatusOK, gin.H{
 			"success": true,
@@ -1857,6 +1919,73 @@ func ManageMultiKeys(c *gin.Context) {
 		})
 		return
 
+	case "set_key_weight":
+		if request.KeyIndex == nil || request.Weight == nil {
+			c.JSON(http.StatusOK, gin.H{
+				"success": false,
+				"message": "未指定密钥索引或权重",
+			})
+			return
+		}
+
+		keyIndex := *request.KeyIndex
+		if keyIndex < 0 || keyIndex >= channel.ChannelInfo.MultiKeySize {
+			c.JSON(http.StatusOK, gin.H{
+				"success": false,
+				"message": "密钥索引超出范围",
+			})
+			return
+		}
+		if *request.Weight < 0 {
+			c.JSON(http.StatusOK, gin.H{
+				"success": false,
+				"message": "权重不能为负数",
+			})
+			return
+		}
+
+		if channel.ChannelInfo.MultiKeyWeights == nil {
+			channel.ChannelInfo.MultiKeyWeights = make(map[int]int)
+		}
+		channel.ChannelInfo.MultiKeyWeights[keyIndex] = *request.Weight
+
+		err = channel.Update()
+		if err != nil {
+			common.ApiError(c, err)
+			return
+		}
+
+		model.InitChannelCache()
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "权重已更新",
+		})
+		return
+
+	case "set_multi_key_mode":
+		mode := constant.MultiKeyMode(request.Mode)
+		if !mode.IsValid() {
+			c.JSON(http.StatusOK, gin.H{
+				"success": false,
+				"message": "不支持的多密钥模式",
+			})
+			return
+		}
+
+		channel.ChannelInfo.MultiKeyMode = mode
+		err = channel.Update()
+		if err != nil {
+			common.ApiError(c, err)
+			return
+		}
+
+		model.InitChannelCache()
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "多密钥模式已更新",
+		})
+		return
+
 	default:
 		c.JSON(http.StatusOK, gin.H{
 			"success": false,
@@ -0,0 +1,263 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/logger"
+	"github.com/QuantumNous/new-api/model"
+	"github.com/QuantumNous/new-api/service"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+
+	"github.com/bytedance/gopkg/util/gopool"
+	"github.com/gin-gonic/gin"
+)
+
+const channelRecoveryTickInterval = 30 * time.Second
+
+var (
+	channelRecoveryOnce    sync.Once
+	channelRecoveryRunning atomic.Bool
+
+	channelRecoveryMu     sync.Mutex
+	channelRecoveryStates = map[string]*channelRecoveryState{} // "channelId:keyIndex"，keyIndex 为 -1 表示整个渠道
+)
+
+// channelRecoveryState 单个自动禁用目标的探测进度，仅保存在主节点内存中
+type channelRecoveryState struct {
+	ChannelId   int    `json:"channel_id"`
+	KeyIndex    int    `json:"key_index"`
+	NextProbeAt int64  `json:"next_probe_at"`
+	Interval    int    `json:"interval"` // 秒
+	Successes   int    `json:"successes"`
+	Failures    int    `json:"failures"`
+	LastProbeAt int64  `json:"last_probe_at,omitempty"`
+	LastError   string `json:"last_error,omitempty"`
+}
+
+type channelRecoveryTarget struct {
+	channel  *model.Channel
+	keyIndex int
+}
+
+func channelRecoveryStateKey(channelId int, keyIndex int) string {
+	return strconv.Itoa(channelId) + ":" + strconv.Itoa(keyIndex)
+}
+
+// StartChannelRecoveryTask 定时探测自动禁用的渠道与 key，连续成功后重新启用
+func StartChannelRecoveryTask() {
+	channelRecoveryOnce.Do(func() {
+		if !common.IsMasterNode {
+			return
+		}
+		gopool.Go(func() {
+			logger.LogInfo(context.Background(), fmt.Sprintf("channel recovery task started: tick=%s", channelRecoveryTickInterval))
+			ticker := time.NewTicker(channelRecoveryTickInterval)
+			defer ticker.Stop()
+			for range ticker.C {
+				runChannelRecoveryOnce()
+			}
+		})
+	})
+}
+
+func runChannelRecoveryOnce() {
+	if !operation_setting.GetChannelRecoverySetting().Enabled {
+		return
+	}
+	if !channelRecoveryRunning.CompareAndSwap(false, true) {
+		return
+	}
+	defer channelRecoveryRunning.Store(false)
+
+	channels, err := model.GetAllChannels(0, 0, true, false)
+	if err != nil {
+		logger.LogWarn(context.Background(), fmt.Sprintf("channel recovery task failed to load channels: %v", err))
+		return
+	}
+	targets := collectChannelRecoveryTargets(channels)
+
+	now := time.Now()
+	var due []channelRecoveryTarget
+	channelRecoveryMu.Lock()
+	seen := make(map[string]bool, len(targets))
+	for _, target := range targets {
+		key := channelRecoveryStateKey(target.channel.Id, target.keyIndex)
+		seen[key] = true
+		state, ok := channelRecoveryStates[key]
+		if !ok {
+			interval := channelRecoveryInitialInterval()
+			state = &channelRecoveryState{
+				ChannelId:   target.channel.Id,
+				KeyIndex:    target.keyIndex,
+				Interval:    int(interval / time.Second),
+				NextProbeAt: now.Add(interval).Unix(),
+			}
+			channelRecoveryStates[key] = state
+		}
+		if state.NextProbeAt <= now.Unix() {
+			due = append(due, target)
+		}
+	}
+	// 已恢复或被手动处理的目标不再跟踪
+	for key := range channelRecoveryStates {
+		if !seen[key] {
+			delete(channelRecoveryStates, key)
+		}
+	}
+	channelRecoveryMu.Unlock()
+
+	for _, target := range due {
+		probeChannelRecoveryTarget(target)
+		time.Sleep(common.RequestInterval)
+	}
+}
+
+// collectChannelRecoveryTargets 只探测自动禁用的目标，手动禁用的渠道与 key 不会被自动恢复
+func collectChannelRecoveryTargets(channels []*model.Channel) []channelRecoveryTarget {
+	var targets []channelRecoveryTarget
+	for _, channel := range channels {
+		if channel.Status == common.ChannelStatusManuallyDisabled {
+			continue
+		}
+		if !channel.ChannelInfo.IsMultiKey {
+			if channel.Status == common.ChannelStatusAutoDisabled {
+				targets = append(targets, channelRecoveryTarget{channel: channel, keyIndex: -1})
+			}
+			continue
+		}
+		// 多 Key 渠道逐个探测自动禁用的 key，任一 key 恢复后渠道随之恢复
+		for idx := 0; idx < channel.ChannelInfo.MultiKeySize; idx++ {
+			if channel.ChannelInfo.MultiKeyStatusList[idx] == common.ChannelStatusAutoDisabled {
+				targets = append(targets, channelRecoveryTarget{channel: channel, keyIndex: idx})
+			}
+		}
+	}
+	return targets
+}
+
+func probeChannelRecoveryTarget(target channelRecoveryTarget) {
+	channel := target.channel
+	probe := channel
+	usingKey := ""
+	if target.keyIndex >= 0 {
+		keys := channel.GetKeys()
+		if target.keyIndex >= len(keys) {
+			return
+		}
+		// 以单 Key 渠道的形式测试指定的 key，避免按多 Key 策略选到其他 key
+		single := *channel
+		single.Key = keys[target.keyIndex]
+		single.ChannelInfo.IsMultiKey = false
+		probe = &single
+		usingKey = keys[target.keyIndex]
+	}
+
+	result := testChannel(probe, "", "", false)
+	var probeErr error
+	if result.localErr != nil {
+		probeErr = result.localErr
+	} else if result.newAPIError != nil {
+		probeErr = result.newAPIError
+	}
+
+	setting := operation_setting.GetChannelRecoverySetting()
+	required := setting.RequiredSuccesses
+	if required <= 0 {
+		required = 1
+	}
+
+	key := channelRecoveryStateKey(channel.Id, target.keyIndex)
+	channelRecoveryMu.Lock()
+	state, ok := channelRecoveryStates[key]
+	if !ok {
+		channelRecoveryMu.Unlock()
+		return
+	}
+	now := time.Now()
+	state.LastProbeAt = now.Unix()
+	recovered := false
+	if probeErr != nil {
+		// 失败后清零连续成功次数，间隔翻倍直到上限
+		state.Successes = 0
+		state.Failures++
+		state.LastError = probeErr.Error()
+		interval := time.Duration(state.Interval) * time.Second * 2
+		if maxInterval := time.Duration(setting.MaxIntervalSeconds) * time.Second; maxInterval > 0 && interval > maxInterval {
+			interval = maxInterval
+		}
+		state.Interval = int(interval / time.Second)
+		state.NextProbeAt = now.Add(interval).Unix()
+	} else {
+		state.Successes++
+		state.LastError = ""
+		if state.Successes >= required {
+			recovered = true
+			delete(channelRecoveryStates, key)
+		} else {
+			state.NextProbeAt = now.Add(channelRecoveryInitialInterval()).Unix()
+		}
+	}
+	successes := state.Successes
+	channelRecoveryMu.Unlock()
+
+	if !recovered {
+		return
+	}
+	reason := fmt.Sprintf("恢复探测连续成功 %d 次", successes)
+	if target.keyIndex >= 0 {
+		reason = fmt.Sprintf("密钥 #%d %s", target.keyIndex, reason)
+	}
+	service.EnableChannel(channel.Id, usingKey, channel.Name, reason)
+}
+
+func channelRecoveryInitialInterval() time.Duration {
+	seconds := operation_setting.GetChannelRecoverySetting().InitialIntervalSeconds
+	if seconds <= 0 {
+		seconds = 60
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func getChannelRecoveryStates(channelId int) []channelRecoveryState {
+	channelRecoveryMu.Lock()
+	defer channelRecoveryMu.Unlock()
+	states := make([]channelRecoveryState, 0)
+	for _, state := range channelRecoveryStates {
+		if state.ChannelId == channelId {
+			states = append(states, *state)
+		}
+	}
+	return states
+}
+
+// GetChannelStatusEvents 返回渠道的禁用/恢复时间线以及正在进行的恢复探测
+func GetChannelStatusEvents(c *gin.Context) {
+	channelId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	pageInfo := common.GetPageQuery(c)
+	events, total, err := model.GetChannelStatusEvents(channelId, pageInfo.GetStartIdx(), pageInfo.GetPageSize())
+	if err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	pageInfo.SetTotal(int(total))
+	pageInfo.SetItems(events)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data": gin.H{
+			"events":   pageInfo,
+			"recovery": getChannelRecoveryStates(channelId),
+		},
+	})
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/QuantumNous/new-api/common"
 	"github.com/QuantumNous/new-api/model"
+	"github.com/QuantumNous/new-api/service"
 
 	"github.com/gin-gonic/gin"
 )
@@ -48,11 +49,14 @@ func ApproveDeletionRequest(c *gin.Context) {
 	}
 	_ = common.DecodeJson(c.Request.Body, &req)
 
+	originRequest, _ := model.GetDeletionRequestById(id)
 	err = model.ApproveDeletionRequest(id, adminId, req.AdminRemark)
 	if err != nil {
 		common.ApiError(c, err)
 		return
 	}
+	updatedRequest, _ := model.GetDeletionRequestById(id)
+	service.RecordAudit(c, model.AuditTargetDeletionRequest, id, model.AuditActionUpdate, originRequest, updatedRequest)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "注销申请已通过，用户已删除",
@@ -72,11 +76,14 @@ func RejectDeletionRequest(c *gin.Context) {
 	}
 	_ = common.DecodeJson(c.Request.Body, &req)
 
+	originRequest, _ := model.GetDeletionRequestById(id)
 	err = model.RejectDeletionRequest(id, adminId, req.AdminRemark)
 	if err != nil {
 		common.ApiError(c, err)
 		return
 	}
+	updatedRequest, _ := model.GetDeletionRequestById(id)
+	service.RecordAudit(c, model.AuditTargetDeletionRequest, id, model.AuditActionUpdate, originRequest, updatedRequest)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "注销申请已拒绝",
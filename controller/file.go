@@ -0,0 +1,194 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
+	"github.com/QuantumNous/new-api/dto"
+	"github.com/QuantumNous/new-api/model"
+	"github.com/QuantumNous/new-api/service"
+	"github.com/QuantumNous/new-api/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+var uploadableFilePurposes = map[string]bool{
+	dto.FilePurposeBatch:      true,
+	dto.FilePurposeUserData:   true,
+	dto.FilePurposeAssistants: true,
+	dto.FilePurposeFineTune:   true,
+	dto.FilePurposeVision:     true,
+	dto.FilePurposeEvals:      true,
+}
+
+// openAIApiError 以 OpenAI 错误格式返回，用于 /v1/files、/v1/batches 等非 relay 接口
+func openAIApiError(c *gin.Context, statusCode int, message string, code string) {
+	c.JSON(statusCode, gin.H{
+		"error": types.OpenAIError{
+			Message: common.MessageWithRequestId(message, c.GetString(common.RequestIdKey)),
+			Type:    "invalid_request_error",
+			Code:    code,
+		},
+	})
+}
+
+func parseOpenAIListLimit(c *gin.Context, defaultLimit int, maxLimit int) int {
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit <= 0 {
+		return defaultLimit
+	}
+	if limit > maxLimit {
+		return maxLimit
+	}
+	return limit
+}
+
+func UploadFile(c *gin.Context) {
+	userId := c.GetInt("id")
+	purpose := c.PostForm("purpose")
+	if !uploadableFilePurposes[purpose] {
+		openAIApiError(c, http.StatusBadRequest, fmt.Sprintf("invalid purpose: '%s'", purpose), "invalid_purpose")
+		return
+	}
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		openAIApiError(c, http.StatusBadRequest, "missing required parameter: 'file'", "missing_file")
+		return
+	}
+	if fileHeader.Size > int64(constant.MaxFileUploadMB)<<20 {
+		openAIApiError(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("file exceeds the maximum allowed size of %d MB", constant.MaxFileUploadMB), "file_too_large")
+		return
+	}
+	filename := filepath.Base(fileHeader.Filename)
+	if purpose == dto.FilePurposeBatch && !strings.HasSuffix(strings.ToLower(filename), ".jsonl") {
+		openAIApiError(c, http.StatusBadRequest, "batch input file must be a .jsonl file", "invalid_file_format")
+		return
+	}
+	src, err := fileHeader.Open()
+	if err != nil {
+		openAIApiError(c, http.StatusBadRequest, err.Error(), "invalid_file")
+		return
+	}
+	defer src.Close()
+
+	fileId := model.NewFileId()
+	relPath, size, err := service.SaveStoredFile(userId, fileId, src)
+	if err != nil {
+		if errors.Is(err, service.ErrFileTooLarge) {
+			openAIApiError(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("file exceeds the maximum allowed size of %d MB", constant.MaxFileUploadMB), "file_too_large")
+			return
+		}
+		common.SysError("save uploaded file failed: " + err.Error())
+		openAIApiError(c, http.StatusInternalServerError, "failed to save file", "file_store_error")
+		return
+	}
+	file := &model.File{
+		FileId:   fileId,
+		UserId:   userId,
+		TokenId:  c.GetInt("token_id"),
+		Filename: filename,
+		Purpose:  purpose,
+		Bytes:    size,
+		Status:   model.FileStatusProcessed,
+		Path:     relPath,
+	}
+	if err := file.Insert(); err != nil {
+		service.RemoveStoredFile(relPath)
+		common.SysError("insert file record failed: " + err.Error())
+		openAIApiError(c, http.StatusInternalServerError, "failed to save file", "file_store_error")
+		return
+	}
+	c.JSON(http.StatusOK, file.ToOpenAIFile())
+}
+
+func ListFiles(c *gin.Context) {
+	userId := c.GetInt("id")
+	limit := parseOpenAIListLimit(c, 100, 1000)
+	files, err := model.GetUserFiles(userId, c.Query("purpose"), c.Query("after"), limit+1, c.Query("order"))
+	if err != nil {
+		openAIApiError(c, http.StatusInternalServerError, err.Error(), "query_error")
+		return
+	}
+	hasMore := len(files) > limit
+	if hasMore {
+		files = files[:limit]
+	}
+	res := dto.OpenAIList[*dto.OpenAIFile]{
+		Object:  "list",
+		Data:    make([]*dto.OpenAIFile, 0, len(files)),
+		HasMore: hasMore,
+	}
+	for _, f := range files {
+		res.Data = append(res.Data, f.ToOpenAIFile())
+	}
+	if len(files) > 0 {
+		res.FirstID = files[0].FileId
+		res.LastID = files[len(files)-1].FileId
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func getUserFileOrAbort(c *gin.Context) *model.File {
+	fileId := c.Param("id")
+	file, err := model.GetUserFileByFileId(c.GetInt("id"), fileId)
+	if err != nil {
+		openAIApiError(c, http.StatusNotFound, fmt.Sprintf("No such File object: %s", fileId), "file_not_found")
+		return nil
+	}
+	return file
+}
+
+func RetrieveFile(c *gin.Context) {
+	file := getUserFileOrAbort(c)
+	if file == nil {
+		return
+	}
+	c.JSON(http.StatusOK, file.ToOpenAIFile())
+}
+
+func RetrieveFileContent(c *gin.Context) {
+	file := getUserFileOrAbort(c)
+	if file == nil {
+		return
+	}
+	f, err := service.OpenStoredFile(file.Path)
+	if err != nil {
+		common.SysError(fmt.Sprintf("open stored file %s failed: %s", file.FileId, err.Error()))
+		openAIApiError(c, http.StatusNotFound, "file content is not available", "file_content_missing")
+		return
+	}
+	defer f.Close()
+	contentType := "application/octet-stream"
+	if strings.HasSuffix(strings.ToLower(file.Filename), ".jsonl") {
+		contentType = "application/jsonl"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", file.Filename))
+	c.Header("Content-Length", strconv.FormatInt(file.Bytes, 10))
+	c.Status(http.StatusOK)
+	_, _ = io.Copy(c.Writer, f)
+}
+
+func DeleteFile(c *gin.Context) {
+	file := getUserFileOrAbort(c)
+	if file == nil {
+		return
+	}
+	if err := model.DeleteFileById(file.Id); err != nil {
+		openAIApiError(c, http.StatusInternalServerError, err.Error(), "delete_error")
+		return
+	}
+	service.RemoveStoredFile(file.Path)
+	c.JSON(http.StatusOK, dto.OpenAIFileDeleted{
+		ID:      file.FileId,
+		Object:  "file",
+		Deleted: true,
+	})
+}
@@ -162,6 +162,10 @@ func DeleteHistoryLogs(c *gin.Context) {
 		common.ApiError(c, err)
 		return
 	}
+	if _, err = model.DeleteOldContentLog(c.Request.Context(), targetTimestamp, 100); err != nil {
+		common.ApiError(c, err)
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "",
@@ -169,3 +173,18 @@ func DeleteHistoryLogs(c *gin.Context) {
 	})
 	return
 }
+
+// GetContentLogs 按 request id 查看请求/响应内容日志，仅管理员可用
+func GetContentLogs(c *gin.Context) {
+	requestId := c.Param("request_id")
+	if requestId == "" {
+		common.ApiErrorMsg(c, "request id is required")
+		return
+	}
+	logs, err := model.GetContentLogsByRequestId(requestId)
+	if err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	common.ApiSuccess(c, logs)
+}
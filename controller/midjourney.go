@@ -177,7 +177,12 @@ func UpdateMidjourneyTaskBulk() {
 				if err != nil {
 					logger.LogError(ctx, "UpdateMidjourneyTask task error: "+err.Error())
 				} else if won && shouldReturnQuota {
-					err = model.IncreaseUserQuota(task.UserId, task.Quota, false)
+					if task.OrgId > 0 {
+						// 组织令牌发起的任务退回组织钱包，并同步扣减成员消费
+						err = model.AdjustOrganizationQuota(task.OrgId, task.UserId, -task.Quota)
+					} else {
+						err = model.IncreaseUserQuota(task.UserId, task.Quota, false)
+					}
 					if err != nil {
 						logger.LogError(ctx, "fail to increase user quota: "+err.Error())
 					}
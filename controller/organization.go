@@ -0,0 +1,326 @@
+package controller
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/logger"
+	"github.com/QuantumNous/new-api/model"
+	"github.com/QuantumNous/new-api/service"
+	"github.com/gin-gonic/gin"
+)
+
+type OrganizationRequest struct {
+	Name   string `json:"name"`
+	Status int    `json:"status"`
+}
+
+type OrganizationMemberRequest struct {
+	Username   string `json:"username"`
+	Role       string `json:"role"`
+	QuotaLimit int    `json:"quota_limit"`
+	ResetUsed  bool   `json:"reset_used"`
+}
+
+type OrganizationTransferRequest struct {
+	Quota int `json:"quota"`
+}
+
+type OrganizationTokenStatusRequest struct {
+	Status int `json:"status"`
+}
+
+// getOrganizationMember 解析路径中的组织 ID 并校验当前用户是该组织成员
+func getOrganizationMember(c *gin.Context) (int, *model.OrganizationMember, bool) {
+	orgId, _ := strconv.Atoi(c.Param("id"))
+	if orgId <= 0 {
+		common.ApiErrorMsg(c, "无效的组织ID")
+		return 0, nil, false
+	}
+	member, err := model.GetOrganizationMember(orgId, c.GetInt("id"))
+	if err != nil {
+		common.ApiErrorMsg(c, "无权访问该组织")
+		return 0, nil, false
+	}
+	return orgId, member, true
+}
+
+// getOrganizationManager 在 getOrganizationMember 基础上要求所有者或管理员角色
+func getOrganizationManager(c *gin.Context) (int, *model.OrganizationMember, bool) {
+	orgId, member, ok := getOrganizationMember(c)
+	if !ok {
+		return 0, nil, false
+	}
+	if !member.CanManage() {
+		common.ApiErrorMsg(c, "需要组织所有者或管理员权限")
+		return 0, nil, false
+	}
+	return orgId, member, true
+}
+
+// canAssignOrgRole 管理员只能管理普通成员，所有者可以管理管理员
+func canAssignOrgRole(operator *model.OrganizationMember, role string) bool {
+	if operator.Role == model.OrgRoleOwner {
+		return role == model.OrgRoleAdmin || role == model.OrgRoleMember
+	}
+	return role == model.OrgRoleMember
+}
+
+func GetSelfOrganizations(c *gin.Context) {
+	orgs, err := model.GetUserOrganizations(c.GetInt("id"))
+	if err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	common.ApiSuccess(c, orgs)
+}
+
+func CreateOrganization(c *gin.Context) {
+	var req OrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ApiErrorMsg(c, "参数错误")
+		return
+	}
+	if len(req.Name) > 64 {
+		common.ApiErrorMsg(c, "组织名称过长")
+		return
+	}
+	org, err := model.CreateOrganization(req.Name, c.GetInt("id"))
+	if err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	common.ApiSuccess(c, org)
+}
+
+func GetOrganization(c *gin.Context) {
+	orgId, member, ok := getOrganizationMember(c)
+	if !ok {
+		return
+	}
+	org, err := model.GetOrganizationById(orgId)
+	if err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	common.ApiSuccess(c, model.UserOrganization{
+		Organization: *org,
+		Role:         member.Role,
+		QuotaLimit:   member.QuotaLimit,
+		MemberUsed:   member.UsedQuota,
+	})
+}
+
+func UpdateOrganization(c *gin.Context) {
+	orgId, member, ok := getOrganizationMember(c)
+	if !ok {
+		return
+	}
+	if member.Role != model.OrgRoleOwner {
+		common.ApiErrorMsg(c, "需要组织所有者权限")
+		return
+	}
+	var req OrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.Name) > 64 {
+		common.ApiErrorMsg(c, "参数错误")
+		return
+	}
+	if err := model.UpdateOrganization(orgId, req.Name, req.Status); err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	common.ApiSuccess(c, nil)
+}
+
+func GetOrganizationMembers(c *gin.Context) {
+	orgId, _, ok := getOrganizationManager(c)
+	if !ok {
+		return
+	}
+	members, err := model.GetOrganizationMembers(orgId)
+	if err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	common.ApiSuccess(c, members)
+}
+
+func AddOrganizationMember(c *gin.Context) {
+	orgId, operator, ok := getOrganizationManager(c)
+	if !ok {
+		return
+	}
+	var req OrganizationMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Username == "" {
+		common.ApiErrorMsg(c, "参数错误")
+		return
+	}
+	if req.Role == "" {
+		req.Role = model.OrgRoleMember
+	}
+	if !canAssignOrgRole(operator, req.Role) {
+		common.ApiErrorMsg(c, "无权分配该角色")
+		return
+	}
+	userId, err := model.GetUserIdByUsername(req.Username)
+	if err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	if err := model.AddOrganizationMember(orgId, userId, req.Role, req.QuotaLimit); err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	common.ApiSuccess(c, nil)
+}
+
+func UpdateOrganizationMember(c *gin.Context) {
+	orgId, operator, ok := getOrganizationManager(c)
+	if !ok {
+		return
+	}
+	userId, _ := strconv.Atoi(c.Param("user_id"))
+	target, err := model.GetOrganizationMember(orgId, userId)
+	if err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	var req OrganizationMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ApiErrorMsg(c, "参数错误")
+		return
+	}
+	if req.Role == "" {
+		req.Role = target.Role
+	}
+	if !canAssignOrgRole(operator, target.Role) || !canAssignOrgRole(operator, req.Role) {
+		common.ApiErrorMsg(c, "无权修改该成员")
+		return
+	}
+	if err := model.UpdateOrganizationMember(orgId, userId, req.Role, req.QuotaLimit, req.ResetUsed); err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	common.ApiSuccess(c, nil)
+}
+
+// RemoveOrganizationMember 管理员移除成员，普通成员可以移除自己（退出组织）
+func RemoveOrganizationMember(c *gin.Context) {
+	orgId, operator, ok := getOrganizationMember(c)
+	if !ok {
+		return
+	}
+	userId, _ := strconv.Atoi(c.Param("user_id"))
+	if userId != operator.UserId {
+		target, err := model.GetOrganizationMember(orgId, userId)
+		if err != nil {
+			common.ApiError(c, err)
+			return
+		}
+		if !operator.CanManage() || !canAssignOrgRole(operator, target.Role) {
+			common.ApiErrorMsg(c, "无权移除该成员")
+			return
+		}
+	}
+	if err := model.RemoveOrganizationMember(orgId, userId); err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	common.ApiSuccess(c, nil)
+}
+
+// TransferToOrganization 成员将个人额度转入组织钱包
+func TransferToOrganization(c *gin.Context) {
+	orgId, member, ok := getOrganizationMember(c)
+	if !ok {
+		return
+	}
+	var req OrganizationTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Quota <= 0 {
+		common.ApiErrorMsg(c, "参数错误")
+		return
+	}
+	if err := model.TransferUserQuotaToOrganization(member.UserId, orgId, req.Quota); err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	model.RecordLog(member.UserId, model.LogTypeManage, fmt.Sprintf("向组织 #%d 转入额度 %s", orgId, logger.LogQuota(req.Quota)))
+	common.ApiSuccess(c, nil)
+}
+
+// GetOrganizationTokens 管理员查看全部组织令牌，普通成员只能看到自己的
+func GetOrganizationTokens(c *gin.Context) {
+	orgId, member, ok := getOrganizationMember(c)
+	if !ok {
+		return
+	}
+	tokens, err := model.GetOrganizationTokens(orgId)
+	if err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	if !member.CanManage() {
+		own := make([]*model.Token, 0, len(tokens))
+		for _, token := range tokens {
+			if token.UserId == member.UserId {
+				own = append(own, token)
+			}
+		}
+		tokens = own
+	}
+	common.ApiSuccess(c, tokens)
+}
+
+func UpdateOrganizationTokenStatus(c *gin.Context) {
+	orgId, _, ok := getOrganizationManager(c)
+	if !ok {
+		return
+	}
+	tokenId, _ := strconv.Atoi(c.Param("token_id"))
+	var req OrganizationTokenStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil ||
+		(req.Status != common.TokenStatusEnabled && req.Status != common.TokenStatusDisabled) {
+		common.ApiErrorMsg(c, "参数错误")
+		return
+	}
+	token, err := model.GetTokenById(tokenId)
+	if err != nil || token.OrgId != orgId {
+		common.ApiErrorMsg(c, "令牌不存在")
+		return
+	}
+	originToken := *token
+	token.Status = req.Status
+	if err := token.SelectUpdate(); err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	// 组织管理员修改的是成员的令牌，需要记录审计；用户自助管理令牌不记录
+	service.RecordAudit(c, model.AuditTargetToken, token.Id, model.AuditActionUpdate, &originToken, token)
+	common.ApiSuccess(c, nil)
+}
+
+// GetOrganizationLogs 管理员可按成员筛选组织日志，普通成员只能查看自己的
+func GetOrganizationLogs(c *gin.Context) {
+	orgId, member, ok := getOrganizationMember(c)
+	if !ok {
+		return
+	}
+	pageInfo := common.GetPageQuery(c)
+	memberId, _ := strconv.Atoi(c.Query("member_id"))
+	if !member.CanManage() {
+		memberId = member.UserId
+	}
+	logType, _ := strconv.Atoi(c.Query("type"))
+	startTimestamp, _ := strconv.ParseInt(c.Query("start_timestamp"), 10, 64)
+	endTimestamp, _ := strconv.ParseInt(c.Query("end_timestamp"), 10, 64)
+	modelName := c.Query("model_name")
+	logs, total, err := model.GetOrganizationLogs(orgId, memberId, logType, startTimestamp, endTimestamp, modelName, pageInfo.GetStartIdx(), pageInfo.GetPageSize())
+	if err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	pageInfo.SetTotal(int(total))
+	pageInfo.SetItems(logs)
+	common.ApiSuccess(c, pageInfo)
+}
@@ -15,6 +15,8 @@ import (
 	"github.com/QuantumNous/new-api/logger"
 	"github.com/QuantumNous/new-api/middleware"
 	"github.com/QuantumNous/new-api/model"
+	"github.com/QuantumNous/new-api/pkg/metrics"
+	"github.com/QuantumNous/new-api/pkg/tracing"
 	"github.com/QuantumNous/new-api/relay"
 	relaycommon "github.com/QuantumNous/new-api/relay/common"
 	relayconstant "github.com/QuantumNous/new-api/relay/constant"
@@ -28,6 +30,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 func relayHandler(c *gin.Context, info *relaycommon.RelayInfo) *types.NewAPIError {
@@ -176,6 +179,21 @@ func Relay(c *gin.Context, relayFormat types.RelayFormat) {
 		}
 	}()
 
+	// 内容日志需要在错误响应写出前保存，后注册的 defer 先执行
+	service.BeginContentLogCapture(c, relayInfo)
+	defer func() {
+		service.SaveContentLog(c, relayInfo, newAPIError)
+	}()
+
+	// 精确匹配响应缓存：命中时直接回放，未命中时记录响应以便成功后写入缓存。
+	// 命中同样经过上方预扣费（校验用户与令牌额度），并经 BillingSession 结算，失败时由上方 defer 退款
+	if entry, hit := service.LookupResponseCache(c, relayInfo); hit {
+		newAPIError = relay.ResponseCacheHelper(c, relayInfo, entry)
+		return
+	}
+	service.BeginResponseCapture(c)
+	service.BeginResponsesStoreCapture(c, relayInfo)
+
 	retryParam := &service.RetryParam{
 		Ctx:        c,
 		TokenGroup: relayInfo.TokenGroup,
@@ -183,60 +201,103 @@ func Relay(c *gin.Context, relayFormat types.RelayFormat) {
 		Retry:      common.GetPointer(0),
 	}
 
-	// multiKeyExtraRetries provides additional retry budget for multi-key channels when a
-	// per-key exhaustion error occurs (e.g. insufficient_quota). This allows transparent
-	// key failover even when the global RetryTimes setting is 0.
-	multiKeyExtraRetries := 0
-	for ; retryParam.GetRetry() <= common.RetryTimes+multiKeyExtraRetries; retryParam.IncreaseRetry() {
-		channel, channelErr := getChannel(c, relayInfo, retryParam)
-		if channelErr != nil {
-			logger.LogError(c, channelErr.Error())
-			newAPIError = channelErr
-			break
-		}
+	// 主模型的渠道全部失败或限流时，按降级链依次改用备选模型
+	fallbackModels := getRemainingFallbackModels(c, relayInfo)
+	for {
+		// multiKeyExtraRetries provides additional retry budget for multi-key channels when a
+		// per-key exhaustion error occurs (e.g. insufficient_quota). This allows transparent
+		// key failover even when the global RetryTimes setting is 0.
+		multiKeyExtraRetries := 0
+		for ; retryParam.GetRetry() <= common.RetryTimes+multiKeyExtraRetries; retryParam.IncreaseRetry() {
+			attemptSpan := tracing.Start(c, "relay.attempt",
+				attribute.String("newapi.model", relayInfo.OriginModelName),
+				attribute.Int("newapi.retry", retryParam.GetRetry()),
+			)
+			channel, channelErr := getChannel(c, relayInfo, retryParam)
+			if channelErr != nil {
+				logger.LogError(c, channelErr.Error())
+				newAPIError = channelErr
+				attemptSpan.EndWithAPIError(newAPIError)
+				break
+			}
+			attemptSpan.SetAttributes(
+				attribute.Int("newapi.channel_id", channel.Id),
+				attribute.String("newapi.group", relayInfo.UsingGroup),
+			)
 
-		addUsedChannel(c, channel.Id)
-		bodyStorage, bodyErr := common.GetBodyStorage(c)
-		if bodyErr != nil {
-			// Ensure consistent 413 for oversized bodies even when error occurs later (e.g., retry path)
-			if common.IsRequestBodyTooLargeError(bodyErr) || errors.Is(bodyErr, common.ErrRequestBodyTooLarge) {
-				newAPIError = types.NewErrorWithStatusCode(bodyErr, types.ErrorCodeReadRequestBodyFailed, http.StatusRequestEntityTooLarge, types.ErrOptionWithSkipRetry())
-			} else {
-				newAPIError = types.NewErrorWithStatusCode(bodyErr, types.ErrorCodeReadRequestBodyFailed, http.StatusBadRequest, types.ErrOptionWithSkipRetry())
+			if len(c.GetStringSlice("use_channel")) > 0 {
+				metrics.IncRelayRetry(relayInfo.OriginModelName, relayInfo.UsingGroup)
 			}
-			break
-		}
-		c.Request.Body = io.NopCloser(bodyStorage)
+			addUsedChannel(c, channel.Id)
+			bodyStorage, bodyErr := common.GetBodyStorage(c)
+			if bodyErr != nil {
+				// Ensure consistent 413 for oversized bodies even when error occurs later (e.g., retry path)
+				if common.IsRequestBodyTooLargeError(bodyErr) || errors.Is(bodyErr, common.ErrRequestBodyTooLarge) {
+					newAPIError = types.NewErrorWithStatusCode(bodyErr, types.ErrorCodeReadRequestBodyFailed, http.StatusRequestEntityTooLarge, types.ErrOptionWithSkipRetry())
+				} else {
+					newAPIError = types.NewErrorWithStatusCode(bodyErr, types.ErrorCodeReadRequestBodyFailed, http.StatusBadRequest, types.ErrOptionWithSkipRetry())
+				}
+				attemptSpan.EndWithAPIError(newAPIError)
+				break
+			}
+			c.Request.Body = io.NopCloser(bodyStorage)
 
-		switch relayFormat {
-		case types.RelayFormatOpenAIRealtime:
-			newAPIError = relay.WssHelper(c, relayInfo)
-		case types.RelayFormatClaude:
-			newAPIError = relay.ClaudeHelper(c, relayInfo)
-		case types.RelayFormatGemini:
-			newAPIError = geminiRelayHandler(c, relayInfo)
-		default:
-			newAPIError = relayHandler(c, relayInfo)
-		}
+			attemptStart := time.Now()
+			switch relayFormat {
+			case types.RelayFormatOpenAIRealtime:
+				newAPIError = relay.WssHelper(c, relayInfo)
+			case types.RelayFormatClaude:
+				newAPIError = relay.ClaudeHelper(c, relayInfo)
+			case types.RelayFormatGemini:
+				newAPIError = geminiRelayHandler(c, relayInfo)
+			default:
+				newAPIError = relayHandler(c, relayInfo)
+			}
 
-		if newAPIError == nil {
-			return
-		}
+			recordChannelHealth(relayInfo, channel.Id, attemptStart, newAPIError)
+			recordChannelKeyResult(c, channel.Id, newAPIError)
+			recordRelayMetrics(relayInfo, channel.Id, attemptStart, newAPIError)
+			attemptSpan.EndWithAPIError(newAPIError)
 
-		newAPIError = service.NormalizeViolationFeeError(newAPIError)
+			if newAPIError == nil {
+				service.SaveResponseCache(c, relayInfo)
+				service.SaveResponsesStore(c, relayInfo)
+				return
+			}
+
+			newAPIError = service.NormalizeViolationFeeError(newAPIError)
 
-		processChannelError(c, *types.NewChannelError(channel.Id, channel.Type, channel.Name, channel.ChannelInfo.IsMultiKey, common.GetContextKeyString(c, constant.ContextKeyChannelKey), channel.GetAutoBan()), newAPIError)
+			processChannelError(c, *types.NewChannelError(channel.Id, channel.Type, channel.Name, channel.ChannelInfo.IsMultiKey, common.GetContextKeyString(c, constant.ContextKeyChannelKey), channel.GetAutoBan()), newAPIError)
+
+			// For multi-key channels, per-key exhaustion errors should transparently fail over
+			// to the next key, regardless of the global RetryTimes setting.
+			// Use the context key (set by SetupContextForSelectedChannel) because on the first
+			// iteration getChannel returns a minimal channel without ChannelInfo populated.
+			if common.GetContextKeyBool(c, constant.ContextKeyChannelIsMultiKey) && isPerKeyExhaustionError(newAPIError) && multiKeyExtraRetries < 20 {
+				multiKeyExtraRetries++
+				continue
+			}
 
-		// For multi-key channels, per-key exhaustion errors should transparently fail over
-		// to the next key, regardless of the global RetryTimes setting.
-		// Use the context key (set by SetupContextForSelectedChannel) because on the first
-		// iteration getChannel returns a minimal channel without ChannelInfo populated.
-		if common.GetContextKeyBool(c, constant.ContextKeyChannelIsMultiKey) && isPerKeyExhaustionError(newAPIError) && multiKeyExtraRetries < 20 {
-			multiKeyExtraRetries++
-			continue
+			if !shouldRetry(c, newAPIError, common.RetryTimes-retryParam.GetRetry()) {
+				break
+			}
 		}
 
-		if !shouldRetry(c, newAPIError, common.RetryTimes-retryParam.GetRetry()) {
+		if !service.ShouldFallbackModel(c, newAPIError) {
+			break
+		}
+		switched := false
+		for len(fallbackModels) > 0 && !switched {
+			fallbackModel := fallbackModels[0]
+			fallbackModels = fallbackModels[1:]
+			var switchErr *types.NewAPIError
+			switched, switchErr = switchToFallbackModel(c, relayInfo, retryParam, fallbackModel, tokens, meta)
+			if switchErr != nil {
+				newAPIError = switchErr
+				break
+			}
+		}
+		if !switched {
 			break
 		}
 	}
@@ -320,6 +381,51 @@ func getChannel(c *gin.Context, info *relaycommon.RelayInfo, retryParam *service
 	return channel, nil
 }
 
+// getRemainingFallbackModels 返回尚未尝试的备选模型，分发阶段已降级时从当前模型之后继续
+func getRemainingFallbackModels(c *gin.Context, relayInfo *relaycommon.RelayInfo) []string {
+	if relayInfo.FallbackFromModel == "" {
+		return service.GetModelFallbackChain(c, relayInfo.UsingGroup, relayInfo.OriginModelName)
+	}
+	chain := service.GetModelFallbackChain(c, relayInfo.UsingGroup, relayInfo.FallbackFromModel)
+	for i, fallback := range chain {
+		if fallback == relayInfo.OriginModelName {
+			return chain[i+1:]
+		}
+	}
+	return nil
+}
+
+// switchToFallbackModel 将请求切换到备选模型并按其倍率重新计算价格，返回 false 表示跳过该模型。
+// 已预扣的额度保留，结算时按实际模型的消耗补差或退还。
+func switchToFallbackModel(c *gin.Context, relayInfo *relaycommon.RelayInfo, retryParam *service.RetryParam, fallbackModel string, tokens int, meta *types.TokenCountMeta) (bool, *types.NewAPIError) {
+	// 尚未初始化渠道信息时 getChannel 不会重新选择渠道
+	if relayInfo.ChannelMeta == nil {
+		return false, nil
+	}
+	previousModel := relayInfo.OriginModelName
+	relayInfo.OriginModelName = fallbackModel
+	priceData, err := helper.ModelPriceHelper(c, relayInfo, tokens, meta)
+	if err != nil {
+		relayInfo.OriginModelName = previousModel
+		logger.LogWarn(c, fmt.Sprintf("skip fallback model %s: %s", fallbackModel, err.Error()))
+		return false, nil
+	}
+	if relayInfo.FallbackFromModel == "" {
+		relayInfo.FallbackFromModel = previousModel
+	}
+	retryParam.ModelName = fallbackModel
+	retryParam.SetRetry(0)
+	service.ResetAutoGroupSelection(c)
+	logger.LogInfo(c, fmt.Sprintf("model %s failed, fallback to %s", previousModel, fallbackModel))
+
+	if relayInfo.Billing == nil && !priceData.FreeModel {
+		if apiErr := service.PreConsumeBilling(c, priceData.QuotaToPreConsume, relayInfo); apiErr != nil {
+			return false, apiErr
+		}
+	}
+	return true, nil
+}
+
 // isPerKeyExhaustionError returns true for errors indicating the specific API key is
 // exhausted or invalid, so a sibling key on the same multi-key channel might succeed.
 func isPerKeyExhaustionError(err *types.NewAPIError) bool {
@@ -355,6 +461,10 @@ func shouldRetry(c *gin.Context, openaiErr *types.NewAPIError, retryTimes int) b
 	if _, ok := c.Get("specific_channel_id"); ok {
 		return false
 	}
+	if openaiErr.GetErrorCode() == types.ErrorCodeFirstTokenTimeout {
+		// 只有尚未向客户端写出任何内容（包括保活 ping）时才能安全地切换渠道重试
+		return !c.Writer.Written()
+	}
 	code := openaiErr.StatusCode
 	if code >= 200 && code < 300 {
 		return false
@@ -365,6 +475,54 @@ func shouldRetry(c *gin.Context, openaiErr *types.NewAPIError, retryTimes int) b
 	return operation_setting.ShouldRetryByStatusCode(code)
 }
 
+// recordChannelHealth 记录单次尝试的结果用于健康度选路，客户端自身的错误不计入渠道失败
+func recordChannelHealth(relayInfo *relaycommon.RelayInfo, channelId int, attemptStart time.Time, err *types.NewAPIError) {
+	if err == nil {
+		latency := time.Since(attemptStart)
+		if relayInfo.FirstResponseTime.After(attemptStart) {
+			latency = relayInfo.FirstResponseTime.Sub(attemptStart)
+		}
+		model.RecordChannelHealth(channelId, relayInfo.OriginModelName, true, latency)
+		return
+	}
+	if !types.IsChannelError(err) {
+		if types.IsSkipRetryError(err) {
+			return
+		}
+		code := err.StatusCode
+		if code >= 100 && code < 500 && code != http.StatusTooManyRequests && code != http.StatusRequestTimeout &&
+			code != http.StatusUnauthorized && code != http.StatusForbidden {
+			return
+		}
+	}
+	model.RecordChannelHealth(channelId, relayInfo.OriginModelName, false, 0)
+}
+
+// recordChannelKeyResult 记录多 Key 渠道中所选 key 的结果，429 会使该 key 进入冷却
+func recordChannelKeyResult(c *gin.Context, channelId int, err *types.NewAPIError) {
+	if !common.GetContextKeyBool(c, constant.ContextKeyChannelIsMultiKey) {
+		return
+	}
+	index := common.GetContextKeyInt(c, constant.ContextKeyChannelMultiKeyIndex)
+	if err == nil {
+		model.RecordChannelKeyResult(channelId, index, http.StatusOK, 0)
+		return
+	}
+	model.RecordChannelKeyResult(channelId, index, err.StatusCode, err.RetryAfter)
+}
+
+func recordRelayMetrics(relayInfo *relaycommon.RelayInfo, channelId int, attemptStart time.Time, err *types.NewAPIError) {
+	status := http.StatusOK
+	if err != nil {
+		status = err.StatusCode
+	}
+	var firstToken time.Duration
+	if err == nil && relayInfo.IsStream && relayInfo.FirstResponseTime.After(attemptStart) {
+		firstToken = relayInfo.FirstResponseTime.Sub(attemptStart)
+	}
+	metrics.ObserveRelay(relayInfo.OriginModelName, channelId, relayInfo.UsingGroup, status, time.Since(attemptStart), firstToken)
+}
+
 func processChannelError(c *gin.Context, channelError types.ChannelError, err *types.NewAPIError) {
 	logger.LogError(c, fmt.Sprintf("channel error (channel #%d, status code: %d): %s", channelError.ChannelId, err.StatusCode, err.Error()))
 	// 不要使用context获取渠道信息，异步处理时可能会出现渠道信息不一致的情况
@@ -592,6 +750,7 @@ func RelayTask(c *gin.Context) {
 		task.PrivateData.UpstreamTaskID = result.UpstreamTaskID
 		task.PrivateData.BillingSource = relayInfo.BillingSource
 		task.PrivateData.SubscriptionId = relayInfo.SubscriptionId
+		task.PrivateData.OrgId = relayInfo.TokenOrgId
 		task.PrivateData.TokenId = relayInfo.TokenId
 		task.PrivateData.BillingContext = &model.TaskBillingContext{
 			ModelPrice:      relayInfo.PriceData.ModelPrice,
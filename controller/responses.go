@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/QuantumNous/new-api/model"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getUserResponseRecordOrAbort 只能读取网关本地保存的响应，上游保存的响应无法在不选择渠道的情况下查询
+func getUserResponseRecordOrAbort(c *gin.Context) *model.ResponseRecord {
+	responseId := c.Param("id")
+	if !operation_setting.GetResponsesStoreSetting().Enabled {
+		openAIApiError(c, http.StatusNotFound, fmt.Sprintf("Response with id '%s' not found.", responseId), "response_not_found")
+		return nil
+	}
+	record, err := model.GetUserResponseRecord(c.GetInt("id"), c.GetInt("token_id"), responseId)
+	if err != nil {
+		openAIApiError(c, http.StatusNotFound, fmt.Sprintf("Response with id '%s' not found.", responseId), "response_not_found")
+		return nil
+	}
+	return record
+}
+
+func RetrieveResponse(c *gin.Context) {
+	record := getUserResponseRecordOrAbort(c)
+	if record == nil {
+		return
+	}
+	c.Data(http.StatusOK, "application/json", []byte(record.Response))
+}
+
+func DeleteResponse(c *gin.Context) {
+	record := getUserResponseRecordOrAbort(c)
+	if record == nil {
+		return
+	}
+	if err := model.DeleteResponseRecordById(record.Id); err != nil {
+		openAIApiError(c, http.StatusInternalServerError, err.Error(), "delete_error")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"id":      record.ResponseId,
+		"object":  "response",
+		"deleted": true,
+	})
+}
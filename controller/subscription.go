@@ -6,6 +6,7 @@ import (
 
 	"github.com/QuantumNous/new-api/common"
 	"github.com/QuantumNous/new-api/model"
+	"github.com/QuantumNous/new-api/service"
 	"github.com/QuantumNous/new-api/setting/ratio_setting"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -162,6 +163,7 @@ func AdminCreateSubscriptionPlan(c *gin.Context) {
 		return
 	}
 	model.InvalidateSubscriptionPlanCache(req.Plan.Id)
+	service.RecordAudit(c, model.AuditTargetSubscriptionPlan, req.Plan.Id, model.AuditActionCreate, nil, &req.Plan)
 	common.ApiSuccess(c, req.Plan)
 }
 
@@ -220,6 +222,12 @@ func AdminUpdateSubscriptionPlan(c *gin.Context) {
 		return
 	}
 
+	var originPlan model.SubscriptionPlan
+	if err := model.DB.Where("id = ?", id).First(&originPlan).Error; err != nil {
+		common.ApiError(c, err)
+		return
+	}
+
 	err := model.DB.Transaction(func(tx *gorm.DB) error {
 		// update plan (allow zero values updates with map)
 		updateMap := map[string]interface{}{
@@ -251,6 +259,10 @@ func AdminUpdateSubscriptionPlan(c *gin.Context) {
 		return
 	}
 	model.InvalidateSubscriptionPlanCache(id)
+	var updatedPlan model.SubscriptionPlan
+	if err := model.DB.Where("id = ?", id).First(&updatedPlan).Error; err == nil {
+		service.RecordAudit(c, model.AuditTargetSubscriptionPlan, id, model.AuditActionUpdate, &originPlan, &updatedPlan)
+	}
 	common.ApiSuccess(c, nil)
 }
 
@@ -269,11 +281,18 @@ func AdminUpdateSubscriptionPlanStatus(c *gin.Context) {
 		common.ApiErrorMsg(c, "参数错误")
 		return
 	}
+	var originPlan model.SubscriptionPlan
+	if err := model.DB.Where("id = ?", id).First(&originPlan).Error; err != nil {
+		common.ApiError(c, err)
+		return
+	}
 	if err := model.DB.Model(&model.SubscriptionPlan{}).Where("id = ?", id).Update("enabled", *req.Enabled).Error; err != nil {
 		common.ApiError(c, err)
 		return
 	}
 	model.InvalidateSubscriptionPlanCache(id)
+	service.RecordAudit(c, model.AuditTargetSubscriptionPlan, id, model.AuditActionUpdate,
+		map[string]bool{"enabled": originPlan.Enabled}, map[string]bool{"enabled": *req.Enabled})
 	common.ApiSuccess(c, nil)
 }
 
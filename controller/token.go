@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/QuantumNous/new-api/common"
 	"github.com/QuantumNous/new-api/i18n"
@@ -22,6 +23,9 @@ func GetAllTokens(c *gin.Context) {
 		common.ApiError(c, err)
 		return
 	}
+	for _, token := range tokens {
+		token.HideKey()
+	}
 	total, _ := model.CountUserTokens(userId)
 	pageInfo.SetTotal(int(total))
 	pageInfo.SetItems(tokens)
@@ -41,6 +45,9 @@ func SearchTokens(c *gin.Context) {
 		common.ApiError(c, err)
 		return
 	}
+	for _, token := range tokens {
+		token.HideKey()
+	}
 	pageInfo.SetTotal(int(total))
 	pageInfo.SetItems(tokens)
 	common.ApiSuccess(c, pageInfo)
@@ -59,6 +66,7 @@ func GetToken(c *gin.Context) {
 		common.ApiError(c, err)
 		return
 	}
+	token.HideKey()
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "",
@@ -148,6 +156,18 @@ func AddToken(c *gin.Context) {
 		common.ApiErrorI18n(c, i18n.MsgTokenNameTooLong)
 		return
 	}
+	if token.RpmLimit < 0 || token.TpmLimit < 0 || token.ConcurrencyLimit < 0 {
+		common.ApiErrorI18n(c, i18n.MsgTokenRateLimitNegative)
+		return
+	}
+	if !normalizeTokenBudget(&token) {
+		common.ApiErrorI18n(c, i18n.MsgTokenBudgetInvalid)
+		return
+	}
+	if !normalizeTokenModelFallbacks(&token) {
+		common.ApiErrorI18n(c, i18n.MsgTokenFallbacksInvalid)
+		return
+	}
 	// 非无限额度时，检查额度值是否超出有效范围
 	if !token.UnlimitedQuota {
 		if token.RemainQuota < 0 {
@@ -160,6 +180,18 @@ func AddToken(c *gin.Context) {
 			return
 		}
 	}
+	// 组织令牌要求当前用户是该组织的成员且组织未被禁用
+	if token.OrgId > 0 {
+		org, err := model.GetOrganizationById(token.OrgId)
+		if err != nil || org.Status != model.OrganizationStatusEnabled {
+			common.ApiErrorMsg(c, "组织不存在或已被禁用")
+			return
+		}
+		if _, err := model.GetOrganizationMember(token.OrgId, c.GetInt("id")); err != nil {
+			common.ApiErrorMsg(c, "无权访问该组织")
+			return
+		}
+	}
 	// 检查用户令牌数量是否已达上限
 	maxTokens := operation_setting.GetMaxUserTokens()
 	count, err := model.CountUserTokens(c.GetInt("id"))
@@ -182,8 +214,8 @@ func AddToken(c *gin.Context) {
 	}
 	cleanToken := model.Token{
 		UserId:             c.GetInt("id"),
+		OrgId:              token.OrgId,
 		Name:               token.Name,
-		Key:                key,
 		CreatedTime:        common.GetTimestamp(),
 		AccessedTime:       common.GetTimestamp(),
 		ExpiredTime:        token.ExpiredTime,
@@ -194,12 +226,30 @@ func AddToken(c *gin.Context) {
 		AllowIps:           token.AllowIps,
 		Group:              token.Group,
 		CrossGroupRetry:    token.CrossGroupRetry,
+		RpmLimit:           token.RpmLimit,
+		TpmLimit:           token.TpmLimit,
+		ConcurrencyLimit:   token.ConcurrencyLimit,
+		ContentLogEnabled:  token.ContentLogEnabled,
+		ModelFallbacks:     token.ModelFallbacks,
+		BudgetPeriod:       token.BudgetPeriod,
+		BudgetQuota:        token.BudgetQuota,
+		BudgetCarryOverCap: token.BudgetCarryOverCap,
+	}
+	if cleanToken.HasBudget() {
+		cleanToken.RemainQuota = cleanToken.BudgetQuota
+		cleanToken.NextBudgetResetTime = model.CalcTokenBudgetNextReset(cleanToken.BudgetPeriod, time.Now())
+	}
+	if err := cleanToken.SetKey(key); err != nil {
+		common.ApiErrorI18n(c, i18n.MsgTokenGenerateFailed)
+		common.SysLog("failed to hash token key: " + err.Error())
+		return
 	}
 	err = cleanToken.Insert()
 	if err != nil {
 		common.ApiError(c, err)
 		return
 	}
+	// 完整密钥只在创建时返回这一次
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "",
@@ -236,6 +286,18 @@ func UpdateToken(c *gin.Context) {
 		common.ApiErrorI18n(c, i18n.MsgTokenNameTooLong)
 		return
 	}
+	if token.RpmLimit < 0 || token.TpmLimit < 0 || token.ConcurrencyLimit < 0 {
+		common.ApiErrorI18n(c, i18n.MsgTokenRateLimitNegative)
+		return
+	}
+	if statusOnly == "" && !normalizeTokenBudget(&token) {
+		common.ApiErrorI18n(c, i18n.MsgTokenBudgetInvalid)
+		return
+	}
+	if statusOnly == "" && !normalizeTokenModelFallbacks(&token) {
+		common.ApiErrorI18n(c, i18n.MsgTokenFallbacksInvalid)
+		return
+	}
 	if !token.UnlimitedQuota {
 		if token.RemainQuota < 0 {
 			common.ApiErrorI18n(c, i18n.MsgTokenQuotaNegative)
@@ -275,16 +337,37 @@ func UpdateToken(c *gin.Context) {
 		cleanToken.AllowIps = token.AllowIps
 		cleanToken.Group = token.Group
 		cleanToken.CrossGroupRetry = token.CrossGroupRetry
+		cleanToken.RpmLimit = token.RpmLimit
+		cleanToken.TpmLimit = token.TpmLimit
+		cleanToken.ConcurrencyLimit = token.ConcurrencyLimit
+		cleanToken.ContentLogEnabled = token.ContentLogEnabled
+		cleanToken.ModelFallbacks = token.ModelFallbacks
+		// 周期变化或首次开启预算时重新计算下次重置时间，首次开启时额度直接按预算发放
+		periodChanged := cleanToken.BudgetPeriod != token.BudgetPeriod
+		budgetEnabled := !cleanToken.HasBudget()
+		cleanToken.BudgetPeriod = token.BudgetPeriod
+		cleanToken.BudgetQuota = token.BudgetQuota
+		cleanToken.BudgetCarryOverCap = token.BudgetCarryOverCap
+		if !cleanToken.HasBudget() {
+			cleanToken.NextBudgetResetTime = 0
+		} else if periodChanged || cleanToken.NextBudgetResetTime == 0 {
+			if budgetEnabled {
+				cleanToken.RemainQuota = cleanToken.BudgetQuota
+			}
+			cleanToken.NextBudgetResetTime = model.CalcTokenBudgetNextReset(cleanToken.BudgetPeriod, time.Now())
+		}
 	}
 	err = cleanToken.Update()
 	if err != nil {
 		common.ApiError(c, err)
 		return
 	}
+	responseToken := *cleanToken
+	responseToken.HideKey()
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "",
-		"data":    cleanToken,
+		"data":    responseToken,
 	})
 }
 
@@ -310,3 +393,41 @@ func DeleteTokenBatch(c *gin.Context) {
 		"data":    count,
 	})
 }
+
+// normalizeTokenBudget 规范化周期预算配置，开启预算的令牌不能是无限额度
+func normalizeTokenBudget(token *model.Token) bool {
+	token.BudgetPeriod = model.NormalizeTokenBudgetPeriod(token.BudgetPeriod)
+	if token.BudgetPeriod == model.SubscriptionResetNever {
+		token.BudgetQuota = 0
+		token.BudgetCarryOverCap = 0
+		return true
+	}
+	if token.BudgetQuota <= 0 || token.BudgetCarryOverCap < 0 {
+		return false
+	}
+	token.UnlimitedQuota = false
+	return true
+}
+
+// normalizeTokenModelFallbacks 校验模型降级链格式：{"主模型": ["备选模型", ...]}
+func normalizeTokenModelFallbacks(token *model.Token) bool {
+	token.ModelFallbacks = strings.TrimSpace(token.ModelFallbacks)
+	if token.ModelFallbacks == "" {
+		return true
+	}
+	fallbacks := make(map[string][]string)
+	if err := common.UnmarshalJsonStr(token.ModelFallbacks, &fallbacks); err != nil {
+		return false
+	}
+	for modelName, chain := range fallbacks {
+		if strings.TrimSpace(modelName) == "" {
+			return false
+		}
+		for _, fallback := range chain {
+			if strings.TrimSpace(fallback) == "" {
+				return false
+			}
+		}
+	}
+	return true
+}
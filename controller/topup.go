@@ -612,7 +612,7 @@ func QueryLinuxDoOrder(c *gin.Context) {
 }
 
 func RefundLinuxDoOrder(c *gin.Context) {
-	if c.GetInt("role") < common.RoleAdminUser {
+	if !model.UserHasAdminPermissions(c.GetInt("id"), c.GetInt("role"), common.PermissionBillingManage) {
 		common.ApiErrorMsg(c, "仅管理员可操作退款")
 		return
 	}
@@ -51,3 +51,16 @@ func GetUserQuotaDates(c *gin.Context) {
 	})
 	return
 }
+
+// GetProfitData 按渠道、模型或天汇总收入、上游成本与毛利
+func GetProfitData(c *gin.Context) {
+	startTimestamp, _ := strconv.ParseInt(c.Query("start_timestamp"), 10, 64)
+	endTimestamp, _ := strconv.ParseInt(c.Query("end_timestamp"), 10, 64)
+	groupBy := c.DefaultQuery("group_by", "channel")
+	data, err := model.GetProfitData(startTimestamp, endTimestamp, groupBy)
+	if err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	common.ApiSuccess(c, data)
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -292,7 +293,6 @@ func Register(c *gin.Context) {
 		token := model.Token{
 			UserId:             insertedUser.Id, // 使用插入后的用户ID
 			Name:               cleanUser.Username + "的初始令牌",
-			Key:                key,
 			CreatedTime:        common.GetTimestamp(),
 			AccessedTime:       common.GetTimestamp(),
 			ExpiredTime:        -1,     // 永不过期
@@ -303,6 +303,10 @@ func Register(c *gin.Context) {
 		if setting.DefaultUseAutoGroup {
 			token.Group = "auto"
 		}
+		if err := token.SetKey(key); err != nil {
+			common.ApiErrorI18n(c, i18n.MsgCreateDefaultTokenErr)
+			return
+		}
 		if err := token.Insert(); err != nil {
 			common.ApiErrorI18n(c, i18n.MsgCreateDefaultTokenErr)
 			return
@@ -467,7 +471,7 @@ func GetSelf(c *gin.Context) {
 	user.Remark = ""
 
 	// 计算用户权限信息
-	permissions := calculateUserPermissions(userRole)
+	permissions := calculateUserPermissions(id, userRole)
 
 	// 获取用户设置并提取sidebar_modules
 	userSetting := user.GetSetting()
@@ -509,9 +513,29 @@ func GetSelf(c *gin.Context) {
 	return
 }
 
+// 管理侧边栏模块对应的访问权限
+var adminSidebarModulePermissions = map[string]string{
+	"channel":             common.PermissionChannelRead,
+	"models":              common.PermissionModelManage,
+	"deployment":          common.PermissionModelManage,
+	"redemption":          common.PermissionBillingManage,
+	"registration_code":   common.PermissionBillingManage,
+	"subscription":        common.PermissionBillingManage,
+	"user":                common.PermissionUserRead,
+	"deletion_request":    common.PermissionUserManage,
+	"tgbot":               common.PermissionFarmManage,
+	"farm_beta_apps":      common.PermissionFarmManage,
+	"farm_beta_ai_config": common.PermissionFarmManage,
+	"farm_steal_config":   common.PermissionFarmManage,
+	"farm_season_config":  common.PermissionFarmManage,
+	"feedback_admin":      common.PermissionFeedbackManage,
+}
+
 // 计算用户权限的辅助函数
-func calculateUserPermissions(userRole int) map[string]interface{} {
+func calculateUserPermissions(userId int, userRole int) map[string]interface{} {
 	permissions := map[string]interface{}{}
+	adminPermissions := model.GetUserAdminPermissions(userId, userRole)
+	permissions["admin_permissions"] = adminPermissions
 
 	// 根据用户角色计算权限
 	if userRole == common.RoleRootUser {
@@ -521,10 +545,17 @@ func calculateUserPermissions(userRole int) map[string]interface{} {
 	} else if userRole == common.RoleAdminUser {
 		// 管理员可以设置边栏，但不包含系统设置功能
 		permissions["sidebar_settings"] = true
+		adminModules := map[string]interface{}{
+			"setting": false, // 管理员不能访问系统设置
+		}
+		// 按管理角色隐藏无权访问的管理模块
+		for module, permission := range adminSidebarModulePermissions {
+			if !slices.Contains(adminPermissions, permission) {
+				adminModules[module] = false
+			}
+		}
 		permissions["sidebar_modules"] = map[string]interface{}{
-			"admin": map[string]interface{}{
-				"setting": false, // 管理员不能访问系统设置
-			},
+			"admin": adminModules,
 		}
 	} else {
 		// 普通用户只能设置个人功能，不包含管理员区域
@@ -657,6 +688,10 @@ func UpdateUser(c *gin.Context) {
 		common.ApiErrorI18n(c, i18n.MsgUserCannotCreateHigherLevel)
 		return
 	}
+	if originUser.Quota != updatedUser.Quota && !model.UserHasAdminPermissions(c.GetInt("id"), myRole, common.PermissionBillingManage) {
+		common.ApiErrorI18n(c, i18n.MsgUserNoQuotaPermission)
+		return
+	}
 	if updatedUser.Password == "$I_LOVE_U" {
 		updatedUser.Password = "" // rollback to what it should be
 	}
@@ -665,6 +700,13 @@ func UpdateUser(c *gin.Context) {
 		common.ApiError(c, err)
 		return
 	}
+	if auditUser, err := model.GetUserById(updatedUser.Id, false); err == nil {
+		if updatePassword {
+			// 密码只标记为已修改，差异中会被遮蔽
+			auditUser.Password = "changed"
+		}
+		service.RecordAudit(c, model.AuditTargetUser, updatedUser.Id, model.AuditActionUpdate, originUser, auditUser)
+	}
 	if originUser.Quota != updatedUser.Quota {
 		model.RecordLog(originUser.Id, model.LogTypeManage, fmt.Sprintf("管理员将用户额度从 %s修改为 %s", logger.LogQuota(originUser.Quota), logger.LogQuota(updatedUser.Quota)))
 	}
@@ -862,6 +904,9 @@ func DeleteUser(c *gin.Context) {
 		return
 	}
 	err = model.HardDeleteUserById(id)
+	if err == nil {
+		service.RecordAudit(c, model.AuditTargetUser, id, model.AuditActionDelete, originUser, nil)
+	}
 	if err != nil {
 		c.JSON(http.StatusOK, gin.H{
 			"success": true,
@@ -971,6 +1016,8 @@ func CreateUser(c *gin.Context) {
 		common.ApiError(c, err)
 		return
 	}
+	cleanUser.Password = ""
+	service.RecordAudit(c, model.AuditTargetUser, cleanUser.Id, model.AuditActionCreate, nil, &cleanUser)
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -1007,6 +1054,7 @@ func ManageUser(c *gin.Context) {
 		common.ApiErrorI18n(c, i18n.MsgUserNoPermissionHigherLevel)
 		return
 	}
+	auditBefore := map[string]int{"role": user.Role, "status": user.Status}
 	switch req.Action {
 	case "disable":
 		user.Status = common.UserStatusDisabled
@@ -1054,6 +1102,14 @@ func ManageUser(c *gin.Context) {
 		common.ApiError(c, err)
 		return
 	}
+	service.RecordAudit(c, model.AuditTargetUser, user.Id, req.Action, auditBefore, map[string]int{"role": user.Role, "status": user.Status})
+	if req.Action == "demote" && user.AdminRoleId != 0 {
+		// 降级后清除管理角色，避免再次提升时沿用旧角色
+		if err := model.SetUserAdminRole(user.Id, 0); err != nil {
+			common.ApiError(c, err)
+			return
+		}
+	}
 	clearUser := model.User{
 		Role:   user.Role,
 		Status: user.Status,
@@ -558,7 +558,7 @@ func WebFarmLeaderboard(c *gin.Context) {
 
 	// cohort（新老玩家分榜）：普通玩家强制绑到自己所属 cohort，看不到另一边；
 	// 管理员可传 old/new/all 自由切换，默认 all（一屏看全部）。
-	isAdmin := user.Role >= common.RoleAdminUser
+	isAdmin := model.UserHasAdminPermissions(user.Id, user.Role, common.PermissionFarmManage)
 	ownCohort := model.GetFarmPlayerCohort(tgId)
 	cohort := c.DefaultQuery("cohort", "")
 	switch cohort {
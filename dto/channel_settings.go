@@ -7,6 +7,10 @@ type ChannelSettings struct {
 	PassThroughBodyEnabled bool   `json:"pass_through_body_enabled,omitempty"`
 	SystemPrompt           string `json:"system_prompt,omitempty"`
 	SystemPromptOverride   bool   `json:"system_prompt_override,omitempty"`
+	// 流式请求首字超时（秒），超时且未向客户端输出内容时切换渠道重试，0 使用全局配置
+	FirstTokenTimeoutSeconds int `json:"first_token_timeout_seconds,omitempty"`
+	// 对冲请求延迟（毫秒），首个请求超过该时长仍无输出时再发起一个请求，取先出字的结果
+	HedgeDelayMs int `json:"hedge_delay_ms,omitempty"`
 }
 
 type VertexKeyType string
@@ -34,6 +38,8 @@ type ChannelOtherSettings struct {
 	AllowSafetyIdentifier   bool          `json:"allow_safety_identifier,omitempty"`   // 是否允许 safety_identifier 透传（默认过滤以保护用户隐私）
 	AllowIncludeObfuscation bool          `json:"allow_include_obfuscation,omitempty"` // 是否允许 stream_options.include_obfuscation 透传（默认过滤以避免关闭流混淆保护）
 	AwsKeyType              AwsKeyType    `json:"aws_key_type,omitempty"`
+	ClaudeCacheControl      string        `json:"claude_cache_control,omitempty"` // 自动注入 cache_control：空为跟随全局配置，off 关闭，5m / 1h 强制开启
+	CostRatio               *float64      `json:"cost_ratio,omitempty"`           // 上游成本相对模型原价（不含分组倍率）的倍率，未设置时按 1 计算
 }
 
 func (s *ChannelOtherSettings) IsOpenRouterEnterprise() bool {
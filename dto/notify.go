@@ -10,13 +10,14 @@ type Notify struct {
 const ContentValueParam = "{{value}}"
 
 const (
-	NotifyTypeQuotaExceed         = "quota_exceed"
-	NotifyTypeChannelUpdate       = "channel_update"
-	NotifyTypeChannelTest         = "channel_test"
-	NotifyTypeFarmCropNeedsWater  = "farm_crop_needs_water"
-	NotifyTypeFarmCropNearDeath   = "farm_crop_near_death"
-	NotifyTypeFarmCropStolen      = "farm_crop_stolen"
-	NotifyTypeRanchAnimalCleanup  = "ranch_animal_needs_cleanup"
+	NotifyTypeQuotaExceed          = "quota_exceed"
+	NotifyTypeTokenBudgetExhausted = "token_budget_exhausted"
+	NotifyTypeChannelUpdate        = "channel_update"
+	NotifyTypeChannelTest          = "channel_test"
+	NotifyTypeFarmCropNeedsWater   = "farm_crop_needs_water"
+	NotifyTypeFarmCropNearDeath    = "farm_crop_near_death"
+	NotifyTypeFarmCropStolen       = "farm_crop_stolen"
+	NotifyTypeRanchAnimalCleanup   = "ranch_animal_needs_cleanup"
 	NotifyTypeRanchAnimalNearDeath = "ranch_animal_near_death"
 	NotifyTypeSocialOfflineMessage = "social_offline_message"
 )
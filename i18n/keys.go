@@ -42,6 +42,9 @@ const (
 	MsgTokenExhausted            = "token.exhausted"
 	MsgTokenStatusUnavailable    = "token.status_unavailable"
 	MsgTokenDbError              = "token.db_error"
+	MsgTokenRateLimitNegative    = "token.rate_limit_negative"
+	MsgTokenBudgetInvalid        = "token.budget_invalid"
+	MsgTokenFallbacksInvalid     = "token.model_fallbacks_invalid"
 )
 
 // Redemption related messages
@@ -76,6 +79,7 @@ const (
 	MsgUserInputInvalid              = "user.input_invalid"
 	MsgUserNoPermissionSameLevel     = "user.no_permission_same_level"
 	MsgUserNoPermissionHigherLevel   = "user.no_permission_higher_level"
+	MsgUserNoQuotaPermission         = "user.no_quota_permission"
 	MsgUserCannotCreateHigherLevel   = "user.cannot_create_higher_level"
 	MsgUserCannotDeleteRootUser      = "user.cannot_delete_root_user"
 	MsgUserCannotDisableRootUser     = "user.cannot_disable_root_user"
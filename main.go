@@ -19,6 +19,7 @@ import (
 	"github.com/QuantumNous/new-api/middleware"
 	"github.com/QuantumNous/new-api/model"
 	"github.com/QuantumNous/new-api/oauth"
+	"github.com/QuantumNous/new-api/pkg/tracing"
 	"github.com/QuantumNous/new-api/relay"
 	"github.com/QuantumNous/new-api/router"
 	"github.com/QuantumNous/new-api/service"
@@ -106,17 +107,35 @@ func main() {
 
 	go controller.AutomaticallyTestChannels()
 
+	// Probe auto-disabled channels and keys with backoff, re-enable after consecutive successes
+	controller.StartChannelRecoveryTask()
+
 	// Codex credential auto-refresh check every 10 minutes, refresh when expires within 1 day
 	service.StartCodexCredentialAutoRefreshTask()
 
 	// Subscription quota reset task (daily/weekly/monthly/custom)
 	service.StartSubscriptionQuotaResetTask()
 
+	// Token budget reset task (daily/weekly/monthly)
+	service.StartTokenBudgetResetTask()
+
+	// Content log retention cleanup
+	service.StartContentLogCleanupTask()
+
+	// Channel disable/recovery event retention cleanup
+	service.StartChannelStatusEventCleanupTask()
+
+	// Expired /v1/responses record cleanup
+	service.StartResponsesStoreCleanupTask()
+
 	// Entrust expiration cleanup (every 5 minutes)
 	controller.StartEntrustCleanupTask()
 	controller.StartFarmAutomationTask()
 	controller.StartWeatherEventTask()
 
+	// OpenAI Batch API worker (validate -> run -> finalize)
+	controller.StartBatchWorkerTask()
+
 	// Wire task polling adaptor factory (breaks service -> relay import cycle)
 	service.GetTaskAdaptorFunc = func(platform constant.TaskPlatform) service.TaskPollingAdaptor {
 		a := relay.GetTaskAdaptor(platform)
@@ -153,6 +172,13 @@ func main() {
 		common.SysError(fmt.Sprintf("start pyroscope error : %v", err))
 	}
 
+	err = tracing.Init(common.Version)
+	if err != nil {
+		common.SysError(fmt.Sprintf("init tracing error : %v", err))
+	} else if tracing.Enabled() {
+		common.SysLog("OpenTelemetry tracing enabled")
+	}
+
 	// Initialize HTTP server
 	server := gin.New()
 	// Cloudflare 代理支持：让 c.ClientIP() 读取 CF-Connecting-IP 而非 Cloudflare edge IP，
@@ -174,6 +200,7 @@ func main() {
 	// This will cause SSE not to work!!!
 	//server.Use(gzip.Gzip(gzip.DefaultCompression))
 	server.Use(middleware.RequestId())
+	server.Use(middleware.Tracing())
 	server.Use(middleware.PoweredBy())
 	server.Use(middleware.I18n())
 	middleware.SetUpLogger(server)
@@ -279,6 +306,27 @@ func InitResources() error {
 
 	model.CheckSetup()
 
+	// 加密历史明文密钥；--rotate-secret-key 时使用当前主密钥重新加密全部密钥后退出
+	if *common.RotateSecretKey {
+		if !common.SecretEncryptionEnabled() {
+			common.FatalLog("SECRET_MASTER_KEY or SECRET_MASTER_KEY_FILE is required to rotate secrets")
+		}
+		count, err := model.EncryptStoredSecrets()
+		if err != nil {
+			common.FatalLog("failed to rotate secrets: " + err.Error())
+		}
+		common.SysLog(fmt.Sprintf("re-encrypted %d secrets with the current master key", count))
+		os.Exit(0)
+	}
+	if common.IsMasterNode {
+		count, err := model.EncryptStoredSecrets()
+		if err != nil {
+			common.SysError("failed to encrypt stored secrets: " + err.Error())
+		} else if count > 0 {
+			common.SysLog(fmt.Sprintf("encrypted %d stored secrets", count))
+		}
+	}
+
 	// Initialize options, should after model.InitDB()
 	model.InitOptionMap()
 
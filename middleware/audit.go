@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
+	"github.com/QuantumNous/new-api/model"
+	"github.com/QuantumNous/new-api/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// auditBodyLimit 记录请求体的最大字节数，超出部分不解析
+const auditBodyLimit = 64 << 10
+
+// AuditTrail 为管理接口的写操作兜底记录审计日志。
+// 处理函数已通过 service.RecordAudit 记录详细差异时跳过，否则以（已遮蔽密钥的）请求体作为变更内容。
+func AuditTrail(targetType string) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead || c.Request.Method == http.MethodOptions {
+			c.Next()
+			return
+		}
+		var body []byte
+		if c.Request.Body != nil && strings.HasPrefix(c.Request.Header.Get("Content-Type"), "application/json") {
+			data, err := io.ReadAll(c.Request.Body)
+			if err == nil {
+				body = data
+				c.Request.Body = io.NopCloser(bytes.NewReader(data))
+			}
+		}
+		c.Next()
+		if common.GetContextKeyBool(c, constant.ContextKeyAuditRecorded) || c.GetInt("id") == 0 {
+			return
+		}
+		var payload any
+		if len(body) > 0 && len(body) <= auditBodyLimit {
+			_ = common.Unmarshal(body, &payload)
+		}
+		if query := c.Request.URL.Query(); len(query) > 0 {
+			payload = map[string]any{"query": query, "body": payload}
+		}
+		targetId := c.Param("id")
+		if targetId == "" {
+			targetId = c.Query("id")
+		}
+		service.RecordAudit(c, targetType, targetId, model.AuditActionRequest, nil, payload)
+	}
+}
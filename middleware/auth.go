@@ -1,6 +1,8 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
@@ -11,14 +13,30 @@ import (
 	"github.com/QuantumNous/new-api/constant"
 	"github.com/QuantumNous/new-api/logger"
 	"github.com/QuantumNous/new-api/model"
+	"github.com/QuantumNous/new-api/pkg/tracing"
 	"github.com/QuantumNous/new-api/service"
 	"github.com/QuantumNous/new-api/setting/ratio_setting"
 	"github.com/QuantumNous/new-api/types"
 
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// abortWithTokenError 周期预算用尽返回 429 并带上 Retry-After，其余令牌错误返回 401
+func abortWithTokenError(c *gin.Context, token *model.Token, err error) {
+	var budgetErr *model.TokenBudgetExhaustedError
+	if errors.As(err, &budgetErr) {
+		service.NotifyTokenBudgetExhausted(token)
+		if wait := budgetErr.NextResetTime - common.GetTimestamp(); wait > 0 {
+			c.Header("Retry-After", strconv.FormatInt(wait, 10))
+		}
+		abortWithOpenAiMessage(c, http.StatusTooManyRequests, err.Error(), types.ErrorCodeTokenBudgetExhausted)
+		return
+	}
+	abortWithOpenAiMessage(c, http.StatusUnauthorized, err.Error())
+}
+
 func validUserInfo(username string, role int) bool {
 	// check username is empty
 	if strings.TrimSpace(username) == "" {
@@ -30,7 +48,18 @@ func validUserInfo(username string, role int) bool {
 	return true
 }
 
-func authHelper(c *gin.Context, minRole int) {
+func authHelper(c *gin.Context, minRole int, permissions ...string) {
+	var check func(userId int, role int) bool
+	if len(permissions) > 0 {
+		check = func(userId int, role int) bool {
+			return model.UserHasAdminPermissions(userId, role, permissions...)
+		}
+	}
+	authHelperWithCheck(c, minRole, check)
+}
+
+// authHelperWithCheck 完成登录态与角色校验，check 不为 nil 时额外校验管理权限
+func authHelperWithCheck(c *gin.Context, minRole int, check func(userId int, role int) bool) {
 	session := sessions.Default(c)
 	username := session.Get("username")
 	role := session.Get("role")
@@ -125,6 +154,10 @@ func authHelper(c *gin.Context, minRole int) {
 		c.Abort()
 		return
 	}
+	if check != nil && !check(apiUserId, role.(int)) {
+		abortWithPermissionDenied(c)
+		return
+	}
 	// 防止不同newapi版本冲突，导致数据不通用
 	c.Header("Auth-Version", "864b7076dbcd0a3c01b5520316720ebf")
 	c.Set("username", username)
@@ -166,6 +199,41 @@ func RootAuth() func(c *gin.Context) {
 	}
 }
 
+// PermissionAuth 要求管理员身份，且拥有全部指定的管理权限
+func PermissionAuth(permissions ...string) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		authHelper(c, common.RoleAdminUser, permissions...)
+	}
+}
+
+// AnyPermissionAuth 要求管理员身份，且拥有指定权限中的任意一个，用于多个管理页面共用的只读接口
+func AnyPermissionAuth(permissions ...string) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		authHelperWithCheck(c, common.RoleAdminUser, func(userId int, role int) bool {
+			return model.UserHasAnyAdminPermission(userId, role, permissions...)
+		})
+	}
+}
+
+// RequirePermission 在已通过管理员认证的分组内为单个路由追加权限要求
+func RequirePermission(permissions ...string) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		if !model.UserHasAdminPermissions(c.GetInt("id"), c.GetInt("role"), permissions...) {
+			abortWithPermissionDenied(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+func abortWithPermissionDenied(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": false,
+		"message": "无权进行此操作，缺少所需的管理权限",
+	})
+	c.Abort()
+}
+
 func WssAuth(c *gin.Context) {
 
 }
@@ -247,6 +315,8 @@ func TokenAuthReadOnly() func(c *gin.Context) {
 
 func TokenAuth() func(c *gin.Context) {
 	return func(c *gin.Context) {
+		span := tracing.Start(c, "middleware.token_auth")
+		defer endMiddlewareSpan(c, span)
 		// 先检测是否为ws
 		if c.Request.Header.Get("Sec-WebSocket-Protocol") != "" {
 			// Sec-WebSocket-Protocol: realtime, openai-insecure-api-key.sk-xxx, openai-beta.realtime-v1
@@ -309,7 +379,7 @@ func TokenAuth() func(c *gin.Context) {
 			}
 		}
 		if err != nil {
-			abortWithOpenAiMessage(c, http.StatusUnauthorized, err.Error())
+			abortWithTokenError(c, token, err)
 			return
 		}
 
@@ -329,40 +399,85 @@ func TokenAuth() func(c *gin.Context) {
 			logger.LogDebug(c, "Client IP %s passed the token IP restrictions check", clientIp)
 		}
 
-		userCache, err := model.GetUserCache(token.UserId)
-		if err != nil {
-			abortWithOpenAiMessage(c, http.StatusInternalServerError, err.Error())
-			return
-		}
-		userEnabled := userCache.Status == common.UserStatusEnabled
-		if !userEnabled {
-			abortWithOpenAiMessage(c, http.StatusForbidden, "用户已被封禁")
+		if !setupTokenUserContext(c, token, parts...) {
 			return
 		}
+		span.SetAttributes(attribute.Int("newapi.token_id", token.Id), attribute.Int("newapi.user_id", token.UserId))
+		endMiddlewareSpan(c, span)
+		c.Next()
+	}
+}
+
+// setupTokenUserContext 在令牌校验通过后写入用户、分组与令牌上下文，失败时已中止请求
+func setupTokenUserContext(c *gin.Context, token *model.Token, parts ...string) bool {
+	userCache, err := model.GetUserCache(token.UserId)
+	if err != nil {
+		abortWithOpenAiMessage(c, http.StatusInternalServerError, err.Error())
+		return false
+	}
+	userEnabled := userCache.Status == common.UserStatusEnabled
+	if !userEnabled {
+		abortWithOpenAiMessage(c, http.StatusForbidden, "用户已被封禁")
+		return false
+	}
 
-		userCache.WriteContext(c)
+	userCache.WriteContext(c)
 
-		userGroup := userCache.Group
-		tokenGroup := token.Group
-		if tokenGroup != "" {
-			// check common.UserUsableGroups[userGroup]
-			if _, ok := service.GetUserUsableGroups(userGroup)[tokenGroup]; !ok {
-				abortWithOpenAiMessage(c, http.StatusForbidden, fmt.Sprintf("无权访问 %s 分组", tokenGroup))
-				return
-			}
-			// check group in common.GroupRatio
-			if !ratio_setting.ContainsGroupRatio(tokenGroup) {
-				if tokenGroup != "auto" {
-					abortWithOpenAiMessage(c, http.StatusForbidden, fmt.Sprintf("分组 %s 已被弃用", tokenGroup))
-					return
-				}
+	userGroup := userCache.Group
+	tokenGroup := token.Group
+	if tokenGroup != "" {
+		// check common.UserUsableGroups[userGroup]
+		if _, ok := service.GetUserUsableGroups(userGroup)[tokenGroup]; !ok {
+			abortWithOpenAiMessage(c, http.StatusForbidden, fmt.Sprintf("无权访问 %s 分组", tokenGroup))
+			return false
+		}
+		// check group in common.GroupRatio
+		if !ratio_setting.ContainsGroupRatio(tokenGroup) {
+			if tokenGroup != "auto" {
+				abortWithOpenAiMessage(c, http.StatusForbidden, fmt.Sprintf("分组 %s 已被弃用", tokenGroup))
+				return false
 			}
-			userGroup = tokenGroup
 		}
-		common.SetContextKey(c, constant.ContextKeyUsingGroup, userGroup)
+		userGroup = tokenGroup
+	}
+	common.SetContextKey(c, constant.ContextKeyUsingGroup, userGroup)
+
+	if err := SetupContextForToken(c, token, parts...); err != nil {
+		return false
+	}
+	return true
+}
 
-		err = SetupContextForToken(c, token, parts...)
+type internalTokenKey struct{}
+
+// WithInternalToken 将令牌 ID 挂到请求上下文，供进程内发起的请求（如批处理 worker）通过 InternalTokenAuth 鉴权
+func WithInternalToken(ctx context.Context, tokenId int) context.Context {
+	return context.WithValue(ctx, internalTokenKey{}, tokenId)
+}
+
+// InternalTokenAuth 与 TokenAuth 等价，但令牌来源于请求上下文而非 Authorization 头，
+// 只能由进程内构造的请求触发，外部请求无法注入。IP 限制不适用于进程内请求。
+func InternalTokenAuth() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		tokenId, ok := c.Request.Context().Value(internalTokenKey{}).(int)
+		if !ok || tokenId == 0 {
+			abortWithOpenAiMessage(c, http.StatusUnauthorized, "未提供令牌")
+			return
+		}
+		token, err := model.GetTokenById(tokenId)
+		if err != nil {
+			abortWithOpenAiMessage(c, http.StatusUnauthorized, "无效的令牌")
+			return
+		}
+		err = model.ValidateToken(token)
+		if c.GetInt("id") == 0 {
+			c.Set("id", token.UserId)
+		}
 		if err != nil {
+			abortWithTokenError(c, token, err)
+			return
+		}
+		if !setupTokenUserContext(c, token) {
 			return
 		}
 		c.Next()
@@ -389,6 +504,12 @@ func SetupContextForToken(c *gin.Context, token *model.Token, parts ...string) e
 	}
 	common.SetContextKey(c, constant.ContextKeyTokenGroup, token.Group)
 	common.SetContextKey(c, constant.ContextKeyTokenCrossGroupRetry, token.CrossGroupRetry)
+	common.SetContextKey(c, constant.ContextKeyTokenRpmLimit, token.RpmLimit)
+	common.SetContextKey(c, constant.ContextKeyTokenTpmLimit, token.TpmLimit)
+	common.SetContextKey(c, constant.ContextKeyTokenConcurrencyLimit, token.ConcurrencyLimit)
+	common.SetContextKey(c, constant.ContextKeyTokenContentLog, token.ContentLogEnabled)
+	common.SetContextKey(c, constant.ContextKeyTokenModelFallbacks, token.GetModelFallbacksMap())
+	common.SetContextKey(c, constant.ContextKeyTokenOrgId, token.OrgId)
 	if len(parts) > 1 {
 		if model.IsAdmin(token.UserId) {
 			c.Set("specific_channel_id", parts[1])
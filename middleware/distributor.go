@@ -13,13 +13,16 @@ import (
 	"github.com/QuantumNous/new-api/constant"
 	"github.com/QuantumNous/new-api/dto"
 	"github.com/QuantumNous/new-api/i18n"
+	"github.com/QuantumNous/new-api/logger"
 	"github.com/QuantumNous/new-api/model"
+	"github.com/QuantumNous/new-api/pkg/tracing"
 	relayconstant "github.com/QuantumNous/new-api/relay/constant"
 	"github.com/QuantumNous/new-api/service"
 	"github.com/QuantumNous/new-api/setting/ratio_setting"
 	"github.com/QuantumNous/new-api/types"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type ModelRequest struct {
@@ -29,6 +32,9 @@ type ModelRequest struct {
 
 func Distribute() func(c *gin.Context) {
 	return func(c *gin.Context) {
+		span := tracing.Start(c, "middleware.distribute")
+		defer endMiddlewareSpan(c, span)
+		defer ReleaseChannelKeyLease(c)
 		var channel *model.Channel
 		channelId, ok := common.GetContextKey(c, constant.ContextKeyTokenSpecificChannelId)
 		modelRequest, shouldSelectChannel, err := getModelRequest(c)
@@ -129,6 +135,14 @@ func Distribute() func(c *gin.Context) {
 						TokenGroup: usingGroup,
 						Retry:      common.GetPointer(0),
 					})
+					if err != nil || channel == nil {
+						// 主模型没有可用渠道时按降级链改用备选模型
+						if fallbackChannel, fallbackGroup, fallbackModel := selectFallbackModelChannel(c, usingGroup, modelRequest.Model); fallbackChannel != nil {
+							common.SetContextKey(c, constant.ContextKeyModelFallbackFrom, modelRequest.Model)
+							channel, selectGroup, err = fallbackChannel, fallbackGroup, nil
+							modelRequest.Model = fallbackModel
+						}
+					}
 					if err != nil {
 						showGroup := usingGroup
 						if usingGroup == "auto" {
@@ -152,6 +166,12 @@ func Distribute() func(c *gin.Context) {
 		}
 		common.SetContextKey(c, constant.ContextKeyRequestStartTime, time.Now())
 		SetupContextForSelectedChannel(c, channel, modelRequest.Model)
+		span.SetAttributes(
+			attribute.String("newapi.model", modelRequest.Model),
+			attribute.Int("newapi.channel_id", common.GetContextKeyInt(c, constant.ContextKeyChannelId)),
+			attribute.String("newapi.group", common.GetContextKeyString(c, constant.ContextKeyUsingGroup)),
+		)
+		endMiddlewareSpan(c, span)
 		c.Next()
 		if channel != nil && c.Writer != nil && c.Writer.Status() < http.StatusBadRequest {
 			service.RecordChannelAffinity(c, channel.Id)
@@ -337,6 +357,31 @@ func getModelRequest(c *gin.Context) (*ModelRequest, bool, error) {
 	return &modelRequest, shouldSelectChannel, nil
 }
 
+// selectFallbackModelChannel 依次为降级链中的备选模型选择渠道，返回第一个可用的渠道及对应模型
+func selectFallbackModelChannel(c *gin.Context, usingGroup string, modelName string) (*model.Channel, string, string) {
+	for _, fallback := range service.GetModelFallbackChain(c, usingGroup, modelName) {
+		service.ResetAutoGroupSelection(c)
+		channel, selectGroup, err := service.CacheGetRandomSatisfiedChannel(&service.RetryParam{
+			Ctx:        c,
+			ModelName:  fallback,
+			TokenGroup: usingGroup,
+			Retry:      common.GetPointer(0),
+		})
+		if err == nil && channel != nil {
+			logger.LogInfo(c, fmt.Sprintf("model %s has no available channel, fallback to %s", modelName, fallback))
+			return channel, selectGroup, fallback
+		}
+	}
+	return nil, "", ""
+}
+
+// ReleaseChannelKeyLease 结束对多 Key 渠道中已选 key 的占用
+func ReleaseChannelKeyLease(c *gin.Context) {
+	if lease, ok := common.GetContextKeyType[*model.ChannelKeyLease](c, constant.ContextKeyChannelKeyLease); ok {
+		lease.Release()
+	}
+}
+
 func SetupContextForSelectedChannel(c *gin.Context, channel *model.Channel, modelName string) *types.NewAPIError {
 	c.Set("original_model", modelName) // for retry
 	if channel == nil {
@@ -371,9 +416,12 @@ func SetupContextForSelectedChannel(c *gin.Context, channel *model.Channel, mode
 	if newAPIError != nil {
 		return newAPIError
 	}
+	// 重试时先释放上一次选中的 key
+	ReleaseChannelKeyLease(c)
 	if channel.ChannelInfo.IsMultiKey {
 		common.SetContextKey(c, constant.ContextKeyChannelIsMultiKey, true)
 		common.SetContextKey(c, constant.ContextKeyChannelMultiKeyIndex, index)
+		common.SetContextKey(c, constant.ContextKeyChannelKeyLease, model.AcquireChannelKey(channel.Id, index))
 		// Record this key index as tried for this request (so retries prefer a different key)
 		var allTriedMap map[int]map[int]bool
 		if v, ok := c.Get(string(constant.ContextKeyChannelTriedKeyIndices)); ok {
@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/common/limiter"
+	"github.com/QuantumNous/new-api/constant"
+	"github.com/QuantumNous/new-api/dto"
+	"github.com/QuantumNous/new-api/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// 并发计数的兜底过期时间，防止进程异常退出后名额无法释放
+	tokenConcurrencyTTL = 15 * time.Minute
+)
+
+func tokenRateLimitKey(tokenId int, kind string) string {
+	return fmt.Sprintf("tokenRateLimit:%d:%s", tokenId, kind)
+}
+
+// setRateLimitHeaders 按 OpenAI 的格式返回 x-ratelimit-* 响应头，kind 为 requests 或 tokens
+func setRateLimitHeaders(c *gin.Context, kind string, result *limiter.WindowResult) {
+	c.Header("x-ratelimit-limit-"+kind, strconv.FormatInt(result.Limit, 10))
+	c.Header("x-ratelimit-remaining-"+kind, strconv.FormatInt(result.Remaining, 10))
+	c.Header("x-ratelimit-reset-"+kind, result.Reset.Round(time.Millisecond).String())
+}
+
+func abortWithTokenRateLimit(c *gin.Context, retryAfter time.Duration, message string) {
+	seconds := int64(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Header("Retry-After", strconv.FormatInt(seconds, 10))
+	abortWithOpenAiMessage(c, http.StatusTooManyRequests, message, types.ErrorCodeTokenRateLimitExceeded)
+}
+
+// TokenRateLimit 令牌级别的 RPM / TPM / 并发数限制
+// TPM 在请求前只检查当前窗口是否已用尽，请求结束后再按实际用量累加
+func TokenRateLimit() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		rpmLimit := common.GetContextKeyInt(c, constant.ContextKeyTokenRpmLimit)
+		tpmLimit := common.GetContextKeyInt(c, constant.ContextKeyTokenTpmLimit)
+		concurrencyLimit := common.GetContextKeyInt(c, constant.ContextKeyTokenConcurrencyLimit)
+		tokenId := common.GetContextKeyInt(c, constant.ContextKeyTokenId)
+		if tokenId == 0 || (rpmLimit <= 0 && tpmLimit <= 0 && concurrencyLimit <= 0) {
+			c.Next()
+			return
+		}
+		ctx := context.Background()
+
+		if rpmLimit > 0 {
+			result, err := limiter.AllowWindow(ctx, tokenRateLimitKey(tokenId, "rpm"), int64(rpmLimit), time.Minute, 1)
+			if err != nil {
+				common.SysError("token rpm limit check failed: " + err.Error())
+				abortWithOpenAiMessage(c, http.StatusInternalServerError, "rate_limit_check_failed")
+				return
+			}
+			setRateLimitHeaders(c, "requests", result)
+			if !result.Allowed {
+				abortWithTokenRateLimit(c, result.Reset, fmt.Sprintf("令牌已达到请求频率限制：每分钟最多 %d 次请求", rpmLimit))
+				return
+			}
+		}
+
+		if tpmLimit > 0 {
+			result, err := limiter.AllowWindow(ctx, tokenRateLimitKey(tokenId, "tpm"), int64(tpmLimit), time.Minute, 0)
+			if err != nil {
+				common.SysError("token tpm limit check failed: " + err.Error())
+				abortWithOpenAiMessage(c, http.StatusInternalServerError, "rate_limit_check_failed")
+				return
+			}
+			setRateLimitHeaders(c, "tokens", result)
+			if !result.Allowed {
+				abortWithTokenRateLimit(c, result.Reset, fmt.Sprintf("令牌已达到 token 用量限制：每分钟最多 %d tokens", tpmLimit))
+				return
+			}
+		}
+
+		if concurrencyLimit > 0 {
+			key := tokenRateLimitKey(tokenId, "concurrency")
+			ok, _, err := limiter.AcquireConcurrency(ctx, key, int64(concurrencyLimit), tokenConcurrencyTTL)
+			if err != nil {
+				common.SysError("token concurrency limit check failed: " + err.Error())
+				abortWithOpenAiMessage(c, http.StatusInternalServerError, "rate_limit_check_failed")
+				return
+			}
+			if !ok {
+				abortWithTokenRateLimit(c, time.Second, fmt.Sprintf("令牌已达到并发限制：最多同时进行 %d 个请求", concurrencyLimit))
+				return
+			}
+			defer func() {
+				if err := limiter.ReleaseConcurrency(context.Background(), key); err != nil {
+					common.SysError("token concurrency release failed: " + err.Error())
+				}
+			}()
+		}
+
+		c.Next()
+
+		if tpmLimit > 0 {
+			usage, ok := common.GetContextKeyType[*dto.Usage](c, constant.ContextKeyRelayUsage)
+			if ok && usage != nil && usage.TotalTokens > 0 {
+				if err := limiter.AddWindow(ctx, tokenRateLimitKey(tokenId, "tpm"), time.Minute, int64(usage.TotalTokens)); err != nil {
+					common.SysError("token tpm usage record failed: " + err.Error())
+				}
+			}
+		}
+	}
+}
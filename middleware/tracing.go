@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
+	"github.com/QuantumNous/new-api/pkg/tracing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// Tracing 为每个请求创建根 span，沿用请求头中的 traceparent，并关联 X-Oneapi-Request-Id
+func Tracing() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		ctx := tracing.Extract(c.Request.Context(), c.Request.Header)
+		ctx, span := tracing.StartServer(ctx, c.Request.Method,
+			attribute.String("http.request.method", c.Request.Method),
+			attribute.String("url.path", c.Request.URL.Path),
+			attribute.String("newapi.request_id", c.GetString(common.RequestIdKey)),
+		)
+		defer span.End()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		if route := c.FullPath(); route != "" {
+			span.SetName(c.Request.Method + " " + route)
+			span.SetAttributes(attribute.String("http.route", route))
+		}
+		status := c.Writer.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if modelName := common.GetContextKeyString(c, constant.ContextKeyOriginalModel); modelName != "" {
+			span.SetAttributes(
+				attribute.String("newapi.model", modelName),
+				attribute.Int("newapi.channel_id", common.GetContextKeyInt(c, constant.ContextKeyChannelId)),
+				attribute.String("newapi.group", common.GetContextKeyString(c, constant.ContextKeyUsingGroup)),
+			)
+		}
+		if userId := c.GetInt("id"); userId != 0 {
+			span.SetAttributes(attribute.Int("newapi.user_id", userId))
+		}
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
+
+// endMiddlewareSpan 结束中间件的 span，请求被中止时标记为错误
+func endMiddlewareSpan(c *gin.Context, span *tracing.Span) {
+	if c.IsAborted() {
+		span.End(fmt.Errorf("request aborted with status %d", c.Writer.Status()))
+		return
+	}
+	span.End(nil)
+}
@@ -174,6 +174,19 @@ func GetChannel(group string, model string, retry int) (*Channel, error) {
 	return &channel, err
 }
 
+// GetEnabledChannelsByGroupModel 查询分组下支持该模型的全部已启用渠道（不区分优先级），供未开启内存缓存时的健康选择使用
+func GetEnabledChannelsByGroupModel(group string, model string) ([]*Channel, error) {
+	var channelIds []int
+	err := DB.Model(&Ability{}).Where(commonGroupCol+" = ? and model = ? and enabled = ?", group, model, true).
+		Pluck("channel_id", &channelIds).Error
+	if err != nil || len(channelIds) == 0 {
+		return nil, err
+	}
+	var channels []*Channel
+	err = DB.Where("id IN ?", channelIds).Find(&channels).Error
+	return channels, err
+}
+
 func (channel *Channel) AddAbilities(tx *gorm.DB) error {
 	models_ := strings.Split(channel.Models, ",")
 	groups_ := strings.Split(channel.Group, ",")
@@ -0,0 +1,234 @@
+package model
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+)
+
+var (
+	ErrAdminRoleNotFound = errors.New("管理角色不存在")
+	ErrAdminRoleInUse    = errors.New("管理角色仍被管理员使用，无法删除")
+)
+
+// AdminRole 可分配给管理员的权限集合。
+// 管理员未分配角色（admin_role_id = 0）时保持原有的全部管理权限，超级管理员始终拥有全部权限。
+type AdminRole struct {
+	Id          int      `json:"id"`
+	Name        string   `json:"name" gorm:"type:varchar(64);uniqueIndex"`
+	Description string   `json:"description" gorm:"type:varchar(255)"`
+	Permissions []string `json:"permissions" gorm:"type:text;serializer:json"`
+	CreatedTime int64    `json:"created_time" gorm:"bigint"`
+	UpdatedTime int64    `json:"updated_time" gorm:"bigint"`
+}
+
+func (r *AdminRole) HasPermission(permission string) bool {
+	for _, p := range r.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// ========== 缓存 ==========
+
+var (
+	adminRoleCache     map[int]*AdminRole
+	adminRoleCacheMu   sync.RWMutex
+	adminRoleCacheTime int64
+)
+
+const adminRoleCacheTTL = 30 // 缓存30秒，多实例部署时角色变更最迟30秒生效
+
+func getAdminRoleCached(id int) (*AdminRole, bool) {
+	adminRoleCacheMu.RLock()
+	if adminRoleCache != nil && time.Now().Unix()-adminRoleCacheTime < adminRoleCacheTTL {
+		role, ok := adminRoleCache[id]
+		adminRoleCacheMu.RUnlock()
+		return role, ok
+	}
+	adminRoleCacheMu.RUnlock()
+
+	adminRoleCacheMu.Lock()
+	defer adminRoleCacheMu.Unlock()
+	if adminRoleCache == nil || time.Now().Unix()-adminRoleCacheTime >= adminRoleCacheTTL {
+		var roles []*AdminRole
+		if err := DB.Find(&roles).Error; err != nil {
+			common.SysError("failed to load admin roles: " + err.Error())
+			return nil, false
+		}
+		adminRoleCache = make(map[int]*AdminRole, len(roles))
+		for _, role := range roles {
+			adminRoleCache[role.Id] = role
+		}
+		adminRoleCacheTime = time.Now().Unix()
+	}
+	role, ok := adminRoleCache[id]
+	return role, ok
+}
+
+func invalidateAdminRoleCache() {
+	adminRoleCacheMu.Lock()
+	adminRoleCache = nil
+	adminRoleCacheMu.Unlock()
+}
+
+// ========== CRUD ==========
+
+func normalizeAdminPermissions(permissions []string) []string {
+	result := make([]string, 0, len(permissions))
+	for _, p := range common.AllAdminPermissions {
+		for _, q := range permissions {
+			if p == q {
+				result = append(result, p)
+				break
+			}
+		}
+	}
+	return result
+}
+
+func (r *AdminRole) Insert() error {
+	now := common.GetTimestamp()
+	r.CreatedTime = now
+	r.UpdatedTime = now
+	r.Permissions = normalizeAdminPermissions(r.Permissions)
+	if err := DB.Create(r).Error; err != nil {
+		return err
+	}
+	invalidateAdminRoleCache()
+	return nil
+}
+
+func (r *AdminRole) Update() error {
+	r.UpdatedTime = common.GetTimestamp()
+	r.Permissions = normalizeAdminPermissions(r.Permissions)
+	if err := DB.Model(r).Select("name", "description", "permissions", "updated_time").Updates(r).Error; err != nil {
+		return err
+	}
+	invalidateAdminRoleCache()
+	return nil
+}
+
+func GetAllAdminRoles() ([]*AdminRole, error) {
+	var roles []*AdminRole
+	err := DB.Order("id asc").Find(&roles).Error
+	return roles, err
+}
+
+func GetAdminRoleById(id int) (*AdminRole, error) {
+	var role AdminRole
+	if err := DB.First(&role, id).Error; err != nil {
+		return nil, ErrAdminRoleNotFound
+	}
+	return &role, nil
+}
+
+func IsAdminRoleNameDuplicated(id int, name string) (bool, error) {
+	var cnt int64
+	err := DB.Model(&AdminRole{}).Where("name = ? AND id <> ?", name, id).Count(&cnt).Error
+	return cnt > 0, err
+}
+
+// DeleteAdminRole 删除角色；仍有管理员使用时拒绝删除，避免其回落为全部权限
+func DeleteAdminRole(id int) error {
+	var cnt int64
+	if err := DB.Model(&User{}).Where("admin_role_id = ?", id).Count(&cnt).Error; err != nil {
+		return err
+	}
+	if cnt > 0 {
+		return ErrAdminRoleInUse
+	}
+	if err := DB.Delete(&AdminRole{}, id).Error; err != nil {
+		return err
+	}
+	invalidateAdminRoleCache()
+	return nil
+}
+
+// SetUserAdminRole 为管理员分配角色，roleId 为 0 表示恢复全部管理权限
+func SetUserAdminRole(userId int, roleId int) error {
+	if roleId != 0 {
+		if _, err := GetAdminRoleById(roleId); err != nil {
+			return err
+		}
+	}
+	if err := DB.Model(&User{}).Where("id = ?", userId).Update("admin_role_id", roleId).Error; err != nil {
+		return err
+	}
+	invalidateUserAdminRoleCache(userId)
+	return nil
+}
+
+// GetUserAdminRoleId 读取用户的管理角色，管理接口每次请求都会调用，优先走缓存
+func GetUserAdminRoleId(userId int) (int, error) {
+	if roleId, ok := getUserAdminRoleCache(userId); ok {
+		return roleId, nil
+	}
+	var user User
+	if err := DB.Select("admin_role_id").First(&user, "id = ?", userId).Error; err != nil {
+		return 0, err
+	}
+	updateUserAdminRoleCache(userId, user.AdminRoleId)
+	return user.AdminRoleId, nil
+}
+
+// GetUserAdminPermissions 返回用户拥有的管理权限
+func GetUserAdminPermissions(userId int, role int) []string {
+	if role >= common.RoleRootUser {
+		return common.AllAdminPermissions
+	}
+	if role < common.RoleAdminUser {
+		return nil
+	}
+	roleId, err := GetUserAdminRoleId(userId)
+	if err != nil {
+		return nil
+	}
+	if roleId == 0 {
+		return common.AllAdminPermissions
+	}
+	adminRole, ok := getAdminRoleCached(roleId)
+	if !ok {
+		// 角色已不存在时不授予任何权限
+		return nil
+	}
+	return adminRole.Permissions
+}
+
+// UserHasAnyAdminPermission 判断用户是否拥有指定权限中的任意一个
+func UserHasAnyAdminPermission(userId int, role int, permissions ...string) bool {
+	for _, p := range permissions {
+		if UserHasAdminPermissions(userId, role, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// UserHasAdminPermissions 判断用户是否拥有全部指定权限
+func UserHasAdminPermissions(userId int, role int, permissions ...string) bool {
+	if role >= common.RoleRootUser {
+		return true
+	}
+	if role < common.RoleAdminUser {
+		return false
+	}
+	owned := GetUserAdminPermissions(userId, role)
+	for _, p := range permissions {
+		found := false
+		for _, q := range owned {
+			if p == q {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminRolePermissions(t *testing.T) {
+	require.NoError(t, DB.AutoMigrate(&AdminRole{}))
+	t.Cleanup(func() {
+		DB.Exec("DELETE FROM admin_roles")
+		DB.Exec("DELETE FROM users")
+		invalidateAdminRoleCache()
+	})
+	require.NoError(t, DB.Create(&User{Id: 201, Username: "support", AffCode: "rbac1", Role: common.RoleAdminUser}).Error)
+	require.NoError(t, DB.Create(&User{Id: 202, Username: "legacy-admin", AffCode: "rbac2", Role: common.RoleAdminUser}).Error)
+
+	role := &AdminRole{Name: "support", Permissions: []string{common.PermissionLogRead, "unknown", common.PermissionUserRead}}
+	require.NoError(t, role.Insert())
+	require.Equal(t, []string{common.PermissionUserRead, common.PermissionLogRead}, role.Permissions)
+	require.NoError(t, SetUserAdminRole(201, role.Id))
+	require.ErrorIs(t, SetUserAdminRole(201, role.Id+100), ErrAdminRoleNotFound)
+
+	require.True(t, UserHasAdminPermissions(201, common.RoleAdminUser, common.PermissionUserRead, common.PermissionLogRead))
+	require.False(t, UserHasAdminPermissions(201, common.RoleAdminUser, common.PermissionChannelRead))
+	// 未分配角色的管理员与超级管理员保持全部权限，普通用户没有管理权限
+	require.True(t, UserHasAdminPermissions(202, common.RoleAdminUser, common.PermissionChannelManage))
+	require.True(t, UserHasAdminPermissions(203, common.RoleRootUser, common.PermissionBillingManage))
+	require.False(t, UserHasAdminPermissions(204, common.RoleCommonUser, common.PermissionLogRead))
+
+	role.Permissions = []string{common.PermissionChannelRead}
+	require.NoError(t, role.Update())
+	require.True(t, UserHasAdminPermissions(201, common.RoleAdminUser, common.PermissionChannelRead))
+	require.False(t, UserHasAdminPermissions(201, common.RoleAdminUser, common.PermissionLogRead))
+
+	require.ErrorIs(t, DeleteAdminRole(role.Id), ErrAdminRoleInUse)
+	require.NoError(t, SetUserAdminRole(201, 0))
+	require.NoError(t, DeleteAdminRole(role.Id))
+	require.True(t, UserHasAdminPermissions(201, common.RoleAdminUser, common.PermissionLogRead))
+}
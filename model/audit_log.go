@@ -0,0 +1,203 @@
+package model
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/QuantumNous/new-api/common"
+
+	"gorm.io/gorm"
+)
+
+// 审计对象类型
+const (
+	AuditTargetChannel          = "channel"
+	AuditTargetToken            = "token"
+	AuditTargetUser             = "user"
+	AuditTargetOption           = "option"
+	AuditTargetRedemption       = "redemption"
+	AuditTargetSubscriptionPlan = "subscription_plan"
+	AuditTargetDeletionRequest  = "deletion_request"
+	AuditTargetAdminRole        = "admin_role"
+	AuditTargetFarm             = "farm"
+	AuditTargetOther            = "other"
+)
+
+// 审计动作
+const (
+	AuditActionCreate  = "create"
+	AuditActionUpdate  = "update"
+	AuditActionDelete  = "delete"
+	AuditActionViewKey = "view_key"
+	AuditActionRequest = "request" // 未单独记录差异的管理请求，记录请求体
+)
+
+const auditMaskedValue = "******"
+
+// AuditLog 管理操作审计记录
+type AuditLog struct {
+	Id         int    `json:"id"`
+	CreatedAt  int64  `json:"created_at" gorm:"bigint;index"`
+	ActorId    int    `json:"actor_id" gorm:"index"`
+	ActorName  string `json:"actor_name" gorm:"type:varchar(64);index"`
+	ActorRole  int    `json:"actor_role"`
+	Ip         string `json:"ip" gorm:"type:varchar(64)"`
+	TargetType string `json:"target_type" gorm:"type:varchar(32);index"`
+	TargetId   string `json:"target_id" gorm:"type:varchar(64);index"`
+	Action     string `json:"action" gorm:"type:varchar(64);index"`
+	Route      string `json:"route" gorm:"type:varchar(255)"`
+	Diff       string `json:"diff" gorm:"type:text"` // JSON: {"field": {"old": x, "new": y}}
+}
+
+type AuditLogQuery struct {
+	ActorName      string
+	TargetType     string
+	TargetId       string
+	Action         string
+	StartTimestamp int64
+	EndTimestamp   int64
+}
+
+// isAuditSecretField 判断字段是否为密钥类字段，如 key、password、*_secret、*token
+func isAuditSecretField(field string) bool {
+	name := strings.ToLower(field)
+	for _, suffix := range []string{"key", "password", "secret", "token"} {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// maskAuditValue 遮蔽密钥类字段的值，嵌套对象逐层处理
+func maskAuditValue(field string, value any) any {
+	if value == nil || value == "" {
+		return value
+	}
+	if isAuditSecretField(field) {
+		return auditMaskedValue
+	}
+	switch v := value.(type) {
+	case map[string]any:
+		masked := make(map[string]any, len(v))
+		for k, item := range v {
+			masked[k] = maskAuditValue(k, item)
+		}
+		// 配置项请求体形如 {"key": 配置名, "value": 值}，key 为配置名可明文记录，按配置名判断是否遮蔽值
+		if optionKey, ok := v["key"].(string); ok {
+			if value, exists := v["value"]; exists {
+				masked["key"] = optionKey
+				masked["value"] = maskAuditValue(optionKey, value)
+			}
+		}
+		return masked
+	case []any:
+		masked := make([]any, len(v))
+		for i, item := range v {
+			masked[i] = maskAuditValue("", item)
+		}
+		return masked
+	}
+	return value
+}
+
+func toAuditMap(v any) map[string]any {
+	if v == nil {
+		return nil
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Pointer && rv.IsNil() {
+		return nil
+	}
+	data, err := common.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	m := map[string]any{}
+	if err := common.Unmarshal(data, &m); err != nil {
+		// 非对象类型统一放在 value 字段下
+		var raw any
+		if common.Unmarshal(data, &raw) != nil {
+			return nil
+		}
+		return map[string]any{"value": raw}
+	}
+	return m
+}
+
+// BuildAuditDiff 对比变更前后的实体（结构体或 map），返回仅包含变化字段的 JSON，密钥类字段只标记变化不记录内容。
+// 新增时 before 传 nil，删除时 after 传 nil。
+func BuildAuditDiff(before, after any) string {
+	oldMap, newMap := toAuditMap(before), toAuditMap(after)
+	diff := map[string]map[string]any{}
+	fields := map[string]struct{}{}
+	for k := range oldMap {
+		fields[k] = struct{}{}
+	}
+	for k := range newMap {
+		fields[k] = struct{}{}
+	}
+	for field := range fields {
+		oldValue, oldOk := oldMap[field]
+		newValue, newOk := newMap[field]
+		if oldOk && newOk && reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		change := map[string]any{}
+		if oldOk {
+			change["old"] = maskAuditValue(field, oldValue)
+		}
+		if newOk {
+			change["new"] = maskAuditValue(field, newValue)
+		}
+		diff[field] = change
+	}
+	data, err := common.Marshal(diff)
+	if err != nil {
+		return "{}"
+	}
+	return string(data)
+}
+
+func RecordAuditLog(entry *AuditLog) {
+	if entry.CreatedAt == 0 {
+		entry.CreatedAt = common.GetTimestamp()
+	}
+	if entry.Diff == "" {
+		entry.Diff = "{}"
+	}
+	if err := LOG_DB.Create(entry).Error; err != nil {
+		common.SysError("failed to record audit log: " + err.Error())
+	}
+}
+
+func buildAuditLogQuery(query AuditLogQuery) *gorm.DB {
+	tx := LOG_DB.Model(&AuditLog{})
+	if query.ActorName != "" {
+		tx = tx.Where("actor_name = ?", query.ActorName)
+	}
+	if query.TargetType != "" {
+		tx = tx.Where("target_type = ?", query.TargetType)
+	}
+	if query.TargetId != "" {
+		tx = tx.Where("target_id = ?", query.TargetId)
+	}
+	if query.Action != "" {
+		tx = tx.Where("action = ?", query.Action)
+	}
+	if query.StartTimestamp != 0 {
+		tx = tx.Where("created_at >= ?", query.StartTimestamp)
+	}
+	if query.EndTimestamp != 0 {
+		tx = tx.Where("created_at <= ?", query.EndTimestamp)
+	}
+	return tx
+}
+
+func GetAuditLogs(query AuditLogQuery, startIdx int, num int) (logs []*AuditLog, total int64, err error) {
+	tx := buildAuditLogQuery(query)
+	if err = tx.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err = tx.Order("id desc").Limit(num).Offset(startIdx).Find(&logs).Error
+	return logs, total, err
+}
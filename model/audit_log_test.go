@@ -0,0 +1,53 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildAuditDiff(t *testing.T) {
+	before := &Channel{Id: 1, Name: "old", Key: "sk-old", Priority: common.GetPointer[int64](0)}
+	after := &Channel{Id: 1, Name: "new", Key: "sk-new", Priority: common.GetPointer[int64](0)}
+
+	diff := map[string]map[string]any{}
+	require.NoError(t, common.UnmarshalJsonStr(BuildAuditDiff(before, after), &diff))
+	require.Equal(t, map[string]any{"old": "old", "new": "new"}, diff["name"])
+	// 密钥只标记变化，不记录明文
+	require.Equal(t, map[string]any{"old": auditMaskedValue, "new": auditMaskedValue}, diff["key"])
+	require.NotContains(t, diff, "id")
+	require.NotContains(t, diff, "priority")
+
+	diff = map[string]map[string]any{}
+	payload := map[string]any{"settings": map[string]any{"api_key": "secret", "model": "gpt"}}
+	require.NoError(t, common.UnmarshalJsonStr(BuildAuditDiff(nil, payload), &diff))
+	require.Equal(t, map[string]any{"new": map[string]any{"api_key": auditMaskedValue, "model": "gpt"}}, diff["settings"])
+
+	// 配置项请求体按配置名遮蔽值
+	diff = map[string]map[string]any{}
+	optionPayload := map[string]any{"body": map[string]any{"key": "StripeApiSecret", "value": "sk_live"}}
+	require.NoError(t, common.UnmarshalJsonStr(BuildAuditDiff(nil, optionPayload), &diff))
+	require.Equal(t, map[string]any{"new": map[string]any{"key": "StripeApiSecret", "value": auditMaskedValue}}, diff["body"])
+}
+
+func TestGetAuditLogs(t *testing.T) {
+	require.NoError(t, LOG_DB.AutoMigrate(&AuditLog{}))
+	t.Cleanup(func() {
+		LOG_DB.Exec("DELETE FROM audit_logs")
+	})
+	RecordAuditLog(&AuditLog{ActorName: "root", TargetType: AuditTargetChannel, TargetId: "1", Action: AuditActionUpdate, CreatedAt: 100})
+	RecordAuditLog(&AuditLog{ActorName: "root", TargetType: AuditTargetUser, TargetId: "2", Action: AuditActionDelete, CreatedAt: 200})
+	RecordAuditLog(&AuditLog{ActorName: "ops", TargetType: AuditTargetChannel, TargetId: "3", Action: AuditActionCreate, CreatedAt: 300})
+
+	logs, total, err := GetAuditLogs(AuditLogQuery{ActorName: "root"}, 0, 10)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, total)
+	require.Equal(t, "2", logs[0].TargetId)
+	require.Equal(t, "{}", logs[0].Diff)
+
+	logs, total, err = GetAuditLogs(AuditLogQuery{TargetType: AuditTargetChannel, StartTimestamp: 200}, 0, 10)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, total)
+	require.Equal(t, "ops", logs[0].ActorName)
+}
@@ -0,0 +1,218 @@
+package model
+
+import (
+	"errors"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/dto"
+)
+
+// Batch 对应 OpenAI Batch API 的批处理任务
+type Batch struct {
+	Id               int    `json:"id" gorm:"primaryKey;autoIncrement"`
+	BatchId          string `json:"batch_id" gorm:"type:varchar(64);uniqueIndex"`
+	UserId           int    `json:"user_id" gorm:"index"`
+	TokenId          int    `json:"token_id" gorm:"index"`
+	Endpoint         string `json:"endpoint" gorm:"type:varchar(64)"`
+	InputFileId      string `json:"input_file_id" gorm:"type:varchar(64)"`
+	OutputFileId     string `json:"output_file_id" gorm:"type:varchar(64);default:''"`
+	ErrorFileId      string `json:"error_file_id" gorm:"type:varchar(64);default:''"`
+	CompletionWindow string `json:"completion_window" gorm:"type:varchar(16)"`
+	Status           string `json:"status" gorm:"type:varchar(20);index"`
+	Errors           string `json:"errors" gorm:"type:text"`
+	Metadata         string `json:"metadata" gorm:"type:text"`
+	TotalCount       int    `json:"total_count" gorm:"default:0"`
+	CompletedCount   int    `json:"completed_count" gorm:"default:0"`
+	FailedCount      int    `json:"failed_count" gorm:"default:0"`
+	CreatedAt        int64  `json:"created_at" gorm:"bigint;index"`
+	InProgressAt     int64  `json:"in_progress_at" gorm:"bigint;default:0"`
+	ExpiresAt        int64  `json:"expires_at" gorm:"bigint;default:0"`
+	FinalizingAt     int64  `json:"finalizing_at" gorm:"bigint;default:0"`
+	CompletedAt      int64  `json:"completed_at" gorm:"bigint;default:0"`
+	FailedAt         int64  `json:"failed_at" gorm:"bigint;default:0"`
+	ExpiredAt        int64  `json:"expired_at" gorm:"bigint;default:0"`
+	CancellingAt     int64  `json:"cancelling_at" gorm:"bigint;default:0"`
+	CancelledAt      int64  `json:"cancelled_at" gorm:"bigint;default:0"`
+}
+
+func NewBatchId() string {
+	return "batch_" + common.GetUUID()
+}
+
+func (b *Batch) IsFinished() bool {
+	switch b.Status {
+	case dto.BatchStatusCompleted, dto.BatchStatusFailed, dto.BatchStatusExpired, dto.BatchStatusCancelled:
+		return true
+	}
+	return false
+}
+
+func (b *Batch) SetErrors(errs []dto.BatchError) {
+	if len(errs) == 0 {
+		b.Errors = ""
+		return
+	}
+	data, _ := common.Marshal(dto.BatchErrors{Object: "list", Data: errs})
+	b.Errors = string(data)
+}
+
+func (b *Batch) SetMetadata(metadata map[string]string) {
+	if len(metadata) == 0 {
+		b.Metadata = ""
+		return
+	}
+	data, _ := common.Marshal(metadata)
+	b.Metadata = string(data)
+}
+
+func optionalTimestamp(ts int64) *int64 {
+	if ts == 0 {
+		return nil
+	}
+	return &ts
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func (b *Batch) ToOpenAIBatch() *dto.OpenAIBatch {
+	res := &dto.OpenAIBatch{
+		ID:               b.BatchId,
+		Object:           "batch",
+		Endpoint:         b.Endpoint,
+		InputFileID:      b.InputFileId,
+		CompletionWindow: b.CompletionWindow,
+		Status:           b.Status,
+		CreatedAt:        b.CreatedAt,
+		InProgressAt:     optionalTimestamp(b.InProgressAt),
+		ExpiresAt:        optionalTimestamp(b.ExpiresAt),
+		FinalizingAt:     optionalTimestamp(b.FinalizingAt),
+		CompletedAt:      optionalTimestamp(b.CompletedAt),
+		FailedAt:         optionalTimestamp(b.FailedAt),
+		ExpiredAt:        optionalTimestamp(b.ExpiredAt),
+		CancellingAt:     optionalTimestamp(b.CancellingAt),
+		CancelledAt:      optionalTimestamp(b.CancelledAt),
+		RequestCounts: dto.BatchRequestCounts{
+			Total:     b.TotalCount,
+			Completed: b.CompletedCount,
+			Failed:    b.FailedCount,
+		},
+	}
+	// 输出文件在批处理结束前仍在写入，结束后才对外可见
+	if b.IsFinished() {
+		res.OutputFileID = optionalString(b.OutputFileId)
+		res.ErrorFileID = optionalString(b.ErrorFileId)
+	}
+	if b.Errors != "" {
+		var errs dto.BatchErrors
+		if err := common.UnmarshalJsonStr(b.Errors, &errs); err == nil {
+			res.Errors = &errs
+		}
+	}
+	if b.Metadata != "" {
+		_ = common.UnmarshalJsonStr(b.Metadata, &res.Metadata)
+	}
+	return res
+}
+
+func (b *Batch) Insert() error {
+	if b.CreatedAt == 0 {
+		b.CreatedAt = common.GetTimestamp()
+	}
+	return DB.Create(b).Error
+}
+
+func (b *Batch) Update() error {
+	return DB.Save(b).Error
+}
+
+// UpdateProgress 仅更新计数，避免覆盖并发写入的取消状态
+func (b *Batch) UpdateProgress() error {
+	return DB.Model(&Batch{}).Where("id = ?", b.Id).Updates(map[string]any{
+		"total_count":     b.TotalCount,
+		"completed_count": b.CompletedCount,
+		"failed_count":    b.FailedCount,
+	}).Error
+}
+
+// UpdateWithStatus CAS 更新：仅当数据库中的状态仍为 fromStatus 时写入
+func (b *Batch) UpdateWithStatus(fromStatus string) (bool, error) {
+	result := DB.Model(&Batch{}).Where("id = ? AND status = ?", b.Id, fromStatus).Select("*").Updates(b)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func GetBatchById(id int) (*Batch, error) {
+	var batch Batch
+	err := DB.First(&batch, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+func GetUserBatchByBatchId(userId int, batchId string) (*Batch, error) {
+	if batchId == "" {
+		return nil, errors.New("batch id is empty")
+	}
+	var batch Batch
+	err := DB.Where("batch_id = ? AND user_id = ?", batchId, userId).First(&batch).Error
+	if err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+func GetUserBatches(userId int, after string, limit int) ([]*Batch, error) {
+	var batches []*Batch
+	query := DB.Where("user_id = ?", userId)
+	if after != "" {
+		var cursor Batch
+		if err := DB.Select("id").Where("batch_id = ? AND user_id = ?", after, userId).First(&cursor).Error; err == nil {
+			query = query.Where("id < ?", cursor.Id)
+		}
+	}
+	err := query.Order("id desc").Limit(limit).Find(&batches).Error
+	return batches, err
+}
+
+// GetUnfinishedBatches 返回需要批处理 worker 推进的任务
+func GetUnfinishedBatches(limit int) ([]*Batch, error) {
+	var batches []*Batch
+	err := DB.Where("status IN ?", []string{
+		dto.BatchStatusValidating,
+		dto.BatchStatusInProgress,
+		dto.BatchStatusFinalizing,
+		dto.BatchStatusCancelling,
+	}).Order("id asc").Limit(limit).Find(&batches).Error
+	return batches, err
+}
+
+// GetBatchStatus 读取最新状态，worker 用于在逐行处理时感知取消
+func GetBatchStatus(id int) (string, error) {
+	var batch Batch
+	err := DB.Select("status").Where("id = ?", id).First(&batch).Error
+	return batch.Status, err
+}
+
+// MarkBatchCancelling 将未结束的批处理标记为取消中
+func MarkBatchCancelling(id int) (bool, error) {
+	result := DB.Model(&Batch{}).Where("id = ? AND status IN ?", id, []string{
+		dto.BatchStatusValidating,
+		dto.BatchStatusInProgress,
+		dto.BatchStatusFinalizing,
+	}).Updates(map[string]any{
+		"status":        dto.BatchStatusCancelling,
+		"cancelling_at": common.GetTimestamp(),
+	})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,89 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/QuantumNous/new-api/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func insertBatch(t *testing.T, status string) *Batch {
+	t.Helper()
+	require.NoError(t, DB.AutoMigrate(&Batch{}))
+	t.Cleanup(func() {
+		DB.Exec("DELETE FROM batches")
+	})
+	batch := &Batch{
+		BatchId:          NewBatchId(),
+		UserId:           1,
+		Endpoint:         "/v1/chat/completions",
+		InputFileId:      NewFileId(),
+		CompletionWindow: "24h",
+		Status:           status,
+	}
+	require.NoError(t, batch.Insert())
+	return batch
+}
+
+func TestBatchUpdateWithStatus_CAS(t *testing.T) {
+	batch := insertBatch(t, dto.BatchStatusInProgress)
+
+	batch.Status = dto.BatchStatusFinalizing
+	won, err := batch.UpdateWithStatus(dto.BatchStatusValidating)
+	require.NoError(t, err)
+	assert.False(t, won, "stale fromStatus must not win")
+
+	won, err = batch.UpdateWithStatus(dto.BatchStatusInProgress)
+	require.NoError(t, err)
+	assert.True(t, won)
+
+	status, err := GetBatchStatus(batch.Id)
+	require.NoError(t, err)
+	assert.Equal(t, dto.BatchStatusFinalizing, status)
+}
+
+func TestMarkBatchCancelling(t *testing.T) {
+	batch := insertBatch(t, dto.BatchStatusInProgress)
+	ok, err := MarkBatchCancelling(batch.Id)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// worker 持有的旧状态不能覆盖取消
+	batch.CompletedCount = 3
+	require.NoError(t, batch.UpdateProgress())
+	batch.Status = dto.BatchStatusFinalizing
+	won, err := batch.UpdateWithStatus(dto.BatchStatusInProgress)
+	require.NoError(t, err)
+	assert.False(t, won)
+
+	reloaded, err := GetBatchById(batch.Id)
+	require.NoError(t, err)
+	assert.Equal(t, dto.BatchStatusCancelling, reloaded.Status)
+	assert.Equal(t, 3, reloaded.CompletedCount)
+	assert.NotZero(t, reloaded.CancellingAt)
+
+	done := insertBatch(t, dto.BatchStatusCompleted)
+	ok, err = MarkBatchCancelling(done.Id)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestBatchToOpenAIBatch_HidesOutputUntilFinished(t *testing.T) {
+	batch := &Batch{
+		BatchId:      "batch_x",
+		Status:       dto.BatchStatusInProgress,
+		OutputFileId: "file-out",
+		InProgressAt: 10,
+	}
+	res := batch.ToOpenAIBatch()
+	assert.Nil(t, res.OutputFileID)
+	assert.Nil(t, res.CompletedAt)
+	require.NotNil(t, res.InProgressAt)
+
+	batch.Status = dto.BatchStatusCompleted
+	res = batch.ToOpenAIBatch()
+	require.NotNil(t, res.OutputFileID)
+	assert.Equal(t, "file-out", *res.OutputFileID)
+	assert.Nil(t, res.ErrorFileID)
+}
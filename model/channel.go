@@ -21,7 +21,7 @@ import (
 type Channel struct {
 	Id                 int     `json:"id"`
 	Type               int     `json:"type" gorm:"default:0"`
-	Key                string  `json:"key" gorm:"not null"`
+	Key                string  `json:"key" gorm:"not null;serializer:secret"`
 	OpenAIOrganization *string `json:"openai_organization"`
 	TestModel          *string `json:"test_model"`
 	Status             int     `json:"status" gorm:"default:1"`
@@ -55,6 +55,9 @@ type Channel struct {
 
 	// cache info
 	Keys []string `json:"-" gorm:"-"`
+
+	// Health 渠道实时健康度，仅在渠道列表接口中填充
+	Health *ChannelHealthSummary `json:"health,omitempty" gorm:"-"`
 }
 
 type ChannelInfo struct {
@@ -65,6 +68,15 @@ type ChannelInfo struct {
 	MultiKeyDisabledTime   map[int]int64         `json:"multi_key_disabled_time,omitempty"`   // key禁用时间列表，key index -> time
 	MultiKeyPollingIndex   int                   `json:"multi_key_polling_index"`             // 多Key模式下轮询的key索引
 	MultiKeyMode           constant.MultiKeyMode `json:"multi_key_mode"`
+	MultiKeyWeights        map[int]int           `json:"multi_key_weights,omitempty"` // weighted 模式下的 key 权重，key index -> weight，缺省为 1
+	// 探测恢复后再次被禁用时，保留 key 上一次的禁用原因与时间，key index -> reason/time
+	MultiKeyPrevDisabledReason map[int]string `json:"multi_key_prev_disabled_reason,omitempty"`
+	MultiKeyPrevDisabledTime   map[int]int64  `json:"multi_key_prev_disabled_time,omitempty"`
+}
+
+// KeyWeight 返回 key 在 weighted 模式下的权重
+func (c *ChannelInfo) KeyWeight(idx int) int {
+	return channelKeyWeight(c.MultiKeyWeights, idx)
 }
 
 // Value implements driver.Valuer interface
@@ -232,6 +244,16 @@ func (channel *Channel) GetNextEnabledKey(skipIndices ...map[int]bool) (string,
 		}
 		// Fallback – should not happen, but return first enabled key
 		return keys[enabledIdx[0]], enabledIdx[0], nil
+	case constant.MultiKeyModeLeastInFlight:
+		selectedIdx := pickLeastInFlightKey(channel.Id, filterCoolingKeys(channel.Id, enabledIdx))
+		return keys[selectedIdx], selectedIdx, nil
+	case constant.MultiKeyModeWeighted:
+		selectedIdx := pickWeightedKey(channel.ChannelInfo.MultiKeyWeights, filterCoolingKeys(channel.Id, enabledIdx))
+		return keys[selectedIdx], selectedIdx, nil
+	case constant.MultiKeyModeCooldown:
+		candidates := filterCoolingKeys(channel.Id, enabledIdx)
+		selectedIdx := candidates[rand.Intn(len(candidates))]
+		return keys[selectedIdx], selectedIdx, nil
 	default:
 		// Unknown mode, default to first enabled key (or original key string)
 		return keys[enabledIdx[0]], enabledIdx[0], nil
@@ -361,7 +383,7 @@ func SearchChannels(keyword string, group string, model string, idSort bool) ([]
 	// 构造基础查询
 	baseQuery := DB.Model(&Channel{}).Omit("key")
 
-	// 构造WHERE子句
+	// 构造WHERE子句，key 列为加密后的密文，不再支持按密钥搜索
 	var whereClause string
 	var args []interface{}
 	if group != "" && group != "null" {
@@ -372,11 +394,11 @@ func SearchChannels(keyword string, group string, model string, idSort bool) ([]
 			// sqlite, PostgreSQL
 			groupCondition = `(',' || ` + commonGroupCol + ` || ',') LIKE ?`
 		}
-		whereClause = "(id = ? OR name LIKE ? OR " + commonKeyCol + " = ? OR " + baseURLCol + " LIKE ?) AND " + modelsCol + ` LIKE ? AND ` + groupCondition
-		args = append(args, common.String2Int(keyword), "%"+keyword+"%", keyword, "%"+keyword+"%", "%"+model+"%", "%,"+group+",%")
+		whereClause = "(id = ? OR name LIKE ? OR " + baseURLCol + " LIKE ?) AND " + modelsCol + ` LIKE ? AND ` + groupCondition
+		args = append(args, common.String2Int(keyword), "%"+keyword+"%", "%"+keyword+"%", "%"+model+"%", "%,"+group+",%")
 	} else {
-		whereClause = "(id = ? OR name LIKE ? OR " + commonKeyCol + " = ? OR " + baseURLCol + " LIKE ?) AND " + modelsCol + " LIKE ?"
-		args = append(args, common.String2Int(keyword), "%"+keyword+"%", keyword, "%"+keyword+"%", "%"+model+"%")
+		whereClause = "(id = ? OR name LIKE ? OR " + baseURLCol + " LIKE ?) AND " + modelsCol + " LIKE ?"
+		args = append(args, common.String2Int(keyword), "%"+keyword+"%", "%"+keyword+"%", "%"+model+"%")
 	}
 
 	// 执行查询
@@ -619,42 +641,67 @@ func CleanupChannelPollingLocks() {
 	})
 }
 
-func handlerMultiKeyUpdate(channel *Channel, usingKey string, status int, reason string) {
+// handlerMultiKeyUpdate 更新多 Key 渠道中 usingKey 的状态，返回 key 的索引以及状态是否发生变化。
+// 已禁用的 key 再次禁用时保留首次禁用的原因与时间，恢复时也不清除，作为最近一次禁用的记录。
+func handlerMultiKeyUpdate(channel *Channel, usingKey string, status int, reason string) (int, bool) {
 	keys := channel.GetKeys()
 	if len(keys) == 0 {
+		changed := channel.Status != status
 		channel.Status = status
-	} else {
-		var keyIndex int
-		for i, key := range keys {
-			if key == usingKey {
-				keyIndex = i
-				break
-			}
-		}
-		if channel.ChannelInfo.MultiKeyStatusList == nil {
-			channel.ChannelInfo.MultiKeyStatusList = make(map[int]int)
+		return -1, changed
+	}
+	var keyIndex int
+	for i, key := range keys {
+		if key == usingKey {
+			keyIndex = i
+			break
 		}
-		if status == common.ChannelStatusEnabled {
+	}
+	if channel.ChannelInfo.MultiKeyStatusList == nil {
+		channel.ChannelInfo.MultiKeyStatusList = make(map[int]int)
+	}
+	_, disabled := channel.ChannelInfo.MultiKeyStatusList[keyIndex]
+	changed := false
+	if status == common.ChannelStatusEnabled {
+		if disabled {
 			delete(channel.ChannelInfo.MultiKeyStatusList, keyIndex)
-		} else {
-			channel.ChannelInfo.MultiKeyStatusList[keyIndex] = status
-			if channel.ChannelInfo.MultiKeyDisabledReason == nil {
-				channel.ChannelInfo.MultiKeyDisabledReason = make(map[int]string)
+			changed = true
+		}
+		// 所有 key 被禁用导致的渠道自动禁用，在有 key 恢复后随之恢复
+		if channel.Status == common.ChannelStatusAutoDisabled && len(channel.ChannelInfo.MultiKeyStatusList) < channel.ChannelInfo.MultiKeySize {
+			channel.Status = common.ChannelStatusEnabled
+		}
+	} else if !disabled {
+		channel.ChannelInfo.MultiKeyStatusList[keyIndex] = status
+		if channel.ChannelInfo.MultiKeyDisabledReason == nil {
+			channel.ChannelInfo.MultiKeyDisabledReason = make(map[int]string)
+		}
+		if channel.ChannelInfo.MultiKeyDisabledTime == nil {
+			channel.ChannelInfo.MultiKeyDisabledTime = make(map[int]int64)
+		}
+		// 恢复后再次禁用：保留上一次的禁用原因
+		if prevReason, ok := channel.ChannelInfo.MultiKeyDisabledReason[keyIndex]; ok && prevReason != "" {
+			if channel.ChannelInfo.MultiKeyPrevDisabledReason == nil {
+				channel.ChannelInfo.MultiKeyPrevDisabledReason = make(map[int]string)
 			}
-			if channel.ChannelInfo.MultiKeyDisabledTime == nil {
-				channel.ChannelInfo.MultiKeyDisabledTime = make(map[int]int64)
+			if channel.ChannelInfo.MultiKeyPrevDisabledTime == nil {
+				channel.ChannelInfo.MultiKeyPrevDisabledTime = make(map[int]int64)
 			}
-			channel.ChannelInfo.MultiKeyDisabledReason[keyIndex] = reason
-			channel.ChannelInfo.MultiKeyDisabledTime[keyIndex] = common.GetTimestamp()
+			channel.ChannelInfo.MultiKeyPrevDisabledReason[keyIndex] = prevReason
+			channel.ChannelInfo.MultiKeyPrevDisabledTime[keyIndex] = channel.ChannelInfo.MultiKeyDisabledTime[keyIndex]
 		}
-		if len(channel.ChannelInfo.MultiKeyStatusList) >= channel.ChannelInfo.MultiKeySize {
-			channel.Status = common.ChannelStatusAutoDisabled
-			info := channel.GetOtherInfo()
-			info["status_reason"] = "All keys are disabled"
-			info["status_time"] = common.GetTimestamp()
-			channel.SetOtherInfo(info)
-		}
-	}
+		channel.ChannelInfo.MultiKeyDisabledReason[keyIndex] = reason
+		channel.ChannelInfo.MultiKeyDisabledTime[keyIndex] = common.GetTimestamp()
+		changed = true
+	}
+	if len(channel.ChannelInfo.MultiKeyStatusList) >= channel.ChannelInfo.MultiKeySize && channel.Status == common.ChannelStatusEnabled {
+		channel.Status = common.ChannelStatusAutoDisabled
+		info := channel.GetOtherInfo()
+		info["status_reason"] = "All keys are disabled"
+		info["status_time"] = common.GetTimestamp()
+		channel.SetOtherInfo(info)
+	}
+	return keyIndex, changed
 }
 
 func UpdateChannelStatus(channelId int, usingKey string, status int, reason string) bool {
@@ -685,9 +732,10 @@ func UpdateChannelStatus(channelId int, usingKey string, status int, reason stri
 	}
 
 	shouldUpdateAbilities := false
+	abilityEnabled := status == common.ChannelStatusEnabled
 	defer func() {
 		if shouldUpdateAbilities {
-			err := UpdateAbilityStatus(channelId, status == common.ChannelStatusEnabled)
+			err := UpdateAbilityStatus(channelId, abilityEnabled)
 			if err != nil {
 				common.SysLog(fmt.Sprintf("failed to update ability status: channel_id=%d, error=%v", channelId, err))
 			}
@@ -697,21 +745,26 @@ func UpdateChannelStatus(channelId int, usingKey string, status int, reason stri
 	if err != nil {
 		return false
 	} else {
-		if channel.Status == status {
-			return false
-		}
-
+		keyIndex := -1
 		if channel.ChannelInfo.IsMultiKey {
 			beforeStatus := channel.Status
 			// Protect map writes with the same per-channel lock used by readers
 			pollingLock := GetChannelPollingLock(channelId)
 			pollingLock.Lock()
-			handlerMultiKeyUpdate(channel, usingKey, status, reason)
+			var changed bool
+			keyIndex, changed = handlerMultiKeyUpdate(channel, usingKey, status, reason)
 			pollingLock.Unlock()
+			if !changed && beforeStatus == channel.Status {
+				return false
+			}
 			if beforeStatus != channel.Status {
 				shouldUpdateAbilities = true
+				abilityEnabled = channel.Status == common.ChannelStatusEnabled
 			}
 		} else {
+			if channel.Status == status {
+				return false
+			}
 			info := channel.GetOtherInfo()
 			info["status_reason"] = reason
 			info["status_time"] = common.GetTimestamp()
@@ -724,6 +777,7 @@ func UpdateChannelStatus(channelId int, usingKey string, status int, reason stri
 			common.SysLog(fmt.Sprintf("failed to update channel status: channel_id=%d, status=%d, error=%v", channel.Id, status, err))
 			return false
 		}
+		RecordChannelStatusEvent(channelId, keyIndex, status, reason)
 	}
 	return true
 }
@@ -856,7 +910,7 @@ func SearchTags(keyword string, group string, model string, idSort bool) ([]*str
 	// 构造基础查询
 	baseQuery := DB.Model(&Channel{}).Omit("key")
 
-	// 构造WHERE子句
+	// 构造WHERE子句，key 列为加密后的密文，不再支持按密钥搜索
 	var whereClause string
 	var args []interface{}
 	if group != "" && group != "null" {
@@ -867,11 +921,11 @@ func SearchTags(keyword string, group string, model string, idSort bool) ([]*str
 			// sqlite, PostgreSQL
 			groupCondition = `(',' || ` + commonGroupCol + ` || ',') LIKE ?`
 		}
-		whereClause = "(id = ? OR name LIKE ? OR " + commonKeyCol + " = ? OR " + baseURLCol + " LIKE ?) AND " + modelsCol + ` LIKE ? AND ` + groupCondition
-		args = append(args, common.String2Int(keyword), "%"+keyword+"%", keyword, "%"+keyword+"%", "%"+model+"%", "%,"+group+",%")
+		whereClause = "(id = ? OR name LIKE ? OR " + baseURLCol + " LIKE ?) AND " + modelsCol + ` LIKE ? AND ` + groupCondition
+		args = append(args, common.String2Int(keyword), "%"+keyword+"%", "%"+keyword+"%", "%"+model+"%", "%,"+group+",%")
 	} else {
-		whereClause = "(id = ? OR name LIKE ? OR " + commonKeyCol + " = ? OR " + baseURLCol + " LIKE ?) AND " + modelsCol + " LIKE ?"
-		args = append(args, common.String2Int(keyword), "%"+keyword+"%", keyword, "%"+keyword+"%", "%"+model+"%")
+		whereClause = "(id = ? OR name LIKE ? OR " + baseURLCol + " LIKE ?) AND " + modelsCol + " LIKE ?"
+		args = append(args, common.String2Int(keyword), "%"+keyword+"%", "%"+keyword+"%", "%"+model+"%")
 	}
 
 	subQuery := baseQuery.Where(whereClause, args...).
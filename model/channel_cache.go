@@ -11,6 +11,7 @@ import (
 
 	"github.com/QuantumNous/new-api/common"
 	"github.com/QuantumNous/new-api/constant"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
 	"github.com/QuantumNous/new-api/setting/ratio_setting"
 )
 
@@ -96,6 +97,13 @@ func SyncChannelCache(frequency int) {
 func GetRandomSatisfiedChannel(group string, model string, retry int) (*Channel, error) {
 	// if memory cache is disabled, get channel directly from database
 	if !common.MemoryCacheEnabled {
+		if operation_setting.IsChannelHealthSelectionEnabled() {
+			channels, err := GetEnabledChannelsByGroupModel(group, model)
+			if err != nil {
+				return nil, err
+			}
+			return pickChannelByHealthAcrossPriorities(groupChannelsByPriority(channels), retry, model)
+		}
 		return GetChannel(group, model, retry)
 	}
 
@@ -136,6 +144,14 @@ func GetRandomSatisfiedChannel(group string, model string, retry int) (*Channel,
 	}
 	sort.Sort(sort.Reverse(sort.IntSlice(sortedUniquePriorities)))
 
+	if operation_setting.IsChannelHealthSelectionEnabled() {
+		candidates := make([]*Channel, 0, len(channels))
+		for _, channelId := range channels {
+			candidates = append(candidates, channelsIDM[channelId])
+		}
+		return pickChannelByHealthAcrossPriorities(groupChannelsByPriority(candidates), retry, model)
+	}
+
 	if retry >= len(uniquePriorities) {
 		retry = len(uniquePriorities) - 1
 	}
@@ -231,6 +247,10 @@ func CacheUpdateChannelStatus(id int, status int) {
 	if channel, ok := channelsIDM[id]; ok {
 		channel.Status = status
 	}
+	if status == common.ChannelStatusEnabled {
+		// 渠道重新启用后，旧的熔断状态不再有意义
+		ResetChannelHealth(id)
+	}
 	if status != common.ChannelStatusEnabled {
 		// delete the channel from group2model2channels
 		for group, model2channels := range group2model2channels {
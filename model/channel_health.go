@@ -0,0 +1,401 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+)
+
+const (
+	ChannelHealthStateClosed   = "closed"    // 正常
+	ChannelHealthStateOpen     = "open"      // 熔断中，暂不参与选路
+	ChannelHealthStateHalfOpen = "half_open" // 熔断到期，放行单个探测请求
+)
+
+// channelHealthStat 单个渠道 + 模型的滚动健康度（仅保存在本节点内存中）
+type channelHealthStat struct {
+	mu                  sync.Mutex
+	successRate         float64 // EWMA 成功率
+	latencyMs           float64 // 成功请求的 EWMA 首字延迟
+	samples             int64
+	consecutiveFailures int
+	state               string
+	openedAt            time.Time
+	probing             bool
+	probeStartedAt      time.Time
+	updatedAt           time.Time
+}
+
+type channelHealthSnapshot struct {
+	state       string
+	successRate float64
+	latencyMs   float64
+	samples     int64
+	available   bool
+}
+
+// ChannelHealthDetail 渠道在某个模型上的健康度
+type ChannelHealthDetail struct {
+	Model               string  `json:"model"`
+	State               string  `json:"state"`
+	Score               float64 `json:"score"`
+	SuccessRate         float64 `json:"success_rate"`
+	LatencyMs           float64 `json:"latency_ms"`
+	Samples             int64   `json:"samples"`
+	ConsecutiveFailures int     `json:"consecutive_failures"`
+	OpenedAt            int64   `json:"opened_at,omitempty"`
+	UpdatedAt           int64   `json:"updated_at"`
+}
+
+// ChannelHealthSummary 渠道列表中展示的健康度汇总：分数按样本数加权，状态取最差的模型
+type ChannelHealthSummary struct {
+	State     string                 `json:"state"`
+	Score     float64                `json:"score"`
+	LatencyMs float64                `json:"latency_ms"`
+	Samples   int64                  `json:"samples"`
+	Models    []*ChannelHealthDetail `json:"models"`
+}
+
+var channelHealthStats sync.Map // map["channelId:model"]*channelHealthStat
+
+func channelHealthKey(channelId int, modelName string) string {
+	return strconv.Itoa(channelId) + ":" + modelName
+}
+
+func getChannelHealthStat(channelId int, modelName string, create bool) *channelHealthStat {
+	key := channelHealthKey(channelId, modelName)
+	if v, ok := channelHealthStats.Load(key); ok {
+		return v.(*channelHealthStat)
+	}
+	if !create {
+		return nil
+	}
+	v, _ := channelHealthStats.LoadOrStore(key, &channelHealthStat{successRate: 1, state: ChannelHealthStateClosed})
+	return v.(*channelHealthStat)
+}
+
+func (s *channelHealthStat) openLocked(now time.Time) {
+	s.state = ChannelHealthStateOpen
+	s.openedAt = now
+	s.probing = false
+}
+
+// refreshLocked 熔断到期后转为半开；探测请求超时未回报时允许重新探测
+func (s *channelHealthStat) refreshLocked(now time.Time, setting *operation_setting.ChannelHealthSetting) {
+	openDuration := time.Duration(setting.OpenSeconds) * time.Second
+	switch s.state {
+	case ChannelHealthStateOpen:
+		if now.Sub(s.openedAt) >= openDuration {
+			s.state = ChannelHealthStateHalfOpen
+			s.probing = false
+		}
+	case ChannelHealthStateHalfOpen:
+		if s.probing && now.Sub(s.probeStartedAt) >= openDuration {
+			s.probing = false
+		}
+	}
+}
+
+// RecordChannelHealth 记录一次请求结果，latency 为首字延迟（失败时忽略）
+func RecordChannelHealth(channelId int, modelName string, success bool, latency time.Duration) {
+	if channelId <= 0 || modelName == "" {
+		return
+	}
+	setting := operation_setting.GetChannelHealthSetting()
+	alpha := setting.EwmaAlpha
+	if alpha <= 0 || alpha > 1 {
+		alpha = 0.2
+	}
+	stat := getChannelHealthStat(channelId, modelName, true)
+	now := time.Now()
+
+	stat.mu.Lock()
+	defer stat.mu.Unlock()
+	stat.refreshLocked(now, setting)
+	stat.samples++
+	stat.updatedAt = now
+	result := 0.0
+	if success {
+		result = 1
+	}
+	stat.successRate = alpha*result + (1-alpha)*stat.successRate
+
+	if success {
+		ms := float64(latency.Milliseconds())
+		if stat.latencyMs == 0 {
+			stat.latencyMs = ms
+		} else {
+			stat.latencyMs = alpha*ms + (1-alpha)*stat.latencyMs
+		}
+		stat.consecutiveFailures = 0
+		if stat.state != ChannelHealthStateClosed {
+			// 探测成功，关闭熔断并重新累计样本
+			stat.state = ChannelHealthStateClosed
+			stat.probing = false
+			stat.successRate = 1
+			stat.samples = 1
+		}
+		return
+	}
+
+	stat.consecutiveFailures++
+	if stat.state == ChannelHealthStateHalfOpen {
+		stat.openLocked(now)
+		return
+	}
+	if stat.state == ChannelHealthStateClosed {
+		if setting.ConsecutiveFailures > 0 && stat.consecutiveFailures >= setting.ConsecutiveFailures {
+			stat.openLocked(now)
+		} else if stat.samples >= int64(setting.MinSamples) && stat.successRate < setting.OpenSuccessRate {
+			stat.openLocked(now)
+		}
+	}
+}
+
+func getChannelHealthSnapshot(channelId int, modelName string, setting *operation_setting.ChannelHealthSetting) channelHealthSnapshot {
+	stat := getChannelHealthStat(channelId, modelName, false)
+	if stat == nil {
+		return channelHealthSnapshot{state: ChannelHealthStateClosed, successRate: 1, available: true}
+	}
+	stat.mu.Lock()
+	defer stat.mu.Unlock()
+	stat.refreshLocked(time.Now(), setting)
+	return channelHealthSnapshot{
+		state:       stat.state,
+		successRate: stat.successRate,
+		latencyMs:   stat.latencyMs,
+		samples:     stat.samples,
+		available:   stat.state == ChannelHealthStateClosed || (stat.state == ChannelHealthStateHalfOpen && !stat.probing),
+	}
+}
+
+// tryStartChannelHealthProbe 半开状态下占用唯一的探测名额
+func tryStartChannelHealthProbe(channelId int, modelName string) {
+	stat := getChannelHealthStat(channelId, modelName, false)
+	if stat == nil {
+		return
+	}
+	stat.mu.Lock()
+	defer stat.mu.Unlock()
+	if stat.state == ChannelHealthStateHalfOpen && !stat.probing {
+		stat.probing = true
+		stat.probeStartedAt = time.Now()
+	}
+}
+
+// hasAvailableHealthChannel 判断同优先级渠道中是否存在未熔断（或可探测）的渠道
+func hasAvailableHealthChannel(channels []*Channel, modelName string) bool {
+	setting := operation_setting.GetChannelHealthSetting()
+	for _, channel := range channels {
+		if getChannelHealthSnapshot(channel.Id, modelName, setting).available {
+			return true
+		}
+	}
+	return false
+}
+
+// pickChannelByHealthAcrossPriorities 从 retry 对应的优先级开始按健康度选择，tiers 按优先级降序排列；
+// 当前优先级全部熔断时顺延到下一优先级，所有后续优先级都熔断时才在原优先级中忽略熔断状态选择。
+func pickChannelByHealthAcrossPriorities(tiers [][]*Channel, retry int, modelName string) (*Channel, error) {
+	if len(tiers) == 0 {
+		return nil, nil
+	}
+	if retry >= len(tiers) {
+		retry = len(tiers) - 1
+	}
+	for i := retry; i < len(tiers); i++ {
+		if hasAvailableHealthChannel(tiers[i], modelName) {
+			return pickChannelByHealth(tiers[i], modelName)
+		}
+	}
+	return pickChannelByHealth(tiers[retry], modelName)
+}
+
+// groupChannelsByPriority 将渠道按优先级降序分组
+func groupChannelsByPriority(channels []*Channel) [][]*Channel {
+	byPriority := make(map[int64][]*Channel)
+	var priorities []int64
+	for _, channel := range channels {
+		priority := channel.GetPriority()
+		if _, ok := byPriority[priority]; !ok {
+			priorities = append(priorities, priority)
+		}
+		byPriority[priority] = append(byPriority[priority], channel)
+	}
+	sort.Slice(priorities, func(i, j int) bool {
+		return priorities[i] > priorities[j]
+	})
+	tiers := make([][]*Channel, 0, len(priorities))
+	for _, priority := range priorities {
+		tiers = append(tiers, byPriority[priority])
+	}
+	return tiers
+}
+
+// pickChannelByHealth 在同优先级渠道中按 权重 × 健康系数 加权随机选择；
+// 熔断中的渠道被剔除，若全部熔断则退化为按原权重选择。
+func pickChannelByHealth(targetChannels []*Channel, modelName string) (*Channel, error) {
+	setting := operation_setting.GetChannelHealthSetting()
+	minFactor := setting.MinWeightFactor
+	if minFactor <= 0 {
+		minFactor = 0.01
+	}
+
+	sumWeight := 0
+	for _, channel := range targetChannels {
+		sumWeight += channel.GetWeight()
+	}
+	baseWeight := func(channel *Channel) float64 {
+		if sumWeight == 0 {
+			return 1
+		}
+		return float64(channel.GetWeight())
+	}
+
+	snapshots := make([]channelHealthSnapshot, len(targetChannels))
+	latencySum, latencyCount := 0.0, 0
+	for i, channel := range targetChannels {
+		snapshots[i] = getChannelHealthSnapshot(channel.Id, modelName, setting)
+		if snapshots[i].available && snapshots[i].latencyMs > 0 && snapshots[i].samples >= int64(setting.MinSamples) {
+			latencySum += snapshots[i].latencyMs
+			latencyCount++
+		}
+	}
+	meanLatency := 0.0
+	if latencyCount > 0 {
+		meanLatency = latencySum / float64(latencyCount)
+	}
+
+	weights := make([]float64, len(targetChannels))
+	totalWeight := 0.0
+	for i, channel := range targetChannels {
+		snapshot := snapshots[i]
+		if !snapshot.available {
+			continue
+		}
+		factor := 1.0
+		if snapshot.samples >= int64(setting.MinSamples) {
+			factor = snapshot.successRate * snapshot.successRate
+			if setting.LatencyWeighting && meanLatency > 0 && snapshot.latencyMs > 0 {
+				factor *= math.Min(2, math.Max(0.5, meanLatency/snapshot.latencyMs))
+			}
+		}
+		weights[i] = baseWeight(channel) * math.Max(factor, minFactor)
+		totalWeight += weights[i]
+	}
+
+	if totalWeight <= 0 {
+		for i, channel := range targetChannels {
+			weights[i] = baseWeight(channel)
+			totalWeight += weights[i]
+		}
+	}
+	if totalWeight <= 0 {
+		return nil, fmt.Errorf("no available channel for model %s", modelName)
+	}
+
+	randomWeight := rand.Float64() * totalWeight
+	for i, channel := range targetChannels {
+		if weights[i] <= 0 {
+			continue
+		}
+		randomWeight -= weights[i]
+		if randomWeight < 0 {
+			if snapshots[i].state == ChannelHealthStateHalfOpen {
+				tryStartChannelHealthProbe(channel.Id, modelName)
+			}
+			return channel, nil
+		}
+	}
+	for i := len(targetChannels) - 1; i >= 0; i-- {
+		if weights[i] > 0 {
+			return targetChannels[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no available channel for model %s", modelName)
+}
+
+func channelHealthStateRank(state string) int {
+	switch state {
+	case ChannelHealthStateOpen:
+		return 2
+	case ChannelHealthStateHalfOpen:
+		return 1
+	}
+	return 0
+}
+
+// GetChannelHealthSummary 返回渠道的健康度汇总，没有任何样本时返回 nil
+func GetChannelHealthSummary(channelId int) *ChannelHealthSummary {
+	setting := operation_setting.GetChannelHealthSetting()
+	prefix := strconv.Itoa(channelId) + ":"
+	summary := &ChannelHealthSummary{State: ChannelHealthStateClosed}
+	var scoreSum, latencySum float64
+	var latencySamples int64
+	channelHealthStats.Range(func(key, value any) bool {
+		k := key.(string)
+		if !strings.HasPrefix(k, prefix) {
+			return true
+		}
+		stat := value.(*channelHealthStat)
+		stat.mu.Lock()
+		stat.refreshLocked(time.Now(), setting)
+		detail := &ChannelHealthDetail{
+			Model:               strings.TrimPrefix(k, prefix),
+			State:               stat.state,
+			Score:               math.Round(stat.successRate*1000) / 10,
+			SuccessRate:         stat.successRate,
+			LatencyMs:           math.Round(stat.latencyMs),
+			Samples:             stat.samples,
+			ConsecutiveFailures: stat.consecutiveFailures,
+			UpdatedAt:           stat.updatedAt.Unix(),
+		}
+		if stat.state != ChannelHealthStateClosed {
+			detail.OpenedAt = stat.openedAt.Unix()
+		}
+		stat.mu.Unlock()
+
+		summary.Models = append(summary.Models, detail)
+		summary.Samples += detail.Samples
+		scoreSum += detail.Score * float64(detail.Samples)
+		if detail.LatencyMs > 0 {
+			latencySum += detail.LatencyMs * float64(detail.Samples)
+			latencySamples += detail.Samples
+		}
+		if channelHealthStateRank(detail.State) > channelHealthStateRank(summary.State) {
+			summary.State = detail.State
+		}
+		return true
+	})
+	if len(summary.Models) == 0 {
+		return nil
+	}
+	sort.Slice(summary.Models, func(i, j int) bool {
+		return summary.Models[i].Model < summary.Models[j].Model
+	})
+	if summary.Samples > 0 {
+		summary.Score = math.Round(scoreSum/float64(summary.Samples)*10) / 10
+	}
+	if latencySamples > 0 {
+		summary.LatencyMs = math.Round(latencySum / float64(latencySamples))
+	}
+	return summary
+}
+
+// ResetChannelHealth 清除渠道的健康度统计（如管理员手动恢复渠道后）
+func ResetChannelHealth(channelId int) {
+	prefix := strconv.Itoa(channelId) + ":"
+	channelHealthStats.Range(func(key, _ any) bool {
+		if strings.HasPrefix(key.(string), prefix) {
+			channelHealthStats.Delete(key)
+		}
+		return true
+	})
+}
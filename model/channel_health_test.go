@@ -0,0 +1,102 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChannelHealth_CircuitOpenHalfOpenClose(t *testing.T) {
+	setting := operation_setting.GetChannelHealthSetting()
+	origin := *setting
+	t.Cleanup(func() {
+		*setting = origin
+		ResetChannelHealth(9001)
+	})
+	setting.ConsecutiveFailures = 3
+	setting.OpenSeconds = 60
+
+	for i := 0; i < 3; i++ {
+		RecordChannelHealth(9001, "gpt-4o", false, 0)
+	}
+	require.Equal(t, ChannelHealthStateOpen, getChannelHealthSnapshot(9001, "gpt-4o", setting).state)
+	require.False(t, getChannelHealthSnapshot(9001, "gpt-4o", setting).available)
+
+	// 熔断到期后进入半开，只放行一个探测请求
+	stat := getChannelHealthStat(9001, "gpt-4o", false)
+	stat.mu.Lock()
+	stat.openedAt = time.Now().Add(-2 * time.Minute)
+	stat.mu.Unlock()
+	snapshot := getChannelHealthSnapshot(9001, "gpt-4o", setting)
+	require.Equal(t, ChannelHealthStateHalfOpen, snapshot.state)
+	require.True(t, snapshot.available)
+	tryStartChannelHealthProbe(9001, "gpt-4o")
+	require.False(t, getChannelHealthSnapshot(9001, "gpt-4o", setting).available)
+
+	RecordChannelHealth(9001, "gpt-4o", true, 200*time.Millisecond)
+	summary := GetChannelHealthSummary(9001)
+	require.NotNil(t, summary)
+	require.Equal(t, ChannelHealthStateClosed, summary.State)
+	require.Equal(t, float64(100), summary.Score)
+}
+
+func TestPickChannelByHealth_SkipsOpenChannel(t *testing.T) {
+	setting := operation_setting.GetChannelHealthSetting()
+	origin := *setting
+	t.Cleanup(func() {
+		*setting = origin
+		ResetChannelHealth(9101)
+		ResetChannelHealth(9102)
+	})
+	setting.ConsecutiveFailures = 1
+
+	weight := uint(10)
+	channels := []*Channel{{Id: 9101, Weight: &weight}, {Id: 9102, Weight: &weight}}
+	RecordChannelHealth(9101, "gpt-4o", false, 0)
+
+	for i := 0; i < 50; i++ {
+		channel, err := pickChannelByHealth(channels, "gpt-4o")
+		require.NoError(t, err)
+		require.Equal(t, 9102, channel.Id)
+	}
+
+	// 全部熔断时退化为按原权重选择
+	RecordChannelHealth(9102, "gpt-4o", false, 0)
+	channel, err := pickChannelByHealth(channels, "gpt-4o")
+	require.NoError(t, err)
+	require.NotNil(t, channel)
+}
+
+func TestPickChannelByHealthAcrossPriorities_FallsThrough(t *testing.T) {
+	setting := operation_setting.GetChannelHealthSetting()
+	origin := *setting
+	t.Cleanup(func() {
+		*setting = origin
+		ResetChannelHealth(9201)
+		ResetChannelHealth(9202)
+	})
+	setting.ConsecutiveFailures = 1
+
+	weight := uint(10)
+	high, low := int64(10), int64(0)
+	tiers := groupChannelsByPriority([]*Channel{
+		{Id: 9202, Weight: &weight, Priority: &low},
+		{Id: 9201, Weight: &weight, Priority: &high},
+	})
+	require.Len(t, tiers, 2)
+	require.Equal(t, 9201, tiers[0][0].Id)
+
+	// 高优先级全部熔断时顺延到下一优先级
+	RecordChannelHealth(9201, "gpt-4o", false, 0)
+	channel, err := pickChannelByHealthAcrossPriorities(tiers, 0, "gpt-4o")
+	require.NoError(t, err)
+	require.Equal(t, 9202, channel.Id)
+
+	// 所有优先级都熔断时回到原优先级
+	RecordChannelHealth(9202, "gpt-4o", false, 0)
+	channel, err = pickChannelByHealthAcrossPriorities(tiers, 0, "gpt-4o")
+	require.NoError(t, err)
+	require.Equal(t, 9201, channel.Id)
+}
@@ -0,0 +1,210 @@
+package model
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// 429 未携带 Retry-After 时的默认冷却时间
+	channelKeyDefaultCooldown = 60 * time.Second
+	// Retry-After 过大时的冷却上限，避免单个 key 长时间不可用
+	channelKeyMaxCooldown = 1 * time.Hour
+)
+
+// channelKeyStat 多 Key 渠道中单个 key 的实时统计（仅保存在本节点内存中）
+type channelKeyStat struct {
+	inFlight        atomic.Int64
+	requests        atomic.Int64
+	failures        atomic.Int64
+	rateLimited     atomic.Int64
+	lastRateLimited atomic.Int64 // unix 秒
+	cooldownUntil   atomic.Int64 // unix 毫秒
+}
+
+// ChannelKeyStats 多 Key 管理接口中展示的单个 key 统计
+type ChannelKeyStats struct {
+	InFlight          int64 `json:"in_flight"`
+	Requests          int64 `json:"requests"`
+	Failures          int64 `json:"failures"`
+	RateLimited       int64 `json:"rate_limited"`
+	LastRateLimitedAt int64 `json:"last_rate_limited_at,omitempty"`
+	CooldownUntil     int64 `json:"cooldown_until,omitempty"` // unix 秒，0 表示未在冷却
+}
+
+var channelKeyStats sync.Map // map["channelId:index"]*channelKeyStat
+
+func channelKeyStatKey(channelId int, index int) string {
+	return strconv.Itoa(channelId) + ":" + strconv.Itoa(index)
+}
+
+func getChannelKeyStat(channelId int, index int, create bool) *channelKeyStat {
+	key := channelKeyStatKey(channelId, index)
+	if v, ok := channelKeyStats.Load(key); ok {
+		return v.(*channelKeyStat)
+	}
+	if !create {
+		return nil
+	}
+	v, _ := channelKeyStats.LoadOrStore(key, &channelKeyStat{})
+	return v.(*channelKeyStat)
+}
+
+// ChannelKeyLease 表示一次占用中的 key，请求结束后需要 Release
+type ChannelKeyLease struct {
+	ChannelId int
+	Index     int
+	released  atomic.Bool
+}
+
+// AcquireChannelKey 记录 key 被选中，在途请求数 +1
+func AcquireChannelKey(channelId int, index int) *ChannelKeyLease {
+	stat := getChannelKeyStat(channelId, index, true)
+	stat.inFlight.Add(1)
+	stat.requests.Add(1)
+	return &ChannelKeyLease{ChannelId: channelId, Index: index}
+}
+
+// Release 在途请求数 -1，重复调用无副作用
+func (l *ChannelKeyLease) Release() {
+	if l == nil || !l.released.CompareAndSwap(false, true) {
+		return
+	}
+	if stat := getChannelKeyStat(l.ChannelId, l.Index, false); stat != nil {
+		stat.inFlight.Add(-1)
+	}
+}
+
+// RecordChannelKeyResult 记录 key 的请求结果；429 时按 Retry-After（缺省为 60 秒）进入冷却
+func RecordChannelKeyResult(channelId int, index int, statusCode int, retryAfter time.Duration) {
+	if statusCode >= 200 && statusCode < 300 {
+		return
+	}
+	stat := getChannelKeyStat(channelId, index, true)
+	stat.failures.Add(1)
+	if statusCode != http.StatusTooManyRequests {
+		return
+	}
+	if retryAfter <= 0 {
+		retryAfter = channelKeyDefaultCooldown
+	}
+	if retryAfter > channelKeyMaxCooldown {
+		retryAfter = channelKeyMaxCooldown
+	}
+	now := time.Now()
+	stat.rateLimited.Add(1)
+	stat.lastRateLimited.Store(now.Unix())
+	stat.cooldownUntil.Store(now.Add(retryAfter).UnixMilli())
+}
+
+// GetChannelKeyStats 返回指定 key 的统计
+func GetChannelKeyStats(channelId int, index int) ChannelKeyStats {
+	stat := getChannelKeyStat(channelId, index, false)
+	if stat == nil {
+		return ChannelKeyStats{}
+	}
+	stats := ChannelKeyStats{
+		InFlight:          stat.inFlight.Load(),
+		Requests:          stat.requests.Load(),
+		Failures:          stat.failures.Load(),
+		RateLimited:       stat.rateLimited.Load(),
+		LastRateLimitedAt: stat.lastRateLimited.Load(),
+	}
+	if until := stat.cooldownUntil.Load(); until > time.Now().UnixMilli() {
+		stats.CooldownUntil = (until + 999) / 1000
+	}
+	return stats
+}
+
+// ResetChannelKeyStats 清除渠道的 key 统计（删除 key 后索引会变化）
+func ResetChannelKeyStats(channelId int) {
+	prefix := strconv.Itoa(channelId) + ":"
+	channelKeyStats.Range(func(key, _ any) bool {
+		if strings.HasPrefix(key.(string), prefix) {
+			channelKeyStats.Delete(key)
+		}
+		return true
+	})
+}
+
+// filterCoolingKeys 剔除冷却中的 key；全部冷却时返回最早结束冷却的 key
+func filterCoolingKeys(channelId int, candidates []int) []int {
+	nowMs := time.Now().UnixMilli()
+	available := make([]int, 0, len(candidates))
+	earliestIdx, earliestUntil := -1, int64(0)
+	for _, idx := range candidates {
+		until := int64(0)
+		if stat := getChannelKeyStat(channelId, idx, false); stat != nil {
+			until = stat.cooldownUntil.Load()
+		}
+		if until <= nowMs {
+			available = append(available, idx)
+			continue
+		}
+		if earliestIdx == -1 || until < earliestUntil {
+			earliestIdx, earliestUntil = idx, until
+		}
+	}
+	if len(available) == 0 && earliestIdx != -1 {
+		available = append(available, earliestIdx)
+	}
+	return available
+}
+
+// pickLeastInFlightKey 选择在途请求最少的 key，数量相同时随机
+func pickLeastInFlightKey(channelId int, candidates []int) int {
+	best := make([]int, 0, len(candidates))
+	bestInFlight := int64(-1)
+	for _, idx := range candidates {
+		var inFlight int64
+		if stat := getChannelKeyStat(channelId, idx, false); stat != nil {
+			inFlight = stat.inFlight.Load()
+		}
+		switch {
+		case bestInFlight == -1 || inFlight < bestInFlight:
+			bestInFlight = inFlight
+			best = append(best[:0], idx)
+		case inFlight == bestInFlight:
+			best = append(best, idx)
+		}
+	}
+	return best[rand.Intn(len(best))]
+}
+
+// pickWeightedKey 按 key 权重随机，未设置权重的 key 视为 1，权重为 0 的 key 仅在其余 key 不可用时使用
+func pickWeightedKey(weights map[int]int, candidates []int) int {
+	total := 0
+	for _, idx := range candidates {
+		total += channelKeyWeight(weights, idx)
+	}
+	if total <= 0 {
+		return candidates[rand.Intn(len(candidates))]
+	}
+	r := rand.Intn(total)
+	for _, idx := range candidates {
+		r -= channelKeyWeight(weights, idx)
+		if r < 0 {
+			return idx
+		}
+	}
+	return candidates[len(candidates)-1]
+}
+
+func channelKeyWeight(weights map[int]int, idx int) int {
+	if weights == nil {
+		return 1
+	}
+	weight, ok := weights[idx]
+	if !ok {
+		return 1
+	}
+	if weight < 0 {
+		return 0
+	}
+	return weight
+}
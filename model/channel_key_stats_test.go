@@ -0,0 +1,42 @@
+package model
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChannelKeyStats_LeastInFlightAndCooldown(t *testing.T) {
+	t.Cleanup(func() { ResetChannelKeyStats(9101) })
+
+	lease := AcquireChannelKey(9101, 0)
+	AcquireChannelKey(9101, 1)
+	require.Equal(t, 2, pickLeastInFlightKey(9101, []int{0, 1, 2}))
+
+	lease.Release()
+	lease.Release()
+	require.Equal(t, int64(0), GetChannelKeyStats(9101, 0).InFlight)
+	require.Equal(t, int64(1), GetChannelKeyStats(9101, 0).Requests)
+
+	// 429 后进入冷却，全部冷却时返回最早结束冷却的 key
+	RecordChannelKeyResult(9101, 0, http.StatusTooManyRequests, 30*time.Second)
+	RecordChannelKeyResult(9101, 1, http.StatusTooManyRequests, 0)
+	require.Equal(t, []int{2}, filterCoolingKeys(9101, []int{0, 1, 2}))
+	require.Equal(t, []int{0}, filterCoolingKeys(9101, []int{0, 1}))
+
+	stats := GetChannelKeyStats(9101, 1)
+	require.Equal(t, int64(1), stats.RateLimited)
+	require.Equal(t, int64(1), stats.Failures)
+	require.Greater(t, stats.CooldownUntil, time.Now().Unix())
+}
+
+func TestPickWeightedKey_SkipsZeroWeight(t *testing.T) {
+	weights := map[int]int{0: 0, 1: 3}
+	for i := 0; i < 20; i++ {
+		require.Equal(t, 1, pickWeightedKey(weights, []int{0, 1}))
+	}
+	// 未设置权重的 key 视为 1
+	require.Equal(t, 2, pickWeightedKey(weights, []int{0, 2}))
+}
@@ -0,0 +1,69 @@
+package model
+
+import (
+	"context"
+
+	"github.com/QuantumNous/new-api/common"
+)
+
+const (
+	ChannelStatusEventDisabled  = "disabled"
+	ChannelStatusEventRecovered = "recovered"
+)
+
+// ChannelStatusEvent 渠道或多 Key 渠道中单个 key 的禁用/恢复记录，KeyIndex 为 -1 表示整个渠道
+type ChannelStatusEvent struct {
+	Id        int    `json:"id" gorm:"primaryKey;autoIncrement"`
+	ChannelId int    `json:"channel_id" gorm:"index"`
+	KeyIndex  int    `json:"key_index"`
+	Event     string `json:"event" gorm:"type:varchar(32)"`
+	Status    int    `json:"status"`
+	Reason    string `json:"reason" gorm:"type:text"`
+	CreatedAt int64  `json:"created_at" gorm:"bigint;index"`
+}
+
+func RecordChannelStatusEvent(channelId int, keyIndex int, status int, reason string) {
+	event := ChannelStatusEventDisabled
+	if status == common.ChannelStatusEnabled {
+		event = ChannelStatusEventRecovered
+	}
+	record := &ChannelStatusEvent{
+		ChannelId: channelId,
+		KeyIndex:  keyIndex,
+		Event:     event,
+		Status:    status,
+		Reason:    reason,
+		CreatedAt: common.GetTimestamp(),
+	}
+	if err := DB.Create(record).Error; err != nil {
+		common.SysLog("failed to record channel status event: " + err.Error())
+	}
+}
+
+// GetChannelStatusEvents 按时间倒序返回渠道的禁用/恢复记录
+func GetChannelStatusEvents(channelId int, startIdx int, num int) (events []*ChannelStatusEvent, total int64, err error) {
+	if err = DB.Model(&ChannelStatusEvent{}).Where("channel_id = ?", channelId).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err = DB.Where("channel_id = ?", channelId).Order("id desc").Limit(num).Offset(startIdx).Find(&events).Error
+	return events, total, err
+}
+
+// DeleteOldChannelStatusEvents 分批删除早于 targetTimestamp 的禁用/恢复记录
+func DeleteOldChannelStatusEvents(ctx context.Context, targetTimestamp int64, limit int) (int64, error) {
+	var total int64 = 0
+	for {
+		if nil != ctx.Err() {
+			return total, ctx.Err()
+		}
+		result := DB.Where("created_at < ?", targetTimestamp).Limit(limit).Delete(&ChannelStatusEvent{})
+		if nil != result.Error {
+			return total, result.Error
+		}
+		total += result.RowsAffected
+		if result.RowsAffected < int64(limit) {
+			break
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,61 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlerMultiKeyUpdate_KeepsFirstReasonAndRecoversChannel(t *testing.T) {
+	channel := &Channel{
+		Key:    "k0\nk1",
+		Status: common.ChannelStatusEnabled,
+		ChannelInfo: ChannelInfo{
+			IsMultiKey:   true,
+			MultiKeySize: 2,
+		},
+	}
+
+	idx, changed := handlerMultiKeyUpdate(channel, "k1", common.ChannelStatusAutoDisabled, "quota exceeded")
+	require.Equal(t, 1, idx)
+	require.True(t, changed)
+
+	// 已禁用的 key 再次禁用时不覆盖首次的原因
+	_, changed = handlerMultiKeyUpdate(channel, "k1", common.ChannelStatusAutoDisabled, "other error")
+	require.False(t, changed)
+	require.Equal(t, "quota exceeded", channel.ChannelInfo.MultiKeyDisabledReason[1])
+
+	_, changed = handlerMultiKeyUpdate(channel, "k0", common.ChannelStatusAutoDisabled, "invalid key")
+	require.True(t, changed)
+	require.Equal(t, common.ChannelStatusAutoDisabled, channel.Status)
+
+	// 任一 key 恢复后渠道随之恢复，禁用原因保留
+	_, changed = handlerMultiKeyUpdate(channel, "k1", common.ChannelStatusEnabled, "")
+	require.True(t, changed)
+	require.Equal(t, common.ChannelStatusEnabled, channel.Status)
+	require.NotContains(t, channel.ChannelInfo.MultiKeyStatusList, 1)
+	require.Equal(t, "quota exceeded", channel.ChannelInfo.MultiKeyDisabledReason[1])
+}
+
+func TestHandlerMultiKeyUpdate_KeepsPreviousReasonAfterRecovery(t *testing.T) {
+	channel := &Channel{
+		Key:    "k0\nk1",
+		Status: common.ChannelStatusEnabled,
+		ChannelInfo: ChannelInfo{
+			IsMultiKey:   true,
+			MultiKeySize: 2,
+		},
+	}
+
+	handlerMultiKeyUpdate(channel, "k1", common.ChannelStatusAutoDisabled, "quota exceeded")
+	firstTime := channel.ChannelInfo.MultiKeyDisabledTime[1]
+	handlerMultiKeyUpdate(channel, "k1", common.ChannelStatusEnabled, "")
+
+	// 恢复后再次禁用，新原因覆盖当前原因，上一次的原因与时间保留
+	_, changed := handlerMultiKeyUpdate(channel, "k1", common.ChannelStatusAutoDisabled, "invalid key")
+	require.True(t, changed)
+	require.Equal(t, "invalid key", channel.ChannelInfo.MultiKeyDisabledReason[1])
+	require.Equal(t, "quota exceeded", channel.ChannelInfo.MultiKeyPrevDisabledReason[1])
+	require.Equal(t, firstTime, channel.ChannelInfo.MultiKeyPrevDisabledTime[1])
+}
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"context"
+
+	"github.com/QuantumNous/new-api/common"
+)
+
+// mysqlTextColumnLimit MySQL TEXT 列最多 65535 字节，预留余量
+const mysqlTextColumnLimit = 60 << 10
+
+// ContentLog 请求/响应内容日志，与 Log 通过 RequestId 关联，仅管理员可查看
+type ContentLog struct {
+	Id                int    `json:"id"`
+	RequestId         string `json:"request_id" gorm:"type:varchar(64);index"`
+	UserId            int    `json:"user_id" gorm:"index"`
+	TokenId           int    `json:"token_id" gorm:"default:0"`
+	ModelName         string `json:"model_name" gorm:"default:''"`
+	Group             string `json:"group" gorm:"default:''"`
+	ChannelId         int    `json:"channel_id" gorm:"default:0"`
+	IsStream          bool   `json:"is_stream"`
+	StatusCode        int    `json:"status_code" gorm:"default:0"`
+	RequestBody       string `json:"request_body" gorm:"type:text"`
+	ResponseBody      string `json:"response_body" gorm:"type:text"`
+	RequestTruncated  bool   `json:"request_truncated"`
+	ResponseTruncated bool   `json:"response_truncated"`
+	ErrorMessage      string `json:"error_message" gorm:"type:text"`
+	CreatedAt         int64  `json:"created_at" gorm:"bigint;index"`
+}
+
+// ContentLogColumnLimit 单个内容字段在当前日志数据库中允许的最大字节数，0 表示不限制
+func ContentLogColumnLimit() int {
+	if common.LogSqlType == common.DatabaseTypeMySQL {
+		return mysqlTextColumnLimit
+	}
+	return 0
+}
+
+func (log *ContentLog) Insert() error {
+	if log.CreatedAt == 0 {
+		log.CreatedAt = common.GetTimestamp()
+	}
+	return LOG_DB.Create(log).Error
+}
+
+// GetContentLogsByRequestId 一次请求可能因重试产生多条记录，按时间顺序返回
+func GetContentLogsByRequestId(requestId string) (logs []*ContentLog, err error) {
+	err = LOG_DB.Where("request_id = ?", requestId).Order("id asc").Find(&logs).Error
+	return logs, err
+}
+
+func DeleteOldContentLog(ctx context.Context, targetTimestamp int64, limit int) (int64, error) {
+	var total int64 = 0
+
+	for {
+		if nil != ctx.Err() {
+			return total, ctx.Err()
+		}
+
+		result := LOG_DB.Where("created_at < ?", targetTimestamp).Limit(limit).Delete(&ContentLog{})
+		if nil != result.Error {
+			return total, result.Error
+		}
+
+		total += result.RowsAffected
+
+		if result.RowsAffected < int64(limit) {
+			break
+		}
+	}
+
+	return total, nil
+}
@@ -55,6 +55,14 @@ func GetDeletionRequests(page, pageSize int, status *int) ([]DeletionRequest, in
 	return requests, total, err
 }
 
+func GetDeletionRequestById(id int) (*DeletionRequest, error) {
+	var req DeletionRequest
+	if err := DB.First(&req, id).Error; err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
 func GetPendingDeletionRequestByUserId(userId int) (*DeletionRequest, error) {
 	var req DeletionRequest
 	err := DB.Where("user_id = ? AND status = ?", userId, DeletionRequestStatusPending).First(&req).Error
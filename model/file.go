@@ -0,0 +1,104 @@
+package model
+
+import (
+	"errors"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/dto"
+)
+
+const (
+	FileStatusUploaded  = "uploaded"
+	FileStatusProcessed = "processed"
+	FileStatusError     = "error"
+)
+
+// File 用户通过 /v1/files 上传或批处理生成的文件，内容保存在本地文件存储中
+type File struct {
+	Id        int    `json:"id" gorm:"primaryKey;autoIncrement"`
+	FileId    string `json:"file_id" gorm:"type:varchar(64);uniqueIndex"`
+	UserId    int    `json:"user_id" gorm:"index"`
+	TokenId   int    `json:"token_id" gorm:"index"`
+	Filename  string `json:"filename" gorm:"type:varchar(255)"`
+	Purpose   string `json:"purpose" gorm:"type:varchar(32);index"`
+	Bytes     int64  `json:"bytes" gorm:"type:bigint"`
+	Status    string `json:"status" gorm:"type:varchar(20)"`
+	Path      string `json:"-" gorm:"type:varchar(512)"` // 存储路径，禁止返回给用户
+	CreatedAt int64  `json:"created_at" gorm:"bigint;index"`
+	ExpiresAt int64  `json:"expires_at" gorm:"bigint;default:0"`
+}
+
+func NewFileId() string {
+	return "file-" + common.GetUUID()
+}
+
+func (f *File) ToOpenAIFile() *dto.OpenAIFile {
+	return &dto.OpenAIFile{
+		ID:        f.FileId,
+		Object:    "file",
+		Bytes:     f.Bytes,
+		CreatedAt: f.CreatedAt,
+		ExpiresAt: f.ExpiresAt,
+		Filename:  f.Filename,
+		Purpose:   f.Purpose,
+		Status:    f.Status,
+	}
+}
+
+func (f *File) Insert() error {
+	if f.CreatedAt == 0 {
+		f.CreatedAt = common.GetTimestamp()
+	}
+	return DB.Create(f).Error
+}
+
+func GetUserFileByFileId(userId int, fileId string) (*File, error) {
+	if fileId == "" {
+		return nil, errors.New("file id is empty")
+	}
+	var file File
+	err := DB.Where("file_id = ? AND user_id = ?", fileId, userId).First(&file).Error
+	if err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+// GetUserFiles 按 OpenAI 游标分页（after 为上一页最后一个 file_id）返回文件列表
+func GetUserFiles(userId int, purpose string, after string, limit int, order string) ([]*File, error) {
+	var files []*File
+	query := DB.Where("user_id = ?", userId)
+	if purpose != "" {
+		query = query.Where("purpose = ?", purpose)
+	}
+	asc := order == "asc"
+	if after != "" {
+		var cursor File
+		if err := DB.Select("id").Where("file_id = ? AND user_id = ?", after, userId).First(&cursor).Error; err == nil {
+			if asc {
+				query = query.Where("id > ?", cursor.Id)
+			} else {
+				query = query.Where("id < ?", cursor.Id)
+			}
+		}
+	}
+	if asc {
+		query = query.Order("id asc")
+	} else {
+		query = query.Order("id desc")
+	}
+	err := query.Limit(limit).Find(&files).Error
+	return files, err
+}
+
+func DeleteFileById(id int) error {
+	return DB.Delete(&File{}, id).Error
+}
+
+// UpdateFileContentInfo 在文件内容写入完成后更新大小与状态
+func UpdateFileContentInfo(id int, bytes int64, status string) error {
+	return DB.Model(&File{}).Where("id = ?", id).Updates(map[string]any{
+		"bytes":  bytes,
+		"status": status,
+	}).Error
+}
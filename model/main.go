@@ -250,6 +250,7 @@ func InitLogDB() (err error) {
 func migrateDB() error {
 	// Migrate price_amount column from float/double to decimal for existing tables
 	migrateSubscriptionPlanPriceAmount()
+	migrateTokenKeyIndex()
 
 	err := DB.AutoMigrate(
 		&Channel{},
@@ -330,6 +331,14 @@ func migrateDB() error {
 		&TgFarmSeasonPointsRule{},
 		&TgFarmWeatherEvent{},
 		&TgFarmRandomEvent{},
+		&File{},
+		&Batch{},
+		&ResponseRecord{},
+		&ChannelStatusEvent{},
+		&ProfitData{},
+		&Organization{},
+		&OrganizationMember{},
+		&AdminRole{},
 	)
 	if err != nil {
 		return err
@@ -367,6 +376,7 @@ func migrateDB() error {
 }
 
 func migrateDBFast() error {
+	migrateTokenKeyIndex()
 
 	var wg sync.WaitGroup
 
@@ -451,6 +461,12 @@ func migrateLOGDB() error {
 	if err = LOG_DB.AutoMigrate(&Log{}); err != nil {
 		return err
 	}
+	if err = LOG_DB.AutoMigrate(&ContentLog{}); err != nil {
+		return err
+	}
+	if err = LOG_DB.AutoMigrate(&AuditLog{}); err != nil {
+		return err
+	}
 	migrateQuotaColumnsToBigInt(LOG_DB)
 	return nil
 }
@@ -625,6 +641,17 @@ func migrateSubscriptionPlanPriceAmount() {
 	}
 }
 
+// migrateTokenKeyIndex 令牌改为保存前缀与哈希后，迁移完成的令牌 key 列为空，需要去掉原来的唯一索引
+func migrateTokenKeyIndex() {
+	const indexName = "idx_tokens_key"
+	if !DB.Migrator().HasTable(&Token{}) || !DB.Migrator().HasIndex(&Token{}, indexName) {
+		return
+	}
+	if err := DB.Migrator().DropIndex(&Token{}, indexName); err != nil {
+		common.SysLog(fmt.Sprintf("Warning: failed to drop index %s: %v", indexName, err))
+	}
+}
+
 func closeDB(db *gorm.DB) error {
 	sqlDB, err := db.DB()
 	if err != nil {
@@ -21,6 +21,7 @@ type Midjourney struct {
 	FailReason  string `json:"fail_reason"`
 	ChannelId   int    `json:"channel_id"`
 	Quota       int    `json:"quota"`
+	OrgId       int    `json:"org_id" gorm:"default:0"` // 组织令牌发起时记录组织 ID，失败时退回组织钱包
 	Buttons     string `json:"buttons"`
 	Properties  string `json:"properties"`
 }
@@ -0,0 +1,344 @@
+package model
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/bytedance/gopkg/util/gopool"
+	"gorm.io/gorm"
+)
+
+const (
+	OrgRoleOwner  = "owner"
+	OrgRoleAdmin  = "admin"
+	OrgRoleMember = "member"
+)
+
+const (
+	OrganizationStatusEnabled  = 1
+	OrganizationStatusDisabled = 2
+)
+
+var (
+	ErrOrganizationNotFound            = errors.New("organization not found")
+	ErrOrganizationDisabled            = errors.New("organization is disabled")
+	ErrNotOrganizationMember           = errors.New("not a member of the organization")
+	ErrOrganizationQuotaInsufficient   = errors.New("organization quota insufficient")
+	ErrOrganizationMemberLimitExceeded = errors.New("organization member spending limit exceeded")
+)
+
+// Organization 组织共享额度钱包，组织令牌的消费从 Quota 中扣除
+type Organization struct {
+	Id          int    `json:"id"`
+	Name        string `json:"name" gorm:"type:varchar(64);index"`
+	OwnerId     int    `json:"owner_id" gorm:"index"`
+	Quota       int    `json:"quota" gorm:"type:bigint;default:0"`
+	UsedQuota   int    `json:"used_quota" gorm:"type:bigint;default:0"`
+	Status      int    `json:"status" gorm:"default:1"`
+	CreatedTime int64  `json:"created_time" gorm:"bigint"`
+}
+
+// OrganizationMember 组织成员，QuotaLimit 为成员累计消费上限（0 表示不限制），UsedQuota 为已消费额度
+type OrganizationMember struct {
+	Id          int    `json:"id"`
+	OrgId       int    `json:"org_id" gorm:"uniqueIndex:idx_org_member,priority:1"`
+	UserId      int    `json:"user_id" gorm:"uniqueIndex:idx_org_member,priority:2;index"`
+	Role        string `json:"role" gorm:"type:varchar(16);default:'member'"`
+	QuotaLimit  int    `json:"quota_limit" gorm:"type:bigint;default:0"`
+	UsedQuota   int    `json:"used_quota" gorm:"type:bigint;default:0"`
+	CreatedTime int64  `json:"created_time" gorm:"bigint"`
+	Username    string `json:"username" gorm:"-"`
+}
+
+// UserOrganization 用户所在组织及其角色
+type UserOrganization struct {
+	Organization
+	Role       string `json:"role"`
+	QuotaLimit int    `json:"quota_limit"`
+	MemberUsed int    `json:"member_used_quota"`
+}
+
+func IsValidOrgRole(role string) bool {
+	return role == OrgRoleOwner || role == OrgRoleAdmin || role == OrgRoleMember
+}
+
+// CanManage 所有者与管理员可以管理成员与组织令牌
+func (m *OrganizationMember) CanManage() bool {
+	return m.Role == OrgRoleOwner || m.Role == OrgRoleAdmin
+}
+
+// ReachedLimit 成员是否已达到消费上限
+func (m *OrganizationMember) ReachedLimit() bool {
+	return m.QuotaLimit > 0 && m.UsedQuota >= m.QuotaLimit
+}
+
+func CreateOrganization(name string, ownerId int) (*Organization, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("organization name is empty")
+	}
+	now := common.GetTimestamp()
+	org := &Organization{
+		Name:        name,
+		OwnerId:     ownerId,
+		Status:      OrganizationStatusEnabled,
+		CreatedTime: now,
+	}
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(org).Error; err != nil {
+			return err
+		}
+		return tx.Create(&OrganizationMember{
+			OrgId:       org.Id,
+			UserId:      ownerId,
+			Role:        OrgRoleOwner,
+			CreatedTime: now,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+func GetOrganizationById(id int) (*Organization, error) {
+	var org Organization
+	err := DB.Where("id = ?", id).Limit(1).Find(&org).Error
+	if err != nil {
+		return nil, err
+	}
+	if org.Id == 0 {
+		return nil, ErrOrganizationNotFound
+	}
+	return &org, nil
+}
+
+func UpdateOrganization(id int, name string, status int) error {
+	updates := map[string]interface{}{}
+	if name = strings.TrimSpace(name); name != "" {
+		updates["name"] = name
+	}
+	if status == OrganizationStatusEnabled || status == OrganizationStatusDisabled {
+		updates["status"] = status
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	return DB.Model(&Organization{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func GetUserOrganizations(userId int) ([]UserOrganization, error) {
+	var members []OrganizationMember
+	if err := DB.Where("user_id = ?", userId).Find(&members).Error; err != nil {
+		return nil, err
+	}
+	result := make([]UserOrganization, 0, len(members))
+	for _, member := range members {
+		org, err := GetOrganizationById(member.OrgId)
+		if err != nil {
+			continue
+		}
+		result = append(result, UserOrganization{
+			Organization: *org,
+			Role:         member.Role,
+			QuotaLimit:   member.QuotaLimit,
+			MemberUsed:   member.UsedQuota,
+		})
+	}
+	return result, nil
+}
+
+func GetOrganizationMember(orgId int, userId int) (*OrganizationMember, error) {
+	var member OrganizationMember
+	err := DB.Where("org_id = ? AND user_id = ?", orgId, userId).Limit(1).Find(&member).Error
+	if err != nil {
+		return nil, err
+	}
+	if member.Id == 0 {
+		return nil, ErrNotOrganizationMember
+	}
+	return &member, nil
+}
+
+func GetOrganizationMembers(orgId int) ([]*OrganizationMember, error) {
+	var members []*OrganizationMember
+	if err := DB.Where("org_id = ?", orgId).Order("id asc").Find(&members).Error; err != nil {
+		return nil, err
+	}
+	for _, member := range members {
+		member.Username, _ = GetUsernameById(member.UserId, false)
+	}
+	return members, nil
+}
+
+func AddOrganizationMember(orgId int, userId int, role string, quotaLimit int) error {
+	if role == OrgRoleOwner || !IsValidOrgRole(role) {
+		return errors.New("invalid organization role")
+	}
+	if quotaLimit < 0 {
+		return errors.New("quota limit must not be negative")
+	}
+	if _, err := GetOrganizationMember(orgId, userId); err == nil {
+		return errors.New("user is already a member of the organization")
+	}
+	return DB.Create(&OrganizationMember{
+		OrgId:       orgId,
+		UserId:      userId,
+		Role:        role,
+		QuotaLimit:  quotaLimit,
+		CreatedTime: common.GetTimestamp(),
+	}).Error
+}
+
+// UpdateOrganizationMember 修改成员角色与消费上限，resetUsed 为 true 时清零成员已消费额度
+func UpdateOrganizationMember(orgId int, userId int, role string, quotaLimit int, resetUsed bool) error {
+	if role == OrgRoleOwner || !IsValidOrgRole(role) {
+		return errors.New("invalid organization role")
+	}
+	if quotaLimit < 0 {
+		return errors.New("quota limit must not be negative")
+	}
+	member, err := GetOrganizationMember(orgId, userId)
+	if err != nil {
+		return err
+	}
+	if member.Role == OrgRoleOwner {
+		return errors.New("cannot change the organization owner")
+	}
+	updates := map[string]interface{}{
+		"role":        role,
+		"quota_limit": quotaLimit,
+	}
+	if resetUsed {
+		updates["used_quota"] = 0
+	}
+	return DB.Model(&OrganizationMember{}).Where("id = ?", member.Id).Updates(updates).Error
+}
+
+// RemoveOrganizationMember 移除成员，成员名下的组织令牌在计费时会因不再是成员而被拒绝
+func RemoveOrganizationMember(orgId int, userId int) error {
+	member, err := GetOrganizationMember(orgId, userId)
+	if err != nil {
+		return err
+	}
+	if member.Role == OrgRoleOwner {
+		return errors.New("cannot remove the organization owner")
+	}
+	return DB.Delete(&OrganizationMember{}, member.Id).Error
+}
+
+// TransferUserQuotaToOrganization 将个人钱包额度转入组织钱包
+func TransferUserQuotaToOrganization(userId int, orgId int, quota int) error {
+	if quota <= 0 {
+		return errors.New("quota must be greater than 0")
+	}
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&User{}).Where("id = ? AND quota >= ?", userId, quota).
+			Update("quota", gorm.Expr("quota - ?", quota))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("user quota insufficient")
+		}
+		return tx.Model(&Organization{}).Where("id = ?", orgId).
+			Update("quota", gorm.Expr("quota + ?", quota)).Error
+	})
+	if err != nil {
+		return err
+	}
+	gopool.Go(func() {
+		if err := cacheDecrUserQuota(userId, int64(quota)); err != nil {
+			common.SysLog("failed to decrease user quota cache: " + err.Error())
+		}
+	})
+	return nil
+}
+
+// CheckOrganizationBilling 在预扣费前校验组织状态、成员身份、组织余额与成员上限
+func CheckOrganizationBilling(orgId int, userId int, amount int) (*Organization, error) {
+	org, err := GetOrganizationById(orgId)
+	if err != nil {
+		return nil, err
+	}
+	if org.Status != OrganizationStatusEnabled {
+		return nil, ErrOrganizationDisabled
+	}
+	member, err := GetOrganizationMember(orgId, userId)
+	if err != nil {
+		return nil, err
+	}
+	if org.Quota <= 0 || org.Quota < amount {
+		return nil, ErrOrganizationQuotaInsufficient
+	}
+	if member.ReachedLimit() || (member.QuotaLimit > 0 && member.UsedQuota+amount > member.QuotaLimit) {
+		return nil, ErrOrganizationMemberLimitExceeded
+	}
+	return org, nil
+}
+
+// PreConsumeOrganizationQuota 在同一事务中扣减组织余额并累计成员消费，余额或成员上限不足时整体失败
+func PreConsumeOrganizationQuota(orgId int, userId int, quota int) error {
+	if quota <= 0 {
+		return nil
+	}
+	return DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&Organization{}).
+			Where("id = ? AND status = ? AND quota >= ?", orgId, OrganizationStatusEnabled, quota).
+			Updates(map[string]interface{}{
+				"quota":      gorm.Expr("quota - ?", quota),
+				"used_quota": gorm.Expr("used_quota + ?", quota),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrOrganizationQuotaInsufficient
+		}
+		result = tx.Model(&OrganizationMember{}).
+			Where("org_id = ? AND user_id = ? AND (quota_limit = 0 OR used_quota + ? <= quota_limit)", orgId, userId, quota).
+			Update("used_quota", gorm.Expr("used_quota + ?", quota))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			var count int64
+			tx.Model(&OrganizationMember{}).Where("org_id = ? AND user_id = ?", orgId, userId).Count(&count)
+			if count == 0 {
+				return ErrNotOrganizationMember
+			}
+			return ErrOrganizationMemberLimitExceeded
+		}
+		return nil
+	})
+}
+
+// AdjustOrganizationQuota 结算或退款时调整组织余额与成员消费，delta > 0 表示补扣，delta < 0 表示退还。
+// 只用于已通过 PreConsumeOrganizationQuota 预扣费后的结算差额，补扣时余额允许为负（超出预估的部分记为欠费）；
+// 未预扣费的扣费必须走 PreConsumeOrganizationQuota，以校验组织余额与成员上限
+func AdjustOrganizationQuota(orgId int, userId int, delta int) error {
+	if delta == 0 {
+		return nil
+	}
+	return DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&Organization{}).Where("id = ?", orgId).Updates(map[string]interface{}{
+			"quota":      gorm.Expr("quota - ?", delta),
+			"used_quota": gorm.Expr("used_quota + ?", delta),
+		}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&OrganizationMember{}).Where("org_id = ? AND user_id = ?", orgId, userId).
+			Update("used_quota", gorm.Expr("used_quota + ?", delta)).Error
+	})
+}
+
+func GetOrganizationTokens(orgId int) ([]*Token, error) {
+	var tokens []*Token
+	err := DB.Where("org_id = ?", orgId).Order("id desc").Find(&tokens).Error
+	for _, token := range tokens {
+		token.HideKey()
+	}
+	return tokens, err
+}
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrganizationQuotaPool(t *testing.T) {
+	require.NoError(t, DB.AutoMigrate(&Organization{}, &OrganizationMember{}))
+	t.Cleanup(func() {
+		DB.Exec("DELETE FROM organizations")
+		DB.Exec("DELETE FROM organization_members")
+		DB.Exec("DELETE FROM users")
+		DB.Exec("DELETE FROM logs")
+	})
+	require.NoError(t, DB.Create(&User{Id: 101, Username: "org-owner", AffCode: "org1", Quota: 5000}).Error)
+	require.NoError(t, DB.Create(&User{Id: 102, Username: "org-member", AffCode: "org2"}).Error)
+
+	org, err := CreateOrganization("acme", 101)
+	require.NoError(t, err)
+	owner, err := GetOrganizationMember(org.Id, 101)
+	require.NoError(t, err)
+	require.Equal(t, OrgRoleOwner, owner.Role)
+
+	require.Error(t, AddOrganizationMember(org.Id, 102, OrgRoleOwner, 0))
+	require.NoError(t, AddOrganizationMember(org.Id, 102, OrgRoleMember, 300))
+	require.Error(t, AddOrganizationMember(org.Id, 102, OrgRoleMember, 0))
+
+	require.Error(t, TransferUserQuotaToOrganization(101, org.Id, 6000))
+	require.NoError(t, TransferUserQuotaToOrganization(101, org.Id, 1000))
+
+	_, err = CheckOrganizationBilling(org.Id, 102, 200)
+	require.NoError(t, err)
+	require.NoError(t, PreConsumeOrganizationQuota(org.Id, 102, 200))
+	// 成员上限 300，已用 200
+	require.ErrorIs(t, PreConsumeOrganizationQuota(org.Id, 102, 200), ErrOrganizationMemberLimitExceeded)
+	_, err = CheckOrganizationBilling(org.Id, 102, 200)
+	require.ErrorIs(t, err, ErrOrganizationMemberLimitExceeded)
+	require.ErrorIs(t, PreConsumeOrganizationQuota(org.Id, 101, 2000), ErrOrganizationQuotaInsufficient)
+
+	// 结算时实际消耗少于预扣，退还差额
+	require.NoError(t, AdjustOrganizationQuota(org.Id, 102, -50))
+	org, err = GetOrganizationById(org.Id)
+	require.NoError(t, err)
+	require.Equal(t, 850, org.Quota)
+	require.Equal(t, 150, org.UsedQuota)
+	member, err := GetOrganizationMember(org.Id, 102)
+	require.NoError(t, err)
+	require.Equal(t, 150, member.UsedQuota)
+
+	// 补扣超过余额时允许为负
+	require.NoError(t, AdjustOrganizationQuota(org.Id, 102, 900))
+	org, err = GetOrganizationById(org.Id)
+	require.NoError(t, err)
+	require.Equal(t, -50, org.Quota)
+	require.Equal(t, 1050, org.UsedQuota)
+	require.NoError(t, AdjustOrganizationQuota(org.Id, 102, -900))
+
+	require.NoError(t, UpdateOrganizationMember(org.Id, 102, OrgRoleAdmin, 0, true))
+	member, err = GetOrganizationMember(org.Id, 102)
+	require.NoError(t, err)
+	require.Equal(t, OrgRoleAdmin, member.Role)
+	require.Equal(t, 0, member.UsedQuota)
+
+	require.Error(t, RemoveOrganizationMember(org.Id, 101))
+	require.NoError(t, RemoveOrganizationMember(org.Id, 102))
+	require.ErrorIs(t, PreConsumeOrganizationQuota(org.Id, 102, 10), ErrNotOrganizationMember)
+
+	require.NoError(t, LOG_DB.Create(&Log{UserId: 101, OrgId: org.Id, Type: LogTypeConsume, Quota: 10}).Error)
+	require.NoError(t, LOG_DB.Create(&Log{UserId: 102, OrgId: org.Id, Type: LogTypeConsume, Quota: 20}).Error)
+	require.NoError(t, LOG_DB.Create(&Log{UserId: 102, Type: LogTypeConsume, Quota: 30}).Error)
+	logs, total, err := GetOrganizationLogs(org.Id, 0, LogTypeConsume, 0, 0, "", 0, 10)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, total)
+	require.Len(t, logs, 2)
+	logs, _, err = GetOrganizationLogs(org.Id, 102, LogTypeConsume, 0, 0, "", 0, 10)
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	require.Equal(t, 20, logs[0].Quota)
+}
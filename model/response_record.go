@@ -0,0 +1,100 @@
+package model
+
+import (
+	"context"
+	"errors"
+
+	"github.com/QuantumNous/new-api/common"
+
+	"gorm.io/gorm"
+)
+
+// ResponseRecord 网关本地保存的 /v1/responses 结果，用于 previous_response_id 续聊与 GET/DELETE /v1/responses/:id
+type ResponseRecord struct {
+	Id         int    `json:"id" gorm:"primaryKey;autoIncrement"`
+	ResponseId string `json:"response_id" gorm:"type:varchar(128);uniqueIndex"`
+	UserId     int    `json:"user_id" gorm:"index"`
+	TokenId    int    `json:"token_id" gorm:"index"`
+	Model      string `json:"model" gorm:"type:varchar(255)"`
+	Input      string `json:"input" gorm:"type:text"`    // 截至本次请求的完整输入条目（JSON 数组）
+	Output     string `json:"output" gorm:"type:text"`   // 本次响应的输出条目（JSON 数组）
+	Response   string `json:"response" gorm:"type:text"` // 完整响应对象
+	CreatedAt  int64  `json:"created_at" gorm:"bigint;index"`
+	ExpiresAt  int64  `json:"expires_at" gorm:"bigint;index"`
+}
+
+// ResponseRecordColumnLimit 单个内容字段在主数据库中允许的最大字节数，0 表示不限制
+func ResponseRecordColumnLimit() int {
+	if common.UsingMySQL {
+		return mysqlTextColumnLimit
+	}
+	return 0
+}
+
+// ErrResponseRecordOwnerMismatch 同一个 response id 已属于其他用户或令牌
+var ErrResponseRecordOwnerMismatch = errors.New("response record belongs to another token")
+
+// Upsert 同一个 response id 重复写入时覆盖旧记录，记录的归属用户与令牌不会被修改
+func (r *ResponseRecord) Upsert() error {
+	if r.CreatedAt == 0 {
+		r.CreatedAt = common.GetTimestamp()
+	}
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var existing ResponseRecord
+		err := tx.Where("response_id = ?", r.ResponseId).First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return tx.Create(r).Error
+		}
+		if err != nil {
+			return err
+		}
+		if existing.UserId != r.UserId || existing.TokenId != r.TokenId {
+			return ErrResponseRecordOwnerMismatch
+		}
+		r.Id = existing.Id
+		return tx.Model(&existing).Updates(map[string]interface{}{
+			"model":      r.Model,
+			"input":      r.Input,
+			"output":     r.Output,
+			"response":   r.Response,
+			"created_at": r.CreatedAt,
+			"expires_at": r.ExpiresAt,
+		}).Error
+	})
+}
+
+// GetUserResponseRecord 只返回属于该用户与令牌且未过期的记录
+func GetUserResponseRecord(userId int, tokenId int, responseId string) (*ResponseRecord, error) {
+	if responseId == "" {
+		return nil, errors.New("response id is empty")
+	}
+	var record ResponseRecord
+	err := DB.Where("response_id = ? AND user_id = ? AND token_id = ? AND expires_at > ?", responseId, userId, tokenId, common.GetTimestamp()).First(&record).Error
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func DeleteResponseRecordById(id int) error {
+	return DB.Delete(&ResponseRecord{}, id).Error
+}
+
+func DeleteExpiredResponseRecords(ctx context.Context, limit int) (int64, error) {
+	var total int64 = 0
+	now := common.GetTimestamp()
+	for {
+		if nil != ctx.Err() {
+			return total, ctx.Err()
+		}
+		result := DB.Where("expires_at <= ?", now).Limit(limit).Delete(&ResponseRecord{})
+		if nil != result.Error {
+			return total, result.Error
+		}
+		total += result.RowsAffected
+		if result.RowsAffected < int64(limit) {
+			break
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseRecordOwnership(t *testing.T) {
+	require.NoError(t, DB.AutoMigrate(&ResponseRecord{}))
+	t.Cleanup(func() {
+		DB.Where("response_id = ?", "resp_owner").Delete(&ResponseRecord{})
+	})
+	expiresAt := common.GetTimestamp() + 3600
+	record := &ResponseRecord{ResponseId: "resp_owner", UserId: 1, TokenId: 10, Output: "[]", ExpiresAt: expiresAt}
+	require.NoError(t, record.Upsert())
+
+	// 同一用户的其他令牌无法读取
+	_, err := GetUserResponseRecord(1, 11, "resp_owner")
+	require.Error(t, err)
+
+	// 其他用户或令牌写入同一 id 时不会改变归属
+	other := &ResponseRecord{ResponseId: "resp_owner", UserId: 2, TokenId: 20, Output: `["x"]`, ExpiresAt: expiresAt}
+	require.ErrorIs(t, other.Upsert(), ErrResponseRecordOwnerMismatch)
+
+	updated := &ResponseRecord{ResponseId: "resp_owner", UserId: 1, TokenId: 10, Output: `["y"]`, ExpiresAt: expiresAt}
+	require.NoError(t, updated.Upsert())
+	got, err := GetUserResponseRecord(1, 10, "resp_owner")
+	require.NoError(t, err)
+	require.Equal(t, `["y"]`, got.Output)
+}
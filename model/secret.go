@@ -0,0 +1,122 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/QuantumNous/new-api/common"
+
+	"gorm.io/gorm/schema"
+)
+
+// SecretSerializer 字段写入数据库前加密、读取后解密，明文只存在于内存中（如 channel cache）。
+// 注意：Update("column", value) 这类按列更新不会经过 serializer，需要先调用 common.EncryptSecret。
+type SecretSerializer struct{}
+
+func (SecretSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var value string
+	switch v := dbValue.(type) {
+	case nil:
+	case []byte:
+		value = string(v)
+	case string:
+		value = v
+	default:
+		return fmt.Errorf("failed to scan secret value: %#v", dbValue)
+	}
+	plaintext, err := common.DecryptSecret(value)
+	if err != nil {
+		return err
+	}
+	return field.Set(ctx, dst, plaintext)
+}
+
+func (SecretSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	value, _ := fieldValue.(string)
+	return common.EncryptSecret(value)
+}
+
+func init() {
+	schema.RegisterSerializer("secret", SecretSerializer{})
+}
+
+// IsSecretOptionKey 与后台隐藏的配置项规则一致，这些配置项在 options 表中加密保存
+func IsSecretOptionKey(key string) bool {
+	return strings.HasSuffix(key, "Token") ||
+		strings.HasSuffix(key, "Secret") ||
+		strings.HasSuffix(key, "Key") ||
+		strings.HasSuffix(key, "secret") ||
+		strings.HasSuffix(key, "api_key")
+}
+
+// UpdateChannelKey 只更新渠道 key，按列更新不会经过 serializer，需要在这里加密
+func UpdateChannelKey(channelId int, key string) error {
+	encrypted, err := common.EncryptSecret(key)
+	if err != nil {
+		return err
+	}
+	return DB.Model(&Channel{}).Where("id = ?", channelId).Update("key", encrypted).Error
+}
+
+type secretColumn struct {
+	table  string
+	pk     string
+	column string
+	where  func(key string) bool // 为 nil 时该列全部为密钥
+}
+
+// 需要加密保存的列；渠道 key 同时包含多 Key 列表与 Codex OAuth 凭据
+func secretColumns() []secretColumn {
+	return []secretColumn{
+		{table: "channels", pk: "id", column: commonKeyCol},
+		{table: "custom_oauth_providers", pk: "id", column: "client_secret"},
+		{table: "options", pk: commonKeyCol, column: "value", where: IsSecretOptionKey},
+	}
+}
+
+// EncryptStoredSecrets 把明文密钥以及由旧主密钥加密的密钥用当前主密钥重新加密，返回更新的条数。
+// 启动时执行一次用于迁移历史明文数据，--rotate-secret-key 时用于轮换主密钥。
+func EncryptStoredSecrets() (int, error) {
+	if !common.SecretEncryptionEnabled() {
+		return 0, nil
+	}
+	total := 0
+	for _, col := range secretColumns() {
+		var rows []struct {
+			Pk    string
+			Value string
+		}
+		err := DB.Table(col.table).
+			Select(fmt.Sprintf("%s AS pk, %s AS value", col.pk, col.column)).
+			Scan(&rows).Error
+		if err != nil {
+			return total, fmt.Errorf("failed to load %s: %w", col.table, err)
+		}
+		for _, row := range rows {
+			if col.where != nil && !col.where(row.Pk) {
+				continue
+			}
+			if !common.SecretNeedsRotation(row.Value) {
+				continue
+			}
+			plaintext, err := common.DecryptSecret(row.Value)
+			if err != nil {
+				return total, fmt.Errorf("failed to decrypt %s %s: %w", col.table, row.Pk, err)
+			}
+			encrypted, err := common.EncryptSecret(plaintext)
+			if err != nil {
+				return total, err
+			}
+			err = DB.Table(col.table).
+				Where(fmt.Sprintf("%s = ?", col.pk), row.Pk).
+				UpdateColumn(strings.Trim(col.column, "`\""), encrypted).Error
+			if err != nil {
+				return total, fmt.Errorf("failed to update %s %s: %w", col.table, row.Pk, err)
+			}
+			total++
+		}
+	}
+	return total, nil
+}
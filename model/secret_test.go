@@ -0,0 +1,64 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/stretchr/testify/require"
+)
+
+func rawChannelKey(t *testing.T, id int) string {
+	t.Helper()
+	var key string
+	require.NoError(t, DB.Table("channels").Select(commonKeyCol).Where("id = ?", id).Scan(&key).Error)
+	return key
+}
+
+func TestEncryptStoredSecrets(t *testing.T) {
+	initCol()
+	require.NoError(t, DB.AutoMigrate(&Option{}, &CustomOAuthProvider{}))
+	t.Cleanup(func() {
+		common.SetSecretMasterKeys("")
+		DB.Exec("DELETE FROM channels")
+		DB.Exec("DELETE FROM options")
+	})
+
+	// 开启加密前写入的明文数据
+	common.SetSecretMasterKeys("")
+	channel := &Channel{Name: "plain", Key: "sk-plain"}
+	require.NoError(t, DB.Create(channel).Error)
+	require.NoError(t, DB.Create(&Option{Key: "StripeApiSecret", Value: "stripe-secret"}).Error)
+	require.NoError(t, DB.Create(&Option{Key: "SystemName", Value: "New API"}).Error)
+
+	common.SetSecretMasterKeys("master-1")
+	count, err := EncryptStoredSecrets()
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+	require.True(t, common.IsEncryptedSecret(rawChannelKey(t, channel.Id)))
+
+	var loaded Channel
+	require.NoError(t, DB.First(&loaded, channel.Id).Error)
+	require.Equal(t, "sk-plain", loaded.Key)
+
+	var option Option
+	require.NoError(t, DB.First(&option, "`key` = ?", "SystemName").Error)
+	require.Equal(t, "New API", option.Value)
+
+	created := &Channel{Name: "encrypted", Key: "sk-new"}
+	require.NoError(t, DB.Create(created).Error)
+	require.True(t, common.IsEncryptedSecret(rawChannelKey(t, created.Id)))
+
+	// 按列更新也需要加密
+	require.NoError(t, UpdateChannelKey(channel.Id, "sk-updated"))
+	require.True(t, common.IsEncryptedSecret(rawChannelKey(t, channel.Id)))
+
+	// 轮换主密钥
+	common.SetSecretMasterKeys("master-2", "master-1")
+	count, err = EncryptStoredSecrets()
+	require.NoError(t, err)
+	require.Equal(t, 3, count)
+	common.SetSecretMasterKeys("master-2")
+	loaded = Channel{}
+	require.NoError(t, DB.First(&loaded, channel.Id).Error)
+	require.Equal(t, "sk-updated", loaded.Key)
+}
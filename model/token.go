@@ -1,6 +1,11 @@
 package model
 
 import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"strings"
@@ -12,27 +17,101 @@ import (
 )
 
 type Token struct {
-	Id                 int            `json:"id"`
-	UserId             int            `json:"user_id" gorm:"index"`
-	Key                string         `json:"key" gorm:"type:char(48);uniqueIndex"`
-	Status             int            `json:"status" gorm:"default:1"`
-	Name               string         `json:"name" gorm:"index" `
-	CreatedTime        int64          `json:"created_time" gorm:"bigint"`
-	AccessedTime       int64          `json:"accessed_time" gorm:"bigint"`
-	ExpiredTime        int64          `json:"expired_time" gorm:"bigint;default:-1"` // -1 means never expired
-	RemainQuota        int            `json:"remain_quota" gorm:"type:bigint;default:0"`
-	UnlimitedQuota     bool           `json:"unlimited_quota"`
-	ModelLimitsEnabled bool           `json:"model_limits_enabled"`
-	ModelLimits        string         `json:"model_limits" gorm:"type:varchar(1024);default:''"`
-	AllowIps           *string        `json:"allow_ips" gorm:"default:''"`
-	UsedQuota          int            `json:"used_quota" gorm:"type:bigint;default:0"` // used quota
-	Group              string         `json:"group" gorm:"default:''"`
-	CrossGroupRetry    bool           `json:"cross_group_retry"` // 跨分组重试，仅auto分组有效
-	DeletedAt          gorm.DeletedAt `gorm:"index"`
+	Id                 int     `json:"id"`
+	UserId             int     `json:"user_id" gorm:"index"`
+	OrgId              int     `json:"org_id" gorm:"index;default:0"`                        // 组织令牌从组织钱包扣费，0 表示个人令牌
+	Key                string  `json:"key" gorm:"type:char(48);index:idx_tokens_legacy_key"` // 仅历史令牌在迁移前保存明文，新令牌只在创建时返回
+	KeyPrefix          string  `json:"key_prefix" gorm:"type:varchar(16);index"`
+	KeyHash            string  `json:"-" gorm:"type:varchar(128)"`
+	Status             int     `json:"status" gorm:"default:1"`
+	Name               string  `json:"name" gorm:"index" `
+	CreatedTime        int64   `json:"created_time" gorm:"bigint"`
+	AccessedTime       int64   `json:"accessed_time" gorm:"bigint"`
+	ExpiredTime        int64   `json:"expired_time" gorm:"bigint;default:-1"` // -1 means never expired
+	RemainQuota        int     `json:"remain_quota" gorm:"type:bigint;default:0"`
+	UnlimitedQuota     bool    `json:"unlimited_quota"`
+	ModelLimitsEnabled bool    `json:"model_limits_enabled"`
+	ModelLimits        string  `json:"model_limits" gorm:"type:varchar(1024);default:''"`
+	AllowIps           *string `json:"allow_ips" gorm:"default:''"`
+	UsedQuota          int     `json:"used_quota" gorm:"type:bigint;default:0"` // used quota
+	Group              string  `json:"group" gorm:"default:''"`
+	CrossGroupRetry    bool    `json:"cross_group_retry"`                  // 跨分组重试，仅auto分组有效
+	RpmLimit           int     `json:"rpm_limit" gorm:"default:0"`         // 每分钟请求数上限，0 表示不限制
+	TpmLimit           int     `json:"tpm_limit" gorm:"default:0"`         // 每分钟 token 数上限，0 表示不限制
+	ConcurrencyLimit   int     `json:"concurrency_limit" gorm:"default:0"` // 最大并发请求数，0 表示不限制
+	ContentLogEnabled  bool    `json:"content_log_enabled"`                // 记录请求/响应内容，需同时开启内容日志
+	ModelFallbacks     string  `json:"model_fallbacks" gorm:"type:text"`   // 模型降级链 JSON：主模型 -> 备选模型列表，优先于分组配置
+	// 周期预算：每个周期开始时将剩余额度重置为 BudgetQuota（可携带不超过 BudgetCarryOverCap 的上期结余）
+	BudgetPeriod        string         `json:"budget_period" gorm:"type:varchar(16);default:'never'"`
+	BudgetQuota         int            `json:"budget_quota" gorm:"type:bigint;default:0"`
+	BudgetCarryOverCap  int            `json:"budget_carry_over_cap" gorm:"type:bigint;default:0"`
+	NextBudgetResetTime int64          `json:"next_budget_reset_time" gorm:"bigint;default:0;index"`
+	LastBudgetResetTime int64          `json:"last_budget_reset_time" gorm:"bigint;default:0"`
+	DeletedAt           gorm.DeletedAt `gorm:"index"`
 }
 
+const (
+	tokenKeyPrefixLength = 8
+	tokenKeySaltLength   = 16
+)
+
 func (token *Token) Clean() {
 	token.Key = ""
+	token.KeyHash = ""
+}
+
+// HideKey 用于返回给前端：不返回完整密钥，历史令牌补齐可公开的前缀
+func (token *Token) HideKey() {
+	if token.KeyPrefix == "" {
+		token.KeyPrefix = tokenKeyPrefix(token.Key)
+	}
+	token.Clean()
+}
+
+// MaskedKey 用于日志与错误信息
+func (token *Token) MaskedKey() string {
+	prefix := token.KeyPrefix
+	if prefix == "" {
+		prefix = tokenKeyPrefix(token.Key)
+	}
+	return "sk-" + prefix + "***"
+}
+
+func tokenKeyPrefix(key string) string {
+	if len(key) > tokenKeyPrefixLength {
+		return key[:tokenKeyPrefixLength]
+	}
+	return key
+}
+
+// hashTokenKey 返回 "salt:sha256(salt+key)"，salt 与哈希均为十六进制
+func hashTokenKey(key string, salt []byte) string {
+	sum := sha256.Sum256(append(bytes.Clone(salt), key...))
+	return hex.EncodeToString(salt) + ":" + hex.EncodeToString(sum[:])
+}
+
+// SetKey 计算前缀与加盐哈希，明文只保留在内存中，不写入数据库
+func (token *Token) SetKey(key string) error {
+	salt := make([]byte, tokenKeySaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	token.Key = key
+	token.KeyPrefix = tokenKeyPrefix(key)
+	token.KeyHash = hashTokenKey(key, salt)
+	return nil
+}
+
+func (token *Token) MatchKey(key string) bool {
+	saltHex, _, ok := strings.Cut(token.KeyHash, ":")
+	if !ok {
+		return false
+	}
+	salt, err := hex.DecodeString(saltHex)
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(hashTokenKey(key, salt)), []byte(token.KeyHash)) == 1
 }
 
 func (token *Token) GetIpLimits() []string {
@@ -145,7 +224,9 @@ func SearchUserTokens(userId int, keyword string, token string, offset int, limi
 		if err != nil {
 			return nil, 0, err
 		}
-		baseQuery = baseQuery.Where(commonKeyCol+" LIKE ? ESCAPE '!'", tokenPattern)
+		// 历史令牌按明文匹配，已迁移的令牌只能按公开前缀匹配
+		baseQuery = baseQuery.Where("("+commonKeyCol+" LIKE ? ESCAPE '!' OR key_prefix = ?)",
+			tokenPattern, tokenKeyPrefix(strings.ReplaceAll(token, "%", "")))
 	}
 
 	// 先查匹配总数（用于分页，受 maxTokens 上限保护，避免全表 COUNT）
@@ -170,40 +251,7 @@ func ValidateUserToken(key string) (token *Token, err error) {
 	}
 	token, err = GetTokenByKey(key, false)
 	if err == nil {
-		if token.Status == common.TokenStatusExhausted {
-			keyPrefix := key[:3]
-			keySuffix := key[len(key)-3:]
-			return token, errors.New("该令牌额度已用尽 TokenStatusExhausted[sk-" + keyPrefix + "***" + keySuffix + "]")
-		} else if token.Status == common.TokenStatusExpired {
-			return token, errors.New("该令牌已过期")
-		}
-		if token.Status != common.TokenStatusEnabled {
-			return token, errors.New("该令牌状态不可用")
-		}
-		if token.ExpiredTime != -1 && token.ExpiredTime < common.GetTimestamp() {
-			if !common.RedisEnabled {
-				token.Status = common.TokenStatusExpired
-				err := token.SelectUpdate()
-				if err != nil {
-					common.SysLog("failed to update token status" + err.Error())
-				}
-			}
-			return token, errors.New("该令牌已过期")
-		}
-		if !token.UnlimitedQuota && token.RemainQuota <= 0 {
-			if !common.RedisEnabled {
-				// in this case, we can make sure the token is exhausted
-				token.Status = common.TokenStatusExhausted
-				err := token.SelectUpdate()
-				if err != nil {
-					common.SysLog("failed to update token status" + err.Error())
-				}
-			}
-			keyPrefix := key[:3]
-			keySuffix := key[len(key)-3:]
-			return token, errors.New(fmt.Sprintf("[sk-%s***%s] 该令牌额度已用尽 !token.UnlimitedQuota && token.RemainQuota = %d", keyPrefix, keySuffix, token.RemainQuota))
-		}
-		return token, nil
+		return token, ValidateToken(token)
 	}
 	common.SysLog("ValidateUserToken: failed to get token: " + err.Error())
 	if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -213,6 +261,47 @@ func ValidateUserToken(key string) (token *Token, err error) {
 	}
 }
 
+// ValidateToken 检查令牌状态、有效期与额度
+func ValidateToken(token *Token) error {
+	if token.Status == common.TokenStatusExhausted && token.HasBudget() {
+		return &TokenBudgetExhaustedError{NextResetTime: token.NextBudgetResetTime}
+	}
+	if token.Status == common.TokenStatusExhausted {
+		return errors.New("该令牌额度已用尽 TokenStatusExhausted[" + token.MaskedKey() + "]")
+	} else if token.Status == common.TokenStatusExpired {
+		return errors.New("该令牌已过期")
+	}
+	if token.Status != common.TokenStatusEnabled {
+		return errors.New("该令牌状态不可用")
+	}
+	if token.ExpiredTime != -1 && token.ExpiredTime < common.GetTimestamp() {
+		if !common.RedisEnabled {
+			token.Status = common.TokenStatusExpired
+			err := token.SelectUpdate()
+			if err != nil {
+				common.SysLog("failed to update token status" + err.Error())
+			}
+		}
+		return errors.New("该令牌已过期")
+	}
+	if !token.UnlimitedQuota && token.RemainQuota <= 0 {
+		if token.HasBudget() {
+			// 周期预算用尽时保持启用状态，由定时任务在下个周期恢复额度
+			return &TokenBudgetExhaustedError{NextResetTime: token.NextBudgetResetTime}
+		}
+		if !common.RedisEnabled {
+			// in this case, we can make sure the token is exhausted
+			token.Status = common.TokenStatusExhausted
+			err := token.SelectUpdate()
+			if err != nil {
+				common.SysLog("failed to update token status" + err.Error())
+			}
+		}
+		return errors.New(fmt.Sprintf("[%s] 该令牌额度已用尽 !token.UnlimitedQuota && token.RemainQuota = %d", token.MaskedKey(), token.RemainQuota))
+	}
+	return nil
+}
+
 func GetTokenByIds(id int, userId int) (*Token, error) {
 	if id == 0 || userId == 0 {
 		return nil, errors.New("id 或 userId 为空！")
@@ -240,6 +329,16 @@ func GetTokenById(id int) (*Token, error) {
 	return &token, err
 }
 
+// GetTokenByIdWithCache 供计费等只持有令牌 id 的路径使用，优先读取缓存
+func GetTokenByIdWithCache(id int) (*Token, error) {
+	if common.RedisEnabled {
+		if token, err := cacheGetTokenById(id); err == nil {
+			return token, nil
+		}
+	}
+	return GetTokenById(id)
+}
+
 func GetTokenByKey(key string, fromDB bool) (token *Token, err error) {
 	defer func() {
 		// Update Redis cache asynchronously on successful DB read
@@ -260,14 +359,52 @@ func GetTokenByKey(key string, fromDB bool) (token *Token, err error) {
 		// Don't return error - fall through to DB
 	}
 	fromDB = true
+	return getTokenByKeyFromDB(key)
+}
+
+// getTokenByKeyFromDB 按公开前缀查出候选令牌后校验哈希；查不到时按历史明文 key 查询，命中后迁移为前缀与哈希
+func getTokenByKeyFromDB(key string) (*Token, error) {
+	if key == "" {
+		return nil, gorm.ErrRecordNotFound
+	}
+	var candidates []*Token
+	err := DB.Where("key_prefix = ? AND key_hash <> ''", tokenKeyPrefix(key)).Find(&candidates).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, candidate := range candidates {
+		if candidate.MatchKey(key) {
+			candidate.Key = key
+			return candidate, nil
+		}
+	}
+
+	var token Token
 	err = DB.Where(commonKeyCol+" = ?", key).First(&token).Error
-	return token, err
+	if err != nil {
+		return nil, err
+	}
+	if err := token.SetKey(key); err != nil {
+		return nil, err
+	}
+	err = DB.Model(&Token{}).Where("id = ?", token.Id).Updates(map[string]interface{}{
+		"key":        "",
+		"key_prefix": token.KeyPrefix,
+		"key_hash":   token.KeyHash,
+	}).Error
+	if err != nil {
+		// 迁移失败不影响本次使用，下次请求会再次尝试
+		common.SysLog(fmt.Sprintf("failed to migrate token %d key: %s", token.Id, err.Error()))
+	}
+	return &token, nil
 }
 
+// Insert 只保存前缀与哈希，调用方需先调用 SetKey；token.Key 保留在内存中用于本次返回给用户
 func (token *Token) Insert() error {
-	var err error
-	err = DB.Create(token).Error
-	return err
+	if token.KeyHash == "" {
+		return errors.New("token key hash is empty")
+	}
+	return DB.Omit("key").Create(token).Error
 }
 
 // Update Make sure your token's fields is completed, because this will update non-zero values
@@ -283,7 +420,9 @@ func (token *Token) Update() (err error) {
 		}
 	}()
 	err = DB.Model(token).Select("name", "status", "expired_time", "remain_quota", "unlimited_quota",
-		"model_limits_enabled", "model_limits", "allow_ips", "group", "cross_group_retry").Updates(token).Error
+		"model_limits_enabled", "model_limits", "allow_ips", "group", "cross_group_retry",
+		"rpm_limit", "tpm_limit", "concurrency_limit", "content_log_enabled", "model_fallbacks",
+		"budget_period", "budget_quota", "budget_carry_over_cap", "next_budget_reset_time").Updates(token).Error
 	return err
 }
 
@@ -306,7 +445,7 @@ func (token *Token) Delete() (err error) {
 	defer func() {
 		if shouldUpdateRedis(true, err) {
 			gopool.Go(func() {
-				err := cacheDeleteToken(token.Key)
+				err := cacheDeleteToken(token.Id)
 				if err != nil {
 					common.SysLog("failed to delete token cache: " + err.Error())
 				}
@@ -337,6 +476,18 @@ func (token *Token) GetModelLimitsMap() map[string]bool {
 	return limitsMap
 }
 
+// GetModelFallbacksMap 解析令牌上的模型降级链，未配置或格式错误时返回 nil
+func (token *Token) GetModelFallbacksMap() map[string][]string {
+	if token.ModelFallbacks == "" {
+		return nil
+	}
+	fallbacks := make(map[string][]string)
+	if err := common.UnmarshalJsonStr(token.ModelFallbacks, &fallbacks); err != nil {
+		return nil
+	}
+	return fallbacks
+}
+
 func DisableModelLimits(tokenId int) error {
 	token, err := GetTokenById(tokenId)
 	if err != nil {
@@ -360,13 +511,13 @@ func DeleteTokenById(id int, userId int) (err error) {
 	return token.Delete()
 }
 
-func IncreaseTokenQuota(tokenId int, key string, quota int) (err error) {
+func IncreaseTokenQuota(tokenId int, quota int) (err error) {
 	if quota < 0 {
 		return errors.New("quota 不能为负数！")
 	}
 	if common.RedisEnabled {
 		gopool.Go(func() {
-			err := cacheIncrTokenQuota(key, int64(quota))
+			err := cacheIncrTokenQuota(tokenId, int64(quota))
 			if err != nil {
 				common.SysLog("failed to increase token quota: " + err.Error())
 			}
@@ -391,13 +542,13 @@ func increaseTokenQuota(id int, quota int) (err error) {
 	return err
 }
 
-func DecreaseTokenQuota(id int, key string, quota int) (err error) {
+func DecreaseTokenQuota(id int, quota int) (err error) {
 	if quota < 0 {
 		return errors.New("quota 不能为负数！")
 	}
 	if common.RedisEnabled {
 		gopool.Go(func() {
-			err := cacheDecrTokenQuota(key, int64(quota))
+			err := cacheDecrTokenQuota(id, int64(quota))
 			if err != nil {
 				common.SysLog("failed to decrease token quota: " + err.Error())
 			}
@@ -429,7 +580,6 @@ func CountUserTokens(userId int) (int64, error) {
 	return total, err
 }
 
-
 // BatchDeleteTokens 删除指定用户的一组令牌，返回成功删除数量
 func BatchDeleteTokens(ids []int, userId int) (int, error) {
 	if len(ids) == 0 {
@@ -456,7 +606,7 @@ func BatchDeleteTokens(ids []int, userId int) (int, error) {
 	if common.RedisEnabled {
 		gopool.Go(func() {
 			for _, t := range tokens {
-				_ = cacheDeleteToken(t.Key)
+				_ = cacheDeleteToken(t.Id)
 			}
 		})
 	}
@@ -0,0 +1,90 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+
+	"gorm.io/gorm"
+)
+
+// TokenBudgetExhaustedError 令牌本周期预算已用尽，与普通的额度用尽区分开
+type TokenBudgetExhaustedError struct {
+	NextResetTime int64
+}
+
+func (e *TokenBudgetExhaustedError) Error() string {
+	if e.NextResetTime <= 0 {
+		return "该令牌本周期预算已用尽"
+	}
+	return fmt.Sprintf("该令牌本周期预算已用尽，将于 %s 重置", time.Unix(e.NextResetTime, 0).Format("2006-01-02 15:04:05"))
+}
+
+// NormalizeTokenBudgetPeriod 令牌预算只支持按天、周、月重置
+func NormalizeTokenBudgetPeriod(period string) string {
+	switch strings.TrimSpace(period) {
+	case SubscriptionResetDaily, SubscriptionResetWeekly, SubscriptionResetMonthly:
+		return strings.TrimSpace(period)
+	default:
+		return SubscriptionResetNever
+	}
+}
+
+func (token *Token) HasBudget() bool {
+	return NormalizeTokenBudgetPeriod(token.BudgetPeriod) != SubscriptionResetNever && token.BudgetQuota > 0
+}
+
+// CalcTokenBudgetNextReset 计算下一次预算重置时间，与订阅的重置周期对齐（次日/下周一/下月一日零点）
+func CalcTokenBudgetNextReset(period string, base time.Time) int64 {
+	return calcNextResetTime(base, &SubscriptionPlan{QuotaResetPeriod: NormalizeTokenBudgetPeriod(period)}, 0)
+}
+
+// ResetDueTokenBudgets 重置到期的令牌周期预算，返回本次重置的数量
+func ResetDueTokenBudgets(limit int) (int, error) {
+	if limit <= 0 {
+		limit = 200
+	}
+	now := common.GetTimestamp()
+	var tokens []*Token
+	if err := DB.Where("budget_period IN ? AND next_budget_reset_time > 0 AND next_budget_reset_time <= ?",
+		[]string{SubscriptionResetDaily, SubscriptionResetWeekly, SubscriptionResetMonthly}, now).
+		Order("next_budget_reset_time asc").
+		Limit(limit).
+		Find(&tokens).Error; err != nil {
+		return 0, err
+	}
+	resetCount := 0
+	for _, token := range tokens {
+		next := CalcTokenBudgetNextReset(token.BudgetPeriod, time.Unix(now, 0))
+		updates := map[string]interface{}{
+			"next_budget_reset_time": next,
+			"last_budget_reset_time": now,
+		}
+		if token.BudgetQuota > 0 {
+			// 在 SQL 中计算结余，避免覆盖读取之后产生的消耗
+			updates["remain_quota"] = gorm.Expr("CASE WHEN remain_quota <= 0 THEN ? WHEN remain_quota >= ? THEN ? ELSE remain_quota + ? END",
+				token.BudgetQuota, token.BudgetCarryOverCap, token.BudgetQuota+token.BudgetCarryOverCap, token.BudgetQuota)
+			if token.Status == common.TokenStatusExhausted {
+				updates["status"] = common.TokenStatusEnabled
+			}
+		}
+		result := DB.Model(&Token{}).
+			Where("id = ? AND next_budget_reset_time = ?", token.Id, token.NextBudgetResetTime).
+			Updates(updates)
+		if result.Error != nil {
+			return resetCount, result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		resetCount++
+		if common.RedisEnabled {
+			if err := cacheDeleteToken(token.Id); err != nil {
+				common.SysLog("failed to delete token cache: " + err.Error())
+			}
+		}
+	}
+	return resetCount, nil
+}
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalcTokenBudgetNextReset(t *testing.T) {
+	base := time.Date(2026, 3, 18, 15, 30, 0, 0, time.Local) // 周三
+
+	require.Equal(t, time.Date(2026, 3, 19, 0, 0, 0, 0, time.Local).Unix(), CalcTokenBudgetNextReset(SubscriptionResetDaily, base))
+	require.Equal(t, time.Date(2026, 3, 23, 0, 0, 0, 0, time.Local).Unix(), CalcTokenBudgetNextReset(SubscriptionResetWeekly, base))
+	require.Equal(t, time.Date(2026, 4, 1, 0, 0, 0, 0, time.Local).Unix(), CalcTokenBudgetNextReset(SubscriptionResetMonthly, base))
+	require.Zero(t, CalcTokenBudgetNextReset("yearly", base))
+}
+
+func TestResetDueTokenBudgets_CarryOver(t *testing.T) {
+	truncateTables(t)
+	past := common.GetTimestamp() - 10
+
+	tokens := []*Token{
+		// 剩余 300，结转上限 500：300 + 1000
+		{Key: "budget-partial", Name: "partial", RemainQuota: 300, BudgetQuota: 1000, BudgetCarryOverCap: 500},
+		// 剩余超过结转上限：500 + 1000
+		{Key: "budget-capped", Name: "capped", RemainQuota: 800, BudgetQuota: 1000, BudgetCarryOverCap: 500},
+		// 已用尽的令牌重置后重新启用
+		{Key: "budget-exhausted", Name: "exhausted", RemainQuota: 0, BudgetQuota: 1000, Status: common.TokenStatusExhausted},
+	}
+	for _, token := range tokens {
+		token.UserId = 1
+		token.BudgetPeriod = SubscriptionResetDaily
+		token.NextBudgetResetTime = past
+		if token.Status == 0 {
+			token.Status = common.TokenStatusEnabled
+		}
+		require.NoError(t, DB.Create(token).Error)
+	}
+
+	n, err := ResetDueTokenBudgets(10)
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+
+	expected := map[string]int{"budget-partial": 1300, "budget-capped": 1500, "budget-exhausted": 1000}
+	for key, quota := range expected {
+		var token Token
+		require.NoError(t, DB.Where("`key` = ?", key).First(&token).Error)
+		require.Equal(t, quota, token.RemainQuota, key)
+		require.Equal(t, common.TokenStatusEnabled, token.Status, key)
+		require.Greater(t, token.NextBudgetResetTime, common.GetTimestamp(), key)
+	}
+
+	// 未到期的令牌不会被再次重置
+	n, err = ResetDueTokenBudgets(10)
+	require.NoError(t, err)
+	require.Zero(t, n)
+}
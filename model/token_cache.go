@@ -2,64 +2,98 @@ package model
 
 import (
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/QuantumNous/new-api/common"
 	"github.com/QuantumNous/new-api/constant"
 )
 
+// 令牌对象按 id 缓存在 token:<id>，另以 token_key:<HMAC(key)> 保存 key 到 id 的映射。
+// 额度变更、删除与预算重置等只知道 id 的路径可以直接更新缓存，缓存中不保存明文 key 与哈希。
+
+func tokenCacheKey(id int) string {
+	return fmt.Sprintf("token:%d", id)
+}
+
+func tokenKeyCacheKey(key string) string {
+	return fmt.Sprintf("token_key:%s", common.GenerateHMAC(key))
+}
+
 func cacheSetToken(token Token) error {
-	key := common.GenerateHMAC(token.Key)
+	expiration := time.Duration(common.RedisKeyCacheSeconds()) * time.Second
+	if token.Key != "" {
+		err := common.RedisSet(tokenKeyCacheKey(token.Key), strconv.Itoa(token.Id), expiration)
+		if err != nil {
+			return err
+		}
+	}
 	token.Clean()
-	err := common.RedisHSetObj(fmt.Sprintf("token:%s", key), &token, time.Duration(common.RedisKeyCacheSeconds())*time.Second)
+	err := common.RedisHSetObj(tokenCacheKey(token.Id), &token, expiration)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func cacheDeleteToken(key string) error {
-	key = common.GenerateHMAC(key)
-	err := common.RedisDelKey(fmt.Sprintf("token:%s", key))
+func cacheDeleteToken(id int) error {
+	err := common.RedisDelKey(tokenCacheKey(id))
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func cacheIncrTokenQuota(key string, increment int64) error {
-	key = common.GenerateHMAC(key)
-	err := common.RedisHIncrBy(fmt.Sprintf("token:%s", key), constant.TokenFiledRemainQuota, increment)
+func cacheIncrTokenQuota(id int, increment int64) error {
+	err := common.RedisHIncrBy(tokenCacheKey(id), constant.TokenFiledRemainQuota, increment)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func cacheDecrTokenQuota(key string, decrement int64) error {
-	return cacheIncrTokenQuota(key, -decrement)
+func cacheDecrTokenQuota(id int, decrement int64) error {
+	return cacheIncrTokenQuota(id, -decrement)
 }
 
-func cacheSetTokenField(key string, field string, value string) error {
-	key = common.GenerateHMAC(key)
-	err := common.RedisHSetField(fmt.Sprintf("token:%s", key), field, value)
+func cacheSetTokenField(id int, field string, value string) error {
+	err := common.RedisHSetField(tokenCacheKey(id), field, value)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-// CacheGetTokenByKey 从缓存中获取 token，如果缓存中不存在，则从数据库中获取
+// cacheGetTokenByKey 从缓存中获取 token，缓存不存在时由调用方回退到数据库
 func cacheGetTokenByKey(key string) (*Token, error) {
-	hmacKey := common.GenerateHMAC(key)
 	if !common.RedisEnabled {
 		return nil, fmt.Errorf("redis is not enabled")
 	}
-	var token Token
-	err := common.RedisHGetObj(fmt.Sprintf("token:%s", hmacKey), &token)
+	idStr, err := common.RedisGet(tokenKeyCacheKey(key))
+	if err != nil {
+		return nil, err
+	}
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return nil, err
+	}
+	token, err := cacheGetTokenById(id)
 	if err != nil {
 		return nil, err
 	}
 	token.Key = key
+	return token, nil
+}
+
+func cacheGetTokenById(id int) (*Token, error) {
+	if !common.RedisEnabled {
+		return nil, fmt.Errorf("redis is not enabled")
+	}
+	var token Token
+	err := common.RedisHGetObj(tokenCacheKey(id), &token)
+	if err != nil {
+		return nil, err
+	}
+	token.Id = id
 	return &token, nil
 }
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenInsert_StoresPrefixAndHashOnly(t *testing.T) {
+	truncateTables(t)
+	key := "abcdefgh" + "0123456789012345678901234567890123456789"
+
+	token := &Token{UserId: 1, Name: "new", Status: common.TokenStatusEnabled, ExpiredTime: -1, UnlimitedQuota: true}
+	require.NoError(t, token.SetKey(key))
+	require.NoError(t, token.Insert())
+	require.Equal(t, key, token.Key)
+
+	var stored Token
+	require.NoError(t, DB.First(&stored, token.Id).Error)
+	require.Empty(t, stored.Key)
+	require.Equal(t, "abcdefgh", stored.KeyPrefix)
+	require.NotContains(t, stored.KeyHash, key)
+
+	found, err := GetTokenByKey(key, true)
+	require.NoError(t, err)
+	require.Equal(t, token.Id, found.Id)
+	require.Equal(t, key, found.Key)
+
+	// 前缀相同但密钥不同
+	_, err = GetTokenByKey("abcdefgh"+"x123456789012345678901234567890123456789", true)
+	require.Error(t, err)
+}
+
+func TestGetTokenByKey_MigratesLegacyKey(t *testing.T) {
+	truncateTables(t)
+	key := "legacy01" + "0123456789012345678901234567890123456789"
+	legacy := &Token{UserId: 1, Name: "legacy", Key: key, Status: common.TokenStatusEnabled, ExpiredTime: -1, UnlimitedQuota: true}
+	require.NoError(t, DB.Create(legacy).Error)
+
+	found, err := GetTokenByKey(key, true)
+	require.NoError(t, err)
+	require.Equal(t, legacy.Id, found.Id)
+	require.Equal(t, key, found.Key)
+
+	var stored Token
+	require.NoError(t, DB.First(&stored, legacy.Id).Error)
+	require.Empty(t, stored.Key)
+	require.Equal(t, "legacy01", stored.KeyPrefix)
+	require.True(t, stored.MatchKey(key))
+
+	// 迁移后按哈希查找
+	found, err = GetTokenByKey(key, true)
+	require.NoError(t, err)
+	require.Equal(t, legacy.Id, found.Id)
+	require.Equal(t, "sk-legacy01***", found.MaskedKey())
+}
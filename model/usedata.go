@@ -6,6 +6,10 @@ import (
 	"time"
 
 	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
+	"github.com/QuantumNous/new-api/dto"
+
+	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
@@ -26,6 +30,9 @@ func UpdateQuotaData() {
 		if common.DataExportEnabled {
 			common.SysLog("正在更新数据看板数据...")
 			SaveQuotaDataCache()
+		} else {
+			// 数据看板关闭时仍需落库利润统计
+			saveProfitDataCache()
 		}
 		time.Sleep(time.Duration(common.DataExportInterval) * time.Minute)
 	}
@@ -87,6 +94,7 @@ func SaveQuotaDataCache() {
 	}
 	CacheQuotaData = make(map[string]*QuotaData)
 	common.SysLog(fmt.Sprintf("保存数据看板数据成功，共保存%d条数据", size))
+	saveProfitDataCache()
 }
 
 func increaseQuotaData(userId int, username string, modelName string, count int, quota int, createdAt int64, tokenUsed int) {
@@ -126,3 +134,151 @@ func GetAllQuotaDates(startTime int64, endTime int64, username string) (quotaDat
 	err = DB.Table("quota_data").Select("model_name, sum(count) as count, sum(quota) as quota, sum(token_used) as token_used, created_at").Where("created_at >= ? and created_at <= ?", startTime, endTime).Group("model_name, created_at").Find(&quotaDatas).Error
 	return quotaDatas, err
 }
+
+// ProfitData 按渠道、模型、天汇总的计费额度（收入）与预估上游成本
+type ProfitData struct {
+	Id        int    `json:"id"`
+	ChannelId int    `json:"channel_id" gorm:"index:idx_pd_channel_model_day,priority:1"`
+	ModelName string `json:"model_name" gorm:"index:idx_pd_channel_model_day,priority:2;size:64;default:''"`
+	CreatedAt int64  `json:"created_at" gorm:"bigint;index:idx_pd_channel_model_day,priority:3;index"`
+	Count     int    `json:"count" gorm:"default:0"`
+	Quota     int    `json:"quota" gorm:"type:bigint;default:0"`
+	Cost      int    `json:"cost" gorm:"type:bigint;default:0"`
+}
+
+var CacheProfitData = make(map[string]*ProfitData)
+var CacheProfitDataLock = sync.Mutex{}
+
+// estimateUpstreamCost 按渠道成本倍率估算上游成本：去掉分组倍率后的原价乘以成本倍率，响应缓存命中时没有上游成本
+func estimateUpstreamCost(c *gin.Context, params RecordConsumeLogParams) int {
+	if params.Quota <= 0 || params.ChannelId == 0 {
+		return 0
+	}
+	if hit, _ := params.Other["response_cache_hit"].(bool); hit {
+		return 0
+	}
+	groupRatio := 1.0
+	if ratio, ok := params.Other["group_ratio"].(float64); ok {
+		groupRatio = ratio
+	}
+	if groupRatio <= 0 {
+		return 0
+	}
+	return int(float64(params.Quota) / groupRatio * getChannelCostRatio(c, params.ChannelId))
+}
+
+func getChannelCostRatio(c *gin.Context, channelId int) float64 {
+	settings, ok := common.GetContextKeyType[dto.ChannelOtherSettings](c, constant.ContextKeyChannelOtherSetting)
+	if !ok || common.GetContextKeyInt(c, constant.ContextKeyChannelId) != channelId {
+		channel, err := CacheGetChannel(channelId)
+		if err != nil {
+			return 1
+		}
+		settings = channel.GetOtherSettings()
+	}
+	if settings.CostRatio == nil || *settings.CostRatio < 0 {
+		return 1
+	}
+	return *settings.CostRatio
+}
+
+func LogProfitData(channelId int, modelName string, quota int, cost int, createdAt int64) {
+	// 精确到天
+	createdAt = createdAt - (createdAt % 86400)
+
+	CacheProfitDataLock.Lock()
+	defer CacheProfitDataLock.Unlock()
+	key := fmt.Sprintf("%d-%s-%d", channelId, modelName, createdAt)
+	profitData, ok := CacheProfitData[key]
+	if !ok {
+		profitData = &ProfitData{
+			ChannelId: channelId,
+			ModelName: modelName,
+			CreatedAt: createdAt,
+		}
+		CacheProfitData[key] = profitData
+	}
+	profitData.Count += 1
+	profitData.Quota += quota
+	profitData.Cost += cost
+}
+
+func saveProfitDataCache() {
+	CacheProfitDataLock.Lock()
+	defer CacheProfitDataLock.Unlock()
+	for _, profitData := range CacheProfitData {
+		result := DB.Model(&ProfitData{}).Where("channel_id = ? and model_name = ? and created_at = ?",
+			profitData.ChannelId, profitData.ModelName, profitData.CreatedAt).Updates(map[string]interface{}{
+			"count": gorm.Expr("count + ?", profitData.Count),
+			"quota": gorm.Expr("quota + ?", profitData.Quota),
+			"cost":  gorm.Expr("cost + ?", profitData.Cost),
+		})
+		if result.Error != nil {
+			common.SysLog(fmt.Sprintf("saveProfitDataCache error: %s", result.Error))
+			continue
+		}
+		if result.RowsAffected == 0 {
+			DB.Create(profitData)
+		}
+	}
+	CacheProfitData = make(map[string]*ProfitData)
+}
+
+// ProfitSummary 收入、成本与毛利汇总，Margin 为毛利率
+type ProfitSummary struct {
+	ChannelId   int     `json:"channel_id,omitempty"`
+	ChannelName string  `json:"channel_name,omitempty"`
+	ModelName   string  `json:"model_name,omitempty"`
+	CreatedAt   int64   `json:"created_at,omitempty"`
+	Count       int     `json:"count"`
+	Quota       int     `json:"quota"`
+	Cost        int     `json:"cost"`
+	Profit      int     `json:"profit" gorm:"-"`
+	Margin      float64 `json:"margin" gorm:"-"`
+}
+
+// GetProfitData 按 channel / model / day 维度汇总收入、成本与毛利
+func GetProfitData(startTime int64, endTime int64, groupBy string) ([]*ProfitSummary, error) {
+	var column string
+	switch groupBy {
+	case "channel":
+		column = "channel_id"
+	case "model":
+		column = "model_name"
+	case "day":
+		column = "created_at"
+	default:
+		return nil, fmt.Errorf("invalid group_by: %s", groupBy)
+	}
+	var summaries []*ProfitSummary
+	err := DB.Model(&ProfitData{}).
+		Select(column+", sum(count) as count, sum(quota) as quota, sum(cost) as cost").
+		Where("created_at >= ? and created_at <= ?", startTime, endTime).
+		Group(column).Order(column).Find(&summaries).Error
+	if err != nil {
+		return nil, err
+	}
+	channelIds := make([]int, 0)
+	for _, summary := range summaries {
+		summary.Profit = summary.Quota - summary.Cost
+		if summary.Quota > 0 {
+			summary.Margin = float64(summary.Profit) / float64(summary.Quota)
+		}
+		if groupBy == "channel" {
+			channelIds = append(channelIds, summary.ChannelId)
+		}
+	}
+	if len(channelIds) > 0 {
+		var channels []Channel
+		if err = DB.Select("id, name").Where("id in ?", channelIds).Find(&channels).Error; err == nil {
+			names := make(map[int]string, len(channels))
+			for _, channel := range channels {
+				names[channel.Id] = channel.Name
+			}
+			for _, summary := range summaries {
+				summary.ChannelName = names[summary.ChannelId]
+			}
+		}
+	}
+	return summaries, nil
+}
@@ -0,0 +1,67 @@
+package model
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
+	"github.com/QuantumNous/new-api/dto"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateUpstreamCost(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	common.SetContextKey(c, constant.ContextKeyChannelId, 7)
+	common.SetContextKey(c, constant.ContextKeyChannelOtherSetting, dto.ChannelOtherSettings{
+		CostRatio: common.GetPointer(0.6),
+	})
+
+	params := RecordConsumeLogParams{
+		ChannelId: 7,
+		Quota:     2000,
+		Other:     map[string]interface{}{"group_ratio": 2.0},
+	}
+	// 原价 1000，渠道成本为原价的 60%
+	require.Equal(t, 600, estimateUpstreamCost(c, params))
+
+	params.Other["response_cache_hit"] = true
+	require.Equal(t, 0, estimateUpstreamCost(c, params))
+}
+
+func TestProfitDataAggregation(t *testing.T) {
+	require.NoError(t, DB.AutoMigrate(&ProfitData{}))
+	t.Cleanup(func() {
+		DB.Where("channel_id = ?", 11).Delete(&ProfitData{})
+		DB.Delete(&Channel{}, 11)
+	})
+	require.NoError(t, DB.Create(&Channel{Id: 11, Name: "reseller"}).Error)
+
+	day := int64(86400 * 100)
+	LogProfitData(11, "gpt-4o", 1000, 600, day+10)
+	saveProfitDataCache()
+	LogProfitData(11, "gpt-4o", 1000, 600, day+20)
+	LogProfitData(11, "claude-sonnet-4-5", 2000, 2200, day+86400)
+	saveProfitDataCache()
+
+	byChannel, err := GetProfitData(day, day+2*86400, "channel")
+	require.NoError(t, err)
+	require.Len(t, byChannel, 1)
+	require.Equal(t, "reseller", byChannel[0].ChannelName)
+	require.Equal(t, 3, byChannel[0].Count)
+	require.Equal(t, 4000, byChannel[0].Quota)
+	require.Equal(t, 3400, byChannel[0].Cost)
+	require.Equal(t, 600, byChannel[0].Profit)
+	require.InDelta(t, 0.15, byChannel[0].Margin, 1e-9)
+
+	byDay, err := GetProfitData(day, day+2*86400, "day")
+	require.NoError(t, err)
+	require.Len(t, byDay, 2)
+	require.Equal(t, day, byDay[0].CreatedAt)
+	require.Equal(t, 800, byDay[0].Profit)
+	require.Equal(t, -200, byDay[1].Profit)
+
+	_, err = GetProfitData(day, day, "user")
+	require.Error(t, err)
+}
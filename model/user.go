@@ -54,6 +54,7 @@ type User struct {
 	Setting          string         `json:"setting" gorm:"type:text;column:setting"`
 	Remark           string         `json:"remark,omitempty" gorm:"type:varchar(255)" validate:"max=255"`
 	StripeCustomer   string         `json:"stripe_customer" gorm:"type:varchar(64);column:stripe_customer;index"`
+	AdminRoleId      int            `json:"admin_role_id" gorm:"type:int;default:0;index"` // 管理角色，0 表示全部管理权限
 }
 
 func (user *User) ToBaseUser() *UserBase {
@@ -1035,6 +1036,14 @@ func updateUserRequestCount(id int, count int) {
 	}
 }
 
+func GetUserIdByUsername(username string) (id int, err error) {
+	err = DB.Model(&User{}).Where("username = ?", username).Select("id").Find(&id).Error
+	if err == nil && id == 0 {
+		err = errors.New("用户不存在")
+	}
+	return id, err
+}
+
 // GetUsernameById gets username from Redis first, falls back to DB if needed
 func GetUsernameById(id int, fromDB bool) (username string, err error) {
 	defer func() {
@@ -2,6 +2,8 @@ package model
 
 import (
 	"fmt"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/QuantumNous/new-api/common"
@@ -57,6 +59,55 @@ func invalidateUserCache(userId int) error {
 	return common.RedisDelKey(getUserCacheKey(userId))
 }
 
+// 管理角色单独缓存，不放入用户哈希：旧的哈希缓存缺少该字段时会被读成 0（全部管理权限）
+type userAdminRoleEntry struct {
+	roleId   int
+	loadedAt int64
+}
+
+var userAdminRoleMemCache sync.Map // userId -> userAdminRoleEntry，未启用 Redis 时使用
+
+func getUserAdminRoleCacheKey(userId int) string {
+	return fmt.Sprintf("user_admin_role:%d", userId)
+}
+
+func getUserAdminRoleCache(userId int) (int, bool) {
+	if common.RedisEnabled {
+		value, err := common.RedisGet(getUserAdminRoleCacheKey(userId))
+		if err != nil {
+			return 0, false
+		}
+		roleId, err := strconv.Atoi(value)
+		return roleId, err == nil
+	}
+	value, ok := userAdminRoleMemCache.Load(userId)
+	if !ok {
+		return 0, false
+	}
+	entry := value.(userAdminRoleEntry)
+	if time.Now().Unix()-entry.loadedAt >= adminRoleCacheTTL {
+		return 0, false
+	}
+	return entry.roleId, true
+}
+
+func updateUserAdminRoleCache(userId int, roleId int) {
+	if common.RedisEnabled {
+		if err := common.RedisSet(getUserAdminRoleCacheKey(userId), strconv.Itoa(roleId), time.Duration(common.RedisKeyCacheSeconds())*time.Second); err != nil {
+			common.SysLog("failed to update user admin role cache: " + err.Error())
+		}
+		return
+	}
+	userAdminRoleMemCache.Store(userId, userAdminRoleEntry{roleId: roleId, loadedAt: time.Now().Unix()})
+}
+
+func invalidateUserAdminRoleCache(userId int) {
+	userAdminRoleMemCache.Delete(userId)
+	if common.RedisEnabled {
+		_ = common.RedisDelKey(getUserAdminRoleCacheKey(userId))
+	}
+}
+
 // updateUserCache updates all user cache fields using hash
 func updateUserCache(user User) error {
 	if !common.RedisEnabled {
@@ -0,0 +1,160 @@
+// Package metrics 提供 Prometheus 指标的注册与采集，/metrics 接口使用独立的 METRICS_TOKEN 鉴权。
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "newapi"
+
+var (
+	registry = prometheus.NewRegistry()
+
+	relayRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "relay_requests_total",
+		Help:      "Relay attempts to upstream channels by model, channel, group and HTTP status.",
+	}, []string{"model", "channel", "group", "status"})
+
+	relayDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "relay_request_duration_seconds",
+		Help:      "Relay attempt latency by model, channel, group and HTTP status.",
+		Buckets:   []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20, 30, 60, 120, 300},
+	}, []string{"model", "channel", "group", "status"})
+
+	relayFirstToken = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "relay_first_token_seconds",
+		Help:      "Time to first token of successful streaming relay attempts.",
+		Buckets:   []float64{0.1, 0.25, 0.5, 1, 2, 3, 5, 10, 20, 30, 60},
+	}, []string{"model", "channel", "group"})
+
+	relayRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "relay_retries_total",
+		Help:      "Relay retries after a failed attempt.",
+	}, []string{"model", "group"})
+
+	promptTokens = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "prompt_tokens_total",
+		Help:      "Billed prompt tokens.",
+	}, []string{"model", "channel", "group"})
+
+	completionTokens = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "completion_tokens_total",
+		Help:      "Billed completion tokens.",
+	}, []string{"model", "channel", "group"})
+
+	quotaConsumed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "quota_consumed_total",
+		Help:      "Quota consumed by billed requests, in quota units.",
+	}, []string{"model", "channel", "group"})
+
+	channelAutoDisabled = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "channel_auto_disabled_total",
+		Help:      "Channels (or multi-key channel keys) disabled automatically after upstream errors.",
+	}, []string{"channel"})
+
+	affinityLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "channel_affinity_lookups_total",
+		Help:      "Channel affinity cache lookups by rule and result (hit/miss).",
+	}, []string{"rule", "result"})
+
+	taskBacklog = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "task_polling_backlog",
+		Help:      "Unfinished async tasks waiting for polling, by platform.",
+	}, []string{"platform"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		relayRequests,
+		relayDuration,
+		relayFirstToken,
+		relayRetries,
+		promptTokens,
+		completionTokens,
+		quotaConsumed,
+		channelAutoDisabled,
+		affinityLookups,
+		taskBacklog,
+	)
+}
+
+// Register 注册自定义 Collector，用于在抓取时才计算的指标
+func Register(collector prometheus.Collector) error {
+	return registry.Register(collector)
+}
+
+// Handler 返回 Prometheus 文本格式的指标输出
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+func channelLabel(channelId int) string {
+	return strconv.Itoa(channelId)
+}
+
+// ObserveRelay 记录一次上游请求尝试；firstToken 为 0 表示非流式或未收到首包
+func ObserveRelay(model string, channelId int, group string, status int, duration time.Duration, firstToken time.Duration) {
+	channel := channelLabel(channelId)
+	statusLabel := strconv.Itoa(status)
+	relayRequests.WithLabelValues(model, channel, group, statusLabel).Inc()
+	relayDuration.WithLabelValues(model, channel, group, statusLabel).Observe(duration.Seconds())
+	if firstToken > 0 {
+		relayFirstToken.WithLabelValues(model, channel, group).Observe(firstToken.Seconds())
+	}
+}
+
+func IncRelayRetry(model string, group string) {
+	relayRetries.WithLabelValues(model, group).Inc()
+}
+
+// ObserveConsume 记录一次计费的 token 与额度消耗
+func ObserveConsume(model string, channelId int, group string, prompt int, completion int, quota int) {
+	channel := channelLabel(channelId)
+	if prompt > 0 {
+		promptTokens.WithLabelValues(model, channel, group).Add(float64(prompt))
+	}
+	if completion > 0 {
+		completionTokens.WithLabelValues(model, channel, group).Add(float64(completion))
+	}
+	if quota > 0 {
+		quotaConsumed.WithLabelValues(model, channel, group).Add(float64(quota))
+	}
+}
+
+func IncChannelAutoDisabled(channelId int) {
+	channelAutoDisabled.WithLabelValues(channelLabel(channelId)).Inc()
+}
+
+func ObserveChannelAffinityLookup(rule string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	affinityLookups.WithLabelValues(rule, result).Inc()
+}
+
+// SetTaskBacklog 用最新的统计结果覆盖各平台的待轮询任务数
+func SetTaskBacklog(backlog map[string]int64) {
+	taskBacklog.Reset()
+	for platform, count := range backlog {
+		taskBacklog.WithLabelValues(platform).Set(float64(count))
+	}
+}
@@ -0,0 +1,29 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_ExportsRelayMetrics(t *testing.T) {
+	ObserveRelay("gpt-4o", 3, "default", http.StatusOK, 1200*time.Millisecond, 300*time.Millisecond)
+	ObserveConsume("gpt-4o", 3, "default", 10, 20, 500)
+	ObserveChannelAffinityLookup("codex", true)
+	SetTaskBacklog(map[string]int64{"suno": 4})
+
+	recorder := httptest.NewRecorder()
+	Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	body := recorder.Body.String()
+	require.Contains(t, body, `newapi_relay_requests_total{channel="3",group="default",model="gpt-4o",status="200"} 1`)
+	require.Contains(t, body, `newapi_relay_first_token_seconds_count{channel="3",group="default",model="gpt-4o"} 1`)
+	require.Contains(t, body, `newapi_completion_tokens_total{channel="3",group="default",model="gpt-4o"} 20`)
+	require.Contains(t, body, `newapi_quota_consumed_total{channel="3",group="default",model="gpt-4o"} 500`)
+	require.Contains(t, body, `newapi_channel_affinity_lookups_total{result="hit",rule="codex"} 1`)
+	require.Contains(t, body, `newapi_task_polling_backlog{platform="suno"} 4`)
+}
@@ -0,0 +1,185 @@
+// Package tracing 封装 OpenTelemetry 链路追踪。
+// 设置 OTEL_EXPORTER_OTLP_ENDPOINT（或 OTEL_EXPORTER_OTLP_TRACES_ENDPOINT）后通过 OTLP/HTTP 导出，
+// 未设置时不导出，但仍会透传请求中的 traceparent。
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/QuantumNous/new-api/types"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	tracerName = "github.com/QuantumNous/new-api"
+
+	// ginKeySpanContext 保存当前活跃 span 所在的 context，子 span 以它为父节点
+	ginKeySpanContext = "tracing_span_context"
+)
+
+var (
+	enabled  bool
+	provider *sdktrace.TracerProvider
+	initOnce sync.Once
+)
+
+// Init 初始化全局 TracerProvider 与 W3C trace context 传播器
+func Init(version string) error {
+	var initErr error
+	initOnce.Do(func() {
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+		if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" && os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") == "" {
+			return
+		}
+		exporter, err := otlptracehttp.New(context.Background())
+		if err != nil {
+			initErr = err
+			return
+		}
+		// OTEL_SERVICE_NAME / OTEL_RESOURCE_ATTRIBUTES 可以覆盖默认的服务名
+		res, err := resource.New(context.Background(),
+			resource.WithTelemetrySDK(),
+			resource.WithAttributes(semconv.ServiceName("new-api"), semconv.ServiceVersion(version)),
+			resource.WithFromEnv(),
+		)
+		if err != nil {
+			initErr = err
+			return
+		}
+		// 采样策略由 OTEL_TRACES_SAMPLER / OTEL_TRACES_SAMPLER_ARG 控制，默认全部采样
+		provider = sdktrace.NewTracerProvider(
+			sdktrace.WithBatcher(exporter),
+			sdktrace.WithResource(res),
+		)
+		otel.SetTracerProvider(provider)
+		enabled = true
+	})
+	return initErr
+}
+
+// Enabled 是否配置了导出端点
+func Enabled() bool {
+	return enabled
+}
+
+// Shutdown 导出剩余的 span 并关闭 TracerProvider
+func Shutdown(ctx context.Context) error {
+	if provider == nil {
+		return nil
+	}
+	return provider.Shutdown(ctx)
+}
+
+func tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// Extract 从请求头中解析上游传入的 traceparent
+func Extract(ctx context.Context, header http.Header) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(header))
+}
+
+// StartServer 为一次 HTTP 请求创建根 span，调用方需要把返回的 context 写回 c.Request
+func StartServer(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer().Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attrs...))
+}
+
+// Context 返回请求当前活跃 span 所在的 context
+func Context(c *gin.Context) context.Context {
+	if c == nil {
+		return context.Background()
+	}
+	if v, ok := c.Get(ginKeySpanContext); ok {
+		if ctx, ok := v.(context.Context); ok {
+			return ctx
+		}
+	}
+	if c.Request == nil {
+		return context.Background()
+	}
+	return c.Request.Context()
+}
+
+// Span 请求内的子 span，End 时恢复父 span 为当前 span
+type Span struct {
+	trace.Span
+	c      *gin.Context
+	ctx    context.Context
+	parent context.Context
+	once   sync.Once
+}
+
+// Start 以请求当前活跃的 span 为父节点创建子 span。
+// 只切换 gin 上下文中记录的 span，不替换 c.Request，避免影响后续对请求体的读写。
+func Start(c *gin.Context, name string, attrs ...attribute.KeyValue) *Span {
+	return start(c, name, trace.SpanKindInternal, attrs...)
+}
+
+// StartClient 创建调用上游服务的 client span
+func StartClient(c *gin.Context, name string, attrs ...attribute.KeyValue) *Span {
+	return start(c, name, trace.SpanKindClient, attrs...)
+}
+
+func start(c *gin.Context, name string, kind trace.SpanKind, attrs ...attribute.KeyValue) *Span {
+	parent := Context(c)
+	ctx, span := tracer().Start(parent, name, trace.WithSpanKind(kind), trace.WithAttributes(attrs...))
+	if c != nil {
+		c.Set(ginKeySpanContext, ctx)
+	}
+	return &Span{Span: span, c: c, ctx: ctx, parent: parent}
+}
+
+// End 结束 span，err 非空时记录为错误状态；可以重复调用
+func (s *Span) End(err error) {
+	s.once.Do(func() {
+		if err != nil {
+			s.Span.RecordError(err)
+			s.Span.SetStatus(codes.Error, err.Error())
+		}
+		s.Span.End()
+		if s.c != nil && Context(s.c) == s.ctx {
+			s.c.Set(ginKeySpanContext, s.parent)
+		}
+	})
+}
+
+// EndWithAPIError 结束 span，并记录 NewAPIError 的状态码与错误码
+func (s *Span) EndWithAPIError(err *types.NewAPIError) {
+	if err == nil {
+		s.End(nil)
+		return
+	}
+	s.Span.SetAttributes(
+		attribute.Int("http.response.status_code", err.StatusCode),
+		attribute.String("newapi.error_code", string(err.GetErrorCode())),
+	)
+	s.End(err)
+}
+
+// Inject 把当前 span 写入上游请求的 traceparent 请求头
+func Inject(c *gin.Context, header http.Header) {
+	otel.GetTextMapPropagator().Inject(Context(c), propagation.HeaderCarrier(header))
+}
+
+// TraceID 返回当前请求的 trace id，未采样时为空
+func TraceID(c *gin.Context) string {
+	spanContext := trace.SpanContextFromContext(Context(c))
+	if !spanContext.HasTraceID() {
+		return ""
+	}
+	return spanContext.TraceID().String()
+}
@@ -0,0 +1,49 @@
+package tracing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestStart_HonoursAndPropagatesTraceparent(t *testing.T) {
+	require.NoError(t, Init("test"))
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	const incomingTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	c.Request.Header.Set("traceparent", "00-"+incomingTraceID+"-00f067aa0ba902b7-01")
+
+	ctx, root := StartServer(Extract(c.Request.Context(), c.Request.Header), "POST")
+	c.Request = c.Request.WithContext(ctx)
+
+	attempt := Start(c, "relay.attempt")
+	upstream := StartClient(c, "relay.upstream_request")
+	header := http.Header{}
+	Inject(c, header)
+	upstream.End(nil)
+	attempt.End(nil)
+	root.End()
+
+	require.Equal(t, incomingTraceID, TraceID(c))
+	require.Contains(t, header.Get("traceparent"), incomingTraceID)
+	require.Contains(t, header.Get("traceparent"), upstream.SpanContext().SpanID().String())
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	require.Equal(t, "relay.upstream_request", spans[0].Name())
+	require.Equal(t, attempt.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	require.Equal(t, root.SpanContext().SpanID(), spans[1].Parent().SpanID())
+}
@@ -0,0 +1,166 @@
+package claude
+
+import (
+	"encoding/json"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/dto"
+	relaycommon "github.com/QuantumNous/new-api/relay/common"
+	"github.com/QuantumNous/new-api/service"
+	"github.com/QuantumNous/new-api/setting/model_setting"
+)
+
+// Claude 单个请求最多允许 4 个 cache_control 断点
+const maxCacheControlBreakpoints = 4
+
+// getAutoCacheControlTTL 渠道配置优先，未配置时使用全局按模型的配置
+func getAutoCacheControlTTL(info *relaycommon.RelayInfo) string {
+	if info == nil || info.ChannelMeta == nil {
+		return ""
+	}
+	switch info.ChannelOtherSettings.ClaudeCacheControl {
+	case "off":
+		return ""
+	case "5m", "1h":
+		return info.ChannelOtherSettings.ClaudeCacheControl
+	}
+	return model_setting.GetClaudeSettings().GetAutoCacheControlTTL(info.OriginModelName)
+}
+
+// ApplyAutoCacheControl 为 OpenAI 格式转换而来的 Claude 请求注入 cache_control 断点，
+// 依次标记工具定义末尾、system 末尾以及最近两条 user 消息（对话前缀）。
+// 断点之前的内容不足最小 token 数时跳过该断点；客户端已自行指定断点时不做改动。
+func ApplyAutoCacheControl(info *relaycommon.RelayInfo, request *dto.ClaudeRequest) {
+	ttl := getAutoCacheControlTTL(info)
+	if ttl == "" || request == nil || hasCacheControl(request) {
+		return
+	}
+	cacheControl := json.RawMessage(`{"type":"ephemeral"}`)
+	if ttl == "1h" {
+		cacheControl = json.RawMessage(`{"type":"ephemeral","ttl":"1h"}`)
+	}
+	minTokens := model_setting.GetClaudeSettings().AutoCacheControlMinTokens
+	countTokens := func(text string) int {
+		return service.CountTextToken(text, info.UpstreamModelName)
+	}
+
+	breakpoints := 0
+	prefixTokens := 0
+
+	var lastTool *dto.Tool
+	for _, tool := range request.GetTools() {
+		data, _ := common.Marshal(tool)
+		prefixTokens += countTokens(string(data))
+		if t, ok := tool.(*dto.Tool); ok {
+			lastTool = t
+		}
+	}
+	if lastTool != nil && prefixTokens >= minTokens {
+		lastTool.CacheControl = cacheControl
+		breakpoints++
+	}
+
+	if system, ok := request.System.([]dto.ClaudeMediaMessage); ok && len(system) > 0 {
+		for _, block := range system {
+			prefixTokens += countTokens(block.GetText())
+		}
+		if prefixTokens >= minTokens && system[len(system)-1].GetText() != "" {
+			system[len(system)-1].CacheControl = cacheControl
+			breakpoints++
+		}
+	}
+
+	// 最后一条 user 消息写入缓存，上一条 user 消息命中上一轮写入的缓存
+	userIndexes := make([]int, 0, 2)
+	for i := len(request.Messages) - 1; i >= 0 && len(userIndexes) < 2; i-- {
+		if request.Messages[i].Role == "user" {
+			userIndexes = append(userIndexes, i)
+		}
+	}
+	messageTokens := make([]int, len(request.Messages))
+	for i := range request.Messages {
+		messageTokens[i] = countTokens(claudeMessageText(&request.Messages[i]))
+	}
+	for j := len(userIndexes) - 1; j >= 0 && breakpoints < maxCacheControlBreakpoints; j-- {
+		index := userIndexes[j]
+		tokens := prefixTokens
+		for i := 0; i <= index; i++ {
+			tokens += messageTokens[i]
+		}
+		if tokens >= minTokens && setMessageCacheControl(&request.Messages[index], cacheControl) {
+			breakpoints++
+		}
+	}
+}
+
+func hasCacheControl(request *dto.ClaudeRequest) bool {
+	for _, tool := range request.GetTools() {
+		if t, ok := tool.(*dto.Tool); ok && len(t.CacheControl) > 0 {
+			return true
+		}
+	}
+	if system, ok := request.System.([]dto.ClaudeMediaMessage); ok {
+		for _, block := range system {
+			if len(block.CacheControl) > 0 {
+				return true
+			}
+		}
+	}
+	for _, message := range request.Messages {
+		if blocks, ok := message.Content.([]dto.ClaudeMediaMessage); ok {
+			for _, block := range blocks {
+				if len(block.CacheControl) > 0 {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func claudeMessageText(message *dto.ClaudeMessage) string {
+	switch content := message.Content.(type) {
+	case string:
+		return content
+	case []dto.ClaudeMediaMessage:
+		var text string
+		for _, block := range content {
+			switch block.Type {
+			case "text":
+				text += block.GetText()
+			case "tool_use", "tool_result":
+				data, _ := common.Marshal(block)
+				text += string(data)
+			}
+		}
+		return text
+	}
+	return ""
+}
+
+// setMessageCacheControl 在消息最后一个内容块上设置断点，字符串内容先转换为文本块
+func setMessageCacheControl(message *dto.ClaudeMessage, cacheControl json.RawMessage) bool {
+	switch content := message.Content.(type) {
+	case string:
+		if content == "" {
+			return false
+		}
+		message.Content = []dto.ClaudeMediaMessage{{
+			Type:         "text",
+			Text:         common.GetPointer[string](content),
+			CacheControl: cacheControl,
+		}}
+		return true
+	case []dto.ClaudeMediaMessage:
+		if len(content) == 0 {
+			return false
+		}
+		last := &content[len(content)-1]
+		if last.Type == "text" && last.GetText() == "" {
+			return false
+		}
+		last.CacheControl = cacheControl
+		return true
+	}
+	return false
+}
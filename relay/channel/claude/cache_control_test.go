@@ -0,0 +1,59 @@
+package claude
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/dto"
+	relaycommon "github.com/QuantumNous/new-api/relay/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyAutoCacheControl(t *testing.T) {
+	longText := strings.Repeat("cacheable system prompt ", 2000)
+	newRequest := func() *dto.ClaudeRequest {
+		return &dto.ClaudeRequest{
+			Tools: []any{&dto.Tool{Name: "get_weather", InputSchema: map[string]any{"type": "object"}}},
+			System: []dto.ClaudeMediaMessage{
+				{Type: "text", Text: common.GetPointer[string](longText)},
+			},
+			Messages: []dto.ClaudeMessage{
+				{Role: "user", Content: "hi"},
+				{Role: "assistant", Content: "hello"},
+				{Role: "user", Content: "how are you"},
+			},
+		}
+	}
+	info := &relaycommon.RelayInfo{
+		OriginModelName: "claude-sonnet-4-5",
+		ChannelMeta: &relaycommon.ChannelMeta{
+			UpstreamModelName:    "claude-sonnet-4-5",
+			ChannelOtherSettings: dto.ChannelOtherSettings{ClaudeCacheControl: "1h"},
+		},
+	}
+
+	request := newRequest()
+	ApplyAutoCacheControl(info, request)
+	// 工具定义过短不打断点，system 与最近两条 user 消息打断点
+	require.Empty(t, request.Tools.([]any)[0].(*dto.Tool).CacheControl)
+	require.JSONEq(t, `{"type":"ephemeral","ttl":"1h"}`, string(request.System.([]dto.ClaudeMediaMessage)[0].CacheControl))
+	require.Equal(t, "hello", request.Messages[1].Content)
+	for _, index := range []int{0, 2} {
+		blocks := request.Messages[index].Content.([]dto.ClaudeMediaMessage)
+		require.NotEmpty(t, blocks[len(blocks)-1].CacheControl)
+	}
+
+	// 客户端已指定断点时不改动
+	request = newRequest()
+	request.Messages[0].Content = []dto.ClaudeMediaMessage{
+		{Type: "text", Text: common.GetPointer[string]("hi"), CacheControl: []byte(`{"type":"ephemeral"}`)},
+	}
+	ApplyAutoCacheControl(info, request)
+	require.Empty(t, request.System.([]dto.ClaudeMediaMessage)[0].CacheControl)
+
+	info.ChannelOtherSettings.ClaudeCacheControl = "off"
+	request = newRequest()
+	ApplyAutoCacheControl(info, request)
+	require.Empty(t, request.System.([]dto.ClaudeMediaMessage)[0].CacheControl)
+}
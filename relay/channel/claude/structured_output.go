@@ -0,0 +1,75 @@
+package claude
+
+import (
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
+	"github.com/QuantumNous/new-api/dto"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Claude 不支持 response_format，json_schema 通过强制调用该工具实现，响应时把工具参数还原为文本内容
+const structuredOutputToolName = "json_response"
+
+func applyStructuredOutputTool(c *gin.Context, textRequest *dto.GeneralOpenAIRequest, claudeRequest *dto.ClaudeRequest) {
+	if textRequest.ResponseFormat == nil || textRequest.ResponseFormat.Type != "json_schema" ||
+		len(textRequest.ResponseFormat.JsonSchema) == 0 {
+		return
+	}
+	// 请求自带工具或开启扩展思考时无法强制调用工具
+	if len(textRequest.Tools) > 0 || claudeRequest.Thinking != nil {
+		return
+	}
+	var jsonSchema dto.FormatJsonSchema
+	if err := common.Unmarshal(textRequest.ResponseFormat.JsonSchema, &jsonSchema); err != nil {
+		return
+	}
+	schema, ok := jsonSchema.Schema.(map[string]any)
+	if !ok || schema["type"] != "object" {
+		return
+	}
+	claudeRequest.AddTool(&dto.Tool{
+		Name:        structuredOutputToolName,
+		Description: common.GetStringIfEmpty(jsonSchema.Description, "Respond with a JSON object that matches the schema."),
+		InputSchema: schema,
+	})
+	claudeRequest.ToolChoice = &dto.ClaudeToolChoice{Type: "tool", Name: structuredOutputToolName}
+	common.SetContextKey(c, constant.ContextKeyClaudeStructuredOutput, true)
+}
+
+func restoreStructuredOutput(c *gin.Context, response *dto.OpenAITextResponse) {
+	if response == nil || !common.GetContextKeyBool(c, constant.ContextKeyClaudeStructuredOutput) {
+		return
+	}
+	for i := range response.Choices {
+		choice := &response.Choices[i]
+		for _, toolCall := range choice.Message.ParseToolCalls() {
+			if toolCall.Function.Name == structuredOutputToolName {
+				choice.Message.SetStringContent(toolCall.Function.Arguments)
+				choice.Message.ToolCalls = nil
+				choice.FinishReason = constant.FinishReasonStop
+				break
+			}
+		}
+	}
+}
+
+func restoreStructuredOutputStream(c *gin.Context, response *dto.ChatCompletionsStreamResponse) {
+	if response == nil || !common.GetContextKeyBool(c, constant.ContextKeyClaudeStructuredOutput) {
+		return
+	}
+	for i := range response.Choices {
+		choice := &response.Choices[i]
+		if len(choice.Delta.ToolCalls) > 0 {
+			var arguments string
+			for _, toolCall := range choice.Delta.ToolCalls {
+				arguments += toolCall.Function.Arguments
+			}
+			choice.Delta.ToolCalls = nil
+			choice.Delta.SetContentString(arguments)
+		}
+		if choice.FinishReason != nil && *choice.FinishReason == constant.FinishReasonToolCalls {
+			choice.FinishReason = common.GetPointer(constant.FinishReasonStop)
+		}
+	}
+}
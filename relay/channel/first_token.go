@@ -0,0 +1,188 @@
+package channel
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/QuantumNous/new-api/logger"
+	"github.com/QuantumNous/new-api/relay/common"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+	"github.com/QuantumNous/new-api/types"
+
+	"github.com/bytedance/gopkg/util/gopool"
+	"github.com/gin-gonic/gin"
+)
+
+// 等待首个 data 行时最多缓存的字节数，超过后直接交给流处理器
+const maxFirstTokenPeekBytes = 64 << 10
+
+// firstTokenBody 包装上游响应体：先回放已预读的内容，关闭时同时取消该请求的 context
+type firstTokenBody struct {
+	io.Reader
+	body   io.Closer
+	cancel context.CancelFunc
+}
+
+func (b *firstTokenBody) Close() error {
+	err := b.body.Close()
+	b.cancel()
+	return err
+}
+
+type firstTokenAttempt struct {
+	index int
+	resp  *http.Response
+	err   error
+}
+
+func getFirstTokenPolicy(info *common.RelayInfo) (timeout time.Duration, hedgeDelay time.Duration) {
+	if !info.IsStream || info.IsChannelTest || info.ChannelMeta == nil {
+		return 0, 0
+	}
+	timeout = operation_setting.GetFirstTokenTimeout(info.OriginModelName, info.ChannelSetting.FirstTokenTimeoutSeconds)
+	hedgeDelay = operation_setting.GetHedgeDelay(info.ChannelSetting.HedgeDelayMs)
+	return timeout, hedgeDelay
+}
+
+func hasSSEData(b []byte) bool {
+	return bytes.HasPrefix(b, []byte("data:")) || bytes.Contains(b, []byte("\ndata:"))
+}
+
+// waitFirstStreamData 阻塞读取直到上游返回第一条 SSE data 行，已读取的内容会在后续读取时回放
+func waitFirstStreamData(resp *http.Response, body *firstTokenBody) error {
+	if resp.StatusCode != http.StatusOK || !strings.Contains(resp.Header.Get("Content-Type"), "text/event-stream") {
+		return nil
+	}
+	var buf bytes.Buffer
+	chunk := make([]byte, 4096)
+	for {
+		n, err := body.Reader.Read(chunk)
+		if n > 0 {
+			buf.Write(chunk[:n])
+		}
+		if hasSSEData(buf.Bytes()) || buf.Len() >= maxFirstTokenPeekBytes || err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	body.Reader = io.MultiReader(bytes.NewReader(buf.Bytes()), body.Reader)
+	return nil
+}
+
+// doStreamRequestWithFirstToken 发起流式请求并等待首个 data 行：
+// 超过首字超时仍无输出时取消请求并返回可重试的错误；开启对冲时在 hedgeDelay 后再发起一个相同请求，
+// 先输出内容的请求胜出，另一个请求会被取消且不计费。
+func doStreamRequestWithFirstToken(c *gin.Context, client *http.Client, req *http.Request, info *common.RelayInfo, timeout time.Duration, hedgeDelay time.Duration) (*http.Response, error) {
+	results := make(chan firstTokenAttempt, 2)
+	cancels := make([]context.CancelFunc, 0, 2)
+	launch := func(r *http.Request) {
+		// 继承客户端请求的 context，客户端断开时上游请求随之取消
+		ctx, cancel := context.WithCancel(c.Request.Context())
+		r = r.WithContext(ctx)
+		index := len(cancels)
+		cancels = append(cancels, cancel)
+		gopool.Go(func() {
+			resp, err := client.Do(r)
+			if err != nil {
+				cancel()
+				results <- firstTokenAttempt{index: index, err: err}
+				return
+			}
+			body := &firstTokenBody{Reader: resp.Body, body: resp.Body, cancel: cancel}
+			resp.Body = body
+			if err = waitFirstStreamData(resp, body); err != nil {
+				_ = resp.Body.Close()
+				results <- firstTokenAttempt{index: index, err: err}
+				return
+			}
+			results <- firstTokenAttempt{index: index, resp: resp}
+		})
+	}
+	// 取消未胜出的请求，并在后台回收尚未返回的结果
+	abandon := func(winner int, pending int) {
+		for i, cancel := range cancels {
+			if i != winner {
+				cancel()
+			}
+		}
+		if pending <= 0 {
+			return
+		}
+		gopool.Go(func() {
+			for ; pending > 0; pending-- {
+				if r := <-results; r.resp != nil {
+					_ = r.resp.Body.Close()
+				}
+			}
+		})
+	}
+	defer func() {
+		_ = req.Body.Close()
+		_ = c.Request.Body.Close()
+	}()
+
+	var timeoutC, hedgeC <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timeoutC = timer.C
+	}
+	if hedgeDelay > 0 && req.GetBody != nil {
+		timer := time.NewTimer(hedgeDelay)
+		defer timer.Stop()
+		hedgeC = timer.C
+	}
+
+	launch(req)
+	pending := 1
+	for {
+		select {
+		case r := <-results:
+			pending--
+			if r.err == nil && r.resp.StatusCode == http.StatusOK {
+				abandon(r.index, pending)
+				if r.index > 0 {
+					logger.LogInfo(c, fmt.Sprintf("hedged stream request won, channel #%d", info.ChannelId))
+				}
+				return r.resp, nil
+			}
+			if pending > 0 {
+				// 另一个请求仍在等待首字，以它的结果为准
+				if r.resp != nil {
+					_ = r.resp.Body.Close()
+				}
+				continue
+			}
+			abandon(r.index, 0)
+			if r.err != nil {
+				logger.LogError(c, "do request failed: "+r.err.Error())
+				return nil, types.NewError(r.err, types.ErrorCodeDoRequestFailed, types.ErrOptionWithHideErrMsg("upstream error: do request failed"))
+			}
+			return r.resp, nil
+		case <-hedgeC:
+			hedgeC = nil
+			body, err := req.GetBody()
+			if err != nil {
+				continue
+			}
+			hedgeReq := req.Clone(req.Context())
+			hedgeReq.Body = body
+			launch(hedgeReq)
+			pending++
+		case <-timeoutC:
+			abandon(-1, pending)
+			logger.LogWarn(c, fmt.Sprintf("first token timeout after %s, channel #%d", timeout, info.ChannelId))
+			return nil, types.NewErrorWithStatusCode(fmt.Errorf("upstream did not send the first token within %s", timeout), types.ErrorCodeFirstTokenTimeout, http.StatusGatewayTimeout)
+		case <-c.Request.Context().Done():
+			abandon(-1, pending)
+			return nil, types.NewError(c.Request.Context().Err(), types.ErrorCodeDoRequestFailed, types.ErrOptionWithSkipRetry())
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package channel
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	relaycommon "github.com/QuantumNous/new-api/relay/common"
+	"github.com/QuantumNous/new-api/types"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func newFirstTokenTestContext(t *testing.T) (*gin.Context, *relaycommon.RelayInfo) {
+	gin.SetMode(gin.TestMode)
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", bytes.NewBufferString(`{}`))
+	info := &relaycommon.RelayInfo{IsStream: true, ChannelMeta: &relaycommon.ChannelMeta{ChannelId: 1}}
+	return ctx, info
+}
+
+func TestDoStreamRequestWithFirstToken_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	c, info := newFirstTokenTestContext(t)
+	req, err := http.NewRequest(http.MethodPost, server.URL, bytes.NewBufferString(`{}`))
+	require.NoError(t, err)
+
+	_, err = doStreamRequestWithFirstToken(c, server.Client(), req, info, 100*time.Millisecond, 0)
+	var apiErr *types.NewAPIError
+	require.True(t, errors.As(err, &apiErr))
+	require.Equal(t, types.ErrorCodeFirstTokenTimeout, apiErr.GetErrorCode())
+	require.Equal(t, http.StatusGatewayTimeout, apiErr.StatusCode)
+}
+
+func TestDoStreamRequestWithFirstToken_HedgeWins(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		if calls.Add(1) == 1 {
+			<-r.Context().Done()
+			return
+		}
+		_, _ = io.WriteString(w, ": keep-alive\n\ndata: {\"id\":\"hedge\"}\n\ndata: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	c, info := newFirstTokenTestContext(t)
+	req, err := http.NewRequest(http.MethodPost, server.URL, bytes.NewBufferString(`{}`))
+	require.NoError(t, err)
+
+	resp, err := doStreamRequestWithFirstToken(c, server.Client(), req, info, 2*time.Second, 50*time.Millisecond)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(body), `data: {"id":"hedge"}`)
+	require.True(t, bytes.HasPrefix(body, []byte(": keep-alive")))
+	require.Equal(t, int32(2), calls.Load())
+}
@@ -0,0 +1,537 @@
+package gemini
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/dto"
+	"github.com/QuantumNous/new-api/logger"
+	relaycommon "github.com/QuantumNous/new-api/relay/common"
+	"github.com/QuantumNous/new-api/relay/helper"
+	"github.com/QuantumNous/new-api/service"
+	"github.com/QuantumNous/new-api/types"
+
+	"github.com/bytedance/gopkg/util/gopool"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// Gemini Live 输入输出均为 24kHz 单声道 PCM16，与 OpenAI 的 pcm16 格式一致
+const geminiLiveAudioMimeType = "audio/pcm;rate=24000"
+
+// OpenAI 预置音色在 Gemini 中不存在，遇到时使用 Gemini 默认音色
+var openaiRealtimeVoices = map[string]bool{
+	"alloy": true, "ash": true, "ballad": true, "coral": true,
+	"echo": true, "sage": true, "shimmer": true, "verse": true,
+}
+
+// geminiLiveBridge 将 OpenAI Realtime 事件协议转换为 Gemini Live (BidiGenerateContent)
+type geminiLiveBridge struct {
+	c    *gin.Context
+	info *relaycommon.RelayInfo
+
+	mu           sync.Mutex
+	session      dto.RealtimeSession
+	setupSent    bool
+	pendingTurns []dto.GeminiChatContent
+	toolNames    map[string]string
+	responseId   string
+	itemId       string
+	turnUsage    *dto.GeminiLiveUsageMetadata
+	localUsage   dto.RealtimeUsage
+	sumUsage     dto.RealtimeUsage
+}
+
+func GeminiLiveRealtimeHandler(c *gin.Context, info *relaycommon.RelayInfo) (*dto.RealtimeUsage, *types.NewAPIError) {
+	if info == nil || info.ClientWs == nil || info.TargetWs == nil {
+		return nil, types.NewError(fmt.Errorf("invalid websocket connection"), types.ErrorCodeBadResponse)
+	}
+
+	info.IsStream = true
+	bridge := &geminiLiveBridge{
+		c:         c,
+		info:      info,
+		toolNames: make(map[string]string),
+		session: dto.RealtimeSession{
+			Modalities:        []string{"text", "audio"},
+			InputAudioFormat:  "pcm16",
+			OutputAudioFormat: "pcm16",
+		},
+	}
+	// OpenAI 上游在连接建立后立即下发 session.created，这里保持一致
+	if err := bridge.sendClient(&dto.RealtimeEvent{Type: dto.RealtimeEventTypeSessionCreated, Session: &bridge.session}); err != nil {
+		return nil, types.NewError(err, types.ErrorCodeBadResponse)
+	}
+
+	clientClosed := make(chan struct{})
+	targetClosed := make(chan struct{})
+	errChan := make(chan error, 2)
+
+	gopool.Go(func() {
+		defer func() {
+			if r := recover(); r != nil {
+				errChan <- fmt.Errorf("panic in client reader: %v", r)
+			}
+		}()
+		for {
+			select {
+			case <-c.Done():
+				return
+			default:
+				_, message, err := info.ClientWs.ReadMessage()
+				if err != nil {
+					if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+						errChan <- fmt.Errorf("error reading from client: %v", err)
+					}
+					close(clientClosed)
+					return
+				}
+				realtimeEvent := &dto.RealtimeEvent{}
+				if err = common.Unmarshal(message, realtimeEvent); err != nil {
+					errChan <- fmt.Errorf("error unmarshalling message: %v", err)
+					return
+				}
+				if err = bridge.handleClientEvent(realtimeEvent); err != nil {
+					errChan <- err
+					return
+				}
+			}
+		}
+	})
+
+	gopool.Go(func() {
+		defer func() {
+			if r := recover(); r != nil {
+				errChan <- fmt.Errorf("panic in target reader: %v", r)
+			}
+		}()
+		for {
+			select {
+			case <-c.Done():
+				return
+			default:
+				_, message, err := info.TargetWs.ReadMessage()
+				if err != nil {
+					if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+						errChan <- fmt.Errorf("error reading from target: %v", err)
+					}
+					close(targetClosed)
+					return
+				}
+				info.SetFirstResponseTime()
+				serverMessage := &dto.GeminiLiveServerMessage{}
+				if err = common.Unmarshal(message, serverMessage); err != nil {
+					errChan <- fmt.Errorf("error unmarshalling message: %v", err)
+					return
+				}
+				if err = bridge.handleServerMessage(serverMessage); err != nil {
+					errChan <- err
+					return
+				}
+			}
+		}
+	})
+
+	select {
+	case <-clientClosed:
+	case <-targetClosed:
+	case err := <-errChan:
+		logger.LogError(c, "gemini live error: "+err.Error())
+	case <-c.Done():
+	}
+
+	// 连接中断时结算未完成一轮的用量
+	bridge.mu.Lock()
+	defer bridge.mu.Unlock()
+	if err := bridge.settleTurnLocked(); err != nil {
+		logger.LogError(c, "error consume usage: "+err.Error())
+	}
+	return &bridge.sumUsage, nil
+}
+
+func (b *geminiLiveBridge) handleClientEvent(event *dto.RealtimeEvent) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if event.Type == dto.RealtimeEventTypeSessionUpdate && event.Session != nil {
+		if b.setupSent {
+			// Gemini Live 的会话配置只能在 setup 中下发一次
+			logger.LogWarn(b.c, "gemini live: session.update after setup is ignored")
+			return nil
+		}
+		b.mergeSession(event.Session)
+		if event.Session.Tools != nil {
+			b.info.RealtimeTools = event.Session.Tools
+		}
+	}
+	if !b.setupSent {
+		if err := b.sendTarget(&dto.GeminiLiveClientMessage{Setup: b.buildSetup()}); err != nil {
+			return err
+		}
+		b.setupSent = true
+	}
+
+	textToken, audioToken, err := service.CountTokenRealtime(b.info, *event, b.info.UpstreamModelName)
+	if err != nil {
+		return fmt.Errorf("error counting text token: %v", err)
+	}
+
+	switch event.Type {
+	case dto.RealtimeEventInputAudioBufferAppend:
+		err = b.sendTarget(&dto.GeminiLiveClientMessage{RealtimeInput: &dto.GeminiLiveRealtimeInput{
+			Audio: &dto.GeminiInlineData{MimeType: geminiLiveAudioMimeType, Data: event.Audio},
+		}})
+	case dto.RealtimeEventInputAudioBufferCommit:
+		err = b.sendTarget(&dto.GeminiLiveClientMessage{RealtimeInput: &dto.GeminiLiveRealtimeInput{AudioStreamEnd: true}})
+	case dto.RealtimeEventTypeConversationCreate:
+		if event.Item == nil {
+			break
+		}
+		if event.Item.Type == "function_call_output" {
+			name := b.toolNames[event.Item.CallId]
+			err = b.sendTarget(&dto.GeminiLiveClientMessage{ToolResponse: &dto.GeminiLiveToolResponse{
+				FunctionResponses: []dto.GeminiLiveFunctionResponse{{
+					Id:       event.Item.CallId,
+					Name:     name,
+					Response: map[string]any{"output": event.Item.Output},
+				}},
+			}})
+			textToken += service.CountTextToken(event.Item.Output, b.info.UpstreamModelName)
+			break
+		}
+		content := dto.GeminiChatContent{Role: "user"}
+		if event.Item.Role == "assistant" {
+			content.Role = "model"
+		}
+		for _, part := range event.Item.Content {
+			switch part.Type {
+			case "input_text", "text":
+				content.Parts = append(content.Parts, dto.GeminiPart{Text: part.Text})
+				textToken += service.CountTextToken(part.Text, b.info.UpstreamModelName)
+			case "input_audio":
+				content.Parts = append(content.Parts, dto.GeminiPart{
+					InlineData: &dto.GeminiInlineData{MimeType: geminiLiveAudioMimeType, Data: part.Audio},
+				})
+			}
+		}
+		if len(content.Parts) > 0 {
+			b.pendingTurns = append(b.pendingTurns, content)
+		}
+	case dto.RealtimeEventTypeResponseCreate:
+		err = b.sendTarget(&dto.GeminiLiveClientMessage{ClientContent: &dto.GeminiLiveClientContent{
+			Turns:        b.pendingTurns,
+			TurnComplete: true,
+		}})
+		b.pendingTurns = nil
+	}
+	if err != nil {
+		return err
+	}
+
+	b.localUsage.TotalTokens += textToken + audioToken
+	b.localUsage.InputTokens += textToken + audioToken
+	b.localUsage.InputTokenDetails.TextTokens += textToken
+	b.localUsage.InputTokenDetails.AudioTokens += audioToken
+	return nil
+}
+
+func (b *geminiLiveBridge) handleServerMessage(message *dto.GeminiLiveServerMessage) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if message.UsageMetadata != nil {
+		// 同一轮内的 usageMetadata 为累计值，保留最后一次
+		b.turnUsage = message.UsageMetadata
+	}
+	if message.GoAway != nil {
+		logger.LogWarn(b.c, "gemini live: server will close the session in "+message.GoAway.TimeLeft)
+	}
+	if message.SetupComplete != nil {
+		return b.sendClient(&dto.RealtimeEvent{Type: dto.RealtimeEventTypeSessionUpdated, Session: &b.session})
+	}
+
+	if message.ToolCall != nil {
+		if err := b.startResponseLocked(); err != nil {
+			return err
+		}
+		for _, call := range message.ToolCall.FunctionCalls {
+			b.toolNames[call.Id] = call.Name
+			arguments, err := common.Marshal(call.Args)
+			if err != nil {
+				return err
+			}
+			if err = b.sendOutput(&dto.RealtimeEvent{
+				Type:       dto.RealtimeEventResponseFunctionCallArgumentsDone,
+				ResponseId: b.responseId,
+				ItemId:     b.itemId,
+				CallId:     call.Id,
+				Name:       call.Name,
+				Arguments:  string(arguments),
+			}, string(arguments)); err != nil {
+				return err
+			}
+		}
+		// 等待客户端返回工具结果，本轮到此结束
+		return b.finishResponseLocked("completed")
+	}
+
+	content := message.ServerContent
+	if content == nil {
+		return nil
+	}
+	if content.InputTranscription != nil && content.InputTranscription.Text != "" {
+		if err := b.sendClient(&dto.RealtimeEvent{
+			Type:  dto.RealtimeEventInputAudioTranscriptionDelta,
+			Delta: content.InputTranscription.Text,
+		}); err != nil {
+			return err
+		}
+	}
+	if content.ModelTurn != nil {
+		if err := b.startResponseLocked(); err != nil {
+			return err
+		}
+		for _, part := range content.ModelTurn.Parts {
+			var err error
+			if part.InlineData != nil && strings.HasPrefix(part.InlineData.MimeType, "audio/") {
+				err = b.sendOutput(&dto.RealtimeEvent{
+					Type:       dto.RealtimeEventResponseAudioDelta,
+					ResponseId: b.responseId,
+					ItemId:     b.itemId,
+					Delta:      part.InlineData.Data,
+				}, "")
+			} else if part.Text != "" && !part.Thought {
+				err = b.sendOutput(&dto.RealtimeEvent{
+					Type:       dto.RealtimeEventResponseTextDelta,
+					ResponseId: b.responseId,
+					ItemId:     b.itemId,
+					Delta:      part.Text,
+				}, part.Text)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if content.OutputTranscription != nil && content.OutputTranscription.Text != "" {
+		if err := b.startResponseLocked(); err != nil {
+			return err
+		}
+		if err := b.sendClient(&dto.RealtimeEvent{
+			Type:       dto.RealtimeEventResponseAudioTranscriptionDelta,
+			ResponseId: b.responseId,
+			ItemId:     b.itemId,
+			Delta:      content.OutputTranscription.Text,
+		}); err != nil {
+			return err
+		}
+	}
+	if content.Interrupted {
+		return b.finishResponseLocked("cancelled")
+	}
+	if content.TurnComplete {
+		return b.finishResponseLocked("completed")
+	}
+	return nil
+}
+
+func (b *geminiLiveBridge) mergeSession(session *dto.RealtimeSession) {
+	if len(session.Modalities) > 0 {
+		b.session.Modalities = session.Modalities
+	}
+	b.session.Instructions = common.GetStringIfEmpty(session.Instructions, b.session.Instructions)
+	b.session.Voice = common.GetStringIfEmpty(session.Voice, b.session.Voice)
+	if session.InputAudioTranscription.Model != "" {
+		b.session.InputAudioTranscription = session.InputAudioTranscription
+	}
+	if session.TurnDetection != nil {
+		b.session.TurnDetection = session.TurnDetection
+	}
+	if session.Tools != nil {
+		b.session.Tools = session.Tools
+	}
+	b.session.ToolChoice = common.GetStringIfEmpty(session.ToolChoice, b.session.ToolChoice)
+	if session.Temperature > 0 {
+		b.session.Temperature = session.Temperature
+	}
+}
+
+func (b *geminiLiveBridge) buildSetup() *dto.GeminiLiveSetup {
+	generationConfig := &dto.GeminiChatGenerationConfig{ResponseModalities: []string{"TEXT"}}
+	audioOutput := false
+	for _, modality := range b.session.Modalities {
+		if modality == "audio" {
+			audioOutput = true
+		}
+	}
+	// Gemini Live 每个会话只支持一种输出模态
+	if audioOutput {
+		generationConfig.ResponseModalities = []string{"AUDIO"}
+		if b.session.Voice != "" && !openaiRealtimeVoices[b.session.Voice] {
+			generationConfig.SpeechConfig = []byte(fmt.Sprintf(`{"voiceConfig":{"prebuiltVoiceConfig":{"voiceName":%q}}}`, b.session.Voice))
+		}
+	}
+	if b.session.Temperature > 0 {
+		temperature := b.session.Temperature
+		generationConfig.Temperature = &temperature
+	}
+
+	setup := &dto.GeminiLiveSetup{
+		Model:            "models/" + b.info.UpstreamModelName,
+		GenerationConfig: generationConfig,
+	}
+	if b.session.Instructions != "" {
+		setup.SystemInstruction = &dto.GeminiChatContent{
+			Parts: []dto.GeminiPart{{Text: b.session.Instructions}},
+		}
+	}
+	functions := make([]map[string]any, 0, len(b.session.Tools))
+	for _, tool := range b.session.Tools {
+		if tool.Type != "" && tool.Type != "function" {
+			continue
+		}
+		function := map[string]any{"name": tool.Name}
+		if tool.Description != "" {
+			function["description"] = tool.Description
+		}
+		if tool.Parameters != nil {
+			function["parameters"] = cleanFunctionParameters(tool.Parameters)
+		}
+		functions = append(functions, function)
+	}
+	if len(functions) > 0 {
+		setup.Tools = []dto.GeminiChatTool{{FunctionDeclarations: functions}}
+	}
+	if b.session.InputAudioTranscription.Model != "" {
+		setup.InputAudioTranscription = &struct{}{}
+	}
+	if audioOutput {
+		setup.OutputAudioTranscription = &struct{}{}
+	}
+	return setup
+}
+
+func (b *geminiLiveBridge) startResponseLocked() error {
+	if b.responseId != "" {
+		return nil
+	}
+	b.responseId = "resp_" + common.GetRandomString(16)
+	b.itemId = "item_" + common.GetRandomString(16)
+	return b.sendClient(&dto.RealtimeEvent{
+		Type: dto.RealtimeEventResponseCreated,
+		Response: &dto.RealtimeResponse{
+			Id:     b.responseId,
+			Object: "realtime.response",
+			Status: "in_progress",
+		},
+	})
+}
+
+// finishResponseLocked 下发 response.done 并结算本轮用量，没有进行中的响应时只结算
+func (b *geminiLiveBridge) finishResponseLocked(status string) error {
+	usage := b.currentTurnUsageLocked()
+	if err := b.settleTurnLocked(); err != nil {
+		return fmt.Errorf("error consume usage: %v", err)
+	}
+	if b.responseId == "" {
+		return nil
+	}
+	responseId := b.responseId
+	b.responseId = ""
+	b.itemId = ""
+	return b.sendClient(&dto.RealtimeEvent{
+		Type: dto.RealtimeEventTypeResponseDone,
+		Response: &dto.RealtimeResponse{
+			Id:     responseId,
+			Object: "realtime.response",
+			Status: status,
+			Usage:  usage,
+		},
+	})
+}
+
+// currentTurnUsageLocked 本轮用量，优先使用上游返回的 usageMetadata，缺失时使用本地估算
+func (b *geminiLiveBridge) currentTurnUsageLocked() *dto.RealtimeUsage {
+	if b.turnUsage != nil {
+		return convertGeminiLiveUsage(b.turnUsage)
+	}
+	usage := b.localUsage
+	return &usage
+}
+
+func (b *geminiLiveBridge) settleTurnLocked() error {
+	usage := b.currentTurnUsageLocked()
+	b.turnUsage = nil
+	b.localUsage = dto.RealtimeUsage{}
+	if usage.TotalTokens == 0 {
+		return nil
+	}
+	b.sumUsage.TotalTokens += usage.TotalTokens
+	b.sumUsage.InputTokens += usage.InputTokens
+	b.sumUsage.OutputTokens += usage.OutputTokens
+	b.sumUsage.InputTokenDetails.CachedTokens += usage.InputTokenDetails.CachedTokens
+	b.sumUsage.InputTokenDetails.TextTokens += usage.InputTokenDetails.TextTokens
+	b.sumUsage.InputTokenDetails.AudioTokens += usage.InputTokenDetails.AudioTokens
+	b.sumUsage.OutputTokenDetails.TextTokens += usage.OutputTokenDetails.TextTokens
+	b.sumUsage.OutputTokenDetails.AudioTokens += usage.OutputTokenDetails.AudioTokens
+	if b.info.RealtimeBilling != nil {
+		return b.info.RealtimeBilling.AddUsage(usage)
+	}
+	return service.PreWssConsumeQuota(b.c, b.info, usage)
+}
+
+// sendOutput 下发模型输出事件，同时累计本地估算的输出用量
+func (b *geminiLiveBridge) sendOutput(event *dto.RealtimeEvent, text string) error {
+	textToken, audioToken, err := service.CountTokenRealtime(b.info, *event, b.info.UpstreamModelName)
+	if err != nil {
+		return fmt.Errorf("error counting text token: %v", err)
+	}
+	if text != "" {
+		textToken += service.CountTextToken(text, b.info.UpstreamModelName)
+	}
+	b.localUsage.TotalTokens += textToken + audioToken
+	b.localUsage.OutputTokens += textToken + audioToken
+	b.localUsage.OutputTokenDetails.TextTokens += textToken
+	b.localUsage.OutputTokenDetails.AudioTokens += audioToken
+	return b.sendClient(event)
+}
+
+func (b *geminiLiveBridge) sendClient(event *dto.RealtimeEvent) error {
+	event.EventId = "event_" + common.GetRandomString(16)
+	if err := helper.WssObject(b.c, b.info.ClientWs, event); err != nil {
+		return fmt.Errorf("error writing to client: %v", err)
+	}
+	return nil
+}
+
+func (b *geminiLiveBridge) sendTarget(message *dto.GeminiLiveClientMessage) error {
+	if err := helper.WssObject(b.c, b.info.TargetWs, message); err != nil {
+		return fmt.Errorf("error writing to target: %v", err)
+	}
+	return nil
+}
+
+// convertGeminiLiveUsage 按模态拆分 Gemini 用量，思考 token 计入文本输出
+func convertGeminiLiveUsage(metadata *dto.GeminiLiveUsageMetadata) *dto.RealtimeUsage {
+	usage := &dto.RealtimeUsage{
+		InputTokens:  metadata.PromptTokenCount + metadata.ToolUsePromptTokenCount,
+		OutputTokens: metadata.ResponseTokenCount + metadata.ThoughtsTokenCount,
+	}
+	usage.InputTokenDetails.CachedTokens = metadata.CachedContentTokenCount
+	for _, detail := range metadata.PromptTokensDetails {
+		if detail.Modality == "AUDIO" {
+			usage.InputTokenDetails.AudioTokens += detail.TokenCount
+		}
+	}
+	usage.InputTokenDetails.TextTokens = usage.InputTokens - usage.InputTokenDetails.AudioTokens
+	for _, detail := range metadata.ResponseTokensDetails {
+		if detail.Modality == "AUDIO" {
+			usage.OutputTokenDetails.AudioTokens += detail.TokenCount
+		}
+	}
+	usage.OutputTokenDetails.TextTokens = usage.OutputTokens - usage.OutputTokenDetails.AudioTokens
+	usage.TotalTokens = usage.InputTokens + usage.OutputTokens
+	return usage
+}
@@ -1494,6 +1494,13 @@ func GeminiChatHandler(c *gin.Context, info *relaycommon.RelayInfo, resp *http.R
 			return nil, types.NewError(err, types.ErrorCodeBadResponseBody)
 		}
 		responseBody = claudeRespStr
+	case types.RelayFormatOpenAIResponses:
+		responsesResp := service.ChatCompletionsResponseToResponsesResponse(fullTextResponse, helper.GetResponsesID(c))
+		responsesRespStr, err := common.Marshal(responsesResp)
+		if err != nil {
+			return nil, types.NewError(err, types.ErrorCodeBadResponseBody)
+		}
+		responseBody = responsesRespStr
 	case types.RelayFormatGemini:
 		break
 	}
@@ -0,0 +1,62 @@
+package gemini
+
+import (
+	"testing"
+
+	"github.com/QuantumNous/new-api/dto"
+	relaycommon "github.com/QuantumNous/new-api/relay/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertGeminiLiveUsageSplitsModalities(t *testing.T) {
+	t.Parallel()
+
+	usage := convertGeminiLiveUsage(&dto.GeminiLiveUsageMetadata{
+		PromptTokenCount:        120,
+		CachedContentTokenCount: 20,
+		ResponseTokenCount:      300,
+		ThoughtsTokenCount:      10,
+		PromptTokensDetails: []dto.GeminiPromptTokensDetails{
+			{Modality: "TEXT", TokenCount: 20},
+			{Modality: "AUDIO", TokenCount: 100},
+		},
+		ResponseTokensDetails: []dto.GeminiPromptTokensDetails{
+			{Modality: "AUDIO", TokenCount: 300},
+		},
+	})
+
+	require.Equal(t, 120, usage.InputTokens)
+	require.Equal(t, 100, usage.InputTokenDetails.AudioTokens)
+	require.Equal(t, 20, usage.InputTokenDetails.TextTokens)
+	require.Equal(t, 20, usage.InputTokenDetails.CachedTokens)
+	require.Equal(t, 310, usage.OutputTokens)
+	require.Equal(t, 300, usage.OutputTokenDetails.AudioTokens)
+	require.Equal(t, 10, usage.OutputTokenDetails.TextTokens)
+	require.Equal(t, 430, usage.TotalTokens)
+}
+
+func TestGeminiLiveBuildSetupFromSession(t *testing.T) {
+	t.Parallel()
+
+	bridge := &geminiLiveBridge{
+		info: &relaycommon.RelayInfo{
+			ChannelMeta: &relaycommon.ChannelMeta{UpstreamModelName: "gemini-2.0-flash-live-001"},
+		},
+		session: dto.RealtimeSession{Modalities: []string{"text", "audio"}},
+	}
+	bridge.mergeSession(&dto.RealtimeSession{
+		Instructions: "be brief",
+		Voice:        "alloy",
+		Tools:        []dto.RealTimeTool{{Type: "function", Name: "get_weather"}},
+	})
+
+	setup := bridge.buildSetup()
+	require.Equal(t, "models/gemini-2.0-flash-live-001", setup.Model)
+	require.Equal(t, []string{"AUDIO"}, setup.GenerationConfig.ResponseModalities)
+	// OpenAI 音色不透传
+	require.Nil(t, setup.GenerationConfig.SpeechConfig)
+	require.Equal(t, "be brief", setup.SystemInstruction.Parts[0].Text)
+	require.Len(t, setup.Tools, 1)
+	require.NotNil(t, setup.OutputAudioTranscription)
+	require.Nil(t, setup.InputAudioTranscription)
+}
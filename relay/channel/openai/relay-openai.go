@@ -293,6 +293,13 @@ func OpenaiHandler(c *gin.Context, info *relaycommon.RelayInfo, resp *http.Respo
 			return nil, types.NewError(err, types.ErrorCodeBadResponseBody)
 		}
 		responseBody = geminiRespStr
+	case types.RelayFormatOpenAIResponses:
+		responsesResp := service.ChatCompletionsResponseToResponsesResponse(&simpleResponse, helper.GetResponsesID(c))
+		responsesRespStr, err := common.Marshal(responsesResp)
+		if err != nil {
+			return nil, types.NewError(err, types.ErrorCodeBadResponseBody)
+		}
+		responseBody = responsesRespStr
 	}
 
 	service.IOCopyBytesGracefully(c, resp, responseBody)
@@ -554,6 +561,9 @@ func preConsumeUsage(ctx *gin.Context, info *relaycommon.RelayInfo, usage *dto.R
 	totalUsage.OutputTokenDetails.TextTokens += usage.OutputTokenDetails.TextTokens
 	totalUsage.OutputTokenDetails.AudioTokens += usage.OutputTokenDetails.AudioTokens
 	// clear usage
+	if info.RealtimeBilling != nil {
+		return info.RealtimeBilling.AddUsage(usage)
+	}
 	err := service.PreWssConsumeQuota(ctx, info, usage)
 	return err
 }
@@ -1,6 +1,10 @@
 package common
 
-import "github.com/gin-gonic/gin"
+import (
+	"github.com/QuantumNous/new-api/dto"
+
+	"github.com/gin-gonic/gin"
+)
 
 // BillingSettler 抽象计费会话的生命周期操作。
 // 由 service.BillingSession 实现，存储在 RelayInfo 上以避免循环引用。
@@ -19,3 +23,9 @@ type BillingSettler interface {
 	// GetPreConsumedQuota 返回实际预扣的额度值（信任用户可能为 0）。
 	GetPreConsumedQuota() int
 }
+
+// RealtimeSettler 抽象实时会话的分段结算，由 service.RealtimeBilling 实现。
+type RealtimeSettler interface {
+	// AddUsage 累加一轮响应的用量，距上次结算超过结算间隔时立即扣费并记录日志。
+	AddUsage(usage *dto.RealtimeUsage) error
+}
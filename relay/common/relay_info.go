@@ -11,6 +11,7 @@ import (
 	"github.com/QuantumNous/new-api/constant"
 	"github.com/QuantumNous/new-api/dto"
 	relayconstant "github.com/QuantumNous/new-api/relay/constant"
+	"github.com/QuantumNous/new-api/service/openaicompat"
 	"github.com/QuantumNous/new-api/setting/model_setting"
 	"github.com/QuantumNous/new-api/types"
 
@@ -90,6 +91,7 @@ type RelayInfo struct {
 	UsingGroup        string // 使用的分组，当auto跨分组重试时，会变动
 	UserGroup         string // 用户所在分组
 	TokenUnlimited    bool
+	TokenOrgId        int // 组织令牌所属组织，非 0 时从组织钱包扣费
 	StartTime         time.Time
 	FirstResponseTime time.Time
 	isFirstResponse   bool
@@ -100,6 +102,7 @@ type RelayInfo struct {
 	UsePrice               bool
 	RelayMode              int
 	OriginModelName        string
+	FallbackFromModel      string // 主模型不可用时按降级链切换前的模型，为空表示未降级
 	RequestURLPath         string
 	ShouldIncludeUsage     bool
 	DisablePing            bool // 是否禁止向下游发送自定义 Ping
@@ -125,6 +128,8 @@ type RelayInfo struct {
 	// Billing 是计费会话，封装了预扣费/结算/退款的统一生命周期。
 	// 免费模型时为 nil。
 	Billing BillingSettler
+	// RealtimeBilling 实时会话的分段结算，仅在 WebSocket 会话期间存在。
+	RealtimeBilling RealtimeSettler
 	// BillingSource indicates whether this request is billed from wallet quota or subscription.
 	// "" or "wallet" => wallet; "subscription" => subscription
 	BillingSource string
@@ -144,6 +149,10 @@ type RelayInfo struct {
 	SubscriptionAmountUsedAfterPreConsume int64
 	IsClaudeBetaQuery                     bool // /v1/messages?beta=true
 	IsChannelTest                         bool // channel test request
+	ResponseCacheHit                      bool // 响应由响应缓存直接返回，未请求上游
+	StreamContinuations                   int  // 流式输出中途失败后切换渠道续写的次数
+	// ResponsesStreamState 上游为 chat 格式时，将流式输出转换为 /v1/responses 事件的状态
+	ResponsesStreamState *openaicompat.ChatToResponsesStreamState
 
 	PriceData types.PriceData
 
@@ -451,11 +460,13 @@ func genBaseRelayInfo(c *gin.Context, request dto.Request) *RelayInfo {
 		UserQuota:  common.GetContextKeyInt(c, constant.ContextKeyUserQuota),
 		UserEmail:  common.GetContextKeyString(c, constant.ContextKeyUserEmail),
 
-		OriginModelName: common.GetContextKeyString(c, constant.ContextKeyOriginalModel),
+		OriginModelName:   common.GetContextKeyString(c, constant.ContextKeyOriginalModel),
+		FallbackFromModel: common.GetContextKeyString(c, constant.ContextKeyModelFallbackFrom),
 
 		TokenId:        common.GetContextKeyInt(c, constant.ContextKeyTokenId),
 		TokenKey:       common.GetContextKeyString(c, constant.ContextKeyTokenKey),
 		TokenUnlimited: common.GetContextKeyBool(c, constant.ContextKeyTokenUnlimited),
+		TokenOrgId:     common.GetContextKeyInt(c, constant.ContextKeyTokenOrgId),
 		TokenGroup:     tokenGroup,
 
 		isFirstResponse: true,
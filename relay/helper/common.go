@@ -8,6 +8,8 @@ import (
 	"github.com/QuantumNous/new-api/common"
 	"github.com/QuantumNous/new-api/dto"
 	"github.com/QuantumNous/new-api/logger"
+	relaycommon "github.com/QuantumNous/new-api/relay/common"
+	"github.com/QuantumNous/new-api/service/openaicompat"
 	"github.com/QuantumNous/new-api/types"
 
 	"github.com/gin-gonic/gin"
@@ -72,6 +74,31 @@ func ClaudeChunkData(c *gin.Context, resp dto.ClaudeResponse, data string) {
 	_ = FlushWriter(c)
 }
 
+func ResponsesData(c *gin.Context, resp dto.ResponsesStreamResponse) error {
+	jsonData, err := common.Marshal(resp)
+	if err != nil {
+		common.SysError("error marshalling stream response: " + err.Error())
+		return err
+	}
+	ResponseChunkData(c, resp, string(jsonData))
+	return nil
+}
+
+// GetResponsesStreamState 获取把 chat 流转换为 /v1/responses 事件的状态，首次调用时创建
+func GetResponsesStreamState(c *gin.Context, info *relaycommon.RelayInfo) *openaicompat.ChatToResponsesStreamState {
+	if info.ResponsesStreamState == nil {
+		info.ResponsesStreamState = openaicompat.NewChatToResponsesStreamState(GetResponsesID(c), info.UpstreamModelName, int(common.GetTimestamp()))
+	}
+	return info.ResponsesStreamState
+}
+
+// FinishResponsesStream 结束转换后的 /v1/responses 流，发送 response.completed
+func FinishResponsesStream(c *gin.Context, info *relaycommon.RelayInfo, usage *dto.Usage) {
+	for _, resp := range GetResponsesStreamState(c, info).Finish(usage) {
+		_ = ResponsesData(c, resp)
+	}
+}
+
 func ResponseChunkData(c *gin.Context, resp dto.ResponsesStreamResponse, data string) {
 	c.Render(-1, common.CustomEvent{Data: fmt.Sprintf("event: %s\n", resp.Type)})
 	c.Render(-1, common.CustomEvent{Data: fmt.Sprintf("data: %s", data)})
@@ -160,6 +187,12 @@ func GetResponseID(c *gin.Context) string {
 	return fmt.Sprintf("chatcmpl-%s", logID)
 }
 
+// GetResponsesID 由其他格式转换而来的 /v1/responses 响应使用的 id
+func GetResponsesID(c *gin.Context) string {
+	logID := c.GetString(common.RequestIdKey)
+	return fmt.Sprintf("resp_%s", logID)
+}
+
 func GetLocalRealtimeID(c *gin.Context) string {
 	logID := c.GetString(common.RequestIdKey)
 	return fmt.Sprintf("evt_%s", logID)
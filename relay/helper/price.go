@@ -60,6 +60,7 @@ func ModelPriceHelper(c *gin.Context, info *relaycommon.RelayInfo, promptTokens
 	var cacheCreationRatio1h float64
 	var audioRatio float64
 	var audioCompletionRatio float64
+	var priceTiers []types.PriceTier
 	var freeModel bool
 	if !usePrice {
 		preConsumedTokens := common.Max(promptTokens, common.PreConsumedQuota)
@@ -87,7 +88,21 @@ func ModelPriceHelper(c *gin.Context, info *relaycommon.RelayInfo, promptTokens
 		imageRatio, _ = ratio_setting.GetImageRatio(info.OriginModelName)
 		audioRatio = ratio_setting.GetAudioRatio(info.OriginModelName)
 		audioCompletionRatio = ratio_setting.GetAudioCompletionRatio(info.OriginModelName)
+		priceTiers = buildPriceTiers(info.OriginModelName, types.PriceTier{
+			ModelRatio:           modelRatio,
+			CompletionRatio:      completionRatio,
+			CacheRatio:           cacheRatio,
+			CacheCreationRatio:   cacheCreationRatio,
+			CacheCreation5mRatio: cacheCreationRatio5m,
+			CacheCreation1hRatio: cacheCreationRatio1h,
+		})
 		ratio := modelRatio * groupRatioInfo.GroupRatio
+		// 预扣费按预估输入 token 命中的阶梯档位计算，结算时按实际输入 token 重新选择
+		for _, tier := range priceTiers {
+			if promptTokens > tier.Threshold {
+				ratio = tier.ModelRatio * groupRatioInfo.GroupRatio
+			}
+		}
 		preConsumedQuota = int(float64(preConsumedTokens) * ratio)
 	} else {
 		if meta.ImagePriceRatio != 0 {
@@ -130,6 +145,7 @@ func ModelPriceHelper(c *gin.Context, info *relaycommon.RelayInfo, promptTokens
 		CacheCreation5mRatio: cacheCreationRatio5m,
 		CacheCreation1hRatio: cacheCreationRatio1h,
 		QuotaToPreConsume:    preConsumedQuota,
+		PriceTiers:           priceTiers,
 	}
 
 	if common.DebugEnabled {
@@ -139,6 +155,33 @@ func ModelPriceHelper(c *gin.Context, info *relaycommon.RelayInfo, promptTokens
 	return priceData, nil
 }
 
+// buildPriceTiers 以基础倍率为底，生成模型的长上下文阶梯档位
+func buildPriceTiers(modelName string, base types.PriceTier) []types.PriceTier {
+	tiers, ok := ratio_setting.GetModelTieredRatio(modelName)
+	if !ok {
+		return nil
+	}
+	priceTiers := make([]types.PriceTier, 0, len(tiers))
+	for _, tier := range tiers {
+		priceTier := base
+		priceTier.Threshold = tier.Threshold
+		priceTier.ModelRatio = tier.ModelRatio
+		if tier.CompletionRatio > 0 {
+			priceTier.CompletionRatio = tier.CompletionRatio
+		}
+		if tier.CacheRatio != nil {
+			priceTier.CacheRatio = *tier.CacheRatio
+		}
+		if tier.CacheCreationRatio != nil {
+			priceTier.CacheCreationRatio = *tier.CacheCreationRatio
+			priceTier.CacheCreation5mRatio = *tier.CacheCreationRatio
+			priceTier.CacheCreation1hRatio = *tier.CacheCreationRatio * claudeCacheCreation1hMultiplier
+		}
+		priceTiers = append(priceTiers, priceTier)
+	}
+	return priceTiers
+}
+
 // ModelPriceHelperPerCall 按次计费的 PriceHelper (MJ、Task)
 func ModelPriceHelperPerCall(c *gin.Context, info *relaycommon.RelayInfo) types.PriceData {
 	groupRatioInfo := HandleGroupRatio(c, info)
@@ -0,0 +1,61 @@
+package helper
+
+import (
+	"testing"
+
+	"github.com/QuantumNous/new-api/setting/ratio_setting"
+	"github.com/QuantumNous/new-api/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildPriceTiersAndApply(t *testing.T) {
+	original := ratio_setting.ModelTieredRatio2JSONString()
+	t.Cleanup(func() {
+		require.NoError(t, ratio_setting.UpdateModelTieredRatioByJSONString(original))
+	})
+	require.NoError(t, ratio_setting.UpdateModelTieredRatioByJSONString(`{
+		"tiered-test-model": [
+			{"threshold": 500000, "model_ratio": 4, "cache_creation_ratio": 2},
+			{"threshold": 200000, "model_ratio": 3, "completion_ratio": 3.75}
+		]
+	}`))
+	require.Error(t, ratio_setting.UpdateModelTieredRatioByJSONString(`{"m": [{"threshold": 0, "model_ratio": 1}]}`))
+
+	priceData := types.PriceData{
+		ModelRatio:           1.5,
+		CompletionRatio:      5,
+		CacheRatio:           0.1,
+		CacheCreationRatio:   1.25,
+		CacheCreation5mRatio: 1.25,
+		CacheCreation1hRatio: 2,
+	}
+	priceData.PriceTiers = buildPriceTiers("tiered-test-model", types.PriceTier{
+		ModelRatio:           priceData.ModelRatio,
+		CompletionRatio:      priceData.CompletionRatio,
+		CacheRatio:           priceData.CacheRatio,
+		CacheCreationRatio:   priceData.CacheCreationRatio,
+		CacheCreation5mRatio: priceData.CacheCreation5mRatio,
+		CacheCreation1hRatio: priceData.CacheCreation1hRatio,
+	})
+	require.Len(t, priceData.PriceTiers, 2)
+	require.Equal(t, 200000, priceData.PriceTiers[0].Threshold)
+
+	priceData.ApplyPriceTier(250000)
+	require.Equal(t, 200000, priceData.TierThreshold)
+	require.Equal(t, 3.0, priceData.ModelRatio)
+	require.Equal(t, 3.75, priceData.CompletionRatio)
+	require.Equal(t, 0.1, priceData.CacheRatio)
+
+	priceData.ApplyPriceTier(600000)
+	require.Equal(t, 4.0, priceData.ModelRatio)
+	// 未配置补全倍率时沿用基础倍率
+	require.Equal(t, 5.0, priceData.CompletionRatio)
+	require.Equal(t, 2.0, priceData.CacheCreation5mRatio)
+	require.InDelta(t, 2*claudeCacheCreation1hMultiplier, priceData.CacheCreation1hRatio, 1e-9)
+
+	// 实际输入回落到阈值以下时恢复基础价格
+	priceData.ApplyPriceTier(1000)
+	require.Equal(t, 0, priceData.TierThreshold)
+	require.Equal(t, 1.5, priceData.ModelRatio)
+	require.Equal(t, 5.0, priceData.CompletionRatio)
+}
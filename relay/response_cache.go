@@ -0,0 +1,63 @@
+package relay
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/dto"
+	relaycommon "github.com/QuantumNous/new-api/relay/common"
+	"github.com/QuantumNous/new-api/relay/helper"
+	"github.com/QuantumNous/new-api/service"
+	"github.com/QuantumNous/new-api/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ResponseCacheHelper 使用缓存的响应回复下游，并按缓存计费倍率结算
+func ResponseCacheHelper(c *gin.Context, info *relaycommon.RelayInfo, entry *service.ResponseCacheEntry) *types.NewAPIError {
+	info.ResponseCacheHit = true
+	// 缓存命中不经过渠道，使用空的渠道信息保证日志与计费逻辑可用
+	info.ChannelMeta = &relaycommon.ChannelMeta{UpstreamModelName: info.OriginModelName}
+	if entry.UsageFormat != "" {
+		info.FinalRequestRelayFormat = entry.UsageFormat
+	}
+	info.SetFirstResponseTime()
+
+	switch {
+	case !info.IsStream:
+		c.Data(http.StatusOK, entry.ContentType, entry.Body)
+	case entry.Stream:
+		helper.SetEventStreamHeaders(c)
+		c.Status(http.StatusOK)
+		if _, err := c.Writer.Write(entry.Body); err != nil {
+			return types.NewError(fmt.Errorf("write cached response failed: %w", err), types.ErrorCodeBadResponse, types.ErrOptionWithSkipRetry())
+		}
+		_ = helper.FlushWriter(c)
+	default:
+		includeUsage := true
+		if textReq, ok := info.Request.(*dto.GeneralOpenAIRequest); ok && textReq.StreamOptions != nil {
+			includeUsage = textReq.StreamOptions.IncludeUsage
+		}
+		helper.SetEventStreamHeaders(c)
+		for _, event := range service.SynthesizeResponseCacheStream(info.RelayFormat, entry.Body, includeUsage) {
+			if event.Event != "" {
+				c.Render(-1, common.CustomEvent{Data: fmt.Sprintf("event: %s\n", event.Event)})
+				c.Render(-1, common.CustomEvent{Data: fmt.Sprintf("data: %s\n", event.Data)})
+				_ = helper.FlushWriter(c)
+				continue
+			}
+			if err := helper.StringData(c, event.Data); err != nil {
+				return types.NewError(err, types.ErrorCodeBadResponse, types.ErrOptionWithSkipRetry())
+			}
+		}
+	}
+
+	usage := entry.Usage
+	if info.RelayFormat == types.RelayFormatClaude {
+		service.PostClaudeConsumeQuota(c, info, &usage)
+	} else {
+		postConsumeQuota(c, info, &usage)
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package relay
+
+import (
+	"net/http"
+
+	"github.com/QuantumNous/new-api/dto"
+	"github.com/QuantumNous/new-api/pkg/tracing"
+	"github.com/QuantumNous/new-api/relay/channel"
+	relaycommon "github.com/QuantumNous/new-api/relay/common"
+	"github.com/QuantumNous/new-api/types"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// doResponseWithTrace 处理上游响应并记录为 span，流式请求时即为整个流的持续时间
+func doResponseWithTrace(c *gin.Context, adaptor channel.Adaptor, resp *http.Response, info *relaycommon.RelayInfo) (any, *types.NewAPIError) {
+	span := tracing.Start(c, "relay.response", attribute.Bool("newapi.stream", info.IsStream))
+	usage, newAPIError := adaptor.DoResponse(c, resp, info)
+	if u, ok := usage.(*dto.Usage); ok && u != nil {
+		span.SetAttributes(
+			attribute.Int("newapi.prompt_tokens", u.PromptTokens),
+			attribute.Int("newapi.completion_tokens", u.CompletionTokens),
+		)
+	}
+	span.EndWithAPIError(newAPIError)
+	return usage, newAPIError
+}
@@ -0,0 +1,484 @@
+package relay
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
+	"github.com/QuantumNous/new-api/dto"
+	"github.com/QuantumNous/new-api/logger"
+	"github.com/QuantumNous/new-api/middleware"
+	"github.com/QuantumNous/new-api/model"
+	"github.com/QuantumNous/new-api/relay/channel"
+	relaycommon "github.com/QuantumNous/new-api/relay/common"
+	"github.com/QuantumNous/new-api/relay/helper"
+	"github.com/QuantumNous/new-api/service"
+	"github.com/QuantumNous/new-api/setting/model_setting"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+	"github.com/QuantumNous/new-api/types"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// 选择续写渠道时最多尝试的次数
+const streamContinuationChannelPicks = 5
+
+// streamContinuationOpener 以已输出的文本作为预填充，在新渠道上重新发起流式请求
+type streamContinuationOpener func(prefill string) (*http.Response, *types.NewAPIError)
+
+// streamContinuation 包装上游的流式响应体。上游在输出结束标记前中断（连接错误、提前 EOF 或流内 error 事件）时，
+// 切换渠道继续生成，并把新的流改写后拼接到原有的流上，流处理器感知不到切换。
+// 仅支持 OpenAI chat.completion.chunk 与 Claude messages 两种上游流格式，且只续写纯文本输出。
+type streamContinuation struct {
+	c      *gin.Context
+	info   *relaycommon.RelayInfo
+	format types.RelayFormat // 上游流格式
+	open   streamContinuationOpener
+	limit  int
+
+	mu      sync.Mutex // 保护 body 与 closed，流处理器可能在读取过程中关闭响应体
+	body    io.ReadCloser
+	closed  bool
+	reader  *bufio.Reader
+	pending []byte
+	done    bool
+
+	attempts   int  // 已续写次数
+	eligible   bool // 输出中出现工具调用、思考内容或多个 choice 时不再续写
+	finished   bool // 当前上游已输出结束标记
+	hasUsage   bool // 当前上游返回了 usage
+	text       strings.Builder
+	attempt    strings.Builder // 当前上游输出的文本
+	prefill    string          // 当前上游请求使用的预填充
+	extraUsage dto.Usage
+
+	// OpenAI
+	responseId string
+	created    int64
+
+	// Claude：续写流的 content block 下标需要接在原有的 block 之后
+	lastIndex   int
+	blockOpen   bool
+	mergeBlock  bool // 续写流的第一个文本 block 并入原来未结束的 block
+	indexOffset int
+}
+
+// shouldContinueStream 判断本次请求是否可以在中途失败后续写
+func shouldContinueStream(c *gin.Context, info *relaycommon.RelayInfo, resp *http.Response) bool {
+	if operation_setting.GetMaxStreamContinuations() <= 0 || resp == nil {
+		return false
+	}
+	if !info.IsStream || info.IsChannelTest || info.ChannelMeta == nil {
+		return false
+	}
+	if _, ok := c.Get("specific_channel_id"); ok {
+		return false
+	}
+	if model_setting.GetGlobalSettings().PassThroughRequestEnabled || info.ChannelSetting.PassThroughBodyEnabled {
+		return false
+	}
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		return false
+	}
+	switch info.GetFinalRequestRelayFormat() {
+	case types.RelayFormatOpenAI, types.RelayFormatClaude:
+		return true
+	}
+	return false
+}
+
+func newStreamContinuation(c *gin.Context, info *relaycommon.RelayInfo, resp *http.Response, open streamContinuationOpener) *streamContinuation {
+	s := &streamContinuation{
+		c:        c,
+		info:     info,
+		format:   info.GetFinalRequestRelayFormat(),
+		open:     open,
+		limit:    operation_setting.GetMaxStreamContinuations(),
+		body:     resp.Body,
+		reader:   bufio.NewReader(resp.Body),
+		eligible: true,
+		// 原始流在第一个 content block 之前中断时，续写流的下标从 0 开始
+		lastIndex: -1,
+	}
+	resp.Body = s
+	return s
+}
+
+func (s *streamContinuation) Read(p []byte) (int, error) {
+	for len(s.pending) == 0 {
+		if s.done || s.isClosed() {
+			return 0, io.EOF
+		}
+		line, err := s.reader.ReadBytes('\n')
+		if len(line) > 0 {
+			if err != nil {
+				line = append(line, '\n')
+			}
+			out, failed := s.handleLine(line)
+			if failed && s.continueStream() {
+				// 丢弃上游的错误事件，改由续写的流继续输出
+				continue
+			}
+			s.pending = append(s.pending, out...)
+		}
+		if err != nil {
+			if s.finished || !s.continueStream() {
+				s.done = true
+			}
+		}
+	}
+	n := copy(p, s.pending)
+	s.pending = s.pending[n:]
+	return n, nil
+}
+
+func (s *streamContinuation) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return s.body.Close()
+}
+
+func (s *streamContinuation) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+// swapBody 替换为续写请求的响应体，流已被关闭时返回 false
+func (s *streamContinuation) swapBody(body io.ReadCloser) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		_ = body.Close()
+		return false
+	}
+	_ = s.body.Close()
+	s.body = body
+	s.reader = bufio.NewReader(body)
+	return true
+}
+
+// continueStream 切换到新渠道继续输出，失败时返回 false，此时保持原有行为直接结束流
+func (s *streamContinuation) continueStream() bool {
+	if s.finished || !s.eligible || s.attempts >= s.limit || s.isClosed() {
+		return false
+	}
+	if s.c.Request.Context().Err() != nil {
+		return false
+	}
+	s.attempts++
+	prefill := s.text.String()
+	resp, apiErr := s.open(prefill)
+	if apiErr != nil {
+		logger.LogWarn(s.c, fmt.Sprintf("stream continuation failed: %s", apiErr.Error()))
+		return false
+	}
+	if !s.swapBody(resp.Body) {
+		return false
+	}
+	s.recordFailedAttempt()
+	s.prefill = prefill
+	s.attempt.Reset()
+	s.hasUsage = false
+	if s.format == types.RelayFormatClaude {
+		s.mergeBlock = s.blockOpen
+		s.indexOffset = s.lastIndex
+		if !s.blockOpen {
+			s.indexOffset = s.lastIndex + 1
+		}
+	}
+	channelId := common.GetContextKeyInt(s.c, constant.ContextKeyChannelId)
+	s.info.StreamContinuations = s.attempts
+	logger.LogInfo(s.c, fmt.Sprintf("stream interrupted after %d chars, continuing on channel #%d", len([]rune(prefill)), channelId))
+	return true
+}
+
+// recordFailedAttempt 记录中断的上游请求的用量，在结算时计入
+func (s *streamContinuation) recordFailedAttempt() {
+	completion := service.EstimateTokenByModel(s.info.UpstreamModelName, s.attempt.String())
+	s.extraUsage.CompletionTokens += completion
+	if s.format == types.RelayFormatOpenAI {
+		// Claude 的输入用量取自续写流的 message_start，OpenAI 流只能按估算的提示词与预填充计算
+		s.extraUsage.PromptTokens += s.info.GetEstimatePromptTokens() + service.EstimateTokenByModel(s.info.UpstreamModelName, s.prefill)
+	}
+}
+
+// applyUsage 将中断的上游请求的用量合并到最终结算的用量中
+func (s *streamContinuation) applyUsage(usage *dto.Usage) {
+	if s.attempts == 0 || usage == nil {
+		return
+	}
+	usage.PromptTokens += s.extraUsage.PromptTokens
+	if s.format == types.RelayFormatOpenAI && !s.hasUsage {
+		// 最后一个上游没有返回 usage 时，补全用量已按全部输出文本估算，只需补上预填充部分的输入
+		usage.PromptTokens += service.EstimateTokenByModel(s.info.UpstreamModelName, s.prefill)
+	} else {
+		usage.CompletionTokens += s.extraUsage.CompletionTokens
+	}
+	usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+}
+
+// handleLine 解析并改写一行 SSE 数据，failed 表示上游在流内返回了错误
+func (s *streamContinuation) handleLine(line []byte) (out []byte, failed bool) {
+	trimmed := bytes.TrimSpace(line)
+	if !bytes.HasPrefix(trimmed, []byte("data:")) {
+		return line, false
+	}
+	data := bytes.TrimSpace(trimmed[5:])
+	if bytes.HasPrefix(data, []byte("[DONE]")) {
+		s.finished = true
+		return line, false
+	}
+	if !gjson.ValidBytes(data) {
+		return line, false
+	}
+	var keep bool
+	if s.format == types.RelayFormatClaude {
+		data, keep, failed = s.handleClaudeData(data)
+	} else {
+		data, keep, failed = s.handleOpenAIData(data)
+	}
+	if !keep {
+		return nil, false
+	}
+	return append(append([]byte("data: "), data...), '\n'), failed
+}
+
+func (s *streamContinuation) handleOpenAIData(data []byte) ([]byte, bool, bool) {
+	chunk := gjson.ParseBytes(data)
+	if chunk.Get("error").Exists() {
+		return data, true, true
+	}
+	if s.attempts == 0 {
+		if s.responseId == "" {
+			s.responseId = chunk.Get("id").String()
+			s.created = chunk.Get("created").Int()
+		}
+	} else if s.responseId != "" {
+		// 续写的 chunk 沿用原始响应的 id 与创建时间
+		data, _ = sjson.SetBytes(data, "id", s.responseId)
+		data, _ = sjson.SetBytes(data, "created", s.created)
+	}
+	for _, choice := range chunk.Get("choices").Array() {
+		if choice.Get("index").Int() != 0 {
+			s.eligible = false
+		}
+		delta := choice.Get("delta")
+		if content := delta.Get("content").String(); content != "" {
+			s.text.WriteString(content)
+			s.attempt.WriteString(content)
+		}
+		if delta.Get("tool_calls").Exists() || delta.Get("reasoning_content").String() != "" || delta.Get("reasoning").String() != "" {
+			s.eligible = false
+		}
+		if choice.Get("finish_reason").String() != "" {
+			s.finished = true
+		}
+	}
+	if usage := chunk.Get("usage"); usage.Get("prompt_tokens").Int() > 0 || usage.Get("completion_tokens").Int() > 0 {
+		s.hasUsage = true
+	}
+	return data, true, false
+}
+
+func (s *streamContinuation) handleClaudeData(data []byte) ([]byte, bool, bool) {
+	event := gjson.ParseBytes(data)
+	switch event.Get("type").String() {
+	case "error":
+		return data, true, true
+	case "message_start":
+		if s.attempts == 0 {
+			return data, true, false
+		}
+		// 续写流的 message_start 不再下发，输入用量在结算时计入
+		s.extraUsage.PromptTokens += int(event.Get("message.usage.input_tokens").Int())
+		return data, false, false
+	case "content_block_start":
+		index := int(event.Get("index").Int())
+		isText := event.Get("content_block.type").String() == "text"
+		if !isText {
+			s.eligible = false
+		}
+		if s.attempts > 0 {
+			if s.mergeBlock && index == 0 && isText {
+				return data, false, false
+			}
+			index += s.indexOffset
+			data, _ = sjson.SetBytes(data, "index", index)
+		}
+		s.lastIndex = index
+		s.blockOpen = true
+	case "content_block_delta":
+		delta := event.Get("delta")
+		if delta.Get("type").String() == "text_delta" {
+			s.text.WriteString(delta.Get("text").String())
+			s.attempt.WriteString(delta.Get("text").String())
+		} else {
+			s.eligible = false
+		}
+		if s.attempts > 0 {
+			data, _ = sjson.SetBytes(data, "index", int(event.Get("index").Int())+s.indexOffset)
+		}
+	case "content_block_stop":
+		if s.attempts > 0 {
+			data, _ = sjson.SetBytes(data, "index", int(event.Get("index").Int())+s.indexOffset)
+		}
+		s.blockOpen = false
+	case "message_delta":
+		if event.Get("delta.stop_reason").String() != "" {
+			s.finished = true
+		}
+		if s.attempts > 0 {
+			// 输入用量以原始请求的 message_start 为准，续写请求的输入已单独计入
+			for _, field := range []string{"usage.input_tokens", "usage.cache_read_input_tokens", "usage.cache_creation_input_tokens"} {
+				data, _ = sjson.DeleteBytes(data, field)
+			}
+		}
+	case "message_stop":
+		s.finished = true
+	}
+	return data, true, false
+}
+
+// selectContinuationChannel 选择一个尚未使用过、且与当前渠道 API 类型相同的渠道，保证上游流格式一致
+func selectContinuationChannel(c *gin.Context, info *relaycommon.RelayInfo) (*model.Channel, error) {
+	used := c.GetStringSlice("use_channel")
+	retryParam := &service.RetryParam{
+		Ctx:        c,
+		TokenGroup: info.TokenGroup,
+		ModelName:  info.OriginModelName,
+		Retry:      common.GetPointer(0),
+	}
+	for i := 0; i < streamContinuationChannelPicks; i++ {
+		channel, _, err := service.CacheGetRandomSatisfiedChannel(retryParam)
+		if err != nil {
+			return nil, err
+		}
+		if channel == nil {
+			break
+		}
+		apiType, _ := common.ChannelType2APIType(channel.Type)
+		if apiType == info.ApiType && !slices.Contains(used, strconv.Itoa(channel.Id)) {
+			return channel, nil
+		}
+		retryParam.IncreaseRetry()
+	}
+	return nil, errors.New("no other channel available for continuation")
+}
+
+// openStreamContinuation 选择新渠道并发起续写请求，build 负责在新渠道上构造请求体
+func openStreamContinuation(c *gin.Context, info *relaycommon.RelayInfo, build func(contInfo *relaycommon.RelayInfo, adaptor channel.Adaptor) (io.Reader, *types.NewAPIError)) (*http.Response, *types.NewAPIError) {
+	selected, err := selectContinuationChannel(c, info)
+	if err != nil {
+		return nil, types.NewError(err, types.ErrorCodeGetChannelFailed)
+	}
+	if apiErr := middleware.SetupContextForSelectedChannel(c, selected, info.OriginModelName); apiErr != nil {
+		return nil, apiErr
+	}
+	c.Set("use_channel", append(c.GetStringSlice("use_channel"), strconv.Itoa(selected.Id)))
+
+	// 流处理器继续使用原来的 info，续写请求使用新渠道的副本
+	contInfo := *info
+	contInfo.InitChannelMeta(c)
+	adaptor := GetAdaptor(contInfo.ApiType)
+	if adaptor == nil {
+		return nil, types.NewError(fmt.Errorf("invalid api type: %d", contInfo.ApiType), types.ErrorCodeInvalidApiType)
+	}
+	requestBody, apiErr := build(&contInfo, adaptor)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	resp, err := adaptor.DoRequest(c, &contInfo, requestBody)
+	if err != nil {
+		return nil, types.NewOpenAIError(err, types.ErrorCodeDoRequestFailed, http.StatusInternalServerError)
+	}
+	httpResp, ok := resp.(*http.Response)
+	if !ok || httpResp == nil {
+		return nil, types.NewError(errors.New("empty continuation response"), types.ErrorCodeBadResponse)
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, service.RelayErrorHandler(c.Request.Context(), httpResp, false)
+	}
+	if !strings.HasPrefix(httpResp.Header.Get("Content-Type"), "text/event-stream") {
+		service.CloseResponseBodyGracefully(httpResp)
+		return nil, types.NewError(errors.New("continuation response is not a stream"), types.ErrorCodeBadResponse)
+	}
+	return httpResp, nil
+}
+
+// continuationPrefill 上游要求 assistant 预填充不能以空白结尾
+func continuationPrefill(prefill string) string {
+	return strings.TrimRight(prefill, " \t\r\n")
+}
+
+// openTextContinuation 在 chat/completions 请求末尾追加已输出的 assistant 文本后重新请求
+func openTextContinuation(c *gin.Context, info *relaycommon.RelayInfo, prefill string) (*http.Response, *types.NewAPIError) {
+	return openStreamContinuation(c, info, func(contInfo *relaycommon.RelayInfo, adaptor channel.Adaptor) (io.Reader, *types.NewAPIError) {
+		textReq, ok := info.Request.(*dto.GeneralOpenAIRequest)
+		if !ok {
+			return nil, types.NewError(fmt.Errorf("invalid request type %T", info.Request), types.ErrorCodeInvalidRequest)
+		}
+		request, err := common.DeepCopy(textReq)
+		if err != nil {
+			return nil, types.NewError(err, types.ErrorCodeInvalidRequest)
+		}
+		if prefill = continuationPrefill(prefill); prefill != "" {
+			last := len(request.Messages) - 1
+			if last >= 0 && request.Messages[last].Role == "assistant" && request.Messages[last].IsStringContent() {
+				request.Messages[last].SetStringContent(request.Messages[last].StringContent() + prefill)
+			} else {
+				message := dto.Message{Role: "assistant"}
+				message.SetStringContent(prefill)
+				request.Messages = append(request.Messages, message)
+			}
+		}
+		if err = helper.ModelMappedHelper(c, contInfo, request); err != nil {
+			return nil, types.NewError(err, types.ErrorCodeChannelModelMappedError)
+		}
+		applyStreamOptions(contInfo, request)
+		adaptor.Init(contInfo)
+		return buildTextRequestBody(c, contInfo, adaptor, request)
+	})
+}
+
+// openClaudeContinuation 在 messages 请求末尾追加已输出的 assistant 文本后重新请求
+func openClaudeContinuation(c *gin.Context, info *relaycommon.RelayInfo, prefill string) (*http.Response, *types.NewAPIError) {
+	return openStreamContinuation(c, info, func(contInfo *relaycommon.RelayInfo, adaptor channel.Adaptor) (io.Reader, *types.NewAPIError) {
+		claudeReq, ok := info.Request.(*dto.ClaudeRequest)
+		if !ok {
+			return nil, types.NewError(fmt.Errorf("invalid request type %T", info.Request), types.ErrorCodeInvalidRequest)
+		}
+		request, err := common.DeepCopy(claudeReq)
+		if err != nil {
+			return nil, types.NewError(err, types.ErrorCodeInvalidRequest)
+		}
+		if prefill = continuationPrefill(prefill); prefill != "" {
+			last := len(request.Messages) - 1
+			if last >= 0 && request.Messages[last].Role == "assistant" && request.Messages[last].IsStringContent() {
+				request.Messages[last].SetStringContent(request.Messages[last].GetStringContent() + prefill)
+			} else {
+				message := dto.ClaudeMessage{Role: "assistant"}
+				message.SetStringContent(prefill)
+				request.Messages = append(request.Messages, message)
+			}
+		}
+		if err = helper.ModelMappedHelper(c, contInfo, request); err != nil {
+			return nil, types.NewError(err, types.ErrorCodeChannelModelMappedError)
+		}
+		adaptor.Init(contInfo)
+		prepareClaudeRequest(c, contInfo, request)
+		return buildClaudeRequestBody(c, contInfo, adaptor, request)
+	})
+}
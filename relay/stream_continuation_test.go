@@ -0,0 +1,170 @@
+package relay
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/QuantumNous/new-api/dto"
+	relaycommon "github.com/QuantumNous/new-api/relay/common"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+	"github.com/QuantumNous/new-api/types"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func newContinuationTestContext(t *testing.T) *gin.Context {
+	gin.SetMode(gin.TestMode)
+	setting := operation_setting.GetStreamContinuationSetting()
+	original := *setting
+	t.Cleanup(func() { *setting = original })
+	setting.Enabled = true
+	setting.MaxContinuations = 1
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	return c
+}
+
+func sseResponse(lines ...string) *http.Response {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"text/event-stream"}},
+		Body:       io.NopCloser(strings.NewReader(strings.Join(lines, "\n") + "\n")),
+	}
+}
+
+func dataLines(output string) []gjson.Result {
+	var events []gjson.Result
+	for _, line := range strings.Split(output, "\n") {
+		if data, ok := strings.CutPrefix(line, "data: "); ok && data != "[DONE]" {
+			events = append(events, gjson.Parse(data))
+		}
+	}
+	return events
+}
+
+func TestStreamContinuation_OpenAISplicesAfterEOF(t *testing.T) {
+	c := newContinuationTestContext(t)
+	info := &relaycommon.RelayInfo{
+		IsStream:                true,
+		FinalRequestRelayFormat: types.RelayFormatOpenAI,
+		ChannelMeta:             &relaycommon.ChannelMeta{UpstreamModelName: "gpt-4o"},
+	}
+	info.SetEstimatePromptTokens(7)
+
+	resp := sseResponse(
+		`data: {"id":"a","created":1,"choices":[{"index":0,"delta":{"role":"assistant","content":""}}]}`,
+		`data: {"id":"a","created":1,"choices":[{"index":0,"delta":{"content":"Hello"}}]}`,
+	)
+	var prefills []string
+	continuation := newStreamContinuation(c, info, resp, func(prefill string) (*http.Response, *types.NewAPIError) {
+		prefills = append(prefills, prefill)
+		return sseResponse(
+			`data: {"id":"b","created":2,"choices":[{"index":0,"delta":{"content":" world"},"finish_reason":null}]}`,
+			`data: {"id":"b","created":2,"choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`,
+			`data: {"id":"b","created":2,"choices":[],"usage":{"prompt_tokens":10,"completion_tokens":2,"total_tokens":12}}`,
+			`data: [DONE]`,
+		), nil
+	})
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, []string{"Hello"}, prefills)
+	require.Equal(t, 1, info.StreamContinuations)
+
+	events := dataLines(string(body))
+	require.Len(t, events, 5)
+	for _, event := range events {
+		require.Equal(t, "a", event.Get("id").String())
+		require.Equal(t, int64(1), event.Get("created").Int())
+	}
+	require.Contains(t, string(body), "data: [DONE]")
+
+	usage := &dto.Usage{PromptTokens: 10, CompletionTokens: 2}
+	continuation.applyUsage(usage)
+	require.Equal(t, 17, usage.PromptTokens)
+	require.Greater(t, usage.CompletionTokens, 2)
+	require.Equal(t, usage.PromptTokens+usage.CompletionTokens, usage.TotalTokens)
+}
+
+func TestStreamContinuation_ClaudeRemapsBlocksAfterErrorEvent(t *testing.T) {
+	c := newContinuationTestContext(t)
+	info := &relaycommon.RelayInfo{
+		IsStream:                true,
+		FinalRequestRelayFormat: types.RelayFormatClaude,
+		ChannelMeta:             &relaycommon.ChannelMeta{UpstreamModelName: "claude-sonnet-4"},
+	}
+
+	resp := sseResponse(
+		`event: message_start`,
+		`data: {"type":"message_start","message":{"id":"msg_1","usage":{"input_tokens":20,"output_tokens":1}}}`,
+		`data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"A"}}`,
+		`data: {"type":"content_block_stop","index":0}`,
+		`data: {"type":"content_block_start","index":1,"content_block":{"type":"text","text":""}}`,
+		`data: {"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"Hi"}}`,
+		`event: error`,
+		`data: {"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`,
+	)
+	var prefills []string
+	continuation := newStreamContinuation(c, info, resp, func(prefill string) (*http.Response, *types.NewAPIError) {
+		prefills = append(prefills, prefill)
+		return sseResponse(
+			`data: {"type":"message_start","message":{"id":"msg_2","usage":{"input_tokens":22,"output_tokens":1}}}`,
+			`data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+			`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":" there"}}`,
+			`data: {"type":"content_block_stop","index":0}`,
+			`data: {"type":"content_block_start","index":1,"content_block":{"type":"text","text":""}}`,
+			`data: {"type":"content_block_stop","index":1}`,
+			`data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"input_tokens":22,"output_tokens":3}}`,
+			`data: {"type":"message_stop"}`,
+		), nil
+	})
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, []string{"AHi"}, prefills)
+	require.NotContains(t, string(body), "overloaded_error")
+	require.NotContains(t, string(body), "msg_2")
+
+	var indexes []int64
+	for _, event := range dataLines(string(body)) {
+		if event.Get("index").Exists() {
+			indexes = append(indexes, event.Get("index").Int())
+		}
+		if event.Get("type").String() == "message_delta" {
+			require.False(t, event.Get("usage.input_tokens").Exists())
+		}
+	}
+	// 续写流的第一个 block 并入原来未结束的 block 1，之后的 block 顺延
+	require.Equal(t, []int64{0, 0, 0, 1, 1, 1, 1, 2, 2}, indexes)
+
+	usage := &dto.Usage{PromptTokens: 20, CompletionTokens: 3}
+	continuation.applyUsage(usage)
+	require.Equal(t, 42, usage.PromptTokens)
+	require.Greater(t, usage.CompletionTokens, 3)
+}
+
+func TestStreamContinuation_SkipsToolCalls(t *testing.T) {
+	c := newContinuationTestContext(t)
+	info := &relaycommon.RelayInfo{
+		IsStream:                true,
+		FinalRequestRelayFormat: types.RelayFormatOpenAI,
+		ChannelMeta:             &relaycommon.ChannelMeta{UpstreamModelName: "gpt-4o"},
+	}
+	resp := sseResponse(
+		`data: {"id":"a","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"name":"f"}}]}}]}`,
+	)
+	newStreamContinuation(c, info, resp, func(prefill string) (*http.Response, *types.NewAPIError) {
+		t.Fatal("tool call output must not be continued")
+		return nil, nil
+	})
+	_, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, 0, info.StreamContinuations)
+}
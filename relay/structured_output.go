@@ -0,0 +1,247 @@
+package relay
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/dto"
+	"github.com/QuantumNous/new-api/logger"
+	relaycommon "github.com/QuantumNous/new-api/relay/common"
+	relayconstant "github.com/QuantumNous/new-api/relay/constant"
+	"github.com/QuantumNous/new-api/service"
+	"github.com/QuantumNous/new-api/setting/model_setting"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+	"github.com/QuantumNous/new-api/types"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+)
+
+// structuredOutputWriter 暂存一次上游响应，校验通过后再写给下游
+type structuredOutputWriter struct {
+	gin.ResponseWriter
+	header http.Header
+	buf    bytes.Buffer
+	status int
+}
+
+func newStructuredOutputWriter(w gin.ResponseWriter) *structuredOutputWriter {
+	return &structuredOutputWriter{
+		ResponseWriter: w,
+		header:         w.Header().Clone(),
+		status:         http.StatusOK,
+	}
+}
+
+func (w *structuredOutputWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *structuredOutputWriter) WriteHeader(code int) {
+	if code > 0 {
+		w.status = code
+	}
+}
+
+func (w *structuredOutputWriter) WriteHeaderNow() {}
+
+func (w *structuredOutputWriter) Write(data []byte) (int, error) {
+	return w.buf.Write(data)
+}
+
+func (w *structuredOutputWriter) WriteString(s string) (int, error) {
+	return w.buf.WriteString(s)
+}
+
+func (w *structuredOutputWriter) Status() int {
+	return w.status
+}
+
+func (w *structuredOutputWriter) Size() int {
+	return w.buf.Len()
+}
+
+func (w *structuredOutputWriter) Written() bool {
+	return w.buf.Len() > 0
+}
+
+func (w *structuredOutputWriter) Flush() {}
+
+// flushTo 将暂存的响应写到真实的 writer
+func (w *structuredOutputWriter) flushTo(dst gin.ResponseWriter) {
+	for key, values := range w.header {
+		dst.Header()[key] = values
+	}
+	dst.WriteHeader(w.status)
+	_, _ = dst.Write(w.buf.Bytes())
+	dst.Flush()
+}
+
+// content 提取 assistant 消息文本，ok 为 false 表示响应中含工具调用等无法校验的内容
+func (w *structuredOutputWriter) content(stream bool) (content string, ok bool) {
+	if !stream {
+		message := gjson.GetBytes(w.buf.Bytes(), "choices.0.message")
+		if message.Get("tool_calls.0").Exists() {
+			return "", false
+		}
+		return message.Get("content").String(), true
+	}
+	var builder strings.Builder
+	scanner := bufio.NewScanner(bytes.NewReader(w.buf.Bytes()))
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if !bytes.HasPrefix(line, []byte("data:")) {
+			continue
+		}
+		data := bytes.TrimSpace(line[5:])
+		if !gjson.ValidBytes(data) {
+			continue
+		}
+		delta := gjson.GetBytes(data, "choices.0.delta")
+		if delta.Get("tool_calls.0").Exists() {
+			return "", false
+		}
+		builder.WriteString(delta.Get("content").String())
+	}
+	return builder.String(), true
+}
+
+type structuredOutputEnforcer struct {
+	c       *gin.Context
+	info    *relaycommon.RelayInfo
+	setting *operation_setting.StructuredOutputSetting
+	schema  any
+	// base 为未经适配器改写的请求副本，修复请求在其基础上构造
+	base *dto.GeneralOpenAIRequest
+}
+
+// newStructuredOutputEnforcer 请求声明了 json_schema 且开启校验时返回校验器，否则返回 nil
+func newStructuredOutputEnforcer(c *gin.Context, info *relaycommon.RelayInfo, request *dto.GeneralOpenAIRequest) *structuredOutputEnforcer {
+	setting := operation_setting.GetStructuredOutputSetting()
+	if !setting.Enabled || info.RelayMode != relayconstant.RelayModeChatCompletions || request.N > 1 {
+		return nil
+	}
+	if info.IsStream && !setting.BufferStream {
+		return nil
+	}
+	// 透传请求体时无法改写请求
+	if model_setting.GetGlobalSettings().PassThroughRequestEnabled || info.ChannelSetting.PassThroughBodyEnabled {
+		return nil
+	}
+	if request.ResponseFormat == nil || request.ResponseFormat.Type != "json_schema" || len(request.ResponseFormat.JsonSchema) == 0 {
+		return nil
+	}
+	var jsonSchema dto.FormatJsonSchema
+	if err := common.Unmarshal(request.ResponseFormat.JsonSchema, &jsonSchema); err != nil || jsonSchema.Schema == nil {
+		return nil
+	}
+	base, err := common.DeepCopy(request)
+	if err != nil {
+		return nil
+	}
+	return &structuredOutputEnforcer{
+		c:       c,
+		info:    info,
+		setting: setting,
+		schema:  jsonSchema.Schema,
+		base:    base,
+	}
+}
+
+// run 执行请求并校验输出，失败时附带修复提示重试。
+// 返回的用量为所有尝试的总和；重试用尽且配置为返回错误时同时返回用量与错误，调用方仍需结算。
+func (e *structuredOutputEnforcer) run(do func(request *dto.GeneralOpenAIRequest) (*dto.Usage, *types.NewAPIError)) (*dto.Usage, *types.NewAPIError) {
+	original := e.c.Writer
+	defer func() {
+		e.c.Writer = original
+	}()
+
+	request, err := common.DeepCopy(e.base)
+	if err != nil {
+		return nil, types.NewError(err, types.ErrorCodeInvalidRequest, types.ErrOptionWithSkipRetry())
+	}
+	maxRetries := max(e.setting.MaxRetries, 0)
+
+	var total *dto.Usage
+	var lastWriter *structuredOutputWriter
+	var validateErr error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		writer := newStructuredOutputWriter(original)
+		e.c.Writer = writer
+		usage, newAPIError := do(request)
+		if newAPIError != nil {
+			if lastWriter == nil {
+				return nil, newAPIError
+			}
+			// 修复请求失败时以上一次的结果收尾
+			logger.LogWarn(e.c, fmt.Sprintf("structured output repair request failed: %s", newAPIError.Error()))
+			break
+		}
+		total = addStructuredOutputUsage(total, usage)
+		lastWriter = writer
+
+		content, ok := writer.content(e.info.IsStream)
+		if !ok {
+			validateErr = nil
+			break
+		}
+		validateErr = service.ValidateJSONSchema(e.schema, strings.TrimSpace(content))
+		if validateErr == nil {
+			break
+		}
+		logger.LogWarn(e.c, fmt.Sprintf("structured output validation failed (attempt %d): %s", attempt+1, validateErr.Error()))
+		if attempt < maxRetries {
+			if request, err = e.repairRequest(content, validateErr); err != nil {
+				break
+			}
+		}
+	}
+
+	if validateErr != nil && e.setting.FailureMode != operation_setting.StructuredOutputFailurePassthrough {
+		return total, types.NewErrorWithStatusCode(
+			fmt.Errorf("response does not match the requested json_schema: %v", validateErr),
+			types.ErrorCodeStructuredOutput, http.StatusUnprocessableEntity, types.ErrOptionWithSkipRetry())
+	}
+	lastWriter.flushTo(original)
+	return total, nil
+}
+
+// repairRequest 在原请求后追加不合规的输出与修复提示
+func (e *structuredOutputEnforcer) repairRequest(content string, validateErr error) (*dto.GeneralOpenAIRequest, error) {
+	request, err := common.DeepCopy(e.base)
+	if err != nil {
+		return nil, err
+	}
+	assistant := dto.Message{Role: "assistant"}
+	assistant.SetStringContent(content)
+	repair := dto.Message{Role: "user"}
+	prompt := e.setting.RepairPrompt
+	if strings.Contains(prompt, "%s") {
+		prompt = fmt.Sprintf(prompt, validateErr.Error())
+	} else {
+		prompt = prompt + "\n" + validateErr.Error()
+	}
+	repair.SetStringContent(prompt)
+	request.Messages = append(request.Messages, assistant, repair)
+	return request, nil
+}
+
+func addStructuredOutputUsage(total *dto.Usage, usage *dto.Usage) *dto.Usage {
+	if usage == nil {
+		return total
+	}
+	if total == nil {
+		return usage
+	}
+	total.PromptTokens += usage.PromptTokens
+	total.CompletionTokens += usage.CompletionTokens
+	total.TotalTokens += usage.TotalTokens
+	total.PromptTokensDetails.CachedTokens += usage.PromptTokensDetails.CachedTokens
+	total.PromptTokensDetails.CachedCreationTokens += usage.PromptTokensDetails.CachedCreationTokens
+	return total
+}
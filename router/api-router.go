@@ -1,8 +1,10 @@
 package router
 
 import (
+	"github.com/QuantumNous/new-api/common"
 	"github.com/QuantumNous/new-api/controller"
 	"github.com/QuantumNous/new-api/middleware"
+	"github.com/QuantumNous/new-api/model"
 
 	// Import oauth package to register providers via init()
 	_ "github.com/QuantumNous/new-api/oauth"
@@ -137,32 +139,35 @@ func SetApiRouter(router *gin.Engine) {
 				selfRoute.DELETE("/oauth/bindings/:provider_id", controller.UnbindCustomOAuth)
 			}
 
+			// 只读接口按分组校验，写操作再追加对应权限
+			userManage := middleware.RequirePermission(common.PermissionUserManage)
+			billingManage := middleware.RequirePermission(common.PermissionBillingManage)
 			adminRoute := userRoute.Group("/")
-			adminRoute.Use(middleware.AdminAuth())
+			adminRoute.Use(middleware.PermissionAuth(common.PermissionUserRead), middleware.AuditTrail(model.AuditTargetUser))
 			{
 				adminRoute.GET("/", controller.GetAllUsers)
-				adminRoute.GET("/topup", controller.GetAllTopUps)
-				adminRoute.POST("/topup/complete", controller.AdminCompleteTopUp)
-				adminRoute.POST("/linuxdo/order/refund", middleware.CriticalRateLimit(), controller.RefundLinuxDoOrder)
+				adminRoute.GET("/topup", billingManage, controller.GetAllTopUps)
+				adminRoute.POST("/topup/complete", billingManage, controller.AdminCompleteTopUp)
+				adminRoute.POST("/linuxdo/order/refund", billingManage, middleware.CriticalRateLimit(), controller.RefundLinuxDoOrder)
 				adminRoute.GET("/search", controller.SearchUsers)
 				adminRoute.GET("/:id/oauth/bindings", controller.GetUserOAuthBindingsByAdmin)
-				adminRoute.DELETE("/:id/oauth/bindings/:provider_id", controller.UnbindCustomOAuthByAdmin)
-				adminRoute.DELETE("/:id/bindings/:binding_type", controller.AdminClearUserBinding)
+				adminRoute.DELETE("/:id/oauth/bindings/:provider_id", userManage, controller.UnbindCustomOAuthByAdmin)
+				adminRoute.DELETE("/:id/bindings/:binding_type", userManage, controller.AdminClearUserBinding)
 				adminRoute.GET("/:id", controller.GetUser)
-				adminRoute.POST("/", controller.CreateUser)
-				adminRoute.POST("/manage", controller.ManageUser)
-				adminRoute.PUT("/", controller.UpdateUser)
-				adminRoute.DELETE("/:id", controller.DeleteUser)
-				adminRoute.DELETE("/:id/reset_passkey", controller.AdminResetPasskey)
+				adminRoute.POST("/", userManage, controller.CreateUser)
+				adminRoute.POST("/manage", userManage, controller.ManageUser)
+				adminRoute.PUT("/", userManage, controller.UpdateUser)
+				adminRoute.DELETE("/:id", userManage, controller.DeleteUser)
+				adminRoute.DELETE("/:id/reset_passkey", userManage, controller.AdminResetPasskey)
 
 				// Admin 2FA routes
 				adminRoute.GET("/2fa/stats", controller.Admin2FAStats)
-				adminRoute.DELETE("/:id/2fa", controller.AdminDisable2FA)
+				adminRoute.DELETE("/:id/2fa", userManage, controller.AdminDisable2FA)
 			}
 		}
 
 		delReqRoute := apiRouter.Group("/delreq")
-		delReqRoute.Use(middleware.AdminAuth())
+		delReqRoute.Use(middleware.PermissionAuth(common.PermissionUserManage), middleware.AuditTrail(model.AuditTargetDeletionRequest))
 		{
 			delReqRoute.GET("/", controller.GetAllDeletionRequests)
 			delReqRoute.POST("/:id/approve", controller.ApproveDeletionRequest)
@@ -170,7 +175,7 @@ func SetApiRouter(router *gin.Engine) {
 		}
 
 		tgBotRoute := apiRouter.Group("/tgbot")
-		tgBotRoute.Use(middleware.AdminAuth())
+		tgBotRoute.Use(middleware.PermissionAuth(common.PermissionFarmManage), middleware.AuditTrail(model.AuditTargetFarm))
 		{
 			tgBotRoute.GET("/category/", controller.GetTgBotCategories)
 			tgBotRoute.POST("/category/", controller.CreateTgBotCategory)
@@ -188,9 +193,9 @@ func SetApiRouter(router *gin.Engine) {
 			tgBotRoute.GET("/settings", controller.GetTgBotSettings)
 			tgBotRoute.GET("/lottery/settings", controller.GetTgBotLotterySettings)
 			tgBotRoute.GET("/farm/users", controller.AdminGetFarmUsers)
-			tgBotRoute.POST("/farm/reset-negative-balances", controller.AdminResetNegativeBalances)
+			tgBotRoute.POST("/farm/reset-negative-balances", middleware.RequirePermission(common.PermissionBillingManage), controller.AdminResetNegativeBalances)
 			tgBotRoute.POST("/farm/reset-all-levels", controller.AdminResetAllFarmLevels)
-			tgBotRoute.POST("/farm/beta-cleanup", controller.AdminBetaCleanup)
+			tgBotRoute.POST("/farm/beta-cleanup", middleware.RequirePermission(common.PermissionBillingManage), controller.AdminBetaCleanup)
 			tgBotRoute.GET("/farm/beta-applications", controller.AdminBetaApplicationList)
 			tgBotRoute.GET("/farm/beta-application/detail", controller.AdminBetaApplicationDetail)
 			tgBotRoute.POST("/farm/beta-application/approve", controller.AdminBetaApplicationApprove)
@@ -230,8 +235,25 @@ func SetApiRouter(router *gin.Engine) {
 			subscriptionRoute.POST("/stripe/pay", middleware.CriticalRateLimit(), controller.SubscriptionRequestStripePay)
 			subscriptionRoute.POST("/creem/pay", middleware.CriticalRateLimit(), controller.SubscriptionRequestCreemPay)
 		}
+		organizationRoute := apiRouter.Group("/organization")
+		organizationRoute.Use(middleware.UserAuth())
+		{
+			organizationRoute.GET("/self", controller.GetSelfOrganizations)
+			organizationRoute.POST("/", controller.CreateOrganization)
+			organizationRoute.GET("/:id", controller.GetOrganization)
+			organizationRoute.PUT("/:id", controller.UpdateOrganization)
+			organizationRoute.GET("/:id/members", controller.GetOrganizationMembers)
+			organizationRoute.POST("/:id/members", controller.AddOrganizationMember)
+			organizationRoute.PUT("/:id/members/:user_id", controller.UpdateOrganizationMember)
+			organizationRoute.DELETE("/:id/members/:user_id", controller.RemoveOrganizationMember)
+			organizationRoute.POST("/:id/transfer", middleware.CriticalRateLimit(), controller.TransferToOrganization)
+			organizationRoute.GET("/:id/tokens", controller.GetOrganizationTokens)
+			organizationRoute.PUT("/:id/tokens/:token_id/status", controller.UpdateOrganizationTokenStatus)
+			organizationRoute.GET("/:id/logs", controller.GetOrganizationLogs)
+		}
+
 		subscriptionAdminRoute := apiRouter.Group("/subscription/admin")
-		subscriptionAdminRoute.Use(middleware.AdminAuth())
+		subscriptionAdminRoute.Use(middleware.PermissionAuth(common.PermissionBillingManage), middleware.AuditTrail(model.AuditTargetSubscriptionPlan))
 		{
 			subscriptionAdminRoute.GET("/plans", controller.AdminListSubscriptionPlans)
 			subscriptionAdminRoute.POST("/plans", controller.AdminCreateSubscriptionPlan)
@@ -252,7 +274,7 @@ func SetApiRouter(router *gin.Engine) {
 		apiRouter.GET("/subscription/epay/return", controller.SubscriptionEpayReturn)
 		apiRouter.POST("/subscription/epay/return", controller.SubscriptionEpayReturn)
 		optionRoute := apiRouter.Group("/option")
-		optionRoute.Use(middleware.RootAuth())
+		optionRoute.Use(middleware.RootAuth(), middleware.AuditTrail(model.AuditTargetOption))
 		{
 			optionRoute.GET("/", controller.GetOptions)
 			optionRoute.PUT("/", controller.UpdateOption)
@@ -263,8 +285,26 @@ func SetApiRouter(router *gin.Engine) {
 		}
 
 		// Custom OAuth provider management (root only)
+		// 管理角色与权限分配，仅超级管理员
+		adminRoleRoute := apiRouter.Group("/admin_role")
+		adminRoleRoute.Use(middleware.RootAuth(), middleware.AuditTrail(model.AuditTargetAdminRole))
+		{
+			adminRoleRoute.GET("/", controller.GetAdminRoles)
+			adminRoleRoute.GET("/permissions", controller.GetAdminPermissions)
+			adminRoleRoute.POST("/", controller.CreateAdminRole)
+			adminRoleRoute.PUT("/", controller.UpdateAdminRole)
+			adminRoleRoute.DELETE("/:id", controller.DeleteAdminRole)
+			adminRoleRoute.PUT("/user", controller.SetUserAdminRole)
+		}
+
+		auditLogRoute := apiRouter.Group("/audit_log")
+		auditLogRoute.Use(middleware.RootAuth())
+		{
+			auditLogRoute.GET("/", controller.GetAuditLogs)
+			auditLogRoute.GET("/export", controller.ExportAuditLogs)
+		}
 		customOAuthRoute := apiRouter.Group("/custom-oauth-provider")
-		customOAuthRoute.Use(middleware.RootAuth())
+		customOAuthRoute.Use(middleware.RootAuth(), middleware.AuditTrail(model.AuditTargetOther))
 		{
 			customOAuthRoute.POST("/discovery", controller.FetchCustomOAuthDiscovery)
 			customOAuthRoute.GET("/", controller.GetCustomOAuthProviders)
@@ -282,13 +322,14 @@ func SetApiRouter(router *gin.Engine) {
 			performanceRoute.POST("/gc", controller.ForceGC)
 		}
 		ratioSyncRoute := apiRouter.Group("/ratio_sync")
-		ratioSyncRoute.Use(middleware.RootAuth())
+		ratioSyncRoute.Use(middleware.RootAuth(), middleware.AuditTrail(model.AuditTargetOther))
 		{
 			ratioSyncRoute.GET("/channels", controller.GetSyncableChannels)
 			ratioSyncRoute.POST("/fetch", controller.FetchUpstreamRatios)
 		}
+		channelManage := middleware.RequirePermission(common.PermissionChannelManage)
 		channelRoute := apiRouter.Group("/channel")
-		channelRoute.Use(middleware.AdminAuth())
+		channelRoute.Use(middleware.PermissionAuth(common.PermissionChannelRead), middleware.AuditTrail(model.AuditTargetChannel))
 		{
 			channelRoute.GET("/", controller.GetAllChannels)
 			channelRoute.GET("/search", controller.SearchChannels)
@@ -296,35 +337,36 @@ func SetApiRouter(router *gin.Engine) {
 			channelRoute.GET("/models_enabled", controller.EnabledListModels)
 			channelRoute.GET("/:id", controller.GetChannel)
 			channelRoute.POST("/:id/key", middleware.RootAuth(), middleware.CriticalRateLimit(), middleware.DisableCache(), middleware.SecureVerificationRequired(), controller.GetChannelKey)
-			channelRoute.GET("/test", controller.TestAllChannels)
-			channelRoute.GET("/test/:id", controller.TestChannel)
-			channelRoute.GET("/update_balance", controller.UpdateAllChannelsBalance)
-			channelRoute.GET("/update_balance/:id", controller.UpdateChannelBalance)
-			channelRoute.POST("/", controller.AddChannel)
-			channelRoute.PUT("/", controller.UpdateChannel)
-			channelRoute.DELETE("/disabled", controller.DeleteDisabledChannel)
-			channelRoute.POST("/tag/disabled", controller.DisableTagChannels)
-			channelRoute.POST("/tag/enabled", controller.EnableTagChannels)
-			channelRoute.PUT("/tag", controller.EditTagChannels)
-			channelRoute.DELETE("/:id", controller.DeleteChannel)
-			channelRoute.POST("/batch", controller.DeleteChannelBatch)
-			channelRoute.POST("/fix", controller.FixChannelsAbilities)
+			channelRoute.GET("/test", channelManage, controller.TestAllChannels)
+			channelRoute.GET("/test/:id", channelManage, controller.TestChannel)
+			channelRoute.GET("/update_balance", channelManage, controller.UpdateAllChannelsBalance)
+			channelRoute.GET("/update_balance/:id", channelManage, controller.UpdateChannelBalance)
+			channelRoute.POST("/", channelManage, controller.AddChannel)
+			channelRoute.PUT("/", channelManage, controller.UpdateChannel)
+			channelRoute.DELETE("/disabled", channelManage, controller.DeleteDisabledChannel)
+			channelRoute.POST("/tag/disabled", channelManage, controller.DisableTagChannels)
+			channelRoute.POST("/tag/enabled", channelManage, controller.EnableTagChannels)
+			channelRoute.PUT("/tag", channelManage, controller.EditTagChannels)
+			channelRoute.DELETE("/:id", channelManage, controller.DeleteChannel)
+			channelRoute.POST("/batch", channelManage, controller.DeleteChannelBatch)
+			channelRoute.POST("/fix", channelManage, controller.FixChannelsAbilities)
 			channelRoute.GET("/fetch_models/:id", controller.FetchUpstreamModels)
-			channelRoute.POST("/fetch_models", controller.FetchModels)
-			channelRoute.POST("/codex/oauth/start", controller.StartCodexOAuth)
-			channelRoute.POST("/codex/oauth/complete", controller.CompleteCodexOAuth)
-			channelRoute.POST("/:id/codex/oauth/start", controller.StartCodexOAuthForChannel)
-			channelRoute.POST("/:id/codex/oauth/complete", controller.CompleteCodexOAuthForChannel)
-			channelRoute.POST("/:id/codex/refresh", controller.RefreshCodexChannelCredential)
+			channelRoute.POST("/fetch_models", channelManage, controller.FetchModels)
+			channelRoute.POST("/codex/oauth/start", channelManage, controller.StartCodexOAuth)
+			channelRoute.POST("/codex/oauth/complete", channelManage, controller.CompleteCodexOAuth)
+			channelRoute.POST("/:id/codex/oauth/start", channelManage, controller.StartCodexOAuthForChannel)
+			channelRoute.POST("/:id/codex/oauth/complete", channelManage, controller.CompleteCodexOAuthForChannel)
+			channelRoute.POST("/:id/codex/refresh", channelManage, controller.RefreshCodexChannelCredential)
 			channelRoute.GET("/:id/codex/usage", controller.GetCodexChannelUsage)
-			channelRoute.POST("/ollama/pull", controller.OllamaPullModel)
-			channelRoute.POST("/ollama/pull/stream", controller.OllamaPullModelStream)
-			channelRoute.DELETE("/ollama/delete", controller.OllamaDeleteModel)
+			channelRoute.GET("/:id/status_events", controller.GetChannelStatusEvents)
+			channelRoute.POST("/ollama/pull", channelManage, controller.OllamaPullModel)
+			channelRoute.POST("/ollama/pull/stream", channelManage, controller.OllamaPullModelStream)
+			channelRoute.DELETE("/ollama/delete", channelManage, controller.OllamaDeleteModel)
 			channelRoute.GET("/ollama/version/:id", controller.OllamaVersion)
-			channelRoute.POST("/batch/tag", controller.BatchSetChannelTag)
+			channelRoute.POST("/batch/tag", channelManage, controller.BatchSetChannelTag)
 			channelRoute.GET("/tag/models", controller.GetTagModels)
-			channelRoute.POST("/copy/:id", controller.CopyChannel)
-			channelRoute.POST("/multi_key/manage", controller.ManageMultiKeys)
+			channelRoute.POST("/copy/:id", channelManage, controller.CopyChannel)
+			channelRoute.POST("/multi_key/manage", channelManage, controller.ManageMultiKeys)
 		}
 		tokenRoute := apiRouter.Group("/token")
 		tokenRoute.Use(middleware.UserAuth())
@@ -349,7 +391,7 @@ func SetApiRouter(router *gin.Engine) {
 		}
 
 		redemptionRoute := apiRouter.Group("/redemption")
-		redemptionRoute.Use(middleware.AdminAuth())
+		redemptionRoute.Use(middleware.PermissionAuth(common.PermissionBillingManage), middleware.AuditTrail(model.AuditTargetRedemption))
 		{
 			redemptionRoute.GET("/", controller.GetAllRedemptions)
 			redemptionRoute.GET("/search", controller.SearchRedemptions)
@@ -529,7 +571,7 @@ func SetApiRouter(router *gin.Engine) {
 
 		// 赛季系统 — 管理员接口
 		seasonAdminRoute := apiRouter.Group("/farm/season/admin")
-		seasonAdminRoute.Use(middleware.AdminAuth())
+		seasonAdminRoute.Use(middleware.PermissionAuth(common.PermissionFarmManage), middleware.AuditTrail(model.AuditTargetFarm))
 		{
 			seasonAdminRoute.GET("/seasons", controller.AdminGetAllSeasons)
 			seasonAdminRoute.POST("/seasons", controller.AdminCreateSeason)
@@ -605,7 +647,7 @@ func SetApiRouter(router *gin.Engine) {
 			feedbackRoute.GET("/public", controller.GetPublicFeedbacks)
 		}
 		feedbackAdminRoute := apiRouter.Group("/feedback/admin")
-		feedbackAdminRoute.Use(middleware.AdminAuth())
+		feedbackAdminRoute.Use(middleware.PermissionAuth(common.PermissionFeedbackManage), middleware.AuditTrail(model.AuditTargetOther))
 		{
 			feedbackAdminRoute.GET("/", controller.AdminGetAllFeedbacks)
 			feedbackAdminRoute.GET("/:id", controller.AdminGetFeedbackDetail)
@@ -624,7 +666,7 @@ func SetApiRouter(router *gin.Engine) {
 		}
 
 		regCodeRoute := apiRouter.Group("/regcode")
-		regCodeRoute.Use(middleware.AdminAuth())
+		regCodeRoute.Use(middleware.PermissionAuth(common.PermissionBillingManage), middleware.AuditTrail(model.AuditTargetRedemption))
 		{
 			regCodeRoute.GET("/", controller.GetAllRegistrationCodes)
 			regCodeRoute.GET("/search", controller.SearchRegistrationCodes)
@@ -635,17 +677,19 @@ func SetApiRouter(router *gin.Engine) {
 			regCodeRoute.DELETE("/:id", controller.DeleteRegistrationCode)
 		}
 		logRoute := apiRouter.Group("/log")
-		logRoute.GET("/", middleware.AdminAuth(), controller.GetAllLogs)
-		logRoute.DELETE("/", middleware.AdminAuth(), controller.DeleteHistoryLogs)
-		logRoute.GET("/stat", middleware.AdminAuth(), controller.GetLogsStat)
+		logRoute.GET("/", middleware.PermissionAuth(common.PermissionLogRead), controller.GetAllLogs)
+		logRoute.DELETE("/", middleware.PermissionAuth(common.PermissionLogManage), controller.DeleteHistoryLogs)
+		logRoute.GET("/stat", middleware.PermissionAuth(common.PermissionLogRead), controller.GetLogsStat)
 		logRoute.GET("/self/stat", middleware.UserAuth(), controller.GetLogsSelfStat)
-		logRoute.GET("/channel_affinity_usage_cache", middleware.AdminAuth(), controller.GetChannelAffinityUsageCacheStats)
-		logRoute.GET("/search", middleware.AdminAuth(), controller.SearchAllLogs)
+		logRoute.GET("/channel_affinity_usage_cache", middleware.PermissionAuth(common.PermissionLogRead), controller.GetChannelAffinityUsageCacheStats)
+		logRoute.GET("/search", middleware.PermissionAuth(common.PermissionLogRead), controller.SearchAllLogs)
+		logRoute.GET("/content/:request_id", middleware.PermissionAuth(common.PermissionLogContent), controller.GetContentLogs)
 		logRoute.GET("/self", middleware.UserAuth(), controller.GetUserLogs)
 		logRoute.GET("/self/search", middleware.UserAuth(), middleware.SearchRateLimit(), controller.SearchUserLogs)
 
 		dataRoute := apiRouter.Group("/data")
-		dataRoute.GET("/", middleware.AdminAuth(), controller.GetAllQuotaDates)
+		dataRoute.GET("/", middleware.PermissionAuth(common.PermissionDataRead), controller.GetAllQuotaDates)
+		dataRoute.GET("/profit", middleware.PermissionAuth(common.PermissionDataRead), controller.GetProfitData)
 		dataRoute.GET("/self", middleware.UserAuth(), controller.GetUserQuotaDates)
 
 		logRoute.Use(middleware.CORS(), middleware.CriticalRateLimit())
@@ -653,32 +697,33 @@ func SetApiRouter(router *gin.Engine) {
 			logRoute.GET("/token", middleware.TokenAuthReadOnly(), controller.GetLogByKey)
 		}
 		groupRoute := apiRouter.Group("/group")
-		groupRoute.Use(middleware.AdminAuth())
+		groupRoute.Use(middleware.AnyPermissionAuth(common.PermissionUserRead, common.PermissionChannelRead, common.PermissionBillingManage))
 		{
 			groupRoute.GET("/", controller.GetGroups)
 		}
 
+		modelManage := middleware.RequirePermission(common.PermissionModelManage)
 		prefillGroupRoute := apiRouter.Group("/prefill_group")
-		prefillGroupRoute.Use(middleware.AdminAuth())
+		prefillGroupRoute.Use(middleware.AnyPermissionAuth(common.PermissionChannelRead, common.PermissionModelManage), middleware.AuditTrail(model.AuditTargetOther))
 		{
 			prefillGroupRoute.GET("/", controller.GetPrefillGroups)
-			prefillGroupRoute.POST("/", controller.CreatePrefillGroup)
-			prefillGroupRoute.PUT("/", controller.UpdatePrefillGroup)
-			prefillGroupRoute.DELETE("/:id", controller.DeletePrefillGroup)
+			prefillGroupRoute.POST("/", modelManage, controller.CreatePrefillGroup)
+			prefillGroupRoute.PUT("/", modelManage, controller.UpdatePrefillGroup)
+			prefillGroupRoute.DELETE("/:id", modelManage, controller.DeletePrefillGroup)
 		}
 
 		mjRoute := apiRouter.Group("/mj")
 		mjRoute.GET("/self", middleware.UserAuth(), controller.GetUserMidjourney)
-		mjRoute.GET("/", middleware.AdminAuth(), controller.GetAllMidjourney)
+		mjRoute.GET("/", middleware.PermissionAuth(common.PermissionLogRead), controller.GetAllMidjourney)
 
 		taskRoute := apiRouter.Group("/task")
 		{
 			taskRoute.GET("/self", middleware.UserAuth(), controller.GetUserTask)
-			taskRoute.GET("/", middleware.AdminAuth(), controller.GetAllTask)
+			taskRoute.GET("/", middleware.PermissionAuth(common.PermissionLogRead), controller.GetAllTask)
 		}
 
 		vendorRoute := apiRouter.Group("/vendors")
-		vendorRoute.Use(middleware.AdminAuth())
+		vendorRoute.Use(middleware.PermissionAuth(common.PermissionModelManage), middleware.AuditTrail(model.AuditTargetOther))
 		{
 			vendorRoute.GET("/", controller.GetAllVendors)
 			vendorRoute.GET("/search", controller.SearchVendors)
@@ -689,7 +734,7 @@ func SetApiRouter(router *gin.Engine) {
 		}
 
 		modelsRoute := apiRouter.Group("/models")
-		modelsRoute.Use(middleware.AdminAuth())
+		modelsRoute.Use(middleware.PermissionAuth(common.PermissionModelManage), middleware.AuditTrail(model.AuditTargetOther))
 		{
 			modelsRoute.GET("/sync_upstream/preview", controller.SyncUpstreamPreview)
 			modelsRoute.POST("/sync_upstream", controller.SyncUpstreamModels)
@@ -704,7 +749,7 @@ func SetApiRouter(router *gin.Engine) {
 
 		// Deployments (model deployment management)
 		deploymentsRoute := apiRouter.Group("/deployments")
-		deploymentsRoute.Use(middleware.AdminAuth())
+		deploymentsRoute.Use(middleware.PermissionAuth(common.PermissionModelManage), middleware.AuditTrail(model.AuditTargetOther))
 		{
 			deploymentsRoute.GET("/settings", controller.GetModelDeploymentSettings)
 			deploymentsRoute.POST("/settings/test-connection", controller.TestIoNetConnection)
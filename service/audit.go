@@ -0,0 +1,33 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
+	"github.com/QuantumNous/new-api/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RecordAudit 记录一次管理操作，操作人与 IP 取自请求上下文。
+// before/after 为变更前后的实体，新增时 before 传 nil，删除时 after 传 nil。
+func RecordAudit(c *gin.Context, targetType string, targetId any, action string, before, after any) {
+	RecordAuditDiff(c, targetType, targetId, action, model.BuildAuditDiff(before, after))
+}
+
+// RecordAuditDiff 使用已生成的差异 JSON 记录管理操作
+func RecordAuditDiff(c *gin.Context, targetType string, targetId any, action string, diff string) {
+	common.SetContextKey(c, constant.ContextKeyAuditRecorded, true)
+	model.RecordAuditLog(&model.AuditLog{
+		ActorId:    c.GetInt("id"),
+		ActorName:  c.GetString("username"),
+		ActorRole:  c.GetInt("role"),
+		Ip:         c.ClientIP(),
+		TargetType: targetType,
+		TargetId:   fmt.Sprint(targetId),
+		Action:     action,
+		Route:      c.Request.Method + " " + c.FullPath(),
+		Diff:       diff,
+	})
+}
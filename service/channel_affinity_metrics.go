@@ -0,0 +1,42 @@
+package service
+
+import (
+	"github.com/QuantumNous/new-api/pkg/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// channelAffinityCollector 在抓取 /metrics 时读取亲和性缓存的条目统计
+type channelAffinityCollector struct {
+	entries  *prometheus.Desc
+	capacity *prometheus.Desc
+}
+
+func (c *channelAffinityCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.entries
+	ch <- c.capacity
+}
+
+func (c *channelAffinityCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := GetChannelAffinityCacheStats()
+	if !stats.Enabled {
+		return
+	}
+	for rule, count := range stats.ByRuleName {
+		ch <- prometheus.MustNewConstMetric(c.entries, prometheus.GaugeValue, float64(count), rule)
+	}
+	if stats.Unknown > 0 {
+		ch <- prometheus.MustNewConstMetric(c.entries, prometheus.GaugeValue, float64(stats.Unknown), "")
+	}
+	ch <- prometheus.MustNewConstMetric(c.capacity, prometheus.GaugeValue, float64(stats.CacheCapacity))
+}
+
+func init() {
+	_ = metrics.Register(&channelAffinityCollector{
+		entries: prometheus.NewDesc("newapi_channel_affinity_cache_entries",
+			"Channel affinity cache entries by rule; entries not matching a rule are reported with an empty rule label.",
+			[]string{"rule"}, nil),
+		capacity: prometheus.NewDesc("newapi_channel_affinity_cache_capacity",
+			"Capacity of the in-memory channel affinity cache.", nil, nil),
+	})
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/logger"
+	"github.com/QuantumNous/new-api/model"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+
+	"github.com/bytedance/gopkg/util/gopool"
+)
+
+const (
+	channelStatusEventCleanupTickInterval = 6 * time.Hour
+	channelStatusEventCleanupBatchSize    = 1000
+)
+
+var (
+	channelStatusEventCleanupOnce    sync.Once
+	channelStatusEventCleanupRunning atomic.Bool
+)
+
+// StartChannelStatusEventCleanupTask 定时清理超过保留天数的渠道禁用/恢复记录
+func StartChannelStatusEventCleanupTask() {
+	channelStatusEventCleanupOnce.Do(func() {
+		if !common.IsMasterNode {
+			return
+		}
+		gopool.Go(func() {
+			logger.LogInfo(context.Background(), fmt.Sprintf("channel status event cleanup task started: tick=%s", channelStatusEventCleanupTickInterval))
+			ticker := time.NewTicker(channelStatusEventCleanupTickInterval)
+			defer ticker.Stop()
+
+			runChannelStatusEventCleanupOnce()
+			for range ticker.C {
+				runChannelStatusEventCleanupOnce()
+			}
+		})
+	})
+}
+
+func runChannelStatusEventCleanupOnce() {
+	if !channelStatusEventCleanupRunning.CompareAndSwap(false, true) {
+		return
+	}
+	defer channelStatusEventCleanupRunning.Store(false)
+
+	retentionDays := operation_setting.GetChannelRecoverySetting().EventRetentionDays
+	if retentionDays <= 0 {
+		return
+	}
+	ctx := context.Background()
+	targetTimestamp := time.Now().AddDate(0, 0, -retentionDays).Unix()
+	count, err := model.DeleteOldChannelStatusEvents(ctx, targetTimestamp, channelStatusEventCleanupBatchSize)
+	if err != nil {
+		logger.LogWarn(ctx, fmt.Sprintf("channel status event cleanup failed: %v", err))
+		return
+	}
+	if count > 0 {
+		logger.LogInfo(ctx, fmt.Sprintf("channel status event cleanup: deleted %d records", count))
+	}
+}
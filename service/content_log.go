@@ -0,0 +1,238 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
+	"github.com/QuantumNous/new-api/logger"
+	"github.com/QuantumNous/new-api/model"
+	relaycommon "github.com/QuantumNous/new-api/relay/common"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+	"github.com/QuantumNous/new-api/types"
+
+	"github.com/bytedance/gopkg/util/gopool"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	ginKeyContentLogWriter = "content_log_writer"
+
+	contentLogRedacted = "[REDACTED]"
+	// 截断前额外保留的字节数，先脱敏再截断，避免密钥跨越截断位置而逃过脱敏
+	contentLogRedactionMargin = 4 << 10
+
+	contentLogCleanupTickInterval = 1 * time.Hour
+	contentLogCleanupBatchSize    = 1000
+)
+
+var (
+	contentLogCleanupOnce    sync.Once
+	contentLogCleanupRunning atomic.Bool
+
+	contentLogRedactionMu    sync.Mutex
+	contentLogRedactionKey   string
+	contentLogRedactionRules []*regexp.Regexp
+)
+
+// ContentLogWriter 在转发响应的同时记录响应体，最多保留 limit + contentLogRedactionMargin 字节，
+// 脱敏后再截断到 limit
+type ContentLogWriter struct {
+	gin.ResponseWriter
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (w *ContentLogWriter) capture(data []byte) {
+	if w.truncated {
+		return
+	}
+	remain := w.limit + contentLogRedactionMargin - w.buf.Len()
+	if len(data) > remain {
+		w.buf.Write(data[:remain])
+		w.truncated = true
+		return
+	}
+	w.buf.Write(data)
+}
+
+func (w *ContentLogWriter) Write(data []byte) (int, error) {
+	w.capture(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *ContentLogWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func contentLogBodyLimit() int {
+	limit := operation_setting.GetContentLogSetting().MaxBodyKB << 10
+	if columnLimit := model.ContentLogColumnLimit(); columnLimit > 0 && (limit <= 0 || limit > columnLimit) {
+		limit = columnLimit
+	}
+	return limit
+}
+
+// BeginContentLogCapture 对开启内容日志的请求包装 c.Writer 以记录下游响应
+func BeginContentLogCapture(c *gin.Context, info *relaycommon.RelayInfo) {
+	if info == nil || info.IsPlayground || info.IsChannelTest {
+		return
+	}
+	if !operation_setting.ShouldLogContent(info.UsingGroup, common.GetContextKeyBool(c, constant.ContextKeyTokenContentLog)) {
+		return
+	}
+	limit := contentLogBodyLimit()
+	if limit <= 0 {
+		return
+	}
+	writer := &ContentLogWriter{ResponseWriter: c.Writer, limit: limit}
+	c.Writer = writer
+	c.Set(ginKeyContentLogWriter, writer)
+}
+
+// SaveContentLog 在请求结束后异步写入内容日志。
+// 需要在错误响应写出之前调用，因此失败请求只记录错误信息，不包含错误响应体。
+func SaveContentLog(c *gin.Context, info *relaycommon.RelayInfo, apiErr *types.NewAPIError) {
+	value, ok := c.Get(ginKeyContentLogWriter)
+	if !ok {
+		return
+	}
+	writer := value.(*ContentLogWriter)
+	limit := writer.limit
+
+	var requestBody []byte
+	if storage, err := common.GetBodyStorage(c); err == nil {
+		requestBody, _ = storage.Bytes()
+	}
+	requestTruncated := len(requestBody) > limit+contentLogRedactionMargin
+	if requestTruncated {
+		requestBody = requestBody[:limit+contentLogRedactionMargin]
+	}
+	responseBody := bytes.Clone(writer.buf.Bytes())
+	log := &model.ContentLog{
+		RequestId:  c.GetString(common.RequestIdKey),
+		UserId:     info.UserId,
+		TokenId:    info.TokenId,
+		ModelName:  info.OriginModelName,
+		Group:      info.UsingGroup,
+		ChannelId:  common.GetContextKeyInt(c, constant.ContextKeyChannelId),
+		IsStream:   info.IsStream,
+		StatusCode: writer.Status(),
+	}
+	if apiErr != nil {
+		log.StatusCode = apiErr.StatusCode
+		log.ErrorMessage = apiErr.Error()
+	}
+	gopool.Go(func() {
+		rules := getContentLogRedactionRules()
+		log.RequestBody, log.RequestTruncated = redactContentLogBody(requestBody, requestTruncated, limit, rules)
+		log.ResponseBody, log.ResponseTruncated = redactContentLogBody(responseBody, writer.truncated, limit, rules)
+		log.ErrorMessage = RedactContent(log.ErrorMessage, rules)
+		if err := log.Insert(); err != nil {
+			common.SysError("failed to record content log: " + err.Error())
+		}
+	})
+}
+
+// redactContentLogBody 先脱敏再截断到 limit 字节，并清理不合法的 UTF-8 字节
+func redactContentLogBody(body []byte, truncated bool, limit int, rules []*regexp.Regexp) (string, bool) {
+	text := RedactContent(strings.ToValidUTF8(string(body), ""), rules)
+	text, cut := truncateContentLogBody([]byte(text), limit)
+	return text, truncated || cut
+}
+
+// truncateContentLogBody 截断到 limit 字节，避免截断在 UTF-8 字符中间
+func truncateContentLogBody(body []byte, limit int) (string, bool) {
+	if len(body) <= limit {
+		return string(body), false
+	}
+	return strings.ToValidUTF8(string(body[:limit]), ""), true
+}
+
+// RedactContent 将匹配脱敏规则的内容替换为 [REDACTED]
+func RedactContent(content string, rules []*regexp.Regexp) string {
+	if content == "" {
+		return content
+	}
+	for _, rule := range rules {
+		content = rule.ReplaceAllString(content, contentLogRedacted)
+	}
+	return content
+}
+
+// getContentLogRedactionRules 编译脱敏规则，规则未变化时复用上次的结果；无效的规则会被跳过
+func getContentLogRedactionRules() []*regexp.Regexp {
+	patterns := operation_setting.GetContentLogSetting().RedactionRules
+	key := strings.Join(patterns, "\n")
+
+	contentLogRedactionMu.Lock()
+	defer contentLogRedactionMu.Unlock()
+	if key == contentLogRedactionKey && contentLogRedactionRules != nil {
+		return contentLogRedactionRules
+	}
+	rules := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		if strings.TrimSpace(pattern) == "" {
+			continue
+		}
+		rule, err := regexp.Compile(pattern)
+		if err != nil {
+			common.SysError(fmt.Sprintf("invalid content log redaction rule %q: %s", pattern, err.Error()))
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	contentLogRedactionKey = key
+	contentLogRedactionRules = rules
+	return rules
+}
+
+// StartContentLogCleanupTask 定时清理超过保留天数的内容日志
+func StartContentLogCleanupTask() {
+	contentLogCleanupOnce.Do(func() {
+		if !common.IsMasterNode {
+			return
+		}
+		gopool.Go(func() {
+			logger.LogInfo(context.Background(), fmt.Sprintf("content log cleanup task started: tick=%s", contentLogCleanupTickInterval))
+			ticker := time.NewTicker(contentLogCleanupTickInterval)
+			defer ticker.Stop()
+
+			runContentLogCleanupOnce()
+			for range ticker.C {
+				runContentLogCleanupOnce()
+			}
+		})
+	})
+}
+
+func runContentLogCleanupOnce() {
+	if !contentLogCleanupRunning.CompareAndSwap(false, true) {
+		return
+	}
+	defer contentLogCleanupRunning.Store(false)
+
+	retentionDays := operation_setting.GetContentLogSetting().RetentionDays
+	if retentionDays <= 0 {
+		return
+	}
+	ctx := context.Background()
+	targetTimestamp := time.Now().AddDate(0, 0, -retentionDays).Unix()
+	count, err := model.DeleteOldContentLog(ctx, targetTimestamp, contentLogCleanupBatchSize)
+	if err != nil {
+		logger.LogWarn(ctx, fmt.Sprintf("content log cleanup failed: %v", err))
+		return
+	}
+	if count > 0 {
+		logger.LogInfo(ctx, fmt.Sprintf("content log cleanup: deleted %d records", count))
+	}
+}
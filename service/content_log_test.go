@@ -0,0 +1,65 @@
+package service
+
+import (
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContentLogWriter_TruncatesAndForwards(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	writer := &ContentLogWriter{ResponseWriter: c.Writer, limit: 8}
+
+	padding := strings.Repeat("x", contentLogRedactionMargin)
+	_, err := writer.WriteString("data: 1234\n")
+	require.NoError(t, err)
+	_, err = writer.Write([]byte(padding + "data: 5678\n"))
+	require.NoError(t, err)
+
+	// 多保留 contentLogRedactionMargin 字节用于脱敏，之后再截断到 limit
+	require.Equal(t, "data: 1234\n"+padding+"data: 5678\n", recorder.Body.String())
+	require.Equal(t, ("data: 1234\n" + padding)[:8+contentLogRedactionMargin], writer.buf.String())
+	require.True(t, writer.truncated)
+}
+
+func TestRedactContentLogBody_RedactsBeforeTruncate(t *testing.T) {
+	rules := []*regexp.Regexp{regexp.MustCompile(`sk-[a-z]{8}`)}
+	// 截断位置落在密钥中间时，先截断会留下密钥前缀
+	text, truncated := redactContentLogBody([]byte("key=sk-abcdefgh"), false, 10, rules)
+	require.True(t, truncated)
+	require.Equal(t, "key=[REDAC", text)
+
+	text, truncated = redactContentLogBody([]byte("ok\xff"), false, 10, rules)
+	require.False(t, truncated)
+	require.Equal(t, "ok", text)
+}
+
+func TestTruncateContentLogBody_KeepsValidUTF8(t *testing.T) {
+	text, truncated := truncateContentLogBody([]byte("你好"), 4)
+	require.True(t, truncated)
+	require.Equal(t, "你", text)
+
+	text, truncated = truncateContentLogBody([]byte("hi"), 4)
+	require.False(t, truncated)
+	require.Equal(t, "hi", text)
+}
+
+func TestRedactContent_DefaultRules(t *testing.T) {
+	setting := operation_setting.GetContentLogSetting()
+	original := setting.RedactionRules
+	t.Cleanup(func() { setting.RedactionRules = original })
+	setting.RedactionRules = append(append([]string{}, original...), `[`, `\d{3}-\d{4}`)
+
+	rules := getContentLogRedactionRules()
+	require.Len(t, rules, len(original)+1)
+
+	got := RedactContent(`{"key":"sk-abcdefghijklmnopqrstuvwx","auth":"Bearer abcdefghijklmnopqrstu","phone":"555-1234"}`, rules)
+	require.Equal(t, `{"key":"[REDACTED]","auth":"[REDACTED]","phone":"[REDACTED]"}`, got)
+}
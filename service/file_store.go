@@ -0,0 +1,123 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
+)
+
+// 本地文件存储：/v1/files 上传的文件与批处理输出文件按 用户/日期 分目录保存。
+// 数据库只记录相对路径，读取时统一拼接 FileStoreDir，避免路径穿越。
+
+var ErrFileTooLarge = errors.New("file exceeds the maximum allowed size")
+
+func fileStoreRoot() (string, error) {
+	root, err := filepath.Abs(constant.FileStoreDir)
+	if err != nil {
+		return "", err
+	}
+	return root, nil
+}
+
+func resolveStoredFilePath(relPath string) (string, error) {
+	root, err := fileStoreRoot()
+	if err != nil {
+		return "", err
+	}
+	full := filepath.Join(root, filepath.Clean("/"+relPath))
+	if !strings.HasPrefix(full, root+string(os.PathSeparator)) {
+		return "", fmt.Errorf("invalid stored file path: %s", relPath)
+	}
+	return full, nil
+}
+
+// CreateStoredFile 创建一个新的存储文件，返回相对路径与可写句柄
+func CreateStoredFile(userId int, fileId string) (string, *os.File, error) {
+	relPath := filepath.Join(fmt.Sprintf("%d", userId), time.Now().Format("20060102"), fileId)
+	full, err := resolveStoredFilePath(relPath)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		return "", nil, err
+	}
+	f, err := os.OpenFile(full, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return "", nil, err
+	}
+	return relPath, f, nil
+}
+
+// SaveStoredFile 将 reader 写入存储，超过 MaxFileUploadMB 时删除已写入内容并返回 ErrFileTooLarge
+func SaveStoredFile(userId int, fileId string, reader io.Reader) (string, int64, error) {
+	relPath, f, err := CreateStoredFile(userId, fileId)
+	if err != nil {
+		return "", 0, err
+	}
+	maxBytes := int64(constant.MaxFileUploadMB) << 20
+	n, copyErr := io.Copy(f, io.LimitReader(reader, maxBytes+1))
+	closeErr := f.Close()
+	if copyErr == nil && n > maxBytes {
+		copyErr = ErrFileTooLarge
+	}
+	if copyErr == nil {
+		copyErr = closeErr
+	}
+	if copyErr != nil {
+		RemoveStoredFile(relPath)
+		return "", 0, copyErr
+	}
+	return relPath, n, nil
+}
+
+// OpenStoredFile 以只读方式打开存储文件
+func OpenStoredFile(relPath string) (*os.File, error) {
+	full, err := resolveStoredFilePath(relPath)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(full)
+}
+
+// OpenStoredFileForAppend 以追加方式打开存储文件，用于批处理断点续跑
+func OpenStoredFileForAppend(relPath string) (*os.File, error) {
+	full, err := resolveStoredFilePath(relPath)
+	if err != nil {
+		return nil, err
+	}
+	return os.OpenFile(full, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+}
+
+// StoredFileSize 返回存储文件当前大小
+func StoredFileSize(relPath string) (int64, error) {
+	full, err := resolveStoredFilePath(relPath)
+	if err != nil {
+		return 0, err
+	}
+	stat, err := os.Stat(full)
+	if err != nil {
+		return 0, err
+	}
+	return stat.Size(), nil
+}
+
+func RemoveStoredFile(relPath string) {
+	if relPath == "" {
+		return
+	}
+	full, err := resolveStoredFilePath(relPath)
+	if err != nil {
+		common.SysError("remove stored file failed: " + err.Error())
+		return
+	}
+	if err := os.Remove(full); err != nil && !os.IsNotExist(err) {
+		common.SysError("remove stored file failed: " + err.Error())
+	}
+}
@@ -0,0 +1,292 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/QuantumNous/new-api/common"
+)
+
+// ValidateJSONSchema 按 JSON Schema 校验 JSON 文本，支持结构化输出常用的子集：
+// type、enum、const、properties、required、additionalProperties、items、
+// 长度与数值范围、pattern、anyOf / oneOf / allOf 以及指向 $defs / definitions 的本地 $ref。
+func ValidateJSONSchema(schema any, data string) error {
+	var value any
+	if err := common.UnmarshalJsonStr(data, &value); err != nil {
+		return fmt.Errorf("invalid JSON: %v", err)
+	}
+	root, _ := schema.(map[string]any)
+	v := &jsonSchemaValidator{root: root}
+	return v.validate(schema, value, "$", 0)
+}
+
+const jsonSchemaMaxDepth = 64
+
+type jsonSchemaValidator struct {
+	root map[string]any
+}
+
+func (v *jsonSchemaValidator) validate(schema any, value any, path string, depth int) error {
+	if depth > jsonSchemaMaxDepth {
+		return fmt.Errorf("%s: schema nesting too deep", path)
+	}
+	switch s := schema.(type) {
+	case bool:
+		if !s {
+			return fmt.Errorf("%s: value is not allowed", path)
+		}
+		return nil
+	case map[string]any:
+		return v.validateObjectSchema(s, value, path, depth)
+	default:
+		return nil
+	}
+}
+
+func (v *jsonSchemaValidator) validateObjectSchema(schema map[string]any, value any, path string, depth int) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		resolved, err := v.resolveRef(ref)
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		if err = v.validate(resolved, value, path, depth+1); err != nil {
+			return err
+		}
+	}
+
+	if t, ok := schema["type"]; ok && !matchJSONSchemaType(t, value) {
+		return fmt.Errorf("%s: expected type %v, got %s", path, t, jsonValueType(value))
+	}
+	if enum, ok := schema["enum"].([]any); ok {
+		matched := false
+		for _, candidate := range enum {
+			if jsonValueEqual(candidate, value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%s: value is not one of the allowed enum values", path)
+		}
+	}
+	if constant, ok := schema["const"]; ok && !jsonValueEqual(constant, value) {
+		return fmt.Errorf("%s: value does not equal the const value", path)
+	}
+
+	switch typed := value.(type) {
+	case map[string]any:
+		if err := v.validateObject(schema, typed, path, depth); err != nil {
+			return err
+		}
+	case []any:
+		if err := v.validateArray(schema, typed, path, depth); err != nil {
+			return err
+		}
+	case string:
+		length := len([]rune(typed))
+		if min, ok := jsonNumber(schema["minLength"]); ok && float64(length) < min {
+			return fmt.Errorf("%s: string is shorter than %v", path, min)
+		}
+		if max, ok := jsonNumber(schema["maxLength"]); ok && float64(length) > max {
+			return fmt.Errorf("%s: string is longer than %v", path, max)
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			re, err := regexp.Compile(pattern)
+			if err == nil && !re.MatchString(typed) {
+				return fmt.Errorf("%s: string does not match pattern %s", path, pattern)
+			}
+		}
+	case float64:
+		if min, ok := jsonNumber(schema["minimum"]); ok && typed < min {
+			return fmt.Errorf("%s: value is less than %v", path, min)
+		}
+		if max, ok := jsonNumber(schema["maximum"]); ok && typed > max {
+			return fmt.Errorf("%s: value is greater than %v", path, max)
+		}
+		if min, ok := jsonNumber(schema["exclusiveMinimum"]); ok && typed <= min {
+			return fmt.Errorf("%s: value must be greater than %v", path, min)
+		}
+		if max, ok := jsonNumber(schema["exclusiveMaximum"]); ok && typed >= max {
+			return fmt.Errorf("%s: value must be less than %v", path, max)
+		}
+	}
+
+	if allOf, ok := schema["allOf"].([]any); ok {
+		for _, sub := range allOf {
+			if err := v.validate(sub, value, path, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	if anyOf, ok := schema["anyOf"].([]any); ok {
+		var firstErr error
+		matched := false
+		for _, sub := range anyOf {
+			err := v.validate(sub, value, path, depth+1)
+			if err == nil {
+				matched = true
+				break
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+		if !matched && firstErr != nil {
+			return fmt.Errorf("%s: value does not match any of anyOf (%v)", path, firstErr)
+		}
+	}
+	if oneOf, ok := schema["oneOf"].([]any); ok {
+		matches := 0
+		for _, sub := range oneOf {
+			if v.validate(sub, value, path, depth+1) == nil {
+				matches++
+			}
+		}
+		if matches != 1 {
+			return fmt.Errorf("%s: value matches %d schemas in oneOf, expected exactly 1", path, matches)
+		}
+	}
+	return nil
+}
+
+func (v *jsonSchemaValidator) validateObject(schema map[string]any, object map[string]any, path string, depth int) error {
+	if required, ok := schema["required"].([]any); ok {
+		for _, name := range required {
+			key, _ := name.(string)
+			if _, exists := object[key]; !exists {
+				return fmt.Errorf("%s: missing required property %q", path, key)
+			}
+		}
+	}
+	properties, _ := schema["properties"].(map[string]any)
+	for key, item := range object {
+		childPath := path + "." + key
+		if propSchema, ok := properties[key]; ok {
+			if err := v.validate(propSchema, item, childPath, depth+1); err != nil {
+				return err
+			}
+			continue
+		}
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				return fmt.Errorf("%s: additional property %q is not allowed", path, key)
+			}
+		case map[string]any:
+			if err := v.validate(additional, item, childPath, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (v *jsonSchemaValidator) validateArray(schema map[string]any, array []any, path string, depth int) error {
+	if min, ok := jsonNumber(schema["minItems"]); ok && float64(len(array)) < min {
+		return fmt.Errorf("%s: array has fewer than %v items", path, min)
+	}
+	if max, ok := jsonNumber(schema["maxItems"]); ok && float64(len(array)) > max {
+		return fmt.Errorf("%s: array has more than %v items", path, max)
+	}
+	if items, ok := schema["items"]; ok {
+		for i, item := range array {
+			if err := v.validate(items, item, fmt.Sprintf("%s[%d]", path, i), depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (v *jsonSchemaValidator) resolveRef(ref string) (any, error) {
+	if ref == "#" {
+		return v.root, nil
+	}
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported $ref %q", ref)
+	}
+	var current any = v.root
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		node, ok := current.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("unresolvable $ref %q", ref)
+		}
+		if current, ok = node[part]; !ok {
+			return nil, fmt.Errorf("unresolvable $ref %q", ref)
+		}
+	}
+	return current, nil
+}
+
+func matchJSONSchemaType(schemaType any, value any) bool {
+	switch t := schemaType.(type) {
+	case string:
+		return matchSingleJSONSchemaType(t, value)
+	case []any:
+		for _, item := range t {
+			if name, ok := item.(string); ok && matchSingleJSONSchemaType(name, value) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func matchSingleJSONSchemaType(schemaType string, value any) bool {
+	switch schemaType {
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		number, ok := value.(float64)
+		return ok && number == math.Trunc(number)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	}
+	return true
+}
+
+func jsonValueType(value any) string {
+	switch value.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func jsonNumber(value any) (float64, bool) {
+	number, ok := value.(float64)
+	return number, ok
+}
+
+func jsonValueEqual(a, b any) bool {
+	left, err1 := common.Marshal(a)
+	right, err2 := common.Marshal(b)
+	return err1 == nil && err2 == nil && string(left) == string(right)
+}
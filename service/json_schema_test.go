@@ -0,0 +1,39 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateJSONSchema(t *testing.T) {
+	var schema any
+	require.NoError(t, common.UnmarshalJsonStr(`{
+		"type": "object",
+		"properties": {
+			"name": {"type": "string", "minLength": 1},
+			"age": {"type": "integer", "minimum": 0},
+			"tags": {"type": "array", "items": {"$ref": "#/$defs/tag"}}
+		},
+		"required": ["name", "age"],
+		"additionalProperties": false,
+		"$defs": {"tag": {"type": "string", "enum": ["a", "b"]}}
+	}`, &schema))
+
+	require.NoError(t, ValidateJSONSchema(schema, `{"name":"bob","age":3,"tags":["a","b"]}`))
+
+	cases := map[string]string{
+		`not json`:                            "invalid JSON",
+		`{"name":"bob"}`:                      `missing required property "age"`,
+		`{"name":"bob","age":1.5}`:            "$.age: expected type integer",
+		`{"name":"bob","age":1,"extra":true}`: `additional property "extra"`,
+		`{"name":"bob","age":1,"tags":["c"]}`: "$.tags[0]: value is not one of the allowed enum values",
+		`{"name":"","age":1}`:                 "$.name: string is shorter than 1",
+	}
+	for data, expected := range cases {
+		err := ValidateJSONSchema(schema, data)
+		require.Error(t, err, data)
+		require.Contains(t, err.Error(), expected, data)
+	}
+}
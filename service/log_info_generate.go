@@ -49,6 +49,19 @@ func GenerateTextOtherInfo(ctx *gin.Context, relayInfo *relaycommon.RelayInfo, m
 		other["is_model_mapped"] = true
 		other["upstream_model_name"] = relayInfo.UpstreamModelName
 	}
+	if relayInfo.FallbackFromModel != "" {
+		other["fallback_from"] = relayInfo.FallbackFromModel
+	}
+	if relayInfo.PriceData.TierThreshold > 0 {
+		other["price_tier_threshold"] = relayInfo.PriceData.TierThreshold
+	}
+	if relayInfo.StreamContinuations > 0 {
+		other["stream_continuations"] = relayInfo.StreamContinuations
+	}
+	if relayInfo.ResponseCacheHit {
+		other["response_cache_hit"] = true
+		other["response_cache_billing_ratio"] = GetResponseCacheBillingRatio()
+	}
 
 	isSystemPromptOverwritten := common.GetContextKeyBool(ctx, constant.ContextKeySystemPromptOverride)
 	if isSystemPromptOverwritten {
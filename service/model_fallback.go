@@ -0,0 +1,93 @@
+package service
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+	"github.com/QuantumNous/new-api/setting/ratio_setting"
+	"github.com/QuantumNous/new-api/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetModelFallbackChain 返回主模型的备选模型列表（不含主模型），令牌上的配置优先于分组配置。
+// 令牌开启模型限制时，不在允许列表内的备选模型会被跳过。
+func GetModelFallbackChain(c *gin.Context, group string, modelName string) []string {
+	if !operation_setting.GetModelFallbackSetting().Enabled || modelName == "" {
+		return nil
+	}
+	if _, ok := c.Get(string(constant.ContextKeyTokenSpecificChannelId)); ok {
+		return nil
+	}
+	// 实时会话与异步任务不支持切换模型
+	path := c.Request.URL.Path
+	if strings.HasPrefix(path, "/v1/realtime") || strings.Contains(path, "/mj/") ||
+		strings.Contains(path, "/suno/") || strings.Contains(path, "/v1/video") {
+		return nil
+	}
+
+	var chain []string
+	if fallbacks, ok := common.GetContextKeyType[map[string][]string](c, constant.ContextKeyTokenModelFallbacks); ok {
+		chain = fallbacks[modelName]
+	}
+	if len(chain) == 0 {
+		chain = operation_setting.GetGroupModelFallbacks(group, modelName)
+	}
+	if len(chain) == 0 {
+		return nil
+	}
+
+	var tokenModelLimit map[string]bool
+	if common.GetContextKeyBool(c, constant.ContextKeyTokenModelLimitEnabled) {
+		tokenModelLimit, _ = common.GetContextKeyType[map[string]bool](c, constant.ContextKeyTokenModelLimit)
+		if tokenModelLimit == nil {
+			return nil
+		}
+	}
+	seen := map[string]bool{modelName: true}
+	result := make([]string, 0, len(chain))
+	for _, fallback := range chain {
+		fallback = strings.TrimSpace(fallback)
+		if fallback == "" || seen[fallback] {
+			continue
+		}
+		seen[fallback] = true
+		if tokenModelLimit != nil && !tokenModelLimit[ratio_setting.FormatMatchingModelName(fallback)] {
+			continue
+		}
+		result = append(result, fallback)
+	}
+	return result
+}
+
+// ShouldFallbackModel 判断当前模型的失败是否可以改用备选模型重试：
+// 没有可用渠道、渠道错误、限流与上游 5xx 时切换，客户端请求本身的错误以及已开始输出的响应不切换。
+func ShouldFallbackModel(c *gin.Context, err *types.NewAPIError) bool {
+	if err == nil || c.Writer.Written() {
+		return false
+	}
+	if err.GetErrorCode() == types.ErrorCodeGetChannelFailed || err.GetErrorCode() == types.ErrorCodeModelNotFound {
+		return true
+	}
+	if types.IsChannelError(err) {
+		return true
+	}
+	if types.IsSkipRetryError(err) {
+		return false
+	}
+	if err.GetErrorCode() == types.ErrorCodeFirstTokenTimeout {
+		return true
+	}
+	return err.StatusCode == http.StatusTooManyRequests || err.StatusCode >= http.StatusInternalServerError
+}
+
+// ResetAutoGroupSelection 切换模型后 auto 分组从第一个分组重新选择渠道
+func ResetAutoGroupSelection(c *gin.Context) {
+	if _, ok := common.GetContextKey(c, constant.ContextKeyAutoGroupIndex); ok {
+		common.SetContextKey(c, constant.ContextKeyAutoGroupIndex, 0)
+		common.SetContextKey(c, constant.ContextKeyAutoGroupRetryIndex, 0)
+	}
+}
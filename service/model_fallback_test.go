@@ -0,0 +1,57 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+	"github.com/QuantumNous/new-api/types"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetModelFallbackChain_TokenOverridesGroupAndRespectsLimits(t *testing.T) {
+	setting := operation_setting.GetModelFallbackSetting()
+	saved := *setting
+	t.Cleanup(func() { *setting = saved })
+	setting.Enabled = true
+	setting.GroupChains = map[string]map[string][]string{
+		"*":   {"gpt-4o": {"gemini-2.5-pro"}},
+		"vip": {"gpt-4o": {"claude-sonnet-4-5", "gpt-4o", "claude-sonnet-4-5", "gemini-2.5-pro"}},
+	}
+
+	newCtx := func() *gin.Context {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+		return c
+	}
+
+	c := newCtx()
+	require.Equal(t, []string{"gemini-2.5-pro"}, GetModelFallbackChain(c, "default", "gpt-4o"))
+	// 去重并跳过主模型自身
+	require.Equal(t, []string{"claude-sonnet-4-5", "gemini-2.5-pro"}, GetModelFallbackChain(c, "vip", "gpt-4o"))
+	require.Nil(t, GetModelFallbackChain(c, "vip", "gpt-4o-mini"))
+
+	common.SetContextKey(c, constant.ContextKeyTokenModelFallbacks, map[string][]string{"gpt-4o": {"deepseek-chat"}})
+	require.Equal(t, []string{"deepseek-chat"}, GetModelFallbackChain(c, "vip", "gpt-4o"))
+
+	c = newCtx()
+	common.SetContextKey(c, constant.ContextKeyTokenModelLimitEnabled, true)
+	common.SetContextKey(c, constant.ContextKeyTokenModelLimit, map[string]bool{"gpt-4o": true, "gemini-2.5-pro": true})
+	require.Equal(t, []string{"gemini-2.5-pro"}, GetModelFallbackChain(c, "vip", "gpt-4o"))
+
+	setting.Enabled = false
+	require.Nil(t, GetModelFallbackChain(newCtx(), "vip", "gpt-4o"))
+}
+
+func TestShouldFallbackModel(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+	require.True(t, ShouldFallbackModel(c, types.NewErrorWithStatusCode(http.ErrHandlerTimeout, types.ErrorCodeBadResponseStatusCode, http.StatusTooManyRequests)))
+	require.True(t, ShouldFallbackModel(c, types.NewError(http.ErrHandlerTimeout, types.ErrorCodeGetChannelFailed, types.ErrOptionWithSkipRetry())))
+	require.False(t, ShouldFallbackModel(c, types.NewErrorWithStatusCode(http.ErrHandlerTimeout, types.ErrorCodeBadResponseStatusCode, http.StatusBadRequest)))
+	require.False(t, ShouldFallbackModel(c, nil))
+}
@@ -0,0 +1,370 @@
+package openaicompat
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/dto"
+)
+
+const (
+	responsesStatusInProgress = "in_progress"
+	responsesStatusCompleted  = "completed"
+	responsesStatusIncomplete = "incomplete"
+)
+
+// ChatUsageToResponsesUsage 补齐 Responses API 使用的 input_tokens / output_tokens 字段
+func ChatUsageToResponsesUsage(usage *dto.Usage) *dto.Usage {
+	if usage == nil {
+		return nil
+	}
+	out := *usage
+	out.InputTokens = usage.PromptTokens
+	out.OutputTokens = usage.CompletionTokens
+	if out.TotalTokens == 0 {
+		out.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	}
+	details := usage.PromptTokensDetails
+	out.InputTokensDetails = &details
+	return &out
+}
+
+func newResponsesResponse(id string, createdAt int, model string) *dto.OpenAIResponsesResponse {
+	return &dto.OpenAIResponsesResponse{
+		ID:        id,
+		Object:    "response",
+		CreatedAt: createdAt,
+		Status:    responsesStatusInProgress,
+		Model:     model,
+		Output:    []dto.ResponsesOutput{},
+	}
+}
+
+func applyResponsesFinishReason(resp *dto.OpenAIResponsesResponse, finishReason string) {
+	switch finishReason {
+	case "length":
+		resp.Status = responsesStatusIncomplete
+		resp.IncompleteDetails = &dto.IncompleteDetails{Reason: "max_output_tokens"}
+	case "content_filter":
+		resp.Status = responsesStatusIncomplete
+		resp.IncompleteDetails = &dto.IncompleteDetails{Reason: "content_filter"}
+	default:
+		resp.Status = responsesStatusCompleted
+	}
+}
+
+func newReasoningOutput(id string, text string) dto.ResponsesOutput {
+	return dto.ResponsesOutput{
+		Type:    "reasoning",
+		ID:      id,
+		Status:  responsesStatusCompleted,
+		Summary: []dto.ResponsesReasoningSummaryPart{{Type: "summary_text", Text: text}},
+	}
+}
+
+func newMessageOutput(id string, text string) dto.ResponsesOutput {
+	return dto.ResponsesOutput{
+		Type:    "message",
+		ID:      id,
+		Status:  responsesStatusCompleted,
+		Role:    "assistant",
+		Content: []dto.ResponsesOutputContent{{Type: "output_text", Text: text, Annotations: []interface{}{}}},
+	}
+}
+
+func newFunctionCallOutput(callId string, name string, arguments string) dto.ResponsesOutput {
+	return dto.ResponsesOutput{
+		Type:      "function_call",
+		ID:        "fc_" + callId,
+		Status:    responsesStatusCompleted,
+		CallId:    callId,
+		Name:      name,
+		Arguments: arguments,
+	}
+}
+
+// ChatCompletionsResponseToResponsesResponse 将 chat completions 非流式响应转换为 /v1/responses 响应
+func ChatCompletionsResponseToResponsesResponse(resp *dto.OpenAITextResponse, id string) *dto.OpenAIResponsesResponse {
+	createdAt := int(common.GetTimestamp())
+	switch created := resp.Created.(type) {
+	case int64:
+		createdAt = int(created)
+	case int:
+		createdAt = created
+	case float64:
+		createdAt = int(created)
+	}
+	out := newResponsesResponse(id, createdAt, resp.Model)
+	finishReason := ""
+	if len(resp.Choices) > 0 {
+		choice := resp.Choices[0]
+		finishReason = choice.FinishReason
+
+		reasoning := choice.Message.ReasoningContent
+		if reasoning == "" {
+			reasoning = choice.Message.Reasoning
+		}
+		if strings.TrimSpace(reasoning) != "" {
+			out.Output = append(out.Output, newReasoningOutput("rs_"+common.GetUUID(), reasoning))
+		}
+		if text := choice.Message.StringContent(); text != "" {
+			out.Output = append(out.Output, newMessageOutput("msg_"+common.GetUUID(), text))
+		}
+		for _, toolCall := range choice.Message.ParseToolCalls() {
+			out.Output = append(out.Output, newFunctionCallOutput(toolCall.ID, toolCall.Function.Name, toolCall.Function.Arguments))
+		}
+	}
+	applyResponsesFinishReason(out, finishReason)
+	out.Usage = ChatUsageToResponsesUsage(&resp.Usage)
+	return out
+}
+
+type responsesStreamItem struct {
+	outputIndex int
+	item        dto.ResponsesOutput
+	text        strings.Builder
+}
+
+// ChatToResponsesStreamState 把 chat completions 流式 chunk 转换为 /v1/responses 的流式事件。
+// 只处理第一个 choice；文本、推理和函数调用分别映射为 message、reasoning、function_call 输出项。
+type ChatToResponsesStreamState struct {
+	ID        string
+	Model     string
+	CreatedAt int
+
+	started         bool
+	completed       bool
+	nextOutputIndex int
+	finishReason    string
+	output          map[int]dto.ResponsesOutput
+
+	reasoning *responsesStreamItem
+	message   *responsesStreamItem
+	toolCalls map[int]*responsesStreamItem
+}
+
+func NewChatToResponsesStreamState(id string, model string, createdAt int) *ChatToResponsesStreamState {
+	return &ChatToResponsesStreamState{
+		ID:        id,
+		Model:     model,
+		CreatedAt: createdAt,
+		output:    make(map[int]dto.ResponsesOutput),
+		toolCalls: make(map[int]*responsesStreamItem),
+	}
+}
+
+func (s *ChatToResponsesStreamState) response(status string) *dto.OpenAIResponsesResponse {
+	resp := newResponsesResponse(s.ID, s.CreatedAt, s.Model)
+	resp.Status = status
+	return resp
+}
+
+func (s *ChatToResponsesStreamState) start() []dto.ResponsesStreamResponse {
+	if s.started {
+		return nil
+	}
+	s.started = true
+	return []dto.ResponsesStreamResponse{
+		{Type: "response.created", Response: s.response(responsesStatusInProgress)},
+		{Type: "response.in_progress", Response: s.response(responsesStatusInProgress)},
+	}
+}
+
+func (s *ChatToResponsesStreamState) openItem(item dto.ResponsesOutput) (*responsesStreamItem, dto.ResponsesStreamResponse) {
+	open := &responsesStreamItem{outputIndex: s.nextOutputIndex, item: item}
+	s.nextOutputIndex++
+	added := item
+	added.Status = responsesStatusInProgress
+	return open, dto.ResponsesStreamResponse{
+		Type:        dto.ResponsesOutputTypeItemAdded,
+		OutputIndex: common.GetPointer(open.outputIndex),
+		Item:        &added,
+	}
+}
+
+func (s *ChatToResponsesStreamState) closeReasoning() []dto.ResponsesStreamResponse {
+	if s.reasoning == nil {
+		return nil
+	}
+	open := s.reasoning
+	s.reasoning = nil
+	text := open.text.String()
+	item := newReasoningOutput(open.item.ID, text)
+	s.output[open.outputIndex] = item
+	return []dto.ResponsesStreamResponse{
+		{Type: "response.reasoning_summary_text.done", ItemID: item.ID, OutputIndex: common.GetPointer(open.outputIndex), SummaryIndex: common.GetPointer(0), Text: text},
+		{Type: "response.reasoning_summary_part.done", ItemID: item.ID, OutputIndex: common.GetPointer(open.outputIndex), SummaryIndex: common.GetPointer(0), Part: &dto.ResponsesReasoningSummaryPart{Type: "summary_text", Text: text}},
+		{Type: dto.ResponsesOutputTypeItemDone, OutputIndex: common.GetPointer(open.outputIndex), Item: &item},
+	}
+}
+
+func (s *ChatToResponsesStreamState) closeMessage() []dto.ResponsesStreamResponse {
+	if s.message == nil {
+		return nil
+	}
+	open := s.message
+	s.message = nil
+	text := open.text.String()
+	item := newMessageOutput(open.item.ID, text)
+	s.output[open.outputIndex] = item
+	return []dto.ResponsesStreamResponse{
+		{Type: "response.output_text.done", ItemID: item.ID, OutputIndex: common.GetPointer(open.outputIndex), ContentIndex: common.GetPointer(0), Text: text},
+		{Type: "response.content_part.done", ItemID: item.ID, OutputIndex: common.GetPointer(open.outputIndex), ContentIndex: common.GetPointer(0), Part: &dto.ResponsesReasoningSummaryPart{Type: "output_text", Text: text}},
+		{Type: dto.ResponsesOutputTypeItemDone, OutputIndex: common.GetPointer(open.outputIndex), Item: &item},
+	}
+}
+
+func (s *ChatToResponsesStreamState) closeToolCalls() []dto.ResponsesStreamResponse {
+	if len(s.toolCalls) == 0 {
+		return nil
+	}
+	opens := make([]*responsesStreamItem, 0, len(s.toolCalls))
+	for _, open := range s.toolCalls {
+		opens = append(opens, open)
+	}
+	sort.Slice(opens, func(i, j int) bool { return opens[i].outputIndex < opens[j].outputIndex })
+	s.toolCalls = make(map[int]*responsesStreamItem)
+
+	events := make([]dto.ResponsesStreamResponse, 0, len(opens)*2)
+	for _, open := range opens {
+		arguments := open.text.String()
+		item := newFunctionCallOutput(open.item.CallId, open.item.Name, arguments)
+		s.output[open.outputIndex] = item
+		events = append(events,
+			dto.ResponsesStreamResponse{Type: "response.function_call_arguments.done", ItemID: item.ID, OutputIndex: common.GetPointer(open.outputIndex), Arguments: arguments},
+			dto.ResponsesStreamResponse{Type: dto.ResponsesOutputTypeItemDone, OutputIndex: common.GetPointer(open.outputIndex), Item: &item},
+		)
+	}
+	return events
+}
+
+// HandleChunk 处理一个 chat completions 流式 chunk，返回需要发送给客户端的事件
+func (s *ChatToResponsesStreamState) HandleChunk(chunk *dto.ChatCompletionsStreamResponse) []dto.ResponsesStreamResponse {
+	if s.completed || chunk == nil {
+		return nil
+	}
+	events := s.start()
+	if chunk.Model != "" && s.Model == "" {
+		s.Model = chunk.Model
+	}
+	if len(chunk.Choices) == 0 {
+		return events
+	}
+	choice := chunk.Choices[0]
+
+	if reasoning := choice.Delta.GetReasoningContent(); reasoning != "" {
+		events = append(events, s.closeMessage()...)
+		events = append(events, s.closeToolCalls()...)
+		if s.reasoning == nil {
+			var added dto.ResponsesStreamResponse
+			s.reasoning, added = s.openItem(dto.ResponsesOutput{Type: "reasoning", ID: "rs_" + common.GetUUID()})
+			events = append(events, added, dto.ResponsesStreamResponse{
+				Type:         "response.reasoning_summary_part.added",
+				ItemID:       s.reasoning.item.ID,
+				OutputIndex:  common.GetPointer(s.reasoning.outputIndex),
+				SummaryIndex: common.GetPointer(0),
+				Part:         &dto.ResponsesReasoningSummaryPart{Type: "summary_text"},
+			})
+		}
+		s.reasoning.text.WriteString(reasoning)
+		events = append(events, dto.ResponsesStreamResponse{
+			Type:         "response.reasoning_summary_text.delta",
+			ItemID:       s.reasoning.item.ID,
+			OutputIndex:  common.GetPointer(s.reasoning.outputIndex),
+			SummaryIndex: common.GetPointer(0),
+			Delta:        reasoning,
+		})
+	}
+
+	if content := choice.Delta.GetContentString(); content != "" {
+		events = append(events, s.closeReasoning()...)
+		events = append(events, s.closeToolCalls()...)
+		if s.message == nil {
+			var added dto.ResponsesStreamResponse
+			s.message, added = s.openItem(dto.ResponsesOutput{Type: "message", ID: "msg_" + common.GetUUID(), Role: "assistant", Content: []dto.ResponsesOutputContent{}})
+			events = append(events, added, dto.ResponsesStreamResponse{
+				Type:         "response.content_part.added",
+				ItemID:       s.message.item.ID,
+				OutputIndex:  common.GetPointer(s.message.outputIndex),
+				ContentIndex: common.GetPointer(0),
+				Part:         &dto.ResponsesReasoningSummaryPart{Type: "output_text"},
+			})
+		}
+		s.message.text.WriteString(content)
+		events = append(events, dto.ResponsesStreamResponse{
+			Type:         "response.output_text.delta",
+			ItemID:       s.message.item.ID,
+			OutputIndex:  common.GetPointer(s.message.outputIndex),
+			ContentIndex: common.GetPointer(0),
+			Delta:        content,
+		})
+	}
+
+	if len(choice.Delta.ToolCalls) > 0 {
+		events = append(events, s.closeReasoning()...)
+		events = append(events, s.closeMessage()...)
+		for _, toolCall := range choice.Delta.ToolCalls {
+			index := 0
+			if toolCall.Index != nil {
+				index = *toolCall.Index
+			}
+			open, ok := s.toolCalls[index]
+			if !ok || (toolCall.ID != "" && toolCall.ID != open.item.CallId) {
+				if ok {
+					// 同一 index 出现新的调用，先结束旧的
+					events = append(events, s.closeToolCalls()...)
+				}
+				callId := toolCall.ID
+				if callId == "" {
+					callId = "call_" + common.GetUUID()
+				}
+				var added dto.ResponsesStreamResponse
+				open, added = s.openItem(newFunctionCallOutput(callId, toolCall.Function.Name, ""))
+				s.toolCalls[index] = open
+				events = append(events, added)
+			}
+			if toolCall.Function.Arguments != "" {
+				open.text.WriteString(toolCall.Function.Arguments)
+				events = append(events, dto.ResponsesStreamResponse{
+					Type:        "response.function_call_arguments.delta",
+					ItemID:      open.item.ID,
+					OutputIndex: common.GetPointer(open.outputIndex),
+					Delta:       toolCall.Function.Arguments,
+				})
+			}
+		}
+	}
+
+	if choice.FinishReason != nil && *choice.FinishReason != "" {
+		s.finishReason = *choice.FinishReason
+	}
+	return events
+}
+
+// Finish 结束所有未完成的输出项并发送 response.completed，重复调用不会再产生事件
+func (s *ChatToResponsesStreamState) Finish(usage *dto.Usage) []dto.ResponsesStreamResponse {
+	if s.completed {
+		return nil
+	}
+	events := s.start()
+	events = append(events, s.closeReasoning()...)
+	events = append(events, s.closeMessage()...)
+	events = append(events, s.closeToolCalls()...)
+	s.completed = true
+
+	resp := s.response(responsesStatusCompleted)
+	for i := 0; i < s.nextOutputIndex; i++ {
+		if item, ok := s.output[i]; ok {
+			resp.Output = append(resp.Output, item)
+		}
+	}
+	applyResponsesFinishReason(resp, s.finishReason)
+	resp.Usage = ChatUsageToResponsesUsage(usage)
+
+	eventType := "response.completed"
+	if resp.Status == responsesStatusIncomplete {
+		eventType = "response.incomplete"
+	}
+	return append(events, dto.ResponsesStreamResponse{Type: eventType, Response: resp})
+}
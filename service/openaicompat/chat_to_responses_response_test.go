@@ -0,0 +1,102 @@
+package openaicompat
+
+import (
+	"testing"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/dto"
+	"github.com/stretchr/testify/require"
+)
+
+func chatChunk(delta dto.ChatCompletionsStreamResponseChoiceDelta, finishReason string) *dto.ChatCompletionsStreamResponse {
+	choice := dto.ChatCompletionsStreamResponseChoice{Delta: delta}
+	if finishReason != "" {
+		choice.FinishReason = &finishReason
+	}
+	return &dto.ChatCompletionsStreamResponse{Choices: []dto.ChatCompletionsStreamResponseChoice{choice}}
+}
+
+func TestChatToResponsesStreamState(t *testing.T) {
+	state := NewChatToResponsesStreamState("resp_1", "gemini-2.5-pro", 100)
+	var events []dto.ResponsesStreamResponse
+
+	events = append(events, state.HandleChunk(chatChunk(dto.ChatCompletionsStreamResponseChoiceDelta{ReasoningContent: common.GetPointer("think")}, ""))...)
+	events = append(events, state.HandleChunk(chatChunk(dto.ChatCompletionsStreamResponseChoiceDelta{Content: common.GetPointer("Hel")}, ""))...)
+	events = append(events, state.HandleChunk(chatChunk(dto.ChatCompletionsStreamResponseChoiceDelta{Content: common.GetPointer("lo")}, ""))...)
+	events = append(events, state.HandleChunk(chatChunk(dto.ChatCompletionsStreamResponseChoiceDelta{ToolCalls: []dto.ToolCallResponse{
+		{Index: common.GetPointer(0), ID: "call_1", Function: dto.FunctionResponse{Name: "f"}},
+	}}, ""))...)
+	events = append(events, state.HandleChunk(chatChunk(dto.ChatCompletionsStreamResponseChoiceDelta{ToolCalls: []dto.ToolCallResponse{
+		{Index: common.GetPointer(0), Function: dto.FunctionResponse{Arguments: `{"a":1}`}},
+	}}, "tool_calls"))...)
+	events = append(events, state.Finish(&dto.Usage{PromptTokens: 10, CompletionTokens: 5})...)
+	require.Empty(t, state.Finish(nil))
+
+	var types []string
+	for _, event := range events {
+		types = append(types, event.Type)
+	}
+	require.Equal(t, []string{
+		"response.created",
+		"response.in_progress",
+		"response.output_item.added",
+		"response.reasoning_summary_part.added",
+		"response.reasoning_summary_text.delta",
+		"response.reasoning_summary_text.done",
+		"response.reasoning_summary_part.done",
+		"response.output_item.done",
+		"response.output_item.added",
+		"response.content_part.added",
+		"response.output_text.delta",
+		"response.output_text.delta",
+		"response.output_text.done",
+		"response.content_part.done",
+		"response.output_item.done",
+		"response.output_item.added",
+		"response.function_call_arguments.delta",
+		"response.function_call_arguments.done",
+		"response.output_item.done",
+		"response.completed",
+	}, types)
+
+	completed := events[len(events)-1].Response
+	require.Equal(t, "completed", completed.Status)
+	require.Len(t, completed.Output, 3)
+	require.Equal(t, "think", completed.Output[0].Summary[0].Text)
+	require.Equal(t, "Hello", completed.Output[1].Content[0].Text)
+	require.Equal(t, "call_1", completed.Output[2].CallId)
+	require.Equal(t, `{"a":1}`, completed.Output[2].Arguments)
+	require.Equal(t, 10, completed.Usage.InputTokens)
+	require.Equal(t, 5, completed.Usage.OutputTokens)
+	require.Equal(t, 15, completed.Usage.TotalTokens)
+}
+
+func TestChatToResponsesStreamState_Incomplete(t *testing.T) {
+	state := NewChatToResponsesStreamState("resp_1", "m", 100)
+	state.HandleChunk(chatChunk(dto.ChatCompletionsStreamResponseChoiceDelta{Content: common.GetPointer("cut")}, "length"))
+	events := state.Finish(&dto.Usage{})
+	last := events[len(events)-1]
+	require.Equal(t, "response.incomplete", last.Type)
+	require.Equal(t, "max_output_tokens", last.Response.IncompleteDetails.Reason)
+}
+
+func TestChatCompletionsResponseToResponsesResponse(t *testing.T) {
+	msg := dto.Message{Role: "assistant", Content: "done", ReasoningContent: "why"}
+	msg.SetToolCalls([]dto.ToolCallRequest{{ID: "call_1", Type: "function", Function: dto.FunctionRequest{Name: "f", Arguments: "{}"}}})
+	resp := ChatCompletionsResponseToResponsesResponse(&dto.OpenAITextResponse{
+		Model:   "claude-sonnet-4",
+		Created: int64(100),
+		Choices: []dto.OpenAITextResponseChoice{{Message: msg, FinishReason: "tool_calls"}},
+		Usage:   dto.Usage{PromptTokens: 3, CompletionTokens: 4, TotalTokens: 7},
+	}, "resp_1")
+
+	require.Equal(t, "resp_1", resp.ID)
+	require.Equal(t, 100, resp.CreatedAt)
+	require.Equal(t, "completed", resp.Status)
+	require.Len(t, resp.Output, 3)
+	require.Equal(t, "reasoning", resp.Output[0].Type)
+	require.Equal(t, "message", resp.Output[1].Type)
+	require.Equal(t, "function_call", resp.Output[2].Type)
+	require.Equal(t, 3, resp.Usage.InputTokens)
+	require.Equal(t, 4, resp.Usage.OutputTokens)
+}
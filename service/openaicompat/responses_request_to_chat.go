@@ -0,0 +1,351 @@
+package openaicompat
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/dto"
+)
+
+// responsesInputItem 覆盖 Responses API input 数组中 message / function_call / function_call_output 等条目的字段
+type responsesInputItem struct {
+	Type      string `json:"type"`
+	Role      string `json:"role"`
+	Content   any    `json:"content"`
+	CallId    string `json:"call_id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+	Output    any    `json:"output"`
+}
+
+// ResponsesRequestToChatCompletionsRequest 将 /v1/responses 请求转换为 chat completions 请求，
+// 供只支持 chat 格式转换的渠道（Claude、Gemini 等）复用已有的请求转换逻辑。
+// reasoning 条目与内置工具调用记录会被忽略。
+func ResponsesRequestToChatCompletionsRequest(req *dto.OpenAIResponsesRequest) (*dto.GeneralOpenAIRequest, error) {
+	if req == nil {
+		return nil, errors.New("request is nil")
+	}
+	if req.Model == "" {
+		return nil, errors.New("model is required")
+	}
+	if req.PreviousResponseID != "" {
+		return nil, errors.New("previous_response_id is not supported by this channel")
+	}
+	if len(req.Conversation) > 0 && common.GetJsonType(req.Conversation) != "null" {
+		return nil, errors.New("conversation is not supported by this channel")
+	}
+
+	out := &dto.GeneralOpenAIRequest{
+		Model:       req.Model,
+		Stream:      req.Stream,
+		MaxTokens:   req.MaxOutputTokens,
+		Temperature: req.Temperature,
+		User:        req.User,
+		Metadata:    req.Metadata,
+	}
+	if req.Stream {
+		out.StreamOptions = &dto.StreamOptions{IncludeUsage: true}
+	}
+	if req.TopP != nil {
+		out.TopP = *req.TopP
+	}
+	if req.Reasoning != nil && req.Reasoning.Effort != "" {
+		out.ReasoningEffort = req.Reasoning.Effort
+	}
+	if len(req.ParallelToolCalls) > 0 {
+		var parallel bool
+		if err := common.Unmarshal(req.ParallelToolCalls, &parallel); err == nil {
+			out.ParallelTooCalls = &parallel
+		}
+	}
+
+	if len(req.Instructions) > 0 {
+		var instructions string
+		if err := common.Unmarshal(req.Instructions, &instructions); err == nil && strings.TrimSpace(instructions) != "" {
+			out.Messages = append(out.Messages, dto.Message{Role: "system", Content: instructions})
+		}
+	}
+
+	messages, err := convertResponsesInputToMessages(req.Input)
+	if err != nil {
+		return nil, err
+	}
+	out.Messages = append(out.Messages, messages...)
+
+	if err := convertResponsesToolsToChat(req.Tools, out); err != nil {
+		return nil, err
+	}
+	if len(req.ToolChoice) > 0 {
+		out.ToolChoice = convertResponsesToolChoiceToChat(req.ToolChoice)
+	}
+	out.ResponseFormat = convertResponsesTextToChatResponseFormat(req.Text)
+
+	return out, nil
+}
+
+func convertResponsesInputToMessages(input []byte) ([]dto.Message, error) {
+	if len(input) == 0 {
+		return nil, nil
+	}
+	switch common.GetJsonType(input) {
+	case "string":
+		var text string
+		if err := common.Unmarshal(input, &text); err != nil {
+			return nil, err
+		}
+		return []dto.Message{{Role: "user", Content: text}}, nil
+	case "array":
+	default:
+		return nil, fmt.Errorf("unsupported input type: %s", common.GetJsonType(input))
+	}
+
+	var items []responsesInputItem
+	if err := common.Unmarshal(input, &items); err != nil {
+		return nil, fmt.Errorf("invalid input: %w", err)
+	}
+
+	messages := make([]dto.Message, 0, len(items))
+	for _, item := range items {
+		switch item.Type {
+		case "", "message":
+			role := strings.TrimSpace(item.Role)
+			if role == "" {
+				role = "user"
+			}
+			if role == "developer" {
+				role = "system"
+			}
+			msg := dto.Message{Role: role}
+			setResponsesContentToChat(&msg, item.Content)
+			messages = append(messages, msg)
+		case "function_call":
+			toolCall := dto.ToolCallRequest{
+				ID:   item.CallId,
+				Type: "function",
+				Function: dto.FunctionRequest{
+					Name:      item.Name,
+					Arguments: item.Arguments,
+				},
+			}
+			// 连续的 function_call 合并到同一条 assistant 消息
+			if n := len(messages); n > 0 && messages[n-1].Role == "assistant" {
+				toolCalls := append(messages[n-1].ParseToolCalls(), toolCall)
+				messages[n-1].SetToolCalls(toolCalls)
+				continue
+			}
+			msg := dto.Message{Role: "assistant"}
+			msg.SetToolCalls([]dto.ToolCallRequest{toolCall})
+			messages = append(messages, msg)
+		case "function_call_output":
+			messages = append(messages, dto.Message{
+				Role:       "tool",
+				ToolCallId: item.CallId,
+				Content:    responsesOutputToString(item.Output),
+			})
+		default:
+			// reasoning、web_search_call、item_reference 等条目无法在 chat 格式中表达
+			continue
+		}
+	}
+	return messages, nil
+}
+
+// setResponsesContentToChat 将 input_text / output_text / input_image 等内容转换为 chat 的 content
+func setResponsesContentToChat(msg *dto.Message, content any) {
+	parts, ok := content.([]any)
+	if !ok {
+		text, _ := content.(string)
+		msg.SetStringContent(text)
+		return
+	}
+
+	mediaContents := make([]dto.MediaContent, 0, len(parts))
+	allText := true
+	for _, partAny := range parts {
+		part, ok := partAny.(map[string]any)
+		if !ok {
+			continue
+		}
+		switch common.Interface2String(part["type"]) {
+		case "input_text", "output_text", "text":
+			mediaContents = append(mediaContents, dto.MediaContent{
+				Type: dto.ContentTypeText,
+				Text: common.Interface2String(part["text"]),
+			})
+		case "refusal":
+			mediaContents = append(mediaContents, dto.MediaContent{
+				Type: dto.ContentTypeText,
+				Text: common.Interface2String(part["refusal"]),
+			})
+		case "input_image":
+			imageUrl := &dto.MessageImageUrl{Detail: common.Interface2String(part["detail"])}
+			switch v := part["image_url"].(type) {
+			case string:
+				imageUrl.Url = v
+			case map[string]any:
+				imageUrl.Url = common.Interface2String(v["url"])
+			}
+			if imageUrl.Url == "" {
+				continue
+			}
+			if imageUrl.Detail == "" {
+				imageUrl.Detail = "auto"
+			}
+			allText = false
+			mediaContents = append(mediaContents, dto.MediaContent{
+				Type:     dto.ContentTypeImageURL,
+				ImageUrl: imageUrl,
+			})
+		case "input_file":
+			file := &dto.MessageFile{
+				FileName: common.Interface2String(part["filename"]),
+				FileData: common.Interface2String(part["file_data"]),
+				FileId:   common.Interface2String(part["file_id"]),
+			}
+			if file.FileData == "" {
+				file.FileData = common.Interface2String(part["file_url"])
+			}
+			allText = false
+			mediaContents = append(mediaContents, dto.MediaContent{
+				Type: dto.ContentTypeFile,
+				File: file,
+			})
+		case "input_audio":
+			inputAudio, ok := part["input_audio"].(map[string]any)
+			if !ok {
+				continue
+			}
+			allText = false
+			mediaContents = append(mediaContents, dto.MediaContent{
+				Type: dto.ContentTypeInputAudio,
+				InputAudio: &dto.MessageInputAudio{
+					Data:   common.Interface2String(inputAudio["data"]),
+					Format: common.Interface2String(inputAudio["format"]),
+				},
+			})
+		}
+	}
+
+	// 纯文本内容合并为字符串，兼容只接受字符串 content 的转换逻辑
+	if allText {
+		var sb strings.Builder
+		for _, media := range mediaContents {
+			sb.WriteString(media.Text)
+		}
+		msg.SetStringContent(sb.String())
+		return
+	}
+	msg.SetMediaContent(mediaContents)
+}
+
+// responsesOutputToString function_call_output 的 output 可以是字符串或内容数组
+func responsesOutputToString(output any) string {
+	switch v := output.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []any:
+		var sb strings.Builder
+		for _, partAny := range v {
+			if part, ok := partAny.(map[string]any); ok {
+				sb.WriteString(common.Interface2String(part["text"]))
+			}
+		}
+		return sb.String()
+	default:
+		b, err := common.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(b)
+	}
+}
+
+func convertResponsesToolsToChat(toolsRaw []byte, out *dto.GeneralOpenAIRequest) error {
+	if len(toolsRaw) == 0 {
+		return nil
+	}
+	var tools []map[string]any
+	if err := common.Unmarshal(toolsRaw, &tools); err != nil {
+		return fmt.Errorf("invalid tools: %w", err)
+	}
+	for _, tool := range tools {
+		switch toolType := common.Interface2String(tool["type"]); toolType {
+		case "function":
+			out.Tools = append(out.Tools, dto.ToolCallRequest{
+				Type: "function",
+				Function: dto.FunctionRequest{
+					Name:        common.Interface2String(tool["name"]),
+					Description: common.Interface2String(tool["description"]),
+					Parameters:  tool["parameters"],
+				},
+			})
+		case dto.BuildInToolWebSearchPreview, "web_search":
+			options := &dto.WebSearchOptions{
+				SearchContextSize: common.Interface2String(tool["search_context_size"]),
+			}
+			if location, ok := tool["user_location"]; ok && location != nil {
+				options.UserLocation, _ = common.Marshal(location)
+			}
+			out.WebSearchOptions = options
+		default:
+			return fmt.Errorf("tool type %q is not supported by this channel", toolType)
+		}
+	}
+	return nil
+}
+
+func convertResponsesToolChoiceToChat(toolChoiceRaw []byte) any {
+	if common.GetJsonType(toolChoiceRaw) == "string" {
+		var choice string
+		_ = common.Unmarshal(toolChoiceRaw, &choice)
+		return choice
+	}
+	var choice map[string]any
+	if err := common.Unmarshal(toolChoiceRaw, &choice); err != nil {
+		return nil
+	}
+	// Responses: {"type":"function","name":"..."}
+	// Chat: {"type":"function","function":{"name":"..."}}
+	if common.Interface2String(choice["type"]) == "function" {
+		if name := common.Interface2String(choice["name"]); name != "" {
+			return map[string]any{
+				"type":     "function",
+				"function": map[string]any{"name": name},
+			}
+		}
+	}
+	return choice
+}
+
+func convertResponsesTextToChatResponseFormat(textRaw []byte) *dto.ResponseFormat {
+	if len(textRaw) == 0 {
+		return nil
+	}
+	var text struct {
+		Format map[string]any `json:"format"`
+	}
+	if err := common.Unmarshal(textRaw, &text); err != nil || text.Format == nil {
+		return nil
+	}
+	formatType := common.Interface2String(text.Format["type"])
+	switch formatType {
+	case "json_object":
+		return &dto.ResponseFormat{Type: formatType}
+	case "json_schema":
+		schema := make(map[string]any, len(text.Format))
+		for key, value := range text.Format {
+			if key == "type" {
+				continue
+			}
+			schema[key] = value
+		}
+		schemaRaw, _ := common.Marshal(schema)
+		return &dto.ResponseFormat{Type: formatType, JsonSchema: schemaRaw}
+	default:
+		return nil
+	}
+}
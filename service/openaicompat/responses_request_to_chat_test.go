@@ -0,0 +1,71 @@
+package openaicompat
+
+import (
+	"testing"
+
+	"github.com/QuantumNous/new-api/dto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponsesRequestToChatCompletionsRequest(t *testing.T) {
+	req := &dto.OpenAIResponsesRequest{
+		Model:           "claude-sonnet-4",
+		Stream:          true,
+		MaxOutputTokens: 1024,
+		Instructions:    []byte(`"be brief"`),
+		Reasoning:       &dto.Reasoning{Effort: "high"},
+		Input: []byte(`[
+			{"role":"developer","content":"use tools"},
+			{"type":"message","role":"user","content":[{"type":"input_text","text":"weather?"},{"type":"input_image","image_url":"https://example.com/a.png"}]},
+			{"type":"reasoning","id":"rs_1","summary":[]},
+			{"type":"message","role":"assistant","content":[{"type":"output_text","text":"checking"}]},
+			{"type":"function_call","call_id":"call_1","name":"get_weather","arguments":"{\"city\":\"Paris\"}"},
+			{"type":"function_call","call_id":"call_2","name":"get_time","arguments":"{}"},
+			{"type":"function_call_output","call_id":"call_1","output":"sunny"}
+		]`),
+		Tools:      []byte(`[{"type":"function","name":"get_weather","parameters":{"type":"object"}}]`),
+		ToolChoice: []byte(`{"type":"function","name":"get_weather"}`),
+		Text:       []byte(`{"format":{"type":"json_schema","name":"out","schema":{"type":"object"}}}`),
+	}
+
+	out, err := ResponsesRequestToChatCompletionsRequest(req)
+	require.NoError(t, err)
+	require.Equal(t, uint(1024), out.MaxTokens)
+	require.Equal(t, "high", out.ReasoningEffort)
+	require.True(t, out.StreamOptions.IncludeUsage)
+
+	require.Len(t, out.Messages, 5)
+	require.Equal(t, "system", out.Messages[0].Role)
+	require.Equal(t, "be brief", out.Messages[0].StringContent())
+	require.Equal(t, "system", out.Messages[1].Role)
+	require.Equal(t, "user", out.Messages[2].Role)
+	parts := out.Messages[2].ParseContent()
+	require.Len(t, parts, 2)
+	require.Equal(t, "https://example.com/a.png", parts[1].GetImageMedia().Url)
+
+	assistant := out.Messages[3]
+	require.Equal(t, "assistant", assistant.Role)
+	require.Equal(t, "checking", assistant.StringContent())
+	toolCalls := assistant.ParseToolCalls()
+	require.Len(t, toolCalls, 2)
+	require.Equal(t, "call_1", toolCalls[0].ID)
+	require.Equal(t, "get_time", toolCalls[1].Function.Name)
+
+	require.Equal(t, "tool", out.Messages[4].Role)
+	require.Equal(t, "call_1", out.Messages[4].ToolCallId)
+	require.Equal(t, "sunny", out.Messages[4].StringContent())
+
+	require.Len(t, out.Tools, 1)
+	require.Equal(t, "get_weather", out.Tools[0].Function.Name)
+	require.Equal(t, map[string]any{"type": "function", "function": map[string]any{"name": "get_weather"}}, out.ToolChoice)
+	require.Equal(t, "json_schema", out.ResponseFormat.Type)
+	require.JSONEq(t, `{"name":"out","schema":{"type":"object"}}`, string(out.ResponseFormat.JsonSchema))
+}
+
+func TestResponsesRequestToChatCompletionsRequest_Rejects(t *testing.T) {
+	_, err := ResponsesRequestToChatCompletionsRequest(&dto.OpenAIResponsesRequest{Model: "m", PreviousResponseID: "resp_1"})
+	require.Error(t, err)
+
+	_, err = ResponsesRequestToChatCompletionsRequest(&dto.OpenAIResponsesRequest{Model: "m", Tools: []byte(`[{"type":"code_interpreter"}]`)})
+	require.Error(t, err)
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"log"
 	"math"
-	"strings"
 	"time"
 
 	"github.com/QuantumNous/new-api/common"
@@ -95,7 +94,7 @@ func PreWssConsumeQuota(ctx *gin.Context, relayInfo *relaycommon.RelayInfo, usag
 		return err
 	}
 
-	token, err := model.GetTokenByKey(strings.TrimPrefix(relayInfo.TokenKey, "sk-"), false)
+	token, err := model.GetTokenByIdWithCache(relayInfo.TokenId)
 	if err != nil {
 		return err
 	}
@@ -138,7 +137,8 @@ func PreWssConsumeQuota(ctx *gin.Context, relayInfo *relaycommon.RelayInfo, usag
 
 	quota := calculateAudioQuota(quotaInfo)
 
-	if userQuota < quota {
+	// 组织令牌不校验个人余额，组织余额与成员上限在 PostConsumeQuota 中原子校验
+	if relayInfo.TokenOrgId == 0 && userQuota < quota {
 		return fmt.Errorf("user quota is not enough, user quota: %s, need quota: %s", logger.FormatQuota(userQuota), logger.FormatQuota(quota))
 	}
 
@@ -237,9 +237,14 @@ func PostWssConsumeQuota(ctx *gin.Context, relayInfo *relaycommon.RelayInfo, mod
 
 func PostClaudeConsumeQuota(ctx *gin.Context, relayInfo *relaycommon.RelayInfo, usage *dto.Usage) {
 	if usage != nil {
-		ObserveChannelAffinityUsageCacheByRelayFormat(ctx, usage, relayInfo.GetFinalRequestRelayFormat())
+		common.SetContextKey(ctx, constant.ContextKeyRelayUsage, usage)
+		if !relayInfo.ResponseCacheHit {
+			ObserveChannelAffinityUsageCacheByRelayFormat(ctx, usage, relayInfo.GetFinalRequestRelayFormat())
+		}
 	}
 
+	ApplyPriceTier(relayInfo, usage, relayInfo.ChannelType != constant.ChannelTypeOpenRouter)
+
 	useTimeSeconds := time.Now().Unix() - relayInfo.StartTime.Unix()
 	promptTokens := usage.PromptTokens
 	completionTokens := usage.CompletionTokens
@@ -292,11 +297,18 @@ func PostClaudeConsumeQuota(ctx *gin.Context, relayInfo *relaycommon.RelayInfo,
 		calculateQuota = 1
 	}
 
+	var logContent string
+	// 响应缓存命中按缓存计费倍率结算
+	if relayInfo.ResponseCacheHit {
+		cacheBillingRatio := GetResponseCacheBillingRatio()
+		calculateQuota *= cacheBillingRatio
+		logContent += fmt.Sprintf("响应缓存命中，计费倍率 %.2f", cacheBillingRatio)
+	}
+
 	quota := int(calculateQuota)
 
 	totalTokens := promptTokens + completionTokens
 
-	var logContent string
 	// record all the consume log even if quota is 0
 	if totalTokens == 0 {
 		// in this case, must be some error happened
@@ -337,6 +349,19 @@ func PostClaudeConsumeQuota(ctx *gin.Context, relayInfo *relaycommon.RelayInfo,
 
 }
 
+// ApplyPriceTier 按实际输入 token 数（含缓存读写）选择长上下文阶梯档位。
+// excludesCache 表示 prompt tokens 不含缓存部分（Anthropic 语义），需要加回。
+func ApplyPriceTier(relayInfo *relaycommon.RelayInfo, usage *dto.Usage, excludesCache bool) {
+	if usage == nil || len(relayInfo.PriceData.PriceTiers) == 0 {
+		return
+	}
+	contextTokens := usage.PromptTokens
+	if excludesCache {
+		contextTokens += usage.PromptTokensDetails.CachedTokens + usage.PromptTokensDetails.CachedCreationTokens
+	}
+	relayInfo.PriceData.ApplyPriceTier(contextTokens)
+}
+
 func CalcOpenRouterCacheCreateTokens(usage dto.Usage, priceData types.PriceData) int {
 	if priceData.CacheCreationRatio == 1 {
 		return 0
@@ -359,6 +384,9 @@ func CalcOpenRouterCacheCreateTokens(usage dto.Usage, priceData types.PriceData)
 }
 
 func PostAudioConsumeQuota(ctx *gin.Context, relayInfo *relaycommon.RelayInfo, usage *dto.Usage, extraContent string) {
+	if usage != nil {
+		common.SetContextKey(ctx, constant.ContextKeyRelayUsage, usage)
+	}
 
 	useTimeSeconds := time.Now().Unix() - relayInfo.StartTime.Unix()
 	textInputTokens := usage.PromptTokensDetails.TextTokens
@@ -452,14 +480,17 @@ func PreConsumeTokenQuota(relayInfo *relaycommon.RelayInfo, quota int) error {
 	//if relayInfo.TokenUnlimited {
 	//	return nil
 	//}
-	token, err := model.GetTokenByKey(relayInfo.TokenKey, false)
+	token, err := model.GetTokenByIdWithCache(relayInfo.TokenId)
 	if err != nil {
 		return err
 	}
+	if !relayInfo.TokenUnlimited && token.RemainQuota < quota && token.HasBudget() {
+		return &model.TokenBudgetExhaustedError{NextResetTime: token.NextBudgetResetTime}
+	}
 	if !relayInfo.TokenUnlimited && token.RemainQuota < quota {
 		return fmt.Errorf("token quota is not enough, token remain quota: %s, need quota: %s", logger.FormatQuota(token.RemainQuota), logger.FormatQuota(quota))
 	}
-	err = model.DecreaseTokenQuota(relayInfo.TokenId, relayInfo.TokenKey, quota)
+	err = model.DecreaseTokenQuota(relayInfo.TokenId, quota)
 	if err != nil {
 		return err
 	}
@@ -480,6 +511,17 @@ func PostConsumeQuota(relayInfo *relaycommon.RelayInfo, quota int, preConsumedQu
 			}
 			relayInfo.SubscriptionPostDelta += delta
 		}
+	} else if relayInfo.TokenOrgId > 0 {
+		// Organization wallet：未预扣费的路径（Midjourney、实时音频、违规扣费等）按预扣费规则原子扣减，
+		// 组织余额或成员上限不足时失败；只有预扣费之后的结算差额才允许透支
+		if preConsumedQuota == 0 && quota > 0 {
+			err = model.PreConsumeOrganizationQuota(relayInfo.TokenOrgId, relayInfo.UserId, quota)
+		} else {
+			err = model.AdjustOrganizationQuota(relayInfo.TokenOrgId, relayInfo.UserId, quota)
+		}
+		if err != nil {
+			return err
+		}
 	} else {
 		// Wallet
 		if quota > 0 {
@@ -494,16 +536,16 @@ func PostConsumeQuota(relayInfo *relaycommon.RelayInfo, quota int, preConsumedQu
 
 	if !relayInfo.IsPlayground {
 		if quota > 0 {
-			err = model.DecreaseTokenQuota(relayInfo.TokenId, relayInfo.TokenKey, quota)
+			err = model.DecreaseTokenQuota(relayInfo.TokenId, quota)
 		} else {
-			err = model.IncreaseTokenQuota(relayInfo.TokenId, relayInfo.TokenKey, -quota)
+			err = model.IncreaseTokenQuota(relayInfo.TokenId, -quota)
 		}
 		if err != nil {
 			return err
 		}
 	}
 
-	if sendEmail {
+	if sendEmail && relayInfo.TokenOrgId == 0 {
 		if (quota + preConsumedQuota) != 0 {
 			checkAndSendQuotaNotify(relayInfo, quota, preConsumedQuota)
 		}
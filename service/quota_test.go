@@ -0,0 +1,49 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/QuantumNous/new-api/model"
+	relaycommon "github.com/QuantumNous/new-api/relay/common"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 未经过 BillingSession 预扣费的路径（如 Midjourney）直接调用 PostConsumeQuota 时，
+// 组织余额与成员上限同样生效，不能透支
+func TestPostConsumeQuota_OrganizationWithoutPreConsume(t *testing.T) {
+	truncate(t)
+	require.NoError(t, model.DB.AutoMigrate(&model.Organization{}, &model.OrganizationMember{}))
+	t.Cleanup(func() {
+		model.DB.Exec("DELETE FROM organizations")
+		model.DB.Exec("DELETE FROM organization_members")
+	})
+
+	const ownerID, memberID, tokenID = 21, 22, 21
+	seedUser(t, memberID, 0)
+	seedToken(t, tokenID, memberID, "sk-org-key", 10000)
+	org := &model.Organization{Name: "org-mj", OwnerId: ownerID, Status: model.OrganizationStatusEnabled, Quota: 1000}
+	require.NoError(t, model.DB.Create(org).Error)
+	require.NoError(t, model.DB.Create(&model.OrganizationMember{OrgId: org.Id, UserId: memberID, Role: model.OrgRoleMember, QuotaLimit: 600}).Error)
+
+	info := &relaycommon.RelayInfo{UserId: memberID, TokenId: tokenID, TokenOrgId: org.Id}
+
+	require.NoError(t, PostConsumeQuota(info, 500, 0, false))
+	// 成员上限 600，已用 500
+	require.ErrorIs(t, PostConsumeQuota(info, 200, 0, false), model.ErrOrganizationMemberLimitExceeded)
+
+	got, err := model.GetOrganizationById(org.Id)
+	require.NoError(t, err)
+	assert.Equal(t, 500, got.Quota)
+	member, err := model.GetOrganizationMember(org.Id, memberID)
+	require.NoError(t, err)
+	assert.Equal(t, 500, member.UsedQuota)
+
+	// 组织余额不足
+	require.NoError(t, model.DB.Model(&model.OrganizationMember{}).Where("id = ?", member.Id).Update("quota_limit", 0).Error)
+	require.ErrorIs(t, PostConsumeQuota(info, 800, 0, false), model.ErrOrganizationQuotaInsufficient)
+	got, err = model.GetOrganizationById(org.Id)
+	require.NoError(t, err)
+	assert.Equal(t, 500, got.Quota)
+}
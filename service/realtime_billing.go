@@ -0,0 +1,102 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/QuantumNous/new-api/dto"
+	"github.com/QuantumNous/new-api/logger"
+	relaycommon "github.com/QuantumNous/new-api/relay/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+// realtimeSettleInterval 实时会话两次分段结算的最小间隔
+const realtimeSettleInterval = 30 * time.Second
+
+// RealtimeBilling 实时会话的分段结算：按轮累加上游返回的用量，超过结算间隔时扣费并记录一条消费日志，
+// 长会话不必等到连接关闭才结算。按次计费的模型仍在会话结束时记录一次。
+type RealtimeBilling struct {
+	ctx          *gin.Context
+	relayInfo    *relaycommon.RelayInfo
+	pending      dto.RealtimeUsage
+	total        dto.RealtimeUsage
+	segments     int
+	lastSettleAt time.Time
+	mu           sync.Mutex
+}
+
+// NewRealtimeBilling 创建会话结算器。按量计费时会话开始前的预扣费只作为额度检查，立即返还，实际用量逐段扣除。
+func NewRealtimeBilling(c *gin.Context, relayInfo *relaycommon.RelayInfo) *RealtimeBilling {
+	if !relayInfo.PriceData.UsePrice && relayInfo.Billing != nil {
+		if err := SettleBilling(c, relayInfo, 0); err != nil {
+			logger.LogError(c, "error returning realtime pre-consumed quota: "+err.Error())
+		}
+	}
+	return &RealtimeBilling{
+		ctx:          c,
+		relayInfo:    relayInfo,
+		lastSettleAt: time.Now(),
+	}
+}
+
+func (b *RealtimeBilling) AddUsage(usage *dto.RealtimeUsage) error {
+	if usage == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	addRealtimeUsage(&b.pending, usage)
+	if b.relayInfo.PriceData.UsePrice || time.Since(b.lastSettleAt) < realtimeSettleInterval {
+		return nil
+	}
+	return b.settleLocked()
+}
+
+func (b *RealtimeBilling) settleLocked() error {
+	if b.pending.TotalTokens == 0 {
+		return nil
+	}
+	segment := b.pending
+	if err := PreWssConsumeQuota(b.ctx, b.relayInfo, &segment); err != nil {
+		return err
+	}
+	b.segments++
+	b.pending = dto.RealtimeUsage{}
+	b.lastSettleAt = time.Now()
+	addRealtimeUsage(&b.total, &segment)
+	PostWssConsumeQuota(b.ctx, b.relayInfo, b.relayInfo.UpstreamModelName, &segment, fmt.Sprintf("实时会话分段结算 #%d", b.segments))
+	return nil
+}
+
+// Finish 会话结束时结算剩余用量，返回整个会话的用量
+func (b *RealtimeBilling) Finish() *dto.RealtimeUsage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.relayInfo.PriceData.UsePrice {
+		addRealtimeUsage(&b.total, &b.pending)
+		b.pending = dto.RealtimeUsage{}
+		PostWssConsumeQuota(b.ctx, b.relayInfo, b.relayInfo.UpstreamModelName, &b.total, "")
+		return &b.total
+	}
+	if err := b.settleLocked(); err != nil {
+		logger.LogError(b.ctx, "error settling realtime usage: "+err.Error())
+	}
+	// 没有任何用量时仍记录一条日志便于排查
+	if b.segments == 0 {
+		PostWssConsumeQuota(b.ctx, b.relayInfo, b.relayInfo.UpstreamModelName, &b.total, "")
+	}
+	return &b.total
+}
+
+func addRealtimeUsage(dst *dto.RealtimeUsage, src *dto.RealtimeUsage) {
+	dst.TotalTokens += src.TotalTokens
+	dst.InputTokens += src.InputTokens
+	dst.OutputTokens += src.OutputTokens
+	dst.InputTokenDetails.CachedTokens += src.InputTokenDetails.CachedTokens
+	dst.InputTokenDetails.TextTokens += src.InputTokenDetails.TextTokens
+	dst.InputTokenDetails.AudioTokens += src.InputTokenDetails.AudioTokens
+	dst.OutputTokenDetails.TextTokens += src.OutputTokenDetails.TextTokens
+	dst.OutputTokenDetails.AudioTokens += src.OutputTokenDetails.AudioTokens
+}
@@ -0,0 +1,471 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
+	"github.com/QuantumNous/new-api/dto"
+	"github.com/QuantumNous/new-api/pkg/cachex"
+	relaycommon "github.com/QuantumNous/new-api/relay/common"
+	relayconstant "github.com/QuantumNous/new-api/relay/constant"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+	"github.com/QuantumNous/new-api/types"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samber/hot"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	responseCacheNamespace = "new-api:response_cache:v1"
+
+	ginKeyResponseCacheKey    = "response_cache_key"
+	ginKeyResponseCacheTTL    = "response_cache_ttl_seconds"
+	ginKeyResponseCacheWriter = "response_cache_writer"
+)
+
+// responseCacheIgnoredFields 不影响上游输出的字段，不参与缓存 key 计算
+var responseCacheIgnoredFields = []string{"stream", "stream_options", "user", "metadata"}
+
+// responseCacheKeyHeaders 会改变上游输出的请求头，参与缓存 key 计算
+var responseCacheKeyHeaders = []string{"anthropic-beta", "anthropic-version", "OpenAI-Beta"}
+
+var (
+	responseCacheOnce sync.Once
+	responseCache     *cachex.HybridCache[ResponseCacheEntry]
+)
+
+// ResponseCacheEntry 缓存的下游响应。Stream 为 true 时 Body 为原始 SSE 字节流，否则为 JSON 响应体。
+type ResponseCacheEntry struct {
+	Stream      bool      `json:"stream"`
+	ContentType string    `json:"content_type"`
+	Body        []byte    `json:"body"`
+	Usage       dto.Usage `json:"usage"`
+	// UsageFormat 原请求最终发往上游的格式，决定 usage 的计费语义
+	UsageFormat types.RelayFormat `json:"usage_format"`
+	CreatedAt   int64             `json:"created_at"`
+}
+
+func getResponseCache() *cachex.HybridCache[ResponseCacheEntry] {
+	responseCacheOnce.Do(func() {
+		setting := operation_setting.GetResponseCacheSetting()
+		capacity := setting.MaxEntries
+		if capacity <= 0 {
+			capacity = 10_000
+		}
+		defaultTTLSeconds := setting.DefaultTTLSeconds
+		if defaultTTLSeconds <= 0 {
+			defaultTTLSeconds = 3600
+		}
+		responseCache = cachex.NewHybridCache[ResponseCacheEntry](cachex.HybridCacheConfig[ResponseCacheEntry]{
+			Namespace: cachex.Namespace(responseCacheNamespace),
+			Redis:     common.RDB,
+			RedisEnabled: func() bool {
+				return common.RedisEnabled && common.RDB != nil
+			},
+			RedisCodec: cachex.JSONCodec[ResponseCacheEntry]{},
+			Memory: func() *hot.HotCache[string, ResponseCacheEntry] {
+				return hot.NewHotCache[string, ResponseCacheEntry](hot.LRU, capacity).
+					WithTTL(time.Duration(defaultTTLSeconds) * time.Second).
+					WithJanitor().
+					Build()
+			},
+		})
+	})
+	return responseCache
+}
+
+// isResponseCacheableRequest 仅缓存 chat/completions、completions、messages 和 embeddings
+func isResponseCacheableRequest(info *relaycommon.RelayInfo) bool {
+	if info == nil || info.IsPlayground || info.IsChannelTest {
+		return false
+	}
+	switch info.RelayFormat {
+	case types.RelayFormatOpenAI:
+		return info.RelayMode == relayconstant.RelayModeChatCompletions || info.RelayMode == relayconstant.RelayModeCompletions
+	case types.RelayFormatClaude, types.RelayFormatEmbedding:
+		return true
+	}
+	return false
+}
+
+// NormalizeResponseCacheBody 将请求体规范化（去除无关字段、按 key 排序）用于计算缓存 key
+func NormalizeResponseCacheBody(body []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var req map[string]any
+	if err := decoder.Decode(&req); err != nil {
+		return nil, err
+	}
+	for _, field := range responseCacheIgnoredFields {
+		delete(req, field)
+	}
+	return json.Marshal(req)
+}
+
+func buildResponseCacheKey(c *gin.Context, info *relaycommon.RelayInfo) (string, error) {
+	storage, err := common.GetBodyStorage(c)
+	if err != nil {
+		return "", err
+	}
+	body, err := storage.Bytes()
+	if err != nil {
+		return "", err
+	}
+	normalized, err := NormalizeResponseCacheBody(body)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	h.Write([]byte(info.RelayFormat))
+	h.Write([]byte{'\n'})
+	h.Write([]byte(c.Request.URL.Path))
+	h.Write([]byte{'\n'})
+	h.Write([]byte(c.Request.URL.RawQuery))
+	h.Write([]byte{'\n'})
+	for _, name := range responseCacheKeyHeaders {
+		h.Write([]byte(strings.Join(c.Request.Header.Values(name), ",")))
+		h.Write([]byte{'\n'})
+	}
+	h.Write(normalized)
+	// 按用户隔离，避免不同用户之间共享响应内容
+	return fmt.Sprintf("%d:%s:%s", info.UserId, info.UsingGroup, hex.EncodeToString(h.Sum(nil))), nil
+}
+
+func responseCacheStreamKey(key string) string {
+	return key + ":sse"
+}
+
+// LookupResponseCache 检查请求是否可缓存并查找缓存。
+// 可缓存时会在上下文中记录 key 与 TTL，供请求成功后 SaveResponseCache 使用。
+func LookupResponseCache(c *gin.Context, info *relaycommon.RelayInfo) (*ResponseCacheEntry, bool) {
+	if !isResponseCacheableRequest(info) {
+		return nil, false
+	}
+	ttl := operation_setting.GetResponseCacheTTLSeconds(info.UsingGroup, info.OriginModelName)
+	if ttl <= 0 {
+		return nil, false
+	}
+	cacheControl := strings.ToLower(c.GetHeader("Cache-Control"))
+	if strings.Contains(cacheControl, "no-store") {
+		return nil, false
+	}
+	key, err := buildResponseCacheKey(c, info)
+	if err != nil {
+		return nil, false
+	}
+	c.Set(ginKeyResponseCacheKey, key)
+	c.Set(ginKeyResponseCacheTTL, ttl)
+	if strings.Contains(cacheControl, "no-cache") {
+		return nil, false
+	}
+
+	cache := getResponseCache()
+	if info.IsStream {
+		if entry, found, err := cache.Get(responseCacheStreamKey(key)); err == nil && found {
+			return &entry, true
+		}
+	}
+	entry, found, err := cache.Get(key)
+	if err != nil || !found {
+		return nil, false
+	}
+	if info.IsStream && !CanSynthesizeResponseCacheStream(info.RelayFormat, entry.Body) {
+		return nil, false
+	}
+	return &entry, true
+}
+
+// ResponseCacheWriter 在转发响应的同时记录响应体，超过大小上限后放弃记录
+type ResponseCacheWriter struct {
+	gin.ResponseWriter
+	buf      bytes.Buffer
+	limit    int
+	overflow bool
+}
+
+func (w *ResponseCacheWriter) capture(n int, write func()) {
+	if w.overflow {
+		return
+	}
+	if w.buf.Len()+n > w.limit {
+		w.overflow = true
+		w.buf.Reset()
+		return
+	}
+	write()
+}
+
+func (w *ResponseCacheWriter) Write(data []byte) (int, error) {
+	w.capture(len(data), func() { w.buf.Write(data) })
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *ResponseCacheWriter) WriteString(s string) (int, error) {
+	w.capture(len(s), func() { w.buf.WriteString(s) })
+	return w.ResponseWriter.WriteString(s)
+}
+
+// BeginResponseCapture 对可缓存请求包装 c.Writer 以记录下游响应
+func BeginResponseCapture(c *gin.Context) {
+	if _, ok := c.Get(ginKeyResponseCacheKey); !ok {
+		return
+	}
+	limit := operation_setting.GetResponseCacheSetting().MaxBodyKB << 10
+	if limit <= 0 {
+		return
+	}
+	writer := &ResponseCacheWriter{ResponseWriter: c.Writer, limit: limit}
+	c.Writer = writer
+	c.Set(ginKeyResponseCacheWriter, writer)
+}
+
+// SaveResponseCache 在请求成功后写入缓存
+func SaveResponseCache(c *gin.Context, info *relaycommon.RelayInfo) {
+	key := c.GetString(ginKeyResponseCacheKey)
+	ttl := c.GetInt(ginKeyResponseCacheTTL)
+	value, ok := c.Get(ginKeyResponseCacheWriter)
+	if key == "" || ttl <= 0 || !ok {
+		return
+	}
+	// 缓存 key 按原始请求的模型计算，降级到备选模型后的响应不能写入该 key
+	if info.FallbackFromModel != "" {
+		return
+	}
+	writer := value.(*ResponseCacheWriter)
+	if writer.overflow || writer.buf.Len() == 0 || writer.Status() != 200 {
+		return
+	}
+	usageValue, ok := common.GetContextKey(c, constant.ContextKeyRelayUsage)
+	if !ok {
+		return
+	}
+	usage, ok := usageValue.(*dto.Usage)
+	if !ok || usage == nil || usage.PromptTokens+usage.CompletionTokens == 0 {
+		return
+	}
+	entry := ResponseCacheEntry{
+		Stream:      info.IsStream,
+		ContentType: writer.Header().Get("Content-Type"),
+		Body:        bytes.Clone(writer.buf.Bytes()),
+		Usage:       *usage,
+		UsageFormat: info.GetFinalRequestRelayFormat(),
+		CreatedAt:   common.GetTimestamp(),
+	}
+	if !entry.Stream && !json.Valid(entry.Body) {
+		return
+	}
+	if entry.Stream {
+		key = responseCacheStreamKey(key)
+	}
+	if err := getResponseCache().SetWithTTL(key, entry, time.Duration(ttl)*time.Second); err != nil {
+		common.SysError("response cache set failed: " + err.Error())
+	}
+}
+
+// GetResponseCacheBillingRatio 命中缓存时的计费倍率
+func GetResponseCacheBillingRatio() float64 {
+	ratio := operation_setting.GetResponseCacheSetting().BillingRatio
+	if ratio < 0 {
+		return 0
+	}
+	return ratio
+}
+
+// CanSynthesizeResponseCacheStream 判断 JSON 响应能否转换为 SSE 流
+func CanSynthesizeResponseCacheStream(relayFormat types.RelayFormat, body []byte) bool {
+	object := gjson.GetBytes(body, "object").String()
+	switch relayFormat {
+	case types.RelayFormatOpenAI:
+		return object == "chat.completion" || object == "text_completion"
+	case types.RelayFormatClaude:
+		return gjson.GetBytes(body, "type").String() == "message"
+	}
+	return false
+}
+
+// ResponseCacheStreamEvent 合成的 SSE 事件，Event 为空时只输出 data 行
+type ResponseCacheStreamEvent struct {
+	Event string
+	Data  string
+}
+
+// SynthesizeResponseCacheStream 将缓存的 JSON 响应转换为等价的 SSE 事件序列
+func SynthesizeResponseCacheStream(relayFormat types.RelayFormat, body []byte, includeUsage bool) []ResponseCacheStreamEvent {
+	if relayFormat == types.RelayFormatClaude {
+		return synthesizeClaudeStream(body)
+	}
+	return synthesizeOpenAIStream(body, includeUsage)
+}
+
+func marshalStreamEvent(event string, v any) ResponseCacheStreamEvent {
+	data, _ := common.Marshal(v)
+	return ResponseCacheStreamEvent{Event: event, Data: string(data)}
+}
+
+func synthesizeOpenAIStream(body []byte, includeUsage bool) []ResponseCacheStreamEvent {
+	resp := gjson.ParseBytes(body)
+	isChat := resp.Get("object").String() == "chat.completion"
+	chunkObject := "text_completion"
+	if isChat {
+		chunkObject = "chat.completion.chunk"
+	}
+	newChunk := func(choices []map[string]any) map[string]any {
+		chunk := map[string]any{
+			"id":      resp.Get("id").String(),
+			"object":  chunkObject,
+			"created": resp.Get("created").Int(),
+			"model":   resp.Get("model").String(),
+			"choices": choices,
+		}
+		if fp := resp.Get("system_fingerprint"); fp.Exists() {
+			chunk["system_fingerprint"] = fp.Value()
+		}
+		return chunk
+	}
+
+	var events []ResponseCacheStreamEvent
+	var finishChoices []map[string]any
+	for _, choice := range resp.Get("choices").Array() {
+		index := choice.Get("index").Int()
+		contentChoice := map[string]any{"index": index, "finish_reason": nil}
+		if isChat {
+			delta := map[string]any{}
+			message := choice.Get("message")
+			delta["role"] = message.Get("role").String()
+			if content := message.Get("content"); content.Exists() && content.Type != gjson.Null {
+				delta["content"] = content.Value()
+			}
+			if reasoning := message.Get("reasoning_content"); reasoning.Exists() && reasoning.String() != "" {
+				delta["reasoning_content"] = reasoning.String()
+			}
+			if toolCalls := message.Get("tool_calls"); toolCalls.IsArray() && len(toolCalls.Array()) > 0 {
+				calls := make([]json.RawMessage, 0, len(toolCalls.Array()))
+				for i, call := range toolCalls.Array() {
+					raw, err := sjson.SetBytes([]byte(call.Raw), "index", i)
+					if err != nil {
+						raw = []byte(call.Raw)
+					}
+					calls = append(calls, raw)
+				}
+				delta["tool_calls"] = calls
+			}
+			contentChoice["delta"] = delta
+		} else {
+			contentChoice["text"] = choice.Get("text").String()
+		}
+		events = append(events, marshalStreamEvent("", newChunk([]map[string]any{contentChoice})))
+
+		finishChoice := map[string]any{"index": index, "finish_reason": choice.Get("finish_reason").Value()}
+		if isChat {
+			finishChoice["delta"] = map[string]any{}
+		} else {
+			finishChoice["text"] = ""
+		}
+		finishChoices = append(finishChoices, finishChoice)
+	}
+	if len(finishChoices) > 0 {
+		events = append(events, marshalStreamEvent("", newChunk(finishChoices)))
+	}
+	if usage := resp.Get("usage"); includeUsage && usage.Exists() {
+		chunk := newChunk([]map[string]any{})
+		chunk["usage"] = json.RawMessage(usage.Raw)
+		events = append(events, marshalStreamEvent("", chunk))
+	}
+	events = append(events, ResponseCacheStreamEvent{Data: "[DONE]"})
+	return events
+}
+
+func synthesizeClaudeStream(body []byte) []ResponseCacheStreamEvent {
+	resp := gjson.ParseBytes(body)
+	usage := resp.Get("usage")
+	startUsage := map[string]any{
+		"input_tokens":  usage.Get("input_tokens").Int(),
+		"output_tokens": 0,
+	}
+	for _, field := range []string{"cache_creation_input_tokens", "cache_read_input_tokens"} {
+		if v := usage.Get(field); v.Exists() {
+			startUsage[field] = v.Int()
+		}
+	}
+	events := []ResponseCacheStreamEvent{
+		marshalStreamEvent("message_start", map[string]any{
+			"type": "message_start",
+			"message": map[string]any{
+				"id":            resp.Get("id").String(),
+				"type":          "message",
+				"role":          resp.Get("role").String(),
+				"model":         resp.Get("model").String(),
+				"content":       []any{},
+				"stop_reason":   nil,
+				"stop_sequence": nil,
+				"usage":         startUsage,
+			},
+		}),
+	}
+
+	for i, block := range resp.Get("content").Array() {
+		var start map[string]any
+		var deltas []map[string]any
+		switch block.Get("type").String() {
+		case "text":
+			start = map[string]any{"type": "text", "text": ""}
+			deltas = append(deltas, map[string]any{"type": "text_delta", "text": block.Get("text").String()})
+		case "thinking":
+			start = map[string]any{"type": "thinking", "thinking": ""}
+			deltas = append(deltas, map[string]any{"type": "thinking_delta", "thinking": block.Get("thinking").String()})
+			if signature := block.Get("signature").String(); signature != "" {
+				deltas = append(deltas, map[string]any{"type": "signature_delta", "signature": signature})
+			}
+		case "tool_use":
+			start = map[string]any{"type": "tool_use", "id": block.Get("id").String(), "name": block.Get("name").String(), "input": map[string]any{}}
+			input := block.Get("input").Raw
+			if input == "" {
+				input = "{}"
+			}
+			deltas = append(deltas, map[string]any{"type": "input_json_delta", "partial_json": input})
+		default:
+			start = map[string]any{}
+			_ = common.UnmarshalJsonStr(block.Raw, &start)
+		}
+		events = append(events, marshalStreamEvent("content_block_start", map[string]any{
+			"type":          "content_block_start",
+			"index":         i,
+			"content_block": start,
+		}))
+		for _, delta := range deltas {
+			events = append(events, marshalStreamEvent("content_block_delta", map[string]any{
+				"type":  "content_block_delta",
+				"index": i,
+				"delta": delta,
+			}))
+		}
+		events = append(events, marshalStreamEvent("content_block_stop", map[string]any{
+			"type":  "content_block_stop",
+			"index": i,
+		}))
+	}
+
+	messageDelta := map[string]any{
+		"type": "message_delta",
+		"delta": map[string]any{
+			"stop_reason":   resp.Get("stop_reason").Value(),
+			"stop_sequence": resp.Get("stop_sequence").Value(),
+		},
+	}
+	if usage.Exists() {
+		messageDelta["usage"] = json.RawMessage(usage.Raw)
+	}
+	events = append(events, marshalStreamEvent("message_delta", messageDelta))
+	events = append(events, marshalStreamEvent("message_stop", map[string]any{"type": "message_stop"}))
+	return events
+}
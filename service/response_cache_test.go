@@ -0,0 +1,92 @@
+package service
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	relaycommon "github.com/QuantumNous/new-api/relay/common"
+	"github.com/QuantumNous/new-api/types"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestNormalizeResponseCacheBody_IgnoresStreamAndKeyOrder(t *testing.T) {
+	a, err := NormalizeResponseCacheBody([]byte(`{"model":"gpt-4o","stream":true,"temperature":0,"messages":[{"role":"user","content":"hi"}],"user":"ci-1"}`))
+	require.NoError(t, err)
+	b, err := NormalizeResponseCacheBody([]byte(`{"messages":[{"role":"user","content":"hi"}],"temperature":0,"model":"gpt-4o"}`))
+	require.NoError(t, err)
+	require.Equal(t, string(a), string(b))
+
+	c, err := NormalizeResponseCacheBody([]byte(`{"messages":[{"role":"user","content":"hi"}],"temperature":0.0001,"model":"gpt-4o"}`))
+	require.NoError(t, err)
+	require.NotEqual(t, string(a), string(c))
+}
+
+func TestSynthesizeResponseCacheStream_OpenAIChat(t *testing.T) {
+	body := []byte(`{"id":"chatcmpl-1","object":"chat.completion","created":1,"model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"hello","tool_calls":[{"id":"call_1","type":"function","function":{"name":"f","arguments":"{}"}}]},"finish_reason":"tool_calls"}],"usage":{"prompt_tokens":3,"completion_tokens":2,"total_tokens":5}}`)
+	require.True(t, CanSynthesizeResponseCacheStream(types.RelayFormatOpenAI, body))
+
+	events := SynthesizeResponseCacheStream(types.RelayFormatOpenAI, body, true)
+	require.Len(t, events, 4)
+	first := gjson.Parse(events[0].Data)
+	require.Equal(t, "chat.completion.chunk", first.Get("object").String())
+	require.Equal(t, "hello", first.Get("choices.0.delta.content").String())
+	require.Equal(t, int64(0), first.Get("choices.0.delta.tool_calls.0.index").Int())
+	require.Equal(t, "tool_calls", gjson.Get(events[1].Data, "choices.0.finish_reason").String())
+	require.Equal(t, int64(5), gjson.Get(events[2].Data, "usage.total_tokens").Int())
+	require.Equal(t, "[DONE]", events[3].Data)
+
+	events = SynthesizeResponseCacheStream(types.RelayFormatOpenAI, body, false)
+	require.Len(t, events, 3)
+}
+
+func TestSynthesizeResponseCacheStream_Claude(t *testing.T) {
+	body := []byte(`{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4","content":[{"type":"text","text":"hi"},{"type":"tool_use","id":"toolu_1","name":"f","input":{"a":1}}],"stop_reason":"tool_use","stop_sequence":null,"usage":{"input_tokens":10,"output_tokens":4}}`)
+	require.True(t, CanSynthesizeResponseCacheStream(types.RelayFormatClaude, body))
+
+	events := SynthesizeResponseCacheStream(types.RelayFormatClaude, body, true)
+	names := make([]string, 0, len(events))
+	for _, e := range events {
+		names = append(names, e.Event)
+	}
+	require.Equal(t, "message_start,content_block_start,content_block_delta,content_block_stop,content_block_start,content_block_delta,content_block_stop,message_delta,message_stop", strings.Join(names, ","))
+	require.Equal(t, "hi", gjson.Get(events[2].Data, "delta.text").String())
+	require.JSONEq(t, `{"a":1}`, gjson.Get(events[5].Data, "delta.partial_json").String())
+	require.Equal(t, "tool_use", gjson.Get(events[7].Data, "delta.stop_reason").String())
+	require.Equal(t, int64(4), gjson.Get(events[7].Data, "usage.output_tokens").Int())
+}
+
+func TestResponseCacheWriter_DropsOversizedBody(t *testing.T) {
+	rec := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(rec)
+	writer := &ResponseCacheWriter{ResponseWriter: ctx.Writer, limit: 8}
+	_, _ = writer.Write([]byte("12345"))
+	require.False(t, writer.overflow)
+	_, _ = writer.WriteString("6789")
+	require.True(t, writer.overflow)
+	require.Zero(t, writer.buf.Len())
+	require.Equal(t, "123456789", rec.Body.String())
+}
+
+func TestBuildResponseCacheKey_IncludesQueryAndHeaders(t *testing.T) {
+	body := `{"model":"claude-3-5-sonnet","messages":[{"role":"user","content":"hi"}]}`
+	info := &relaycommon.RelayInfo{UserId: 1, UsingGroup: "default", RelayFormat: types.RelayFormatClaude}
+	buildKey := func(target string, headers map[string]string) string {
+		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ctx.Request = httptest.NewRequest("POST", target, strings.NewReader(body))
+		for k, v := range headers {
+			ctx.Request.Header.Set(k, v)
+		}
+		key, err := buildResponseCacheKey(ctx, info)
+		require.NoError(t, err)
+		return key
+	}
+
+	base := buildKey("/v1/messages", nil)
+	require.Equal(t, base, buildKey("/v1/messages", nil))
+	require.NotEqual(t, base, buildKey("/v1/messages?beta=true", nil))
+	require.NotEqual(t, base, buildKey("/v1/messages", map[string]string{"anthropic-beta": "prompt-caching-2024-07-31"}))
+	require.NotEqual(t, base, buildKey("/v1/messages", map[string]string{"anthropic-version": "2023-06-01"}))
+}
@@ -0,0 +1,324 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
+	"github.com/QuantumNous/new-api/dto"
+	"github.com/QuantumNous/new-api/logger"
+	"github.com/QuantumNous/new-api/model"
+	relaycommon "github.com/QuantumNous/new-api/relay/common"
+	relayconstant "github.com/QuantumNous/new-api/relay/constant"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+	"github.com/QuantumNous/new-api/types"
+
+	"github.com/bytedance/gopkg/util/gopool"
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+	"gorm.io/gorm"
+)
+
+const (
+	ginKeyResponsesStoreWriter = "responses_store_writer"
+	ginKeyResponsesStoreInput  = "responses_store_input"
+
+	responsesStoreCleanupTickInterval = 1 * time.Hour
+	responsesStoreCleanupBatchSize    = 1000
+)
+
+var (
+	responsesStoreCleanupOnce    sync.Once
+	responsesStoreCleanupRunning atomic.Bool
+)
+
+// ResponsesStoreWriter 在转发响应的同时记录 Responses 结果。
+// 非流式记录完整响应体；流式逐行解析 SSE，只保留最终的 response.completed / response.incomplete 事件中的 response 对象。
+type ResponsesStoreWriter struct {
+	gin.ResponseWriter
+	stream   bool
+	buf      bytes.Buffer // 非流式为响应体，流式为尚未读到换行的半行数据
+	final    []byte
+	limit    int
+	overflow bool
+}
+
+func (w *ResponsesStoreWriter) capture(data []byte) {
+	if w.overflow {
+		return
+	}
+	if w.buf.Len()+len(data) > w.limit {
+		w.overflow = true
+		w.buf.Reset()
+		return
+	}
+	w.buf.Write(data)
+	if !w.stream {
+		return
+	}
+	for {
+		line, rest, found := bytes.Cut(w.buf.Bytes(), []byte("\n"))
+		if !found {
+			break
+		}
+		w.handleStreamLine(bytes.TrimSpace(line))
+		remain := bytes.Clone(rest)
+		w.buf.Reset()
+		w.buf.Write(remain)
+	}
+}
+
+func (w *ResponsesStoreWriter) handleStreamLine(line []byte) {
+	data, ok := bytes.CutPrefix(line, []byte("data:"))
+	if !ok {
+		return
+	}
+	event := gjson.ParseBytes(bytes.TrimSpace(data))
+	switch event.Get("type").String() {
+	case "response.completed", "response.incomplete":
+		w.final = []byte(event.Get("response").Raw)
+	}
+}
+
+func (w *ResponsesStoreWriter) Write(data []byte) (int, error) {
+	w.capture(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *ResponsesStoreWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *ResponsesStoreWriter) responseBody() []byte {
+	if w.overflow {
+		return nil
+	}
+	if w.stream {
+		return w.final
+	}
+	return w.buf.Bytes()
+}
+
+// BeginResponsesStoreCapture 对 /v1/responses 请求包装 c.Writer 以记录响应结果
+func BeginResponsesStoreCapture(c *gin.Context, info *relaycommon.RelayInfo) {
+	if info == nil || info.IsPlayground || info.IsChannelTest || info.RelayFormat != types.RelayFormatOpenAIResponses {
+		return
+	}
+	setting := operation_setting.GetResponsesStoreSetting()
+	limit := setting.MaxRecordKB << 10
+	if !setting.Enabled || limit <= 0 {
+		return
+	}
+	writer := &ResponsesStoreWriter{ResponseWriter: c.Writer, stream: info.IsStream, limit: limit}
+	c.Writer = writer
+	c.Set(ginKeyResponsesStoreWriter, writer)
+}
+
+// ApplyResponsesStore 处理引用本地记录的 previous_response_id：把历史输入与输出拼接到本次 input 中并清除该字段，
+// 使请求可以发往任意渠道；同时记下完整输入，供请求成功后保存。
+// 本地不存在的 id 对 OpenAI 类渠道原样转发（可能是上游保存的响应），其余渠道返回 404。
+func ApplyResponsesStore(c *gin.Context, info *relaycommon.RelayInfo, request *dto.OpenAIResponsesRequest) *types.NewAPIError {
+	c.Set(ginKeyResponsesStoreInput, nil)
+	if !operation_setting.GetResponsesStoreSetting().Enabled {
+		return nil
+	}
+	items, err := responsesInputToItems(request.Input)
+	if err != nil {
+		return types.NewErrorWithStatusCode(err, types.ErrorCodeInvalidRequest, http.StatusBadRequest, types.ErrOptionWithSkipRetry())
+	}
+
+	if request.PreviousResponseID != "" {
+		record, err := model.GetUserResponseRecord(info.UserId, info.TokenId, request.PreviousResponseID)
+		if err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return types.NewError(err, types.ErrorCodeQueryDataError, types.ErrOptionWithSkipRetry())
+			}
+			switch info.ApiType {
+			case constant.APITypeOpenAI, constant.APITypeCodex:
+				// 上游保存的响应无法得知完整上下文，本次结果不做本地保存
+				return nil
+			}
+			return types.NewErrorWithStatusCode(
+				fmt.Errorf("previous response with id '%s' not found", request.PreviousResponseID),
+				types.ErrorCodeInvalidRequest,
+				http.StatusNotFound,
+				types.ErrOptionWithSkipRetry(),
+			)
+		}
+		items, err = mergeResponsesRecordInput(record, items)
+		if err != nil {
+			return types.NewError(err, types.ErrorCodeInvalidRequest, types.ErrOptionWithSkipRetry())
+		}
+		input, err := common.Marshal(items)
+		if err != nil {
+			return types.NewError(err, types.ErrorCodeInvalidRequest, types.ErrOptionWithSkipRetry())
+		}
+		request.Input = input
+		request.PreviousResponseID = ""
+	}
+
+	// compact 的结果不是普通响应，不做保存
+	if info.RelayMode == relayconstant.RelayModeResponsesCompact {
+		return nil
+	}
+	if strings.TrimSpace(string(request.Store)) == "false" {
+		return nil
+	}
+	c.Set(ginKeyResponsesStoreInput, items)
+	return nil
+}
+
+// SaveResponsesStore 在请求成功后异步保存本次响应
+func SaveResponsesStore(c *gin.Context, info *relaycommon.RelayInfo) {
+	value, ok := c.Get(ginKeyResponsesStoreWriter)
+	if !ok {
+		return
+	}
+	writer := value.(*ResponsesStoreWriter)
+	itemsValue, _ := c.Get(ginKeyResponsesStoreInput)
+	items, ok := itemsValue.([]any)
+	if !ok || writer.Status() != http.StatusOK {
+		return
+	}
+	body := writer.responseBody()
+	if len(body) == 0 || !gjson.ValidBytes(body) {
+		return
+	}
+	response := gjson.ParseBytes(body)
+	responseId := response.Get("id").String()
+	output := response.Get("output")
+	if responseId == "" || !output.IsArray() {
+		return
+	}
+	input, err := common.Marshal(items)
+	if err != nil {
+		return
+	}
+
+	setting := operation_setting.GetResponsesStoreSetting()
+	if len(input)+len(output.Raw)+len(body) > setting.MaxRecordKB<<10 {
+		return
+	}
+	if columnLimit := model.ResponseRecordColumnLimit(); columnLimit > 0 &&
+		(len(input) > columnLimit || len(output.Raw) > columnLimit || len(body) > columnLimit) {
+		return
+	}
+	now := common.GetTimestamp()
+	record := &model.ResponseRecord{
+		ResponseId: responseId,
+		UserId:     info.UserId,
+		TokenId:    info.TokenId,
+		Model:      response.Get("model").String(),
+		Input:      string(input),
+		Output:     output.Raw,
+		Response:   string(bytes.Clone(body)),
+		CreatedAt:  now,
+		ExpiresAt:  now + int64(setting.TTLHours)*3600,
+	}
+	gopool.Go(func() {
+		if err := record.Upsert(); err != nil {
+			common.SysError("failed to save response record: " + err.Error())
+		}
+	})
+}
+
+// responsesInputToItems 将字符串形式的 input 统一为条目数组
+func responsesInputToItems(input []byte) ([]any, error) {
+	if len(input) == 0 {
+		return []any{}, nil
+	}
+	switch common.GetJsonType(input) {
+	case "string":
+		var text string
+		if err := common.Unmarshal(input, &text); err != nil {
+			return nil, err
+		}
+		return []any{map[string]any{"type": "message", "role": "user", "content": text}}, nil
+	case "array":
+		var items []any
+		if err := common.Unmarshal(input, &items); err != nil {
+			return nil, fmt.Errorf("invalid input: %w", err)
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("unsupported input type: %s", common.GetJsonType(input))
+	}
+}
+
+// mergeResponsesRecordInput 按 历史输入 + 历史输出 + 本次输入 的顺序拼接上下文
+func mergeResponsesRecordInput(record *model.ResponseRecord, items []any) ([]any, error) {
+	var history []any
+	if err := common.UnmarshalJsonStr(record.Input, &history); err != nil {
+		return nil, fmt.Errorf("invalid stored input: %w", err)
+	}
+	var output []map[string]any
+	if err := common.UnmarshalJsonStr(record.Output, &output); err != nil {
+		return nil, fmt.Errorf("invalid stored output: %w", err)
+	}
+	merged := make([]any, 0, len(history)+len(output)+len(items))
+	merged = append(merged, history...)
+	for _, item := range output {
+		if outputItem := responsesOutputToInputItem(item); outputItem != nil {
+			merged = append(merged, outputItem)
+		}
+	}
+	return append(merged, items...), nil
+}
+
+// responsesOutputToInputItem 输出条目作为下一轮输入时去掉 id，避免上游按 id 查找未保存的条目；
+// 没有 encrypted_content 的 reasoning 条目无法回传，直接丢弃
+func responsesOutputToInputItem(item map[string]any) map[string]any {
+	if common.Interface2String(item["type"]) == "reasoning" {
+		if common.Interface2String(item["encrypted_content"]) == "" {
+			return nil
+		}
+		return item
+	}
+	delete(item, "id")
+	return item
+}
+
+// StartResponsesStoreCleanupTask 定时清理过期的 Responses 记录
+func StartResponsesStoreCleanupTask() {
+	responsesStoreCleanupOnce.Do(func() {
+		if !common.IsMasterNode {
+			return
+		}
+		gopool.Go(func() {
+			logger.LogInfo(context.Background(), fmt.Sprintf("responses store cleanup task started: tick=%s", responsesStoreCleanupTickInterval))
+			ticker := time.NewTicker(responsesStoreCleanupTickInterval)
+			defer ticker.Stop()
+
+			runResponsesStoreCleanupOnce()
+			for range ticker.C {
+				runResponsesStoreCleanupOnce()
+			}
+		})
+	})
+}
+
+func runResponsesStoreCleanupOnce() {
+	if !responsesStoreCleanupRunning.CompareAndSwap(false, true) {
+		return
+	}
+	defer responsesStoreCleanupRunning.Store(false)
+
+	ctx := context.Background()
+	count, err := model.DeleteExpiredResponseRecords(ctx, responsesStoreCleanupBatchSize)
+	if err != nil {
+		logger.LogWarn(ctx, fmt.Sprintf("responses store cleanup failed: %v", err))
+		return
+	}
+	if count > 0 {
+		logger.LogInfo(ctx, fmt.Sprintf("responses store cleanup: deleted %d records", count))
+	}
+}
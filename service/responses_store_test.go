@@ -0,0 +1,45 @@
+package service
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/QuantumNous/new-api/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeResponsesRecordInput(t *testing.T) {
+	record := &model.ResponseRecord{
+		Input: `[{"type":"message","role":"user","content":"hi"}]`,
+		Output: `[
+			{"type":"reasoning","id":"rs_1","summary":[]},
+			{"type":"reasoning","id":"rs_2","summary":[],"encrypted_content":"enc"},
+			{"type":"message","id":"msg_1","role":"assistant","content":[{"type":"output_text","text":"hello"}]},
+			{"type":"function_call","id":"fc_1","call_id":"call_1","name":"f","arguments":"{}"}
+		]`,
+	}
+	items, err := responsesInputToItems([]byte(`"next"`))
+	require.NoError(t, err)
+
+	merged, err := mergeResponsesRecordInput(record, items)
+	require.NoError(t, err)
+	require.Len(t, merged, 5)
+	require.Equal(t, "enc", merged[1].(map[string]any)["encrypted_content"])
+	require.NotContains(t, merged[2].(map[string]any), "id")
+	require.Equal(t, "call_1", merged[3].(map[string]any)["call_id"])
+	require.Equal(t, map[string]any{"type": "message", "role": "user", "content": "next"}, merged[4])
+}
+
+func TestResponsesStoreWriter_KeepsFinalStreamResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	writer := &ResponsesStoreWriter{ResponseWriter: c.Writer, stream: true, limit: 1 << 10}
+
+	_, _ = writer.WriteString("event: response.output_text.delta\ndata: {\"type\":\"response.output_text.delta\",\"delta\":\"hi\"}\n\n")
+	_, _ = writer.WriteString("event: response.completed\ndata: {\"type\":\"response.completed\",")
+	_, _ = writer.WriteString("\"response\":{\"id\":\"resp_1\",\"output\":[]}}\n\n")
+
+	require.JSONEq(t, `{"id":"resp_1","output":[]}`, string(writer.responseBody()))
+}
@@ -287,6 +287,30 @@ func TestRefundTaskQuota_NoToken(t *testing.T) {
 	assert.Equal(t, model.LogTypeRefund, log.Type)
 }
 
+func TestRefundTaskQuota_DeletedToken(t *testing.T) {
+	truncate(t)
+	ctx := context.Background()
+
+	const userID, tokenID, channelID = 5, 5, 5
+	const initQuota, preConsumed = 10000, 2000
+	const tokenRemain = 5000
+
+	seedUser(t, userID, initQuota)
+	seedToken(t, tokenID, userID, "sk-deleted-key", tokenRemain)
+	seedChannel(t, channelID)
+	require.NoError(t, model.DB.Delete(&model.Token{}, tokenID).Error)
+
+	task := makeTask(userID, channelID, preConsumed, tokenID, BillingSourceWallet, 0)
+
+	RefundTaskQuota(ctx, task, "deleted token task failed")
+
+	// 用户额度照常退还，已删除的令牌不再调整
+	assert.Equal(t, initQuota+preConsumed, getUserQuota(t, userID))
+	var token model.Token
+	require.NoError(t, model.DB.Unscoped().First(&token, tokenID).Error)
+	assert.Equal(t, tokenRemain, token.RemainQuota)
+}
+
 // ===========================================================================
 // RecalculateTaskQuota tests
 // ===========================================================================
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/dto"
+	"github.com/QuantumNous/new-api/logger"
+	"github.com/QuantumNous/new-api/model"
+
+	"github.com/bytedance/gopkg/util/gopool"
+)
+
+const (
+	tokenBudgetResetTickInterval = 1 * time.Minute
+	tokenBudgetResetBatchSize    = 300
+)
+
+var (
+	tokenBudgetResetOnce    sync.Once
+	tokenBudgetResetRunning atomic.Bool
+
+	// 记录每个令牌已通知过的预算周期（以下次重置时间区分），同一周期只通知一次
+	tokenBudgetNotified sync.Map // map[tokenId]int64
+)
+
+// StartTokenBudgetResetTask 定时重置令牌的周期预算（daily/weekly/monthly）
+func StartTokenBudgetResetTask() {
+	tokenBudgetResetOnce.Do(func() {
+		if !common.IsMasterNode {
+			return
+		}
+		gopool.Go(func() {
+			logger.LogInfo(context.Background(), fmt.Sprintf("token budget reset task started: tick=%s", tokenBudgetResetTickInterval))
+			ticker := time.NewTicker(tokenBudgetResetTickInterval)
+			defer ticker.Stop()
+
+			runTokenBudgetResetOnce()
+			for range ticker.C {
+				runTokenBudgetResetOnce()
+			}
+		})
+	})
+}
+
+func runTokenBudgetResetOnce() {
+	if !tokenBudgetResetRunning.CompareAndSwap(false, true) {
+		return
+	}
+	defer tokenBudgetResetRunning.Store(false)
+
+	ctx := context.Background()
+	totalReset := 0
+	for {
+		n, err := model.ResetDueTokenBudgets(tokenBudgetResetBatchSize)
+		if err != nil {
+			logger.LogWarn(ctx, fmt.Sprintf("token budget reset task failed: %v", err))
+			return
+		}
+		totalReset += n
+		if n < tokenBudgetResetBatchSize {
+			break
+		}
+	}
+	if common.DebugEnabled && totalReset > 0 {
+		logger.LogDebug(ctx, "token budget reset: reset_count=%d", totalReset)
+	}
+}
+
+func markTokenBudgetNotified(tokenId int, nextResetTime int64) bool {
+	if common.RedisEnabled {
+		ttl := time.Until(time.Unix(nextResetTime, 0))
+		if ttl <= 0 {
+			ttl = time.Hour
+		}
+		key := fmt.Sprintf("token_budget_notified:%d:%d", tokenId, nextResetTime)
+		ok, err := common.RDB.SetNX(context.Background(), key, "1", ttl).Result()
+		return err == nil && ok
+	}
+	previous, loaded := tokenBudgetNotified.Swap(tokenId, nextResetTime)
+	return !loaded || previous.(int64) != nextResetTime
+}
+
+// NotifyTokenBudgetExhausted 令牌周期预算用尽时通知令牌所属用户，同一周期只通知一次
+func NotifyTokenBudgetExhausted(token *model.Token) {
+	if token == nil || !token.HasBudget() {
+		return
+	}
+	tokenId, userId, tokenName, nextResetTime := token.Id, token.UserId, token.Name, token.NextBudgetResetTime
+	gopool.Go(func() {
+		if !markTokenBudgetNotified(tokenId, nextResetTime) {
+			return
+		}
+		user, err := model.GetUserById(userId, false)
+		if err != nil {
+			common.SysError(fmt.Sprintf("failed to get user %d for token budget notify: %s", userId, err.Error()))
+			return
+		}
+		resetAt := "-"
+		if nextResetTime > 0 {
+			resetAt = time.Unix(nextResetTime, 0).Format("2006-01-02 15:04:05")
+		}
+		prompt := "令牌周期预算已用尽"
+		content := "您的令牌「{{value}}」本周期预算已用尽，将于 {{value}} 自动重置。"
+		values := []interface{}{tokenName, resetAt}
+		if err := NotifyUser(userId, user.Email, user.GetSetting(), dto.NewNotify(dto.NotifyTypeTokenBudgetExhausted, prompt, content, values)); err != nil {
+			common.SysError(fmt.Sprintf("failed to send token budget notify to user %d (token %d): %s", userId, tokenId, err.Error()))
+		}
+	})
+}
@@ -18,6 +18,12 @@ type ClaudeSettings struct {
 	DefaultMaxTokens                      map[string]int                 `json:"default_max_tokens"`
 	ThinkingAdapterEnabled                bool                           `json:"thinking_adapter_enabled"`
 	ThinkingAdapterBudgetTokensPercentage float64                        `json:"thinking_adapter_budget_tokens_percentage"`
+	// OpenAI 格式请求转换为 Claude 时自动注入 cache_control 断点
+	AutoCacheControlEnabled bool `json:"auto_cache_control_enabled"`
+	// 模型 -> 缓存时长（5m / 1h / off），default 为未单独配置的模型
+	AutoCacheControlTTL map[string]string `json:"auto_cache_control_ttl"`
+	// 断点之前的内容不足该 token 数时不注入（低于上游最小可缓存长度不会生效）
+	AutoCacheControlMinTokens int `json:"auto_cache_control_min_tokens"`
 }
 
 // 默认配置
@@ -28,6 +34,11 @@ var defaultClaudeSettings = ClaudeSettings{
 		"default": 8192,
 	},
 	ThinkingAdapterBudgetTokensPercentage: 0.8,
+	AutoCacheControlEnabled:               false,
+	AutoCacheControlTTL: map[string]string{
+		"default": "5m",
+	},
+	AutoCacheControlMinTokens: 1024,
 }
 
 // 全局实例
@@ -73,3 +84,18 @@ func (c *ClaudeSettings) GetDefaultMaxTokens(model string) int {
 	}
 	return c.DefaultMaxTokens["default"]
 }
+
+// GetAutoCacheControlTTL 返回模型自动注入 cache_control 的缓存时长，未开启或配置为 off 时返回空
+func (c *ClaudeSettings) GetAutoCacheControlTTL(model string) string {
+	if !c.AutoCacheControlEnabled {
+		return ""
+	}
+	ttl, ok := c.AutoCacheControlTTL[model]
+	if !ok {
+		ttl = c.AutoCacheControlTTL["default"]
+	}
+	if ttl != "5m" && ttl != "1h" {
+		return ""
+	}
+	return ttl
+}
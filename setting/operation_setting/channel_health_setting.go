@@ -0,0 +1,39 @@
+package operation_setting
+
+import "github.com/QuantumNous/new-api/setting/config"
+
+// ChannelHealthSetting 健康度自适应选路配置
+type ChannelHealthSetting struct {
+	Enabled             bool    `json:"enabled"`              // 是否按健康度调整渠道权重
+	EwmaAlpha           float64 `json:"ewma_alpha"`           // EWMA 平滑系数，越大越敏感
+	MinSamples          int     `json:"min_samples"`          // 样本数达到后才按成功率降权
+	OpenSuccessRate     float64 `json:"open_success_rate"`    // 成功率低于该值时熔断
+	ConsecutiveFailures int     `json:"consecutive_failures"` // 连续失败次数达到后熔断
+	OpenSeconds         int     `json:"open_seconds"`         // 熔断时长，到期后进入半开状态放行单个探测请求
+	MinWeightFactor     float64 `json:"min_weight_factor"`    // 降权后的最低权重系数
+	LatencyWeighting    bool    `json:"latency_weighting"`    // 是否参考延迟调整权重
+}
+
+// 默认配置
+var channelHealthSetting = ChannelHealthSetting{
+	Enabled:             false,
+	EwmaAlpha:           0.2,
+	MinSamples:          10,
+	OpenSuccessRate:     0.3,
+	ConsecutiveFailures: 5,
+	OpenSeconds:         60,
+	MinWeightFactor:     0.05,
+	LatencyWeighting:    true,
+}
+
+func init() {
+	config.GlobalConfig.Register("channel_health_setting", &channelHealthSetting)
+}
+
+func GetChannelHealthSetting() *ChannelHealthSetting {
+	return &channelHealthSetting
+}
+
+func IsChannelHealthSelectionEnabled() bool {
+	return channelHealthSetting.Enabled
+}
@@ -0,0 +1,30 @@
+package operation_setting
+
+import "github.com/QuantumNous/new-api/setting/config"
+
+// ChannelRecoverySetting 自动禁用的渠道与多 Key 渠道中单个 key 的恢复探测配置。
+// 开启后主节点按指数退避对其单独发起测试请求，连续成功达到次数后重新启用。
+type ChannelRecoverySetting struct {
+	Enabled                bool `json:"enabled"`
+	InitialIntervalSeconds int  `json:"initial_interval_seconds"` // 首次探测的等待时间，探测失败后翻倍
+	MaxIntervalSeconds     int  `json:"max_interval_seconds"`     // 退避的最长间隔
+	RequiredSuccesses      int  `json:"required_successes"`       // 连续成功多少次后恢复
+	EventRetentionDays     int  `json:"event_retention_days"`     // 禁用/恢复记录保留天数，<= 0 表示不自动清理
+}
+
+// 默认配置
+var channelRecoverySetting = ChannelRecoverySetting{
+	Enabled:                false,
+	InitialIntervalSeconds: 60,
+	MaxIntervalSeconds:     3600,
+	RequiredSuccesses:      2,
+	EventRetentionDays:     30,
+}
+
+func init() {
+	config.GlobalConfig.Register("channel_recovery_setting", &channelRecoverySetting)
+}
+
+func GetChannelRecoverySetting() *ChannelRecoverySetting {
+	return &channelRecoverySetting
+}
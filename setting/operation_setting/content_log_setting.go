@@ -0,0 +1,50 @@
+package operation_setting
+
+import "github.com/QuantumNous/new-api/setting/config"
+
+// ContentLogSetting 请求/响应内容日志配置。
+// 开启后仅记录启用了内容日志的令牌或 Groups 中分组的请求，内容只有管理员可以查看。
+type ContentLogSetting struct {
+	Enabled       bool     `json:"enabled"`        // 总开关
+	Groups        []string `json:"groups"`         // 对这些分组的所有请求记录内容
+	MaxBodyKB     int      `json:"max_body_kb"`    // 请求体与响应体各自的最大记录大小，超出部分截断
+	RetentionDays int      `json:"retention_days"` // 保留天数，<= 0 表示不自动清理
+	// RedactionRules 正则脱敏规则，匹配的内容替换为 [REDACTED]
+	RedactionRules []string `json:"redaction_rules"`
+}
+
+// 默认配置
+var contentLogSetting = ContentLogSetting{
+	Enabled:       false,
+	Groups:        []string{},
+	MaxBodyKB:     64,
+	RetentionDays: 7,
+	RedactionRules: []string{
+		`sk-[A-Za-z0-9_-]{16,}`,
+		`(?i)bearer\s+[A-Za-z0-9._~+/=-]{16,}`,
+	},
+}
+
+func init() {
+	config.GlobalConfig.Register("content_log_setting", &contentLogSetting)
+}
+
+func GetContentLogSetting() *ContentLogSetting {
+	return &contentLogSetting
+}
+
+// ShouldLogContent 判断请求是否需要记录内容
+func ShouldLogContent(group string, tokenEnabled bool) bool {
+	if !contentLogSetting.Enabled {
+		return false
+	}
+	if tokenEnabled {
+		return true
+	}
+	for _, g := range contentLogSetting.Groups {
+		if g == group {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package operation_setting
+
+import (
+	"time"
+
+	"github.com/QuantumNous/new-api/setting/config"
+)
+
+// FirstTokenSetting 流式请求首字超时与对冲请求配置
+type FirstTokenSetting struct {
+	TimeoutSeconds      int            `json:"timeout_seconds"`       // 默认首字超时，0 表示不限制
+	ModelTimeoutSeconds map[string]int `json:"model_timeout_seconds"` // 按模型覆盖首字超时
+	HedgeEnabled        bool           `json:"hedge_enabled"`         // 是否启用对冲请求
+	HedgeDelayMs        int            `json:"hedge_delay_ms"`        // 首个请求超过该时长仍无输出时发起第二个请求
+}
+
+// 默认配置
+var firstTokenSetting = FirstTokenSetting{
+	TimeoutSeconds:      0,
+	ModelTimeoutSeconds: map[string]int{},
+	HedgeEnabled:        false,
+	HedgeDelayMs:        3000,
+}
+
+func init() {
+	config.GlobalConfig.Register("first_token_setting", &firstTokenSetting)
+}
+
+func GetFirstTokenSetting() *FirstTokenSetting {
+	return &firstTokenSetting
+}
+
+// GetFirstTokenTimeout 优先级：渠道配置 > 模型配置 > 默认配置
+func GetFirstTokenTimeout(modelName string, channelSeconds int) time.Duration {
+	if channelSeconds > 0 {
+		return time.Duration(channelSeconds) * time.Second
+	}
+	if seconds, ok := firstTokenSetting.ModelTimeoutSeconds[modelName]; ok {
+		return time.Duration(seconds) * time.Second
+	}
+	return time.Duration(firstTokenSetting.TimeoutSeconds) * time.Second
+}
+
+// GetHedgeDelay 渠道单独配置的延迟优先，返回 0 表示不发起对冲请求
+func GetHedgeDelay(channelDelayMs int) time.Duration {
+	if channelDelayMs > 0 {
+		return time.Duration(channelDelayMs) * time.Millisecond
+	}
+	if !firstTokenSetting.HedgeEnabled || firstTokenSetting.HedgeDelayMs <= 0 {
+		return 0
+	}
+	return time.Duration(firstTokenSetting.HedgeDelayMs) * time.Millisecond
+}
@@ -0,0 +1,38 @@
+package operation_setting
+
+import "github.com/QuantumNous/new-api/setting/config"
+
+// ModelFallbackSetting 模型降级链配置。
+// 主模型的所有渠道均失败或限流时，依次改用备选模型重试，按实际使用的模型计费。
+type ModelFallbackSetting struct {
+	Enabled bool `json:"enabled"`
+	// GroupChains 分组 -> 主模型 -> 备选模型列表，分组为 "*" 时对未单独配置的分组生效；令牌上的配置优先
+	GroupChains map[string]map[string][]string `json:"group_chains"`
+}
+
+// 默认配置
+var modelFallbackSetting = ModelFallbackSetting{
+	Enabled:     false,
+	GroupChains: map[string]map[string][]string{},
+}
+
+func init() {
+	config.GlobalConfig.Register("model_fallback_setting", &modelFallbackSetting)
+}
+
+func GetModelFallbackSetting() *ModelFallbackSetting {
+	return &modelFallbackSetting
+}
+
+// GetGroupModelFallbacks 返回分组下主模型的备选模型列表
+func GetGroupModelFallbacks(group string, modelName string) []string {
+	if chains, ok := modelFallbackSetting.GroupChains[group]; ok {
+		if fallbacks, ok := chains[modelName]; ok {
+			return fallbacks
+		}
+	}
+	if chains, ok := modelFallbackSetting.GroupChains["*"]; ok {
+		return chains[modelName]
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package operation_setting
+
+import "github.com/QuantumNous/new-api/setting/config"
+
+// ResponseCacheSetting 精确匹配响应缓存配置
+type ResponseCacheSetting struct {
+	Enabled           bool    `json:"enabled"`             // 是否启用响应缓存
+	DefaultTTLSeconds int     `json:"default_ttl_seconds"` // 默认缓存时长
+	MaxEntries        int     `json:"max_entries"`         // 内存缓存最大条目数（Redis 模式下不生效）
+	MaxBodyKB         int     `json:"max_body_kb"`         // 单条响应体最大缓存大小
+	BillingRatio      float64 `json:"billing_ratio"`       // 命中缓存时的计费倍率，0 表示免费
+	// GroupTTLSeconds / ModelTTLSeconds 覆盖默认时长，模型优先于分组；值 <= 0 表示该分组/模型不缓存
+	GroupTTLSeconds map[string]int `json:"group_ttl_seconds"`
+	ModelTTLSeconds map[string]int `json:"model_ttl_seconds"`
+}
+
+// 默认配置
+var responseCacheSetting = ResponseCacheSetting{
+	Enabled:           false,
+	DefaultTTLSeconds: 3600,
+	MaxEntries:        10_000,
+	MaxBodyKB:         1024,
+	BillingRatio:      0.1,
+	GroupTTLSeconds:   map[string]int{},
+	ModelTTLSeconds:   map[string]int{},
+}
+
+func init() {
+	config.GlobalConfig.Register("response_cache_setting", &responseCacheSetting)
+}
+
+func GetResponseCacheSetting() *ResponseCacheSetting {
+	return &responseCacheSetting
+}
+
+// GetResponseCacheTTLSeconds 返回指定分组和模型的缓存时长，<= 0 表示不缓存
+func GetResponseCacheTTLSeconds(group string, modelName string) int {
+	if !responseCacheSetting.Enabled {
+		return 0
+	}
+	if ttl, ok := responseCacheSetting.ModelTTLSeconds[modelName]; ok {
+		return ttl
+	}
+	if ttl, ok := responseCacheSetting.GroupTTLSeconds[group]; ok {
+		return ttl
+	}
+	return responseCacheSetting.DefaultTTLSeconds
+}
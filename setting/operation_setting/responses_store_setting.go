@@ -0,0 +1,27 @@
+package operation_setting
+
+import "github.com/QuantumNous/new-api/setting/config"
+
+// ResponsesStoreSetting /v1/responses 本地会话存储配置。
+// 开启后网关会保存每次 Responses 请求的输入与输出，previous_response_id 引用本地记录时由网关自行拼接上下文，
+// 使续聊请求可以路由到任意渠道，并支持 GET/DELETE /v1/responses/:id。
+type ResponsesStoreSetting struct {
+	Enabled     bool `json:"enabled"`
+	TTLHours    int  `json:"ttl_hours"`     // 记录保留时长
+	MaxRecordKB int  `json:"max_record_kb"` // 单条记录（含完整上下文）最大大小，超过则不保存
+}
+
+// 默认配置
+var responsesStoreSetting = ResponsesStoreSetting{
+	Enabled:     false,
+	TTLHours:    720,
+	MaxRecordKB: 1024,
+}
+
+func init() {
+	config.GlobalConfig.Register("responses_store_setting", &responsesStoreSetting)
+}
+
+func GetResponsesStoreSetting() *ResponsesStoreSetting {
+	return &responsesStoreSetting
+}
@@ -0,0 +1,33 @@
+package operation_setting
+
+import "github.com/QuantumNous/new-api/setting/config"
+
+// StreamContinuationSetting 流式输出中途失败时的续写配置。
+// 开启后 chat/completions 与 Claude messages 的流在上游中断时，会把已输出的文本作为 assistant 预填充，
+// 切换到同类型的其他渠道继续生成，并拼接到原有的 SSE 连接上。
+type StreamContinuationSetting struct {
+	Enabled          bool `json:"enabled"`
+	MaxContinuations int  `json:"max_continuations"` // 单次请求最多续写次数
+}
+
+// 默认配置
+var streamContinuationSetting = StreamContinuationSetting{
+	Enabled:          false,
+	MaxContinuations: 1,
+}
+
+func init() {
+	config.GlobalConfig.Register("stream_continuation_setting", &streamContinuationSetting)
+}
+
+func GetStreamContinuationSetting() *StreamContinuationSetting {
+	return &streamContinuationSetting
+}
+
+// GetMaxStreamContinuations 返回 0 表示未开启续写
+func GetMaxStreamContinuations() int {
+	if !streamContinuationSetting.Enabled || streamContinuationSetting.MaxContinuations <= 0 {
+		return 0
+	}
+	return streamContinuationSetting.MaxContinuations
+}
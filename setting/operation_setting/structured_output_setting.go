@@ -0,0 +1,39 @@
+package operation_setting
+
+import "github.com/QuantumNous/new-api/setting/config"
+
+const (
+	StructuredOutputFailureError       = "error"
+	StructuredOutputFailurePassthrough = "passthrough"
+)
+
+// StructuredOutputSetting 结构化输出校验配置。
+// 请求携带 response_format: json_schema 时，网关按 schema 校验最终的 assistant 消息，失败时附带修复提示重试。
+type StructuredOutputSetting struct {
+	Enabled bool `json:"enabled"`
+	// MaxRetries 校验失败后的最大重试次数
+	MaxRetries int `json:"max_retries"`
+	// BufferStream 流式请求先缓冲完整输出再校验，关闭时流式请求不校验
+	BufferStream bool `json:"buffer_stream"`
+	// FailureMode 重试用尽后的处理方式：error 返回错误，passthrough 原样返回最后一次输出
+	FailureMode string `json:"failure_mode"`
+	// RepairPrompt 修复提示，%s 替换为校验错误
+	RepairPrompt string `json:"repair_prompt"`
+}
+
+// 默认配置
+var structuredOutputSetting = StructuredOutputSetting{
+	Enabled:      false,
+	MaxRetries:   1,
+	BufferStream: false,
+	FailureMode:  StructuredOutputFailureError,
+	RepairPrompt: "Your previous response did not conform to the required JSON schema: %s. Reply again with only a JSON value that strictly matches the schema, without any extra text or markdown.",
+}
+
+func init() {
+	config.GlobalConfig.Register("structured_output_setting", &structuredOutputSetting)
+}
+
+func GetStructuredOutputSetting() *StructuredOutputSetting {
+	return &structuredOutputSetting
+}
@@ -0,0 +1,108 @@
+package ratio_setting
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/types"
+)
+
+// ModelRatioTier 长上下文阶梯价格，输入 token 数超过 Threshold 时整次请求按该档位计费。
+// CompletionRatio 为 0、CacheRatio / CacheCreationRatio 未填写时沿用基础倍率。
+type ModelRatioTier struct {
+	Threshold          int      `json:"threshold"`
+	ModelRatio         float64  `json:"model_ratio"`
+	CompletionRatio    float64  `json:"completion_ratio,omitempty"`
+	CacheRatio         *float64 `json:"cache_ratio,omitempty"`
+	CacheCreationRatio *float64 `json:"cache_creation_ratio,omitempty"`
+}
+
+var defaultModelTieredRatio = map[string][]ModelRatioTier{
+	// 输入超过 128k：$2.5 / $10
+	"gemini-1.5-pro-latest": {{Threshold: 128000, ModelRatio: 1.25, CompletionRatio: 4}},
+	// 输入超过 200k：$2.5 / $15
+	"gemini-2.5-pro": {{Threshold: 200000, ModelRatio: 1.25, CompletionRatio: 6}},
+	// 输入超过 200k：$4 / $18
+	"gemini-3-pro-preview": {{Threshold: 200000, ModelRatio: 2, CompletionRatio: 4.5}},
+	// 1M 上下文输入超过 200k：$6 / $22.5
+	"claude-sonnet-4-20250514":   {{Threshold: 200000, ModelRatio: 3, CompletionRatio: 3.75}},
+	"claude-sonnet-4-5-20250929": {{Threshold: 200000, ModelRatio: 3, CompletionRatio: 3.75}},
+}
+
+var modelTieredRatioMap = types.NewRWMap[string, []ModelRatioTier]()
+
+func GetModelTieredRatioCopy() map[string][]ModelRatioTier {
+	return modelTieredRatioMap.ReadAll()
+}
+
+// ModelTieredRatio2JSONString converts the tiered ratio map to a JSON string
+func ModelTieredRatio2JSONString() string {
+	return modelTieredRatioMap.MarshalJSONString()
+}
+
+// UpdateModelTieredRatioByJSONString 校验并更新阶梯价格，各模型的档位按阈值升序保存
+func UpdateModelTieredRatioByJSONString(jsonStr string) error {
+	tiers := make(map[string][]ModelRatioTier)
+	if err := common.UnmarshalJsonStr(jsonStr, &tiers); err != nil {
+		return err
+	}
+	if err := checkModelTieredRatio(tiers); err != nil {
+		return err
+	}
+	modelTieredRatioMap.Clear()
+	modelTieredRatioMap.AddAll(tiers)
+	InvalidateExposedDataCache()
+	return nil
+}
+
+func checkModelTieredRatio(tiers map[string][]ModelRatioTier) error {
+	for model, modelTiers := range tiers {
+		if model == "" {
+			return errors.New("model name is empty")
+		}
+		seen := make(map[int]bool, len(modelTiers))
+		for _, tier := range modelTiers {
+			if tier.Threshold <= 0 {
+				return fmt.Errorf("model %s: threshold must be greater than 0", model)
+			}
+			if seen[tier.Threshold] {
+				return fmt.Errorf("model %s: duplicate threshold %d", model, tier.Threshold)
+			}
+			seen[tier.Threshold] = true
+			if tier.ModelRatio < 0 || tier.CompletionRatio < 0 ||
+				(tier.CacheRatio != nil && *tier.CacheRatio < 0) ||
+				(tier.CacheCreationRatio != nil && *tier.CacheCreationRatio < 0) {
+				return fmt.Errorf("model %s: ratio must not be negative", model)
+			}
+		}
+		sort.Slice(modelTiers, func(i, j int) bool {
+			return modelTiers[i].Threshold < modelTiers[j].Threshold
+		})
+	}
+	return nil
+}
+
+// GetModelTieredRatio 返回模型的阶梯价格（按阈值升序）。
+// 与基础倍率一致先做模型名归一化，未精确命中时去掉 compact 后缀，
+// 再按最长前缀匹配思考预算、日期等后缀变体，如 gemini-2.5-pro-thinking-*。
+func GetModelTieredRatio(name string) ([]ModelRatioTier, bool) {
+	name = strings.TrimSuffix(FormatMatchingModelName(name), CompactModelSuffix)
+	if tiers, ok := modelTieredRatioMap.Get(name); ok && len(tiers) > 0 {
+		return tiers, true
+	}
+	var matched string
+	var matchedTiers []ModelRatioTier
+	for model, tiers := range modelTieredRatioMap.ReadAll() {
+		if len(tiers) > 0 && len(model) > len(matched) && strings.HasPrefix(name, model+"-") {
+			matched = model
+			matchedTiers = tiers
+		}
+	}
+	if matched == "" {
+		return nil, false
+	}
+	return matchedTiers, true
+}
@@ -0,0 +1,39 @@
+package ratio_setting
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetModelTieredRatio_Variants(t *testing.T) {
+	require.NoError(t, UpdateModelTieredRatioByJSONString(`{
+		"gemini-2.5-pro": [{"threshold": 200000, "model_ratio": 1.25, "completion_ratio": 6}],
+		"gemini-2.5-pro-exp": [{"threshold": 100000, "model_ratio": 2}]
+	}`))
+	t.Cleanup(func() {
+		modelTieredRatioMap.Clear()
+		modelTieredRatioMap.AddAll(defaultModelTieredRatio)
+	})
+
+	for _, name := range []string{
+		"gemini-2.5-pro",
+		"gemini-2.5-pro-thinking-128",
+		"gemini-2.5-pro-preview-06-05",
+		"gemini-2.5-pro" + CompactModelSuffix,
+	} {
+		tiers, ok := GetModelTieredRatio(name)
+		require.True(t, ok, name)
+		require.Equal(t, 200000, tiers[0].Threshold, name)
+	}
+
+	// 更长的前缀优先
+	tiers, ok := GetModelTieredRatio("gemini-2.5-pro-exp-03-25")
+	require.True(t, ok)
+	require.Equal(t, 100000, tiers[0].Threshold)
+
+	_, ok = GetModelTieredRatio("gemini-2.5-profile")
+	require.False(t, ok)
+	_, ok = GetModelTieredRatio("gemini-2.5-flash")
+	require.False(t, ok)
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/QuantumNous/new-api/common"
 )
@@ -77,6 +78,8 @@ const (
 	ErrorCodeAwsInvokeError         ErrorCode = "aws_invoke_error"
 	ErrorCodeModelNotFound          ErrorCode = "model_not_found"
 	ErrorCodePromptBlocked          ErrorCode = "prompt_blocked"
+	ErrorCodeFirstTokenTimeout      ErrorCode = "first_token_timeout"
+	ErrorCodeStructuredOutput       ErrorCode = "structured_output_invalid"
 
 	// sql error
 	ErrorCodeQueryDataError  ErrorCode = "query_data_error"
@@ -85,6 +88,8 @@ const (
 	// quota error
 	ErrorCodeInsufficientUserQuota      ErrorCode = "insufficient_user_quota"
 	ErrorCodePreConsumeTokenQuotaFailed ErrorCode = "pre_consume_token_quota_failed"
+	ErrorCodeTokenRateLimitExceeded     ErrorCode = "token_rate_limit_exceeded"
+	ErrorCodeTokenBudgetExhausted       ErrorCode = "token_budget_exhausted"
 )
 
 type NewAPIError struct {
@@ -96,6 +101,7 @@ type NewAPIError struct {
 	errorCode      ErrorCode
 	StatusCode     int
 	Metadata       json.RawMessage
+	RetryAfter     time.Duration // 上游响应的 Retry-After，用于多 Key 冷却
 }
 
 // Unwrap enables errors.Is / errors.As to work with NewAPIError by exposing the underlying error.
@@ -25,6 +25,51 @@ type PriceData struct {
 	Quota                int // 按次计费的最终额度（MJ / Task）
 	QuotaToPreConsume    int // 按量计费的预消耗额度
 	GroupRatioInfo       GroupRatioInfo
+	// PriceTiers 长上下文阶梯价格，按阈值升序；TierThreshold 为当前生效档位的阈值，0 表示基础价格
+	PriceTiers    []PriceTier
+	TierThreshold int
+	basePrice     *PriceTier
+}
+
+// PriceTier 输入 token 数超过 Threshold 时整次请求适用的倍率
+type PriceTier struct {
+	Threshold            int
+	ModelRatio           float64
+	CompletionRatio      float64
+	CacheRatio           float64
+	CacheCreationRatio   float64
+	CacheCreation5mRatio float64
+	CacheCreation1hRatio float64
+}
+
+// ApplyPriceTier 按输入 token 数选择阶梯档位并替换倍率，可重复调用
+func (p *PriceData) ApplyPriceTier(promptTokens int) {
+	if len(p.PriceTiers) == 0 || p.UsePrice {
+		return
+	}
+	if p.basePrice == nil {
+		p.basePrice = &PriceTier{
+			ModelRatio:           p.ModelRatio,
+			CompletionRatio:      p.CompletionRatio,
+			CacheRatio:           p.CacheRatio,
+			CacheCreationRatio:   p.CacheCreationRatio,
+			CacheCreation5mRatio: p.CacheCreation5mRatio,
+			CacheCreation1hRatio: p.CacheCreation1hRatio,
+		}
+	}
+	tier := p.basePrice
+	for i := range p.PriceTiers {
+		if promptTokens > p.PriceTiers[i].Threshold {
+			tier = &p.PriceTiers[i]
+		}
+	}
+	p.TierThreshold = tier.Threshold
+	p.ModelRatio = tier.ModelRatio
+	p.CompletionRatio = tier.CompletionRatio
+	p.CacheRatio = tier.CacheRatio
+	p.CacheCreationRatio = tier.CacheCreationRatio
+	p.CacheCreation5mRatio = tier.CacheCreation5mRatio
+	p.CacheCreation1hRatio = tier.CacheCreation1hRatio
 }
 
 func (p *PriceData) AddOtherRatio(key string, ratio float64) {
@@ -38,5 +83,5 @@ func (p *PriceData) AddOtherRatio(key string, ratio float64) {
 }
 
 func (p *PriceData) ToSetting() string {
-	return fmt.Sprintf("ModelPrice: %f, ModelRatio: %f, CompletionRatio: %f, CacheRatio: %f, GroupRatio: %f, UsePrice: %t, CacheCreationRatio: %f, CacheCreation5mRatio: %f, CacheCreation1hRatio: %f, QuotaToPreConsume: %d, ImageRatio: %f, AudioRatio: %f, AudioCompletionRatio: %f", p.ModelPrice, p.ModelRatio, p.CompletionRatio, p.CacheRatio, p.GroupRatioInfo.GroupRatio, p.UsePrice, p.CacheCreationRatio, p.CacheCreation5mRatio, p.CacheCreation1hRatio, p.QuotaToPreConsume, p.ImageRatio, p.AudioRatio, p.AudioCompletionRatio)
+	return fmt.Sprintf("ModelPrice: %f, ModelRatio: %f, CompletionRatio: %f, CacheRatio: %f, GroupRatio: %f, UsePrice: %t, CacheCreationRatio: %f, CacheCreation5mRatio: %f, CacheCreation1hRatio: %f, QuotaToPreConsume: %d, ImageRatio: %f, AudioRatio: %f, AudioCompletionRatio: %f, TierThreshold: %d", p.ModelPrice, p.ModelRatio, p.CompletionRatio, p.CacheRatio, p.GroupRatioInfo.GroupRatio, p.UsePrice, p.CacheCreationRatio, p.CacheCreation5mRatio, p.CacheCreation1hRatio, p.QuotaToPreConsume, p.ImageRatio, p.AudioRatio, p.AudioCompletionRatio, p.TierThreshold)
 }
@@ -37,6 +37,7 @@ const PasswordResetForm = lazy(() => import('./components/auth/PasswordResetForm
 const PasswordResetConfirm = lazy(() => import('./components/auth/PasswordResetConfirm'));
 const Channel = lazy(() => import('./pages/Channel'));
 const Token = lazy(() => import('./pages/Token'));
+const Organization = lazy(() => import('./pages/Organization'));
 const Redemption = lazy(() => import('./pages/Redemption'));
 const RegistrationCode = lazy(() => import('./pages/RegistrationCode'));
 const TopUp = lazy(() => import('./pages/TopUp'));
@@ -170,6 +171,16 @@ function App() {
             </PrivateRoute>
           }
         />
+        <Route
+          path='/console/organization'
+          element={
+            <PrivateRoute>
+              <Suspense fallback={<Loading></Loading>} key={location.pathname}>
+                <Organization />
+              </Suspense>
+            </PrivateRoute>
+          }
+        />
         <Route
           path='/console/playground'
           element={
@@ -0,0 +1,137 @@
+/*
+Copyright (C) 2025 QuantumNous
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as
+published by the Free Software Foundation, either version 3 of the
+License, or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+For commercial licensing, please contact support@quantumnous.com
+*/
+
+import React, { useCallback, useEffect, useState } from 'react';
+import { Card, Table, Tabs, TabPane, Typography } from '@douyinfe/semi-ui';
+import { TrendingUp } from 'lucide-react';
+import { API, renderQuota, showError, timestamp2string } from '../../helpers';
+
+const { Text } = Typography;
+
+const ProfitPanel = ({ inputs, CARD_PROPS, t }) => {
+  const [groupBy, setGroupBy] = useState('channel');
+  const [loading, setLoading] = useState(false);
+  const [data, setData] = useState([]);
+
+  const loadProfitData = useCallback(async () => {
+    setLoading(true);
+    try {
+      const startTimestamp = Date.parse(inputs.start_timestamp) / 1000;
+      const endTimestamp = Date.parse(inputs.end_timestamp) / 1000;
+      const res = await API.get(
+        `/api/data/profit?group_by=${groupBy}&start_timestamp=${startTimestamp}&end_timestamp=${endTimestamp}`,
+      );
+      const { success, message, data } = res.data;
+      if (success) {
+        setData(data || []);
+      } else {
+        showError(message);
+      }
+    } finally {
+      setLoading(false);
+    }
+  }, [groupBy, inputs.start_timestamp, inputs.end_timestamp]);
+
+  useEffect(() => {
+    loadProfitData();
+  }, [loadProfitData]);
+
+  const dimensionColumn = {
+    channel: {
+      title: t('渠道'),
+      dataIndex: 'channel_id',
+      render: (id, record) =>
+        record.channel_name ? `#${id} ${record.channel_name}` : `#${id}`,
+    },
+    model: {
+      title: t('模型'),
+      dataIndex: 'model_name',
+    },
+    day: {
+      title: t('日期'),
+      dataIndex: 'created_at',
+      render: (value) => timestamp2string(value).slice(0, 10),
+    },
+  }[groupBy];
+
+  const columns = [
+    dimensionColumn,
+    {
+      title: t('请求次数'),
+      dataIndex: 'count',
+    },
+    {
+      title: t('收入'),
+      dataIndex: 'quota',
+      render: (value) => renderQuota(value),
+    },
+    {
+      title: t('上游成本'),
+      dataIndex: 'cost',
+      render: (value) => renderQuota(value),
+    },
+    {
+      title: t('毛利'),
+      dataIndex: 'profit',
+      render: (value) => (
+        <Text type={value < 0 ? 'danger' : 'success'}>
+          {renderQuota(value)}
+        </Text>
+      ),
+    },
+    {
+      title: t('毛利率'),
+      dataIndex: 'margin',
+      render: (value) => `${(value * 100).toFixed(1)}%`,
+    },
+  ];
+
+  return (
+    <Card
+      {...CARD_PROPS}
+      className='!rounded-2xl'
+      title={
+        <div className='flex items-center gap-2'>
+          <TrendingUp size={16} />
+          <span style={{ fontWeight: 600 }}>{t('收入与成本')}</span>
+        </div>
+      }
+    >
+      <Tabs type='button' activeKey={groupBy} onChange={setGroupBy}>
+        <TabPane tab={t('按渠道')} itemKey='channel' />
+        <TabPane tab={t('按模型')} itemKey='model' />
+        <TabPane tab={t('按日期')} itemKey='day' />
+      </Tabs>
+      <Table
+        className='mt-3'
+        columns={columns}
+        dataSource={data}
+        rowKey={(record) =>
+          `${record.channel_id}-${record.model_name}-${record.created_at}`
+        }
+        loading={loading}
+        pagination={{ pageSize: 10 }}
+        size='small'
+        empty={t('暂无数据')}
+      />
+    </Card>
+  );
+};
+
+export default ProfitPanel;
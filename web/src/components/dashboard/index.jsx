@@ -28,6 +28,7 @@ import ApiInfoPanel from './ApiInfoPanel';
 import AnnouncementsPanel from './AnnouncementsPanel';
 import FaqPanel from './FaqPanel';
 import UptimePanel from './UptimePanel';
+import ProfitPanel from './ProfitPanel';
 import SearchModal from './modals/SearchModal';
 import GoHomeBanner from '../common/GoHomeBanner';
 const StatsCards = lazy(() => import('./StatsCards'));
@@ -214,6 +215,17 @@ const Dashboard = () => {
         </div>
       </div>
 
+      {/* 收入与成本（管理员） */}
+      {dashboardData.isAdminUser && (
+        <div className='mb-4'>
+          <ProfitPanel
+            inputs={dashboardData.inputs}
+            CARD_PROPS={CARD_PROPS}
+            t={dashboardData.t}
+          />
+        </div>
+      )}
+
       {/* 系统公告和常见问答卡片 */}
       {dashboardData.hasInfoPanels && (
         <div className='mb-4'>
@@ -34,6 +34,7 @@ const routerMap = {
   home: '/',
   channel: '/console/channel',
   token: '/console/token',
+  organization: '/console/organization',
   redemption: '/console/redemption',
   registration_code: '/console/registration-code',
   topup: '/console/topup',
@@ -95,6 +96,11 @@ const SiderBar = ({ onNavigate = () => {} }) => {
         itemKey: 'token',
         to: '/token',
       },
+      {
+        text: t('组织管理'),
+        itemKey: 'organization',
+        to: '/organization',
+      },
       {
         text: t('使用日志'),
         itemKey: 'log',
@@ -0,0 +1,515 @@
+/*
+Copyright (C) 2025 QuantumNous
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as
+published by the Free Software Foundation, either version 3 of the
+License, or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+For commercial licensing, please contact support@quantumnous.com
+*/
+
+import React, { useCallback, useEffect, useState } from 'react';
+import { useTranslation } from 'react-i18next';
+import {
+  Button,
+  Card,
+  Descriptions,
+  Empty,
+  Input,
+  InputNumber,
+  Modal,
+  Popconfirm,
+  Select,
+  Space,
+  Table,
+  TabPane,
+  Tabs,
+  Tag,
+} from '@douyinfe/semi-ui';
+import {
+  API,
+  renderQuota,
+  showError,
+  showSuccess,
+  timestamp2string,
+} from '../../helpers';
+import {
+  displayAmountToQuota,
+  quotaToDisplayAmount,
+} from '../../helpers/quota';
+
+const ROLE_COLORS = { owner: 'red', admin: 'orange', member: 'blue' };
+
+const OrganizationPanel = () => {
+  const { t } = useTranslation();
+  const [orgs, setOrgs] = useState([]);
+  const [orgId, setOrgId] = useState(null);
+  const [members, setMembers] = useState([]);
+  const [tokens, setTokens] = useState([]);
+  const [logs, setLogs] = useState([]);
+  const [logTotal, setLogTotal] = useState(0);
+  const [logPage, setLogPage] = useState(1);
+  const [logMemberId, setLogMemberId] = useState(0);
+  const [createVisible, setCreateVisible] = useState(false);
+  const [createName, setCreateName] = useState('');
+  const [transferAmount, setTransferAmount] = useState(0);
+  const [memberForm, setMemberForm] = useState(null);
+
+  const org = orgs.find((item) => item.id === orgId);
+  const canManage = org && (org.role === 'owner' || org.role === 'admin');
+
+  const roleLabel = (role) =>
+    ({ owner: t('所有者'), admin: t('管理员'), member: t('成员') })[role] ||
+    role;
+
+  const loadOrgs = useCallback(async () => {
+    const res = await API.get('/api/organization/self');
+    const { success, message, data } = res.data;
+    if (!success) {
+      showError(message);
+      return;
+    }
+    setOrgs(data || []);
+    setOrgId((current) => {
+      if (data?.some((item) => item.id === current)) return current;
+      return data?.length ? data[0].id : null;
+    });
+  }, []);
+
+  const loadMembers = useCallback(async () => {
+    if (!orgId || !canManage) {
+      setMembers([]);
+      return;
+    }
+    const res = await API.get(`/api/organization/${orgId}/members`);
+    if (res.data.success) {
+      setMembers(res.data.data || []);
+    } else {
+      showError(res.data.message);
+    }
+  }, [orgId, canManage]);
+
+  const loadTokens = useCallback(async () => {
+    if (!orgId) return;
+    const res = await API.get(`/api/organization/${orgId}/tokens`);
+    if (res.data.success) {
+      setTokens(res.data.data || []);
+    } else {
+      showError(res.data.message);
+    }
+  }, [orgId]);
+
+  const loadLogs = useCallback(async () => {
+    if (!orgId) return;
+    const res = await API.get(
+      `/api/organization/${orgId}/logs?type=2&p=${logPage}&page_size=10&member_id=${logMemberId}`,
+    );
+    if (res.data.success) {
+      setLogs(res.data.data.items || []);
+      setLogTotal(res.data.data.total || 0);
+    } else {
+      showError(res.data.message);
+    }
+  }, [orgId, logPage, logMemberId]);
+
+  useEffect(() => {
+    loadOrgs();
+  }, [loadOrgs]);
+
+  useEffect(() => {
+    loadMembers();
+    loadTokens();
+  }, [loadMembers, loadTokens]);
+
+  useEffect(() => {
+    loadLogs();
+  }, [loadLogs]);
+
+  const createOrg = async () => {
+    const res = await API.post('/api/organization/', { name: createName });
+    if (res.data.success) {
+      showSuccess(t('创建成功'));
+      setCreateVisible(false);
+      setCreateName('');
+      await loadOrgs();
+      setOrgId(res.data.data.id);
+    } else {
+      showError(res.data.message);
+    }
+  };
+
+  const transfer = async () => {
+    const quota = displayAmountToQuota(transferAmount);
+    if (quota <= 0) {
+      showError(t('请输入有效的金额'));
+      return;
+    }
+    const res = await API.post(`/api/organization/${orgId}/transfer`, {
+      quota,
+    });
+    if (res.data.success) {
+      showSuccess(t('转入成功'));
+      setTransferAmount(0);
+      loadOrgs();
+    } else {
+      showError(res.data.message);
+    }
+  };
+
+  const saveMember = async () => {
+    const payload = {
+      username: memberForm.username,
+      role: memberForm.role,
+      quota_limit: displayAmountToQuota(memberForm.quota_limit),
+      reset_used: memberForm.reset_used,
+    };
+    const res = memberForm.user_id
+      ? await API.put(
+          `/api/organization/${orgId}/members/${memberForm.user_id}`,
+          payload,
+        )
+      : await API.post(`/api/organization/${orgId}/members`, payload);
+    if (res.data.success) {
+      showSuccess(t('保存成功'));
+      setMemberForm(null);
+      loadMembers();
+    } else {
+      showError(res.data.message);
+    }
+  };
+
+  const removeMember = async (userId) => {
+    const res = await API.delete(
+      `/api/organization/${orgId}/members/${userId}`,
+    );
+    if (res.data.success) {
+      showSuccess(t('操作成功'));
+      loadMembers();
+    } else {
+      showError(res.data.message);
+    }
+  };
+
+  const setTokenStatus = async (tokenId, status) => {
+    const res = await API.put(
+      `/api/organization/${orgId}/tokens/${tokenId}/status`,
+      { status },
+    );
+    if (res.data.success) {
+      loadTokens();
+    } else {
+      showError(res.data.message);
+    }
+  };
+
+  const memberColumns = [
+    { title: t('用户名'), dataIndex: 'username' },
+    {
+      title: t('角色'),
+      dataIndex: 'role',
+      render: (role) => <Tag color={ROLE_COLORS[role]}>{roleLabel(role)}</Tag>,
+    },
+    {
+      title: t('已消费'),
+      dataIndex: 'used_quota',
+      render: (value) => renderQuota(value),
+    },
+    {
+      title: t('消费上限'),
+      dataIndex: 'quota_limit',
+      render: (value) => (value > 0 ? renderQuota(value) : t('不限制')),
+    },
+    {
+      title: '',
+      dataIndex: 'operate',
+      render: (_, record) =>
+        record.role === 'owner' ? null : (
+          <Space>
+            <Button
+              size='small'
+              onClick={() =>
+                setMemberForm({
+                  user_id: record.user_id,
+                  username: record.username,
+                  role: record.role,
+                  quota_limit: quotaToDisplayAmount(record.quota_limit),
+                  reset_used: false,
+                })
+              }
+            >
+              {t('编辑')}
+            </Button>
+            <Popconfirm
+              title={t('确定移除该成员？')}
+              onConfirm={() => removeMember(record.user_id)}
+            >
+              <Button size='small' type='danger'>
+                {t('移除')}
+              </Button>
+            </Popconfirm>
+          </Space>
+        ),
+    },
+  ];
+
+  const tokenColumns = [
+    { title: t('名称'), dataIndex: 'name' },
+    {
+      title: t('密钥'),
+      dataIndex: 'key_prefix',
+      render: (value) => `sk-${value}***`,
+    },
+    {
+      title: t('创建者'),
+      dataIndex: 'user_id',
+      render: (value) =>
+        members.find((member) => member.user_id === value)?.username ||
+        `#${value}`,
+    },
+    {
+      title: t('已用额度'),
+      dataIndex: 'used_quota',
+      render: (value) => renderQuota(value),
+    },
+    {
+      title: t('状态'),
+      dataIndex: 'status',
+      render: (status, record) =>
+        canManage ? (
+          <Button
+            size='small'
+            type={status === 1 ? 'danger' : 'primary'}
+            onClick={() => setTokenStatus(record.id, status === 1 ? 2 : 1)}
+          >
+            {status === 1 ? t('禁用') : t('启用')}
+          </Button>
+        ) : (
+          <Tag color={status === 1 ? 'green' : 'grey'}>
+            {status === 1 ? t('已启用') : t('已禁用')}
+          </Tag>
+        ),
+    },
+  ];
+
+  const logColumns = [
+    {
+      title: t('时间'),
+      dataIndex: 'created_at',
+      render: (value) => timestamp2string(value),
+    },
+    { title: t('成员'), dataIndex: 'username' },
+    { title: t('令牌'), dataIndex: 'token_name' },
+    { title: t('模型'), dataIndex: 'model_name' },
+    {
+      title: t('花费'),
+      dataIndex: 'quota',
+      render: (value) => renderQuota(value, 6),
+    },
+  ];
+
+  return (
+    <Card
+      className='!rounded-2xl'
+      title={t('组织管理')}
+      headerExtraContent={
+        <Space>
+          <Select
+            style={{ width: 220 }}
+            value={orgId}
+            onChange={(value) => {
+              setOrgId(value);
+              setLogPage(1);
+              setLogMemberId(0);
+            }}
+            placeholder={t('选择组织')}
+            optionList={orgs.map((item) => ({
+              label: item.name,
+              value: item.id,
+            }))}
+          />
+          <Button theme='solid' onClick={() => setCreateVisible(true)}>
+            {t('创建组织')}
+          </Button>
+        </Space>
+      }
+    >
+      {!org ? (
+        <Empty description={t('暂未加入任何组织')} />
+      ) : (
+        <>
+          <Descriptions
+            row
+            data={[
+              { key: t('我的角色'), value: roleLabel(org.role) },
+              { key: t('组织余额'), value: renderQuota(org.quota) },
+              { key: t('组织已消费'), value: renderQuota(org.used_quota) },
+              {
+                key: t('我的消费'),
+                value:
+                  org.quota_limit > 0
+                    ? `${renderQuota(org.member_used_quota)} / ${renderQuota(org.quota_limit)}`
+                    : renderQuota(org.member_used_quota),
+              },
+            ]}
+          />
+          <Space className='mt-3'>
+            <InputNumber
+              min={0}
+              value={transferAmount}
+              onChange={(value) => setTransferAmount(value)}
+              placeholder={t('转入金额')}
+            />
+            <Button onClick={transfer}>{t('从个人钱包转入')}</Button>
+          </Space>
+          <Tabs className='mt-4' type='line'>
+            {canManage && (
+              <TabPane tab={t('成员')} itemKey='members'>
+                <Button
+                  className='mb-3'
+                  onClick={() =>
+                    setMemberForm({
+                      username: '',
+                      role: 'member',
+                      quota_limit: 0,
+                      reset_used: false,
+                    })
+                  }
+                >
+                  {t('添加成员')}
+                </Button>
+                <Table
+                  columns={memberColumns}
+                  dataSource={members}
+                  rowKey='id'
+                  pagination={false}
+                  size='small'
+                />
+              </TabPane>
+            )}
+            <TabPane tab={t('组织令牌')} itemKey='tokens'>
+              <Table
+                columns={tokenColumns}
+                dataSource={tokens}
+                rowKey='id'
+                pagination={{ pageSize: 10 }}
+                size='small'
+                empty={t('在令牌管理中创建令牌时选择该组织即可')}
+              />
+            </TabPane>
+            <TabPane tab={t('消费日志')} itemKey='logs'>
+              {canManage && (
+                <Select
+                  className='mb-3'
+                  style={{ width: 220 }}
+                  value={logMemberId}
+                  onChange={(value) => {
+                    setLogMemberId(value);
+                    setLogPage(1);
+                  }}
+                  optionList={[
+                    { label: t('全部成员'), value: 0 },
+                    ...members.map((member) => ({
+                      label: member.username,
+                      value: member.user_id,
+                    })),
+                  ]}
+                />
+              )}
+              <Table
+                columns={logColumns}
+                dataSource={logs}
+                rowKey='id'
+                size='small'
+                pagination={{
+                  currentPage: logPage,
+                  pageSize: 10,
+                  total: logTotal,
+                  onPageChange: setLogPage,
+                }}
+              />
+            </TabPane>
+          </Tabs>
+        </>
+      )}
+      <Modal
+        title={t('创建组织')}
+        visible={createVisible}
+        onOk={createOrg}
+        onCancel={() => setCreateVisible(false)}
+      >
+        <Input
+          value={createName}
+          onChange={setCreateName}
+          placeholder={t('组织名称')}
+          maxLength={64}
+        />
+      </Modal>
+      <Modal
+        title={memberForm?.user_id ? t('编辑成员') : t('添加成员')}
+        visible={!!memberForm}
+        onOk={saveMember}
+        onCancel={() => setMemberForm(null)}
+      >
+        {memberForm && (
+          <Space vertical align='start' style={{ width: '100%' }}>
+            <Input
+              value={memberForm.username}
+              disabled={!!memberForm.user_id}
+              onChange={(value) =>
+                setMemberForm({ ...memberForm, username: value })
+              }
+              placeholder={t('用户名')}
+            />
+            <Select
+              style={{ width: '100%' }}
+              value={memberForm.role}
+              onChange={(value) => setMemberForm({ ...memberForm, role: value })}
+              optionList={[
+                { label: t('成员'), value: 'member' },
+                ...(org?.role === 'owner'
+                  ? [{ label: t('管理员'), value: 'admin' }]
+                  : []),
+              ]}
+            />
+            <InputNumber
+              style={{ width: '100%' }}
+              min={0}
+              value={memberForm.quota_limit}
+              onChange={(value) =>
+                setMemberForm({ ...memberForm, quota_limit: value })
+              }
+              prefix={t('消费上限')}
+              placeholder={t('0 表示不限制')}
+            />
+            {memberForm.user_id && (
+              <Button
+                type={memberForm.reset_used ? 'primary' : 'tertiary'}
+                onClick={() =>
+                  setMemberForm({
+                    ...memberForm,
+                    reset_used: !memberForm.reset_used,
+                  })
+                }
+              >
+                {memberForm.reset_used
+                  ? t('保存时将清零已消费额度')
+                  : t('清零已消费额度')}
+              </Button>
+            )}
+          </Space>
+        )}
+      </Modal>
+    </Card>
+  );
+};
+
+export default OrganizationPanel;
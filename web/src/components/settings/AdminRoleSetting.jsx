@@ -0,0 +1,290 @@
+/*
+Copyright (C) 2025 QuantumNous
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as
+published by the Free Software Foundation, either version 3 of the
+License, or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+For commercial licensing, please contact support@quantumnous.com
+*/
+
+import React, { useEffect, useState } from 'react';
+import {
+  Banner,
+  Button,
+  Card,
+  Checkbox,
+  CheckboxGroup,
+  Form,
+  Input,
+  InputNumber,
+  Modal,
+  Popconfirm,
+  Select,
+  Space,
+  Table,
+  Tag,
+} from '@douyinfe/semi-ui';
+import { IconDelete, IconEdit, IconPlus } from '@douyinfe/semi-icons';
+import { useTranslation } from 'react-i18next';
+import { API, showError, showSuccess } from '../../helpers';
+
+// Human readable labels for permission identifiers
+const PERMISSION_LABELS = {
+  'user.read': '查看用户',
+  'user.manage': '管理用户',
+  'log.read': '查看日志',
+  'log.manage': '清理日志',
+  'log.content': '查看内容日志',
+  'data.read': '查看数据看板',
+  'channel.read': '查看渠道',
+  'channel.manage': '管理渠道',
+  'model.manage': '管理模型与部署',
+  'billing.manage': '管理计费与额度',
+  'farm.manage': '管理农场',
+  'feedback.manage': '处理留言反馈',
+};
+
+const emptyRole = { id: 0, name: '', description: '', permissions: [] };
+
+const AdminRoleSetting = () => {
+  const { t } = useTranslation();
+  const [roles, setRoles] = useState([]);
+  const [permissions, setPermissions] = useState([]);
+  const [loading, setLoading] = useState(false);
+  const [editingRole, setEditingRole] = useState(null);
+  const [saving, setSaving] = useState(false);
+  const [assignUserId, setAssignUserId] = useState();
+  const [assignRoleId, setAssignRoleId] = useState(0);
+
+  const loadRoles = async () => {
+    setLoading(true);
+    try {
+      const [rolesRes, permissionsRes] = await Promise.all([
+        API.get('/api/admin_role/'),
+        API.get('/api/admin_role/permissions'),
+      ]);
+      if (rolesRes.data.success) {
+        setRoles(rolesRes.data.data || []);
+      } else {
+        showError(rolesRes.data.message);
+      }
+      if (permissionsRes.data.success) {
+        setPermissions(permissionsRes.data.data || []);
+      }
+    } catch (error) {
+      showError(t('获取管理角色失败'));
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  useEffect(() => {
+    loadRoles();
+  }, []);
+
+  const saveRole = async () => {
+    if (!editingRole.name.trim()) {
+      showError(t('角色名称不能为空'));
+      return;
+    }
+    setSaving(true);
+    try {
+      const res = editingRole.id
+        ? await API.put('/api/admin_role/', editingRole)
+        : await API.post('/api/admin_role/', editingRole);
+      if (res.data.success) {
+        showSuccess(t('保存成功'));
+        setEditingRole(null);
+        loadRoles();
+      } else {
+        showError(res.data.message);
+      }
+    } finally {
+      setSaving(false);
+    }
+  };
+
+  const deleteRole = async (id) => {
+    const res = await API.delete(`/api/admin_role/${id}`);
+    if (res.data.success) {
+      showSuccess(t('删除成功'));
+      loadRoles();
+    } else {
+      showError(res.data.message);
+    }
+  };
+
+  const assignRole = async () => {
+    if (!assignUserId) {
+      showError(t('请输入用户 ID'));
+      return;
+    }
+    const res = await API.put('/api/admin_role/user', {
+      user_id: assignUserId,
+      admin_role_id: assignRoleId,
+    });
+    if (res.data.success) {
+      showSuccess(t('分配成功'));
+    } else {
+      showError(res.data.message);
+    }
+  };
+
+  const permissionLabel = (permission) =>
+    PERMISSION_LABELS[permission]
+      ? t(PERMISSION_LABELS[permission])
+      : permission;
+
+  const columns = [
+    { title: 'ID', dataIndex: 'id', width: 60 },
+    { title: t('角色名称'), dataIndex: 'name' },
+    { title: t('描述'), dataIndex: 'description' },
+    {
+      title: t('权限'),
+      dataIndex: 'permissions',
+      render: (value) => (
+        <Space wrap>
+          {(value || []).map((permission) => (
+            <Tag key={permission} color='blue'>
+              {permissionLabel(permission)}
+            </Tag>
+          ))}
+        </Space>
+      ),
+    },
+    {
+      title: '',
+      dataIndex: 'operate',
+      width: 180,
+      render: (_, record) => (
+        <Space>
+          <Button
+            icon={<IconEdit />}
+            size='small'
+            onClick={() =>
+              setEditingRole({
+                ...record,
+                permissions: record.permissions || [],
+              })
+            }
+          >
+            {t('编辑')}
+          </Button>
+          <Popconfirm
+            title={t('确定删除该管理角色？')}
+            onConfirm={() => deleteRole(record.id)}
+          >
+            <Button icon={<IconDelete />} size='small' type='danger'>
+              {t('删除')}
+            </Button>
+          </Popconfirm>
+        </Space>
+      ),
+    },
+  ];
+
+  return (
+    <Card>
+      <Form.Section text={t('管理角色')}>
+        <Banner
+          type='info'
+          description={t(
+            '未分配角色的管理员拥有全部管理权限；分配角色后仅能访问角色包含的功能。系统设置始终仅限超级管理员。',
+          )}
+          style={{ marginBottom: 16 }}
+        />
+        <Button
+          icon={<IconPlus />}
+          theme='solid'
+          onClick={() => setEditingRole({ ...emptyRole })}
+          style={{ marginBottom: 16 }}
+        >
+          {t('添加管理角色')}
+        </Button>
+        <Table
+          columns={columns}
+          dataSource={roles}
+          loading={loading}
+          rowKey='id'
+          pagination={false}
+          empty={t('暂无管理角色')}
+        />
+      </Form.Section>
+
+      <Form.Section text={t('分配管理角色')}>
+        <Space wrap>
+          <InputNumber
+            placeholder={t('管理员用户 ID')}
+            min={1}
+            value={assignUserId}
+            onChange={setAssignUserId}
+            style={{ width: 180 }}
+          />
+          <Select
+            value={assignRoleId}
+            onChange={setAssignRoleId}
+            style={{ width: 220 }}
+            optionList={[
+              { label: t('全部管理权限'), value: 0 },
+              ...roles.map((role) => ({ label: role.name, value: role.id })),
+            ]}
+          />
+          <Button theme='solid' onClick={assignRole}>
+            {t('分配')}
+          </Button>
+        </Space>
+      </Form.Section>
+
+      <Modal
+        title={editingRole?.id ? t('编辑管理角色') : t('添加管理角色')}
+        visible={!!editingRole}
+        onCancel={() => setEditingRole(null)}
+        onOk={saveRole}
+        confirmLoading={saving}
+      >
+        {editingRole && (
+          <Space vertical align='start' style={{ width: '100%' }}>
+            <Input
+              placeholder={t('角色名称')}
+              value={editingRole.name}
+              onChange={(value) =>
+                setEditingRole({ ...editingRole, name: value })
+              }
+            />
+            <Input
+              placeholder={t('描述')}
+              value={editingRole.description}
+              onChange={(value) =>
+                setEditingRole({ ...editingRole, description: value })
+              }
+            />
+            <CheckboxGroup
+              value={editingRole.permissions}
+              onChange={(value) =>
+                setEditingRole({ ...editingRole, permissions: value })
+              }
+            >
+              {permissions.map((permission) => (
+                <Checkbox key={permission} value={permission}>
+                  {permissionLabel(permission)}
+                </Checkbox>
+              ))}
+            </CheckboxGroup>
+          </Space>
+        )}
+      </Modal>
+    </Card>
+  );
+};
+
+export default AdminRoleSetting;
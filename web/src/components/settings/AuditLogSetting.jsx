@@ -0,0 +1,283 @@
+/*
+Copyright (C) 2025 QuantumNous
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as
+published by the Free Software Foundation, either version 3 of the
+License, or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+For commercial licensing, please contact support@quantumnous.com
+*/
+
+import React, { useEffect, useState } from 'react';
+import {
+  Button,
+  Card,
+  DatePicker,
+  Form,
+  Input,
+  Select,
+  Space,
+  Table,
+  Tag,
+  Typography,
+} from '@douyinfe/semi-ui';
+import { IconDownload, IconSearch } from '@douyinfe/semi-icons';
+import { useTranslation } from 'react-i18next';
+import {
+  API,
+  downloadTextAsFile,
+  showError,
+  timestamp2string,
+} from '../../helpers';
+
+const TARGET_TYPES = [
+  { value: 'channel', label: '渠道' },
+  { value: 'token', label: '令牌' },
+  { value: 'user', label: '用户' },
+  { value: 'option', label: '系统设置' },
+  { value: 'redemption', label: '兑换码' },
+  { value: 'subscription_plan', label: '订阅套餐' },
+  { value: 'deletion_request', label: '注销申请' },
+  { value: 'admin_role', label: '管理角色' },
+  { value: 'farm', label: '农场' },
+  { value: 'other', label: '其他' },
+];
+
+const ACTION_COLORS = {
+  create: 'green',
+  update: 'blue',
+  delete: 'red',
+  view_key: 'orange',
+  request: 'grey',
+};
+
+const emptyFilters = {
+  actor_name: '',
+  target_type: '',
+  target_id: '',
+  action: '',
+  range: [],
+};
+
+const AuditLogSetting = () => {
+  const { t } = useTranslation();
+  const [logs, setLogs] = useState([]);
+  const [loading, setLoading] = useState(false);
+  const [exporting, setExporting] = useState(false);
+  const [page, setPage] = useState(1);
+  const [pageSize, setPageSize] = useState(20);
+  const [total, setTotal] = useState(0);
+  const [filters, setFilters] = useState(emptyFilters);
+
+  const buildQuery = () => {
+    const params = new URLSearchParams();
+    ['actor_name', 'target_type', 'target_id', 'action'].forEach((key) => {
+      if (filters[key]) {
+        params.set(key, filters[key].trim());
+      }
+    });
+    if (filters.range?.length === 2) {
+      params.set(
+        'start_timestamp',
+        Math.floor(new Date(filters.range[0]).getTime() / 1000),
+      );
+      params.set(
+        'end_timestamp',
+        Math.floor(new Date(filters.range[1]).getTime() / 1000),
+      );
+    }
+    return params;
+  };
+
+  const loadLogs = async (nextPage = page, nextPageSize = pageSize) => {
+    setLoading(true);
+    try {
+      const params = buildQuery();
+      params.set('p', nextPage);
+      params.set('page_size', nextPageSize);
+      const res = await API.get(`/api/audit_log/?${params.toString()}`);
+      if (res.data.success) {
+        setLogs(res.data.data.items || []);
+        setTotal(res.data.data.total || 0);
+      } else {
+        showError(res.data.message);
+      }
+    } catch (error) {
+      showError(t('获取审计日志失败'));
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  useEffect(() => {
+    loadLogs(1, pageSize);
+  }, []);
+
+  const search = () => {
+    setPage(1);
+    loadLogs(1, pageSize);
+  };
+
+  const exportLogs = async () => {
+    setExporting(true);
+    try {
+      const res = await API.get(
+        `/api/audit_log/export?${buildQuery().toString()}`,
+        { responseType: 'text' },
+      );
+      downloadTextAsFile(res.data, `audit_logs_${Date.now()}.csv`);
+    } catch (error) {
+      showError(t('导出审计日志失败'));
+    } finally {
+      setExporting(false);
+    }
+  };
+
+  const targetLabel = (value) => {
+    const target = TARGET_TYPES.find((item) => item.value === value);
+    return target ? t(target.label) : value;
+  };
+
+  const columns = [
+    {
+      title: t('时间'),
+      dataIndex: 'created_at',
+      width: 170,
+      render: (value) => timestamp2string(value),
+    },
+    {
+      title: t('操作人'),
+      dataIndex: 'actor_name',
+      width: 140,
+      render: (value, record) => `${value} (#${record.actor_id})`,
+    },
+    { title: 'IP', dataIndex: 'ip', width: 130 },
+    {
+      title: t('对象'),
+      dataIndex: 'target_type',
+      width: 150,
+      render: (value, record) =>
+        record.target_id
+          ? `${targetLabel(value)} #${record.target_id}`
+          : targetLabel(value),
+    },
+    {
+      title: t('动作'),
+      dataIndex: 'action',
+      width: 110,
+      render: (value) => (
+        <Tag color={ACTION_COLORS[value] || 'grey'}>{value}</Tag>
+      ),
+    },
+    { title: t('接口'), dataIndex: 'route', width: 220 },
+    {
+      title: t('变更内容'),
+      dataIndex: 'diff',
+      render: (value) => (
+        <Typography.Paragraph
+          ellipsis={{ rows: 2, expandable: true }}
+          style={{ fontFamily: 'monospace', fontSize: 12, maxWidth: 480 }}
+        >
+          {value}
+        </Typography.Paragraph>
+      ),
+    },
+  ];
+
+  return (
+    <Card>
+      <Form.Section text={t('审计日志')}>
+        <Space wrap style={{ marginBottom: 16 }}>
+          <Input
+            placeholder={t('操作人用户名')}
+            value={filters.actor_name}
+            onChange={(value) => setFilters({ ...filters, actor_name: value })}
+            style={{ width: 160 }}
+          />
+          <Select
+            placeholder={t('对象类型')}
+            value={filters.target_type || undefined}
+            onChange={(value) =>
+              setFilters({ ...filters, target_type: value || '' })
+            }
+            optionList={TARGET_TYPES.map((item) => ({
+              value: item.value,
+              label: t(item.label),
+            }))}
+            showClear
+            style={{ width: 150 }}
+          />
+          <Input
+            placeholder={t('对象 ID')}
+            value={filters.target_id}
+            onChange={(value) => setFilters({ ...filters, target_id: value })}
+            style={{ width: 120 }}
+          />
+          <Select
+            placeholder={t('动作')}
+            value={filters.action || undefined}
+            onChange={(value) =>
+              setFilters({ ...filters, action: value || '' })
+            }
+            optionList={Object.keys(ACTION_COLORS).map((value) => ({
+              value,
+              label: value,
+            }))}
+            showClear
+            style={{ width: 130 }}
+          />
+          <DatePicker
+            type='dateTimeRange'
+            value={filters.range}
+            onChange={(value) => setFilters({ ...filters, range: value || [] })}
+            style={{ width: 360 }}
+          />
+          <Button icon={<IconSearch />} theme='solid' onClick={search}>
+            {t('查询')}
+          </Button>
+          <Button
+            icon={<IconDownload />}
+            loading={exporting}
+            onClick={exportLogs}
+          >
+            {t('导出 CSV')}
+          </Button>
+        </Space>
+        <Table
+          columns={columns}
+          dataSource={logs}
+          loading={loading}
+          rowKey='id'
+          empty={t('暂无审计日志')}
+          pagination={{
+            currentPage: page,
+            pageSize,
+            total,
+            showSizeChanger: true,
+            pageSizeOpts: [20, 50, 100],
+            onPageChange: (nextPage) => {
+              setPage(nextPage);
+              loadLogs(nextPage, pageSize);
+            },
+            onPageSizeChange: (nextPageSize) => {
+              setPageSize(nextPageSize);
+              setPage(1);
+              loadLogs(1, nextPageSize);
+            },
+          }}
+        />
+      </Form.Section>
+    </Card>
+  );
+};
+
+export default AuditLogSetting;
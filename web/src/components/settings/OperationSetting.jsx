@@ -63,6 +63,11 @@ const OperationSetting = () => {
 
     /* 日志设置 */
     LogConsumeEnabled: false,
+    'content_log_setting.enabled': false,
+    'content_log_setting.groups': '[]',
+    'content_log_setting.max_body_kb': 64,
+    'content_log_setting.retention_days': 7,
+    'content_log_setting.redaction_rules': '[]',
 
     /* 监控设置 */
     ChannelDisableThreshold: 0,
@@ -74,7 +79,28 @@ const OperationSetting = () => {
     AutomaticRetryStatusCodes:
       '100-199,300-399,401-407,409-499,500-503,505-523,525-599',
     'monitor_setting.auto_test_channel_enabled': false,
-    'monitor_setting.auto_test_channel_minutes': 10 /* 签到设置 */,
+    'monitor_setting.auto_test_channel_minutes': 10,
+    'channel_health_setting.enabled': false,
+    'channel_health_setting.consecutive_failures': 5,
+    'channel_health_setting.open_success_rate': 0.3,
+    'channel_health_setting.open_seconds': 60,
+    'channel_recovery_setting.enabled': false,
+    'channel_recovery_setting.initial_interval_seconds': 60,
+    'channel_recovery_setting.max_interval_seconds': 3600,
+    'channel_recovery_setting.required_successes': 2,
+    'channel_recovery_setting.event_retention_days': 30,
+    'first_token_setting.timeout_seconds': 0,
+    'first_token_setting.hedge_enabled': false,
+    'first_token_setting.hedge_delay_ms': 3000,
+    'stream_continuation_setting.enabled': false,
+    'stream_continuation_setting.max_continuations': 1,
+    'model_fallback_setting.enabled': false,
+    'model_fallback_setting.group_chains': '{}',
+    'structured_output_setting.enabled': false,
+    'structured_output_setting.max_retries': 1,
+    'structured_output_setting.buffer_stream': false,
+    'structured_output_setting.failure_mode': 'error',
+    'structured_output_setting.repair_prompt': '' /* 签到设置 */,
     'checkin_setting.enabled': false,
     'checkin_setting.min_quota': 1000,
     'checkin_setting.max_quota': 10000,
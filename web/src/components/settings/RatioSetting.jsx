@@ -38,6 +38,7 @@ const RatioSetting = () => {
     CacheRatio: '',
     CreateCacheRatio: '',
     CompletionRatio: '',
+    ModelTieredRatio: '',
     GroupRatio: '',
     GroupGroupRatio: '',
     ImageRatio: '',
@@ -72,6 +72,7 @@ const NotificationSettings = ({
       enabled: true,
       detail: true,
       token: true,
+      organization: true,
       log: true,
       midjourney: true,
       task: true,
@@ -160,6 +161,7 @@ const NotificationSettings = ({
         enabled: true,
         detail: true,
         token: true,
+        organization: true,
         log: true,
         midjourney: true,
         task: true,
@@ -263,6 +265,11 @@ const NotificationSettings = ({
       modules: [
         { key: 'detail', title: t('数据看板'), description: t('系统数据统计') },
         { key: 'token', title: t('令牌管理'), description: t('API令牌管理') },
+        {
+          key: 'organization',
+          title: t('组织管理'),
+          description: t('组织共享额度与成员'),
+        },
         { key: 'log', title: t('使用日志'), description: t('API使用记录') },
         {
           key: 'midjourney',
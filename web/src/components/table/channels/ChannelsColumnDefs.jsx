@@ -45,6 +45,7 @@ import {
   IconAlertTriangle,
 } from '@douyinfe/semi-icons';
 import { FaRandom } from 'react-icons/fa';
+import { openChannelStatusEventsModal } from './modals/ChannelStatusEventsModal';
 
 // Render functions
 const renderType = (type, record = {}, t) => {
@@ -248,6 +249,39 @@ const renderResponseTime = (responseTime, t) => {
   }
 };
 
+const renderHealth = (health, t) => {
+  if (!health) {
+    return (
+      <Tag color='grey' shape='circle'>
+        {t('暂无数据')}
+      </Tag>
+    );
+  }
+  const stateMap = {
+    closed: { color: 'green', label: t('正常') },
+    half_open: { color: 'orange', label: t('探测中') },
+    open: { color: 'red', label: t('已熔断') },
+  };
+  const state = stateMap[health.state] || stateMap.closed;
+  const content = (
+    <div>
+      {(health.models || []).map((item) => (
+        <div key={item.model}>
+          {item.model}: {(stateMap[item.state] || stateMap.closed).label} ·{' '}
+          {item.score}% · {item.latency_ms}ms · {t('样本')} {item.samples}
+        </div>
+      ))}
+    </div>
+  );
+  return (
+    <Tooltip content={content}>
+      <Tag color={state.color} shape='circle'>
+        {state.label} {health.score}%
+      </Tag>
+    </Tooltip>
+  );
+};
+
 const isRequestPassThroughEnabled = (record) => {
   if (!record || record.children !== undefined) {
     return false;
@@ -428,6 +462,13 @@ export const getChannelsColumns = ({
       dataIndex: 'response_time',
       render: (text, record, index) => <div>{renderResponseTime(text, t)}</div>,
     },
+    {
+      key: COLUMN_KEYS.HEALTH,
+      title: t('健康度'),
+      dataIndex: 'health',
+      render: (text, record, index) =>
+        record.children === undefined ? <div>{renderHealth(text, t)}</div> : null,
+    },
     {
       key: COLUMN_KEYS.BALANCE,
       title: t('已用/剩余'),
@@ -620,6 +661,12 @@ export const getChannelsColumns = ({
                 });
               },
             },
+            {
+              node: 'item',
+              name: t('状态记录'),
+              type: 'tertiary',
+              onClick: () => openChannelStatusEventsModal({ t, record }),
+            },
           ];
 
           if (record.type === 4) {
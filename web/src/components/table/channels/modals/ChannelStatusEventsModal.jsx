@@ -0,0 +1,128 @@
+/*
+Copyright (C) 2025 QuantumNous
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as
+published by the Free Software Foundation, either version 3 of the
+License, or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+For commercial licensing, please contact support@quantumnous.com
+*/
+
+import React, { useEffect, useState } from 'react';
+import {
+  Modal,
+  Button,
+  Empty,
+  Spin,
+  Tag,
+  Timeline,
+  Typography,
+} from '@douyinfe/semi-ui';
+import { API, showError, timestamp2string } from '../../../../helpers';
+
+const { Text } = Typography;
+
+const renderTarget = (tt, keyIndex) =>
+  keyIndex >= 0 ? `${tt('密钥')} #${keyIndex}` : tt('渠道');
+
+const ChannelStatusEventsLoader = ({ t, record }) => {
+  const [loading, setLoading] = useState(true);
+  const [events, setEvents] = useState([]);
+  const [recovery, setRecovery] = useState([]);
+
+  useEffect(() => {
+    (async () => {
+      try {
+        const res = await API.get(
+          `/api/channel/${record.id}/status_events?p=1&page_size=50`,
+        );
+        const { success, message, data } = res.data;
+        if (success) {
+          setEvents(data?.events?.items || []);
+          setRecovery(data?.recovery || []);
+        } else {
+          showError(message);
+        }
+      } catch (error) {
+        showError(error.message);
+      } finally {
+        setLoading(false);
+      }
+    })();
+  }, [record.id]);
+
+  return (
+    <Spin spinning={loading}>
+      {recovery.length > 0 && (
+        <div className='mb-4 flex flex-col gap-1'>
+          <Text strong>{t('恢复探测中')}</Text>
+          {recovery.map((state) => (
+            <Text key={state.key_index} size='small' type='tertiary'>
+              {renderTarget(t, state.key_index)} · {t('下次探测')}:{' '}
+              {timestamp2string(state.next_probe_at)} · {t('连续成功')}:{' '}
+              {state.successes} · {t('失败次数')}: {state.failures}
+              {state.last_error ? ` · ${state.last_error}` : ''}
+            </Text>
+          ))}
+        </div>
+      )}
+      {events.length === 0 ? (
+        <Empty description={t('暂无状态记录')} style={{ padding: 30 }} />
+      ) : (
+        <Timeline>
+          {events.map((event) => (
+            <Timeline.Item
+              key={event.id}
+              time={timestamp2string(event.created_at)}
+              type={event.event === 'recovered' ? 'success' : 'error'}
+            >
+              <div className='flex items-center gap-2'>
+                <Tag
+                  size='small'
+                  shape='circle'
+                  color={event.event === 'recovered' ? 'green' : 'red'}
+                >
+                  {event.event === 'recovered' ? t('已恢复') : t('已禁用')}
+                </Tag>
+                <Text>{renderTarget(t, event.key_index)}</Text>
+              </div>
+              {event.reason && (
+                <Text size='small' type='tertiary'>
+                  {event.reason}
+                </Text>
+              )}
+            </Timeline.Item>
+          ))}
+        </Timeline>
+      )}
+    </Spin>
+  );
+};
+
+export const openChannelStatusEventsModal = ({ t, record }) => {
+  const tt = typeof t === 'function' ? t : (v) => v;
+
+  Modal.info({
+    title: `${tt('状态记录')} · ${record.name}`,
+    centered: true,
+    width: 640,
+    style: { maxWidth: '95vw' },
+    content: <ChannelStatusEventsLoader t={tt} record={record} />,
+    footer: (
+      <div className='flex justify-end gap-2'>
+        <Button type='primary' theme='solid' onClick={() => Modal.destroyAll()}>
+          {tt('关闭')}
+        </Button>
+      </div>
+    ),
+  });
+};
@@ -165,6 +165,8 @@ const EditChannelModal = (props) => {
     pass_through_body_enabled: false,
     system_prompt: '',
     system_prompt_override: false,
+    first_token_timeout_seconds: 0,
+    hedge_delay_ms: 0,
     settings: '',
     // 仅 Vertex: 密钥格式（存入 settings.vertex_key_type）
     vertex_key_type: 'json',
@@ -179,6 +181,8 @@ const EditChannelModal = (props) => {
     allow_include_obfuscation: false,
     allow_inference_geo: false,
     claude_beta_query: false,
+    claude_cache_control: '',
+    cost_ratio: '',
   };
   const [batch, setBatch] = useState(false);
   const [multiToSingle, setMultiToSingle] = useState(false);
@@ -636,6 +640,9 @@ const EditChannelModal = (props) => {
           data.system_prompt = parsedSettings.system_prompt || '';
           data.system_prompt_override =
             parsedSettings.system_prompt_override || false;
+          data.first_token_timeout_seconds =
+            parsedSettings.first_token_timeout_seconds || 0;
+          data.hedge_delay_ms = parsedSettings.hedge_delay_ms || 0;
         } catch (error) {
           console.error('解析渠道设置失败:', error);
           data.force_format = false;
@@ -644,6 +651,8 @@ const EditChannelModal = (props) => {
           data.pass_through_body_enabled = false;
           data.system_prompt = '';
           data.system_prompt_override = false;
+          data.first_token_timeout_seconds = 0;
+          data.hedge_delay_ms = 0;
         }
       } else {
         data.force_format = false;
@@ -652,6 +661,8 @@ const EditChannelModal = (props) => {
         data.pass_through_body_enabled = false;
         data.system_prompt = '';
         data.system_prompt_override = false;
+        data.first_token_timeout_seconds = 0;
+        data.hedge_delay_ms = 0;
       }
 
       if (data.settings) {
@@ -676,6 +687,8 @@ const EditChannelModal = (props) => {
           data.allow_inference_geo =
             parsedSettings.allow_inference_geo || false;
           data.claude_beta_query = parsedSettings.claude_beta_query || false;
+          data.claude_cache_control = parsedSettings.claude_cache_control || '';
+          data.cost_ratio = parsedSettings.cost_ratio ?? '';
         } catch (error) {
           console.error('解析其他设置失败:', error);
           data.azure_responses_version = '';
@@ -689,6 +702,8 @@ const EditChannelModal = (props) => {
           data.allow_include_obfuscation = false;
           data.allow_inference_geo = false;
           data.claude_beta_query = false;
+          data.claude_cache_control = '';
+          data.cost_ratio = '';
         }
       } else {
         // 兼容历史数据：老渠道没有 settings 时，默认按 json 展示
@@ -701,6 +716,8 @@ const EditChannelModal = (props) => {
         data.allow_include_obfuscation = false;
         data.allow_inference_geo = false;
         data.claude_beta_query = false;
+        data.claude_cache_control = '';
+        data.cost_ratio = '';
       }
 
       if (
@@ -731,6 +748,8 @@ const EditChannelModal = (props) => {
         pass_through_body_enabled: data.pass_through_body_enabled,
         system_prompt: data.system_prompt,
         system_prompt_override: data.system_prompt_override || false,
+        first_token_timeout_seconds: data.first_token_timeout_seconds || 0,
+        hedge_delay_ms: data.hedge_delay_ms || 0,
       });
       initialModelsRef.current = (data.models || [])
         .map((model) => (model || '').trim())
@@ -1089,6 +1108,8 @@ const EditChannelModal = (props) => {
       pass_through_body_enabled: false,
       system_prompt: '',
       system_prompt_override: false,
+      first_token_timeout_seconds: 0,
+      hedge_delay_ms: 0,
     });
     // 重置密钥模式状态
     setKeyMode('append');
@@ -1454,6 +1475,9 @@ const EditChannelModal = (props) => {
       pass_through_body_enabled: localInputs.pass_through_body_enabled || false,
       system_prompt: localInputs.system_prompt || '',
       system_prompt_override: localInputs.system_prompt_override || false,
+      first_token_timeout_seconds:
+        parseInt(localInputs.first_token_timeout_seconds) || 0,
+      hedge_delay_ms: parseInt(localInputs.hedge_delay_ms) || 0,
     };
     localInputs.setting = JSON.stringify(channelExtraSettings);
 
@@ -1502,6 +1526,24 @@ const EditChannelModal = (props) => {
       }
     }
 
+    // Claude / AWS / Vertex: 自动注入 cache_control 策略，留空跟随全局设置
+    if (
+      [14, 33, 41].includes(localInputs.type) &&
+      localInputs.claude_cache_control
+    ) {
+      settings.claude_cache_control = localInputs.claude_cache_control;
+    } else {
+      delete settings.claude_cache_control;
+    }
+
+    // 上游成本倍率，留空按模型原价估算成本
+    const costRatio = parseFloat(localInputs.cost_ratio);
+    if (!isNaN(costRatio) && costRatio >= 0) {
+      settings.cost_ratio = costRatio;
+    } else {
+      delete settings.cost_ratio;
+    }
+
     localInputs.settings = JSON.stringify(settings);
 
     // 清理不需要发送到后端的字段
@@ -1511,6 +1553,8 @@ const EditChannelModal = (props) => {
     delete localInputs.pass_through_body_enabled;
     delete localInputs.system_prompt;
     delete localInputs.system_prompt_override;
+    delete localInputs.first_token_timeout_seconds;
+    delete localInputs.hedge_delay_ms;
     delete localInputs.is_enterprise_account;
     // 顶层的 vertex_key_type 不应发送给后端
     delete localInputs.vertex_key_type;
@@ -1523,6 +1567,8 @@ const EditChannelModal = (props) => {
     delete localInputs.allow_include_obfuscation;
     delete localInputs.allow_inference_geo;
     delete localInputs.claude_beta_query;
+    delete localInputs.claude_cache_control;
+    delete localInputs.cost_ratio;
 
     let res;
     localInputs.auto_ban = localInputs.auto_ban ? 1 : 0;
@@ -2475,6 +2521,9 @@ const EditChannelModal = (props) => {
                                         optionList={[
                                           { label: t('随机'), value: 'random' },
                                           { label: t('轮询'), value: 'polling' },
+                                          { label: t('最少在途'), value: 'least_in_flight' },
+                                          { label: t('权重'), value: 'weighted' },
+                                          { label: t('限流冷却'), value: 'cooldown' },
                                         ]}
                                         style={{ width: 100 }}
                                       />
@@ -2519,6 +2568,9 @@ const EditChannelModal = (props) => {
                           optionList={[
                             { label: t('随机'), value: 'random' },
                             { label: t('轮询'), value: 'polling' },
+                            { label: t('最少在途'), value: 'least_in_flight' },
+                            { label: t('权重'), value: 'weighted' },
+                            { label: t('限流冷却'), value: 'cooldown' },
                           ]}
                           style={{ width: '100%' }}
                           value={inputs.multi_key_mode || 'random'}
@@ -3529,6 +3581,44 @@ const EditChannelModal = (props) => {
                       />
                     )}
 
+                    {[14, 33, 41].includes(inputs.type) && (
+                      <Form.Select
+                        field='claude_cache_control'
+                        label={t('自动注入 cache_control')}
+                        optionList={[
+                          { label: t('跟随全局设置'), value: '' },
+                          { label: t('关'), value: 'off' },
+                          { label: '5m', value: '5m' },
+                          { label: '1h', value: '1h' },
+                        ]}
+                        style={{ width: '100%' }}
+                        onChange={(value) =>
+                          handleChannelOtherSettingsChange(
+                            'claude_cache_control',
+                            value,
+                          )
+                        }
+                        extraText={t(
+                          'OpenAI 格式请求转换为 Claude 时自动添加缓存断点，缓存写入与命中按缓存倍率计费',
+                        )}
+                      />
+                    )}
+
+                    <Form.InputNumber
+                      field='cost_ratio'
+                      label={t('上游成本倍率')}
+                      placeholder={t('留空按模型原价计算')}
+                      min={0}
+                      step={0.05}
+                      style={{ width: '100%' }}
+                      onChange={(value) =>
+                        handleChannelOtherSettingsChange('cost_ratio', value)
+                      }
+                      extraText={t(
+                        '该渠道实际成本相对模型原价（不含分组倍率）的比例，例如 8 折填 0.8，用于估算上游成本与毛利',
+                      )}
+                    />
+
                     {inputs.type === 1 && (
                       <Form.Switch
                         field='force_format'
@@ -3585,6 +3675,37 @@ const EditChannelModal = (props) => {
                       extraText={t('用于配置网络代理，支持 socks5 协议')}
                     />
 
+                    <Form.InputNumber
+                      field='first_token_timeout_seconds'
+                      label={t('首字超时')}
+                      min={0}
+                      step={1}
+                      suffix={t('秒')}
+                      onChange={(value) =>
+                        handleChannelSettingsChange(
+                          'first_token_timeout_seconds',
+                          value,
+                        )
+                      }
+                      extraText={t(
+                        '流式请求超过该时间仍未返回首个数据块时取消并切换渠道重试，0 表示使用全局设置',
+                      )}
+                    />
+
+                    <Form.InputNumber
+                      field='hedge_delay_ms'
+                      label={t('对冲请求延迟')}
+                      min={0}
+                      step={100}
+                      suffix={t('毫秒')}
+                      onChange={(value) =>
+                        handleChannelSettingsChange('hedge_delay_ms', value)
+                      }
+                      extraText={t(
+                        '流式请求超过该时间仍无输出时再发起一个相同请求，采用先输出的结果，仅对胜出的请求计费；0 表示使用全局设置',
+                      )}
+                    />
+
                     <Form.TextArea
                       field='system_prompt'
                       label={t('系统提示词')}
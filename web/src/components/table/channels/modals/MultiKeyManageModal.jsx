@@ -31,6 +31,7 @@ import {
   Empty,
   Spin,
   Select,
+  InputNumber,
   Row,
   Col,
   Badge,
@@ -70,6 +71,17 @@ const MultiKeyManageModal = ({ visible, onCancel, channel, onRefresh }) => {
   // Filter states
   const [statusFilter, setStatusFilter] = useState(null); // null=all, 1=enabled, 2=manual_disabled, 3=auto_disabled
 
+  // Key selection mode
+  const [multiKeyMode, setMultiKeyMode] = useState('random');
+
+  const multiKeyModeOptions = [
+    { label: t('随机模式'), value: 'random' },
+    { label: t('轮询模式'), value: 'polling' },
+    { label: t('最少在途模式'), value: 'least_in_flight' },
+    { label: t('权重模式'), value: 'weighted' },
+    { label: t('限流冷却模式'), value: 'cooldown' },
+  ];
+
   // Load key status data
   const loadKeyStatus = async (
     page = currentPage,
@@ -273,6 +285,58 @@ const MultiKeyManageModal = ({ visible, onCancel, channel, onRefresh }) => {
     }
   };
 
+  // Change key selection mode
+  const handleModeChange = async (mode) => {
+    setOperationLoading((prev) => ({ ...prev, set_mode: true }));
+
+    try {
+      const res = await API.post('/api/channel/multi_key/manage', {
+        channel_id: channel.id,
+        action: 'set_multi_key_mode',
+        mode: mode,
+      });
+
+      if (res.data.success) {
+        showSuccess(t('多密钥模式已更新'));
+        setMultiKeyMode(mode);
+        onRefresh && onRefresh(); // Refresh parent component
+      } else {
+        showError(res.data.message);
+      }
+    } catch (error) {
+      showError(t('更新多密钥模式失败'));
+    } finally {
+      setOperationLoading((prev) => ({ ...prev, set_mode: false }));
+    }
+  };
+
+  // Update weight of a specific key
+  const handleWeightChange = async (keyIndex, weight) => {
+    if (weight === null || weight === undefined || weight < 0) return;
+    const operationId = `weight_${keyIndex}`;
+    setOperationLoading((prev) => ({ ...prev, [operationId]: true }));
+
+    try {
+      const res = await API.post('/api/channel/multi_key/manage', {
+        channel_id: channel.id,
+        action: 'set_key_weight',
+        key_index: keyIndex,
+        weight: weight,
+      });
+
+      if (res.data.success) {
+        showSuccess(t('权重已更新'));
+        await loadKeyStatus(currentPage, pageSize); // Reload current page
+      } else {
+        showError(res.data.message);
+      }
+    } catch (error) {
+      showError(t('更新权重失败'));
+    } finally {
+      setOperationLoading((prev) => ({ ...prev, [operationId]: false }));
+    }
+  };
+
   // Handle page change
   const handlePageChange = (page) => {
     setCurrentPage(page);
@@ -296,6 +360,7 @@ const MultiKeyManageModal = ({ visible, onCancel, channel, onRefresh }) => {
   // Effect to load data when modal opens
   useEffect(() => {
     if (visible && channel?.id) {
+      setMultiKeyMode(channel?.channel_info?.multi_key_mode || 'random');
       setCurrentPage(1); // Reset to first page when opening
       loadKeyStatus(1, pageSize);
     }
@@ -376,6 +441,55 @@ const MultiKeyManageModal = ({ visible, onCancel, channel, onRefresh }) => {
       dataIndex: 'status',
       render: (status) => renderStatusTag(status),
     },
+    {
+      title: t('权重'),
+      dataIndex: 'weight',
+      render: (weight, record) => (
+        <InputNumber
+          size='small'
+          min={0}
+          precision={0}
+          style={{ width: 80 }}
+          defaultValue={weight}
+          key={`${record.index}_${weight}`}
+          disabled={operationLoading[`weight_${record.index}`]}
+          onBlur={(e) => {
+            const value = parseInt(e.target.value, 10);
+            if (!isNaN(value) && value !== weight) {
+              handleWeightChange(record.index, value);
+            }
+          }}
+        />
+      ),
+    },
+    {
+      title: t('在途请求'),
+      dataIndex: 'in_flight',
+      render: (inFlight) => inFlight || 0,
+    },
+    {
+      title: t('请求 / 失败 / 429'),
+      dataIndex: 'requests',
+      render: (requests, record) => (
+        <Text style={{ fontSize: '12px' }}>
+          {requests || 0} / {record.failures || 0} / {record.rate_limited || 0}
+        </Text>
+      ),
+    },
+    {
+      title: t('冷却至'),
+      dataIndex: 'cooldown_until',
+      render: (time) => {
+        if (!time) {
+          return <Text type='quaternary'>-</Text>;
+        }
+        return (
+          <Tag color='orange' shape='circle' size='small'>
+            {timestamp2string(time)}
+          </Tag>
+        );
+      },
+    },
     {
       title: t('禁用原因'),
       dataIndex: 'reason',
@@ -383,8 +497,23 @@ const MultiKeyManageModal = ({ visible, onCancel, channel, onRefresh }) => {
         if (record.status === 1 || !reason) {
           return <Text type='quaternary'>-</Text>;
         }
+        // Show the previous disable reason when the key was disabled again after recovering
+        const tooltip = record.prev_reason ? (
+          <div>
+            <div>{reason}</div>
+            <div>
+              {t('上次禁用')}
+              {record.prev_disabled_time
+                ? ` (${timestamp2string(record.prev_disabled_time)})`
+                : ''}
+              : {record.prev_reason}
+            </div>
+          </div>
+        ) : (
+          reason
+        );
         return (
-          <Tooltip content={reason}>
+          <Tooltip content={tooltip}>
             <Text style={{ maxWidth: '200px', display: 'block' }} ellipsis>
               {reason}
             </Text>
@@ -465,13 +594,11 @@ const MultiKeyManageModal = ({ visible, onCancel, channel, onRefresh }) => {
           <Tag size='small' shape='circle' color='white'>
             {t('总密钥数')}: {total}
           </Tag>
-          {channel?.channel_info?.multi_key_mode && (
-            <Tag size='small' shape='circle' color='white'>
-              {channel.channel_info.multi_key_mode === 'random'
-                ? t('随机模式')
-                : t('轮询模式')}
-            </Tag>
-          )}
+          <Tag size='small' shape='circle' color='white'>
+            {multiKeyModeOptions.find(
+              (option) => option.value === multiKeyMode,
+            )?.label || t('随机模式')}
+          </Tag>
         </Space>
       }
       visible={visible}
@@ -624,6 +751,16 @@ const MultiKeyManageModal = ({ visible, onCancel, channel, onRefresh }) => {
                             </Select.Option>
                           </Select>
                         </Col>
+                        <Col>
+                          <Select
+                            value={multiKeyMode}
+                            onChange={handleModeChange}
+                            optionList={multiKeyModeOptions}
+                            size='small'
+                            loading={operationLoading.set_mode}
+                            style={{ width: 150 }}
+                          />
+                        </Col>
                       </Row>
                     </Col>
                     <Col
@@ -220,6 +220,16 @@ export const getPricingTableColumns = ({
           <div className='text-gray-700'>
             {t('分组倍率')}：{priceData?.usedGroupRatio ?? '-'}
           </div>
+          {record.quota_type === 0 &&
+            (record.price_tiers || []).map((tier) => (
+              <div key={tier.threshold} className='text-orange-600'>
+                {t('输入超过 {{threshold}} tokens', {
+                  threshold: tier.threshold,
+                })}
+                ：{t('模型倍率')} {tier.model_ratio} / {t('补全倍率')}{' '}
+                {tier.completion_ratio || completionRatio}
+              </div>
+            ))}
         </div>
       );
     },
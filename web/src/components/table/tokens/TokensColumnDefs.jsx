@@ -40,12 +40,7 @@ import {
   getModelCategories,
   showError,
 } from '../../../helpers';
-import {
-  IconTreeTriangleDown,
-  IconCopy,
-  IconEyeOpened,
-  IconEyeClosed,
-} from '@douyinfe/semi-icons';
+import { IconTreeTriangleDown } from '@douyinfe/semi-icons';
 
 // progress color helper
 const getProgressColor = (pct) => {
@@ -107,46 +102,15 @@ const renderGroupColumn = (text, record, t) => {
   return renderGroup(text);
 };
 
-// Render token key column with show/hide and copy functionality
-const renderTokenKey = (text, record, showKeys, setShowKeys, copyText) => {
-  const fullKey = 'sk-' + record.key;
-  const maskedKey =
-    'sk-' + record.key.slice(0, 4) + '**********' + record.key.slice(-4);
-  const revealed = !!showKeys[record.id];
+// Render token key column; the full key is only returned once at creation
+const renderTokenKey = (text, record, t) => {
+  const maskedKey = 'sk-' + (record.key_prefix || '') + '**********';
 
   return (
     <div className='w-[200px]'>
-      <Input
-        readOnly
-        value={revealed ? fullKey : maskedKey}
-        size='small'
-        suffix={
-          <div className='flex items-center'>
-            <Button
-              theme='borderless'
-              size='small'
-              type='tertiary'
-              icon={revealed ? <IconEyeClosed /> : <IconEyeOpened />}
-              aria-label='toggle token visibility'
-              onClick={(e) => {
-                e.stopPropagation();
-                setShowKeys((prev) => ({ ...prev, [record.id]: !revealed }));
-              }}
-            />
-            <Button
-              theme='borderless'
-              size='small'
-              type='tertiary'
-              icon={<IconCopy />}
-              aria-label='copy token key'
-              onClick={async (e) => {
-                e.stopPropagation();
-                await copyText(fullKey);
-              }}
-            />
-          </div>
-        }
-      />
+      <Tooltip content={t('完整密钥仅在创建时显示一次')} position='top'>
+        <Input readOnly value={maskedKey} size='small' />
+      </Tooltip>
     </div>
   );
 };
@@ -426,9 +390,6 @@ const renderOperations = (
 
 export const getTokensColumns = ({
   t,
-  showKeys,
-  setShowKeys,
-  copyText,
   manageToken,
   onOpenLink,
   setEditingToken,
@@ -460,8 +421,7 @@ export const getTokensColumns = ({
     {
       title: t('密钥'),
       key: 'token_key',
-      render: (text, record) =>
-        renderTokenKey(text, record, showKeys, setShowKeys, copyText),
+      render: (text, record) => renderTokenKey(text, record, t),
     },
     {
       title: t('可用模型'),
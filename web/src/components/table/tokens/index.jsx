@@ -38,6 +38,7 @@ import TokensActions from './TokensActions';
 import TokensFilters from './TokensFilters';
 import TokensDescription from './TokensDescription';
 import EditTokenModal from './modals/EditTokenModal';
+import CreatedTokensModal from './modals/CreatedTokensModal';
 import { useTokensData } from '../../../hooks/tokens/useTokensData';
 import { useIsMobile } from '../../../hooks/common/useIsMobile';
 import { createCardProPagination } from '../../../helpers/utils';
@@ -60,6 +61,7 @@ function TokensPage() {
   const [selectedModel, setSelectedModel] = useState('');
   const [fluentNoticeOpen, setFluentNoticeOpen] = useState(false);
   const [prefillKey, setPrefillKey] = useState('');
+  const [createdTokens, setCreatedTokens] = useState([]);
 
   // Keep latest data for handlers inside notifications
   useEffect(() => {
@@ -227,7 +229,12 @@ function TokensPage() {
         Toast.warning(t('没有可用令牌用于填充'));
         return;
       }
-      apiKeyToUse = 'sk-' + token.key;
+      // The token list only carries the public key prefix
+      if (token.key) {
+        apiKeyToUse = 'sk-' + token.key;
+      } else {
+        Toast.info(t('完整密钥仅在创建时显示，请在打开的应用中手动填写密钥'));
+      }
     }
 
     const payload = {
@@ -335,7 +342,6 @@ function TokensPage() {
     selectedKeys,
     setEditingToken,
     setShowEdit,
-    batchCopyTokens,
     batchDeleteTokens,
     copyText,
 
@@ -361,6 +367,15 @@ function TokensPage() {
         editingToken={editingToken}
         visiable={showEdit}
         handleClose={closeEdit}
+        onCreated={setCreatedTokens}
+      />
+
+      <CreatedTokensModal
+        visible={createdTokens.length > 0}
+        tokens={createdTokens}
+        onClose={() => setCreatedTokens([])}
+        copyText={copyText}
+        t={t}
       />
 
       <CardPro
@@ -378,9 +393,7 @@ function TokensPage() {
               selectedKeys={selectedKeys}
               setEditingToken={setEditingToken}
               setShowEdit={setShowEdit}
-              batchCopyTokens={batchCopyTokens}
               batchDeleteTokens={batchDeleteTokens}
-              copyText={copyText}
               t={t}
             />
 
@@ -0,0 +1,86 @@
+/*
+Copyright (C) 2025 QuantumNous
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as
+published by the Free Software Foundation, either version 3 of the
+License, or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+For commercial licensing, please contact support@quantumnous.com
+*/
+
+import React from 'react';
+import { Modal, Button, Banner, Input, Space } from '@douyinfe/semi-ui';
+import { IconCopy } from '@douyinfe/semi-icons';
+
+// Shows the full keys of newly created tokens; they cannot be retrieved again
+const CreatedTokensModal = ({ visible, tokens, onClose, copyText, t }) => {
+  const handleCopyAll = async () => {
+    let content = '';
+    for (let i = 0; i < tokens.length; i++) {
+      content += tokens[i].name + '    sk-' + tokens[i].key + '\n';
+    }
+    await copyText(content);
+  };
+
+  return (
+    <Modal
+      title={t('请保存您的密钥')}
+      visible={visible}
+      onCancel={onClose}
+      maskClosable={false}
+      footer={
+        <Space>
+          {tokens.length > 1 && (
+            <Button type='tertiary' onClick={handleCopyAll}>
+              {t('名称+密钥')}
+            </Button>
+          )}
+          <Button theme='solid' type='primary' onClick={onClose}>
+            {t('我已保存')}
+          </Button>
+        </Space>
+      }
+    >
+      <Banner
+        type='warning'
+        closeIcon={null}
+        description={t(
+          '完整密钥只显示这一次，关闭后将无法再次查看，请立即复制并妥善保存',
+        )}
+        className='!mb-3'
+      />
+      <div className='flex flex-col gap-2'>
+        {tokens.map((token) => (
+          <div key={token.id}>
+            <div className='text-sm mb-1'>{token.name}</div>
+            <Input
+              readOnly
+              value={'sk-' + token.key}
+              suffix={
+                <Button
+                  theme='borderless'
+                  size='small'
+                  type='tertiary'
+                  icon={<IconCopy />}
+                  aria-label='copy token key'
+                  onClick={() => copyText('sk-' + token.key)}
+                />
+              }
+            />
+          </div>
+        ))}
+      </div>
+    </Modal>
+  );
+};
+
+export default CreatedTokensModal;
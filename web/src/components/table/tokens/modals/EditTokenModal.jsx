@@ -29,6 +29,7 @@ import {
   renderQuotaWithPrompt,
   getModelCategories,
   selectFilter,
+  verifyJSON,
 } from '../../../../helpers';
 import { useIsMobile } from '../../../../hooks/common/useIsMobile';
 import {
@@ -64,6 +65,7 @@ const EditTokenModal = (props) => {
   const formApiRef = useRef(null);
   const [models, setModels] = useState([]);
   const [groups, setGroups] = useState([]);
+  const [orgs, setOrgs] = useState([]);
   const isEdit = props.editingToken.id !== undefined;
 
   const getInitValues = () => ({
@@ -76,6 +78,15 @@ const EditTokenModal = (props) => {
     allow_ips: '',
     group: '',
     cross_group_retry: false,
+    org_id: 0,
+    rpm_limit: 0,
+    tpm_limit: 0,
+    concurrency_limit: 0,
+    content_log_enabled: false,
+    model_fallbacks: '',
+    budget_period: 'never',
+    budget_quota: 0,
+    budget_carry_over_cap: 0,
     tokenCount: 1,
   });
 
@@ -151,6 +162,17 @@ const EditTokenModal = (props) => {
     }
   };
 
+  const loadOrgs = async () => {
+    const res = await API.get('/api/organization/self');
+    if (res.data.success) {
+      setOrgs(
+        (res.data.data || [])
+          .filter((org) => org.status === 1)
+          .map((org) => ({ label: org.name, value: org.id })),
+      );
+    }
+  };
+
   const loadToken = async () => {
     setLoading(true);
     let res = await API.get(`/api/token/${props.editingToken.id}`);
@@ -181,6 +203,7 @@ const EditTokenModal = (props) => {
     }
     loadModels();
     loadGroups();
+    loadOrgs();
   }, [props.editingToken.id]);
 
   useEffect(() => {
@@ -208,10 +231,21 @@ const EditTokenModal = (props) => {
   };
 
   const submit = async (values) => {
+    if (values.model_fallbacks?.trim() && !verifyJSON(values.model_fallbacks)) {
+      showError(t('模型降级链不是合法的 JSON 字符串'));
+      return;
+    }
     setLoading(true);
     if (isEdit) {
       let { tokenCount: _tc, ...localInputs } = values;
       localInputs.remain_quota = parseInt(localInputs.remain_quota);
+      localInputs.rpm_limit = parseInt(localInputs.rpm_limit) || 0;
+      localInputs.tpm_limit = parseInt(localInputs.tpm_limit) || 0;
+      localInputs.concurrency_limit =
+        parseInt(localInputs.concurrency_limit) || 0;
+      localInputs.budget_quota = parseInt(localInputs.budget_quota) || 0;
+      localInputs.budget_carry_over_cap =
+        parseInt(localInputs.budget_carry_over_cap) || 0;
       if (localInputs.expired_time !== -1) {
         let time = Date.parse(localInputs.expired_time);
         if (isNaN(time)) {
@@ -237,7 +271,7 @@ const EditTokenModal = (props) => {
       }
     } else {
       const count = parseInt(values.tokenCount, 10) || 1;
-      let successCount = 0;
+      const createdTokens = [];
       for (let i = 0; i < count; i++) {
         let { tokenCount: _tc, ...localInputs } = values;
         const baseName =
@@ -248,6 +282,13 @@ const EditTokenModal = (props) => {
           localInputs.name = baseName;
         }
         localInputs.remain_quota = parseInt(localInputs.remain_quota);
+        localInputs.rpm_limit = parseInt(localInputs.rpm_limit) || 0;
+        localInputs.tpm_limit = parseInt(localInputs.tpm_limit) || 0;
+        localInputs.concurrency_limit =
+          parseInt(localInputs.concurrency_limit) || 0;
+        localInputs.budget_quota = parseInt(localInputs.budget_quota) || 0;
+        localInputs.budget_carry_over_cap =
+          parseInt(localInputs.budget_carry_over_cap) || 0;
 
         if (localInputs.expired_time !== -1) {
           let time = Date.parse(localInputs.expired_time);
@@ -261,18 +302,19 @@ const EditTokenModal = (props) => {
         localInputs.model_limits = localInputs.model_limits.join(',');
         localInputs.model_limits_enabled = localInputs.model_limits.length > 0;
         let res = await API.post(`/api/token/`, localInputs);
-        const { success, message } = res.data;
+        const { success, message, data } = res.data;
         if (success) {
-          successCount++;
+          createdTokens.push(data);
         } else {
           showError(t(message));
           break;
         }
       }
-      if (successCount > 0) {
-        showSuccess(t('令牌创建成功，请在列表页面点击复制获取令牌！'));
+      if (createdTokens.length > 0) {
+        showSuccess(t('令牌创建成功，请立即复制并保存密钥！'));
         props.refresh();
         props.handleClose();
+        props.onCreated?.(createdTokens);
       }
     }
     setLoading(false);
@@ -395,6 +437,21 @@ const EditTokenModal = (props) => {
                       )}
                     />
                   </Col>
+                  {orgs.length > 0 && (
+                    <Col span={24}>
+                      <Form.Select
+                        field='org_id'
+                        label={t('所属组织')}
+                        optionList={[
+                          { label: t('个人令牌'), value: 0 },
+                          ...orgs,
+                        ]}
+                        disabled={isEdit}
+                        extraText={t('组织令牌从组织钱包扣费，创建后不可更改')}
+                        style={{ width: '100%' }}
+                      />
+                    </Col>
+                  )}
                   <Col xs={24} sm={24} md={24} lg={10} xl={10}>
                     <Form.DatePicker
                       field='expired_time'
@@ -523,6 +580,51 @@ const EditTokenModal = (props) => {
                       )}
                     />
                   </Col>
+                  <Col span={24}>
+                    <Form.Select
+                      field='budget_period'
+                      label={t('周期预算')}
+                      optionList={[
+                        { value: 'never', label: t('不启用') },
+                        { value: 'daily', label: t('每天') },
+                        { value: 'weekly', label: t('每周') },
+                        { value: 'monthly', label: t('每月') },
+                      ]}
+                      extraText={t(
+                        '启用后令牌额度按周期自动重置为预算额度，用尽后返回 429 直到下次重置',
+                      )}
+                      style={{ width: '100%' }}
+                    />
+                  </Col>
+                  {values.budget_period && values.budget_period !== 'never' && (
+                    <>
+                      <Col xs={24} sm={12}>
+                        <Form.InputNumber
+                          field='budget_quota'
+                          label={t('每周期预算额度')}
+                          min={1}
+                          step={500000}
+                          extraText={renderQuotaWithPrompt(values.budget_quota)}
+                          rules={[
+                            { required: true, message: t('请输入预算额度') },
+                          ]}
+                          style={{ width: '100%' }}
+                        />
+                      </Col>
+                      <Col xs={24} sm={12}>
+                        <Form.InputNumber
+                          field='budget_carry_over_cap'
+                          label={t('结转上限')}
+                          min={0}
+                          step={500000}
+                          extraText={t(
+                            '未用完的额度最多结转到下一周期的数量，0 表示不结转',
+                          )}
+                          style={{ width: '100%' }}
+                        />
+                      </Col>
+                    </>
+                  )}
                 </Row>
               </Card>
 
@@ -561,6 +663,20 @@ const EditTokenModal = (props) => {
                       style={{ width: '100%' }}
                     />
                   </Col>
+                  <Col span={24}>
+                    <Form.TextArea
+                      field='model_fallbacks'
+                      label={t('模型降级链')}
+                      placeholder={'{"gpt-4o": ["claude-sonnet-4-5"]}'}
+                      autosize
+                      rows={1}
+                      extraText={t(
+                        '主模型不可用时依次改用的备选模型，需管理员开启模型降级链，留空则使用分组配置',
+                      )}
+                      showClear
+                      style={{ width: '100%' }}
+                    />
+                  </Col>
                   <Col span={24}>
                     <Form.TextArea
                       field='allow_ips'
@@ -575,6 +691,46 @@ const EditTokenModal = (props) => {
                       style={{ width: '100%' }}
                     />
                   </Col>
+                  <Col xs={24} sm={8}>
+                    <Form.InputNumber
+                      field='rpm_limit'
+                      label={t('每分钟请求数')}
+                      min={0}
+                      step={1}
+                      extraText={t('0 表示不限制')}
+                      style={{ width: '100%' }}
+                    />
+                  </Col>
+                  <Col xs={24} sm={8}>
+                    <Form.InputNumber
+                      field='tpm_limit'
+                      label={t('每分钟 Token 数')}
+                      min={0}
+                      step={1000}
+                      extraText={t('按请求实际用量累计，0 表示不限制')}
+                      style={{ width: '100%' }}
+                    />
+                  </Col>
+                  <Col xs={24} sm={8}>
+                    <Form.InputNumber
+                      field='concurrency_limit'
+                      label={t('最大并发请求数')}
+                      min={0}
+                      step={1}
+                      extraText={t('0 表示不限制')}
+                      style={{ width: '100%' }}
+                    />
+                  </Col>
+                  <Col span={24}>
+                    <Form.Switch
+                      field='content_log_enabled'
+                      label={t('记录请求内容')}
+                      size='default'
+                      extraText={t(
+                        '需要管理员开启请求内容日志，开启后该令牌的请求体与响应体会被保存以便排查问题',
+                      )}
+                    />
+                  </Col>
                 </Row>
               </Card>
             </div>
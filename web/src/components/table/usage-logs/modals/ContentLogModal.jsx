@@ -0,0 +1,172 @@
+/*
+Copyright (C) 2025 QuantumNous
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as
+published by the Free Software Foundation, either version 3 of the
+License, or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+For commercial licensing, please contact support@quantumnous.com
+*/
+
+import React, { useEffect, useRef, useState } from 'react';
+import {
+  Modal,
+  Descriptions,
+  Spin,
+  Typography,
+  Tag,
+  Divider,
+} from '@douyinfe/semi-ui';
+import { API, showError, timestamp2string } from '../../../../helpers';
+
+const { Text } = Typography;
+
+// JSON 响应体格式化展示，SSE 等非 JSON 内容原样展示
+function formatBody(body) {
+  if (!body) return '';
+  try {
+    return JSON.stringify(JSON.parse(body), null, 2);
+  } catch (e) {
+    return body;
+  }
+}
+
+const bodyStyle = {
+  maxHeight: 320,
+  overflow: 'auto',
+  padding: 12,
+  margin: '8px 0 0',
+  borderRadius: 6,
+  background: 'var(--semi-color-fill-0)',
+  fontSize: 12,
+  whiteSpace: 'pre-wrap',
+  wordBreak: 'break-all',
+};
+
+const ContentLogModal = ({
+  t,
+  showContentLogModal,
+  setShowContentLogModal,
+  contentLogRequestId,
+}) => {
+  const [loading, setLoading] = useState(false);
+  const [logs, setLogs] = useState([]);
+  const requestSeqRef = useRef(0);
+
+  useEffect(() => {
+    if (!showContentLogModal || !contentLogRequestId) {
+      requestSeqRef.current += 1; // invalidate inflight request
+      setLoading(false);
+      setLogs([]);
+      return;
+    }
+
+    const reqSeq = (requestSeqRef.current += 1);
+    setLogs([]);
+    setLoading(true);
+    (async () => {
+      try {
+        const res = await API.get(
+          `/api/log/content/${encodeURIComponent(contentLogRequestId)}`,
+          { disableDuplicate: true },
+        );
+        if (reqSeq !== requestSeqRef.current) return;
+        const { success, message, data } = res.data || {};
+        if (!success) {
+          showError(t(message || '请求失败'));
+          return;
+        }
+        setLogs(Array.isArray(data) ? data : []);
+      } catch (e) {
+        if (reqSeq !== requestSeqRef.current) return;
+        showError(t('请求失败'));
+      } finally {
+        if (reqSeq !== requestSeqRef.current) return;
+        setLoading(false);
+      }
+    })();
+  }, [showContentLogModal, contentLogRequestId, t]);
+
+  const renderBody = (title, body, truncated) => (
+    <div style={{ marginTop: 12 }}>
+      <Text strong>{title}</Text>
+      {truncated && (
+        <Tag color='orange' size='small' style={{ marginLeft: 8 }}>
+          {t('已截断')}
+        </Tag>
+      )}
+      <pre style={bodyStyle}>{formatBody(body) || '-'}</pre>
+    </div>
+  );
+
+  return (
+    <Modal
+      title={t('请求内容')}
+      visible={showContentLogModal}
+      onCancel={() => setShowContentLogModal(false)}
+      footer={null}
+      centered
+      closable
+      maskClosable
+      width={800}
+    >
+      <div style={{ padding: 16 }}>
+        <Spin spinning={loading} tip={t('加载中...')}>
+          {logs.length > 0 ? (
+            logs.map((log, index) => (
+              <div key={log.id}>
+                {index > 0 && <Divider margin={16} />}
+                <Descriptions
+                  data={[
+                    { key: t('时间'), value: timestamp2string(log.created_at) },
+                    { key: t('模型'), value: log.model_name || '-' },
+                    { key: t('渠道'), value: log.channel_id || '-' },
+                    { key: t('状态码'), value: log.status_code || '-' },
+                    {
+                      key: t('流式'),
+                      value: log.is_stream ? t('是') : t('否'),
+                    },
+                  ]}
+                />
+                {log.error_message && (
+                  <div style={{ marginTop: 12 }}>
+                    <Text type='danger'>{log.error_message}</Text>
+                  </div>
+                )}
+                {renderBody(
+                  t('请求体'),
+                  log.request_body,
+                  log.request_truncated,
+                )}
+                {renderBody(
+                  t('响应体'),
+                  log.response_body,
+                  log.response_truncated,
+                )}
+              </div>
+            ))
+          ) : (
+            <div style={{ padding: '24px 0' }}>
+              <Text type='tertiary' size='small'>
+                {loading
+                  ? t('加载中...')
+                  : t('该请求没有记录内容，或内容已超过保留期限被清理')}
+              </Text>
+            </div>
+          )}
+        </Spin>
+      </div>
+    </Modal>
+  );
+};
+
+export default ContentLogModal;
@@ -76,6 +76,7 @@ import {
   Server,
   CalendarClock,
   Bot,
+  Building2,
 } from 'lucide-react';
 import {
   SiAtlassian,
@@ -125,6 +126,8 @@ export function getLucideIcon(key, selected = false) {
       return <MessageSquare {...commonProps} color={iconColor} />;
     case 'token':
       return <Key {...commonProps} color={iconColor} />;
+    case 'organization':
+      return <Building2 {...commonProps} color={iconColor} />;
     case 'log':
       return <BarChart3 {...commonProps} color={iconColor} />;
     case 'midjourney':
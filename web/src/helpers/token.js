@@ -21,6 +21,7 @@ import { API } from './api';
 
 /**
  * 获取可用的token keys
+ * 令牌列表不返回完整密钥，已迁移为哈希存储的令牌对应项为空字符串
  * @returns {Promise<string[]>} 返回active状态的token key数组
  */
 export async function fetchTokenKeys() {
@@ -31,7 +32,7 @@ export async function fetchTokenKeys() {
 
     const tokenItems = Array.isArray(data) ? data : data.items || [];
     const activeTokens = tokenItems.filter((token) => token.status === 1);
-    return activeTokens.map((token) => token.key);
+    return activeTokens.map((token) => token.key || '');
   } catch (error) {
     console.error('Error fetching token keys:', error);
     return [];
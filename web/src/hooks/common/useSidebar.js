@@ -35,6 +35,7 @@ export const DEFAULT_ADMIN_CONFIG = {
     enabled: true,
     detail: true,
     token: true,
+    organization: true,
     log: true,
     midjourney: true,
     task: true,
@@ -90,6 +91,8 @@ export const mergeAdminConfig = (savedConfig) => {
 export const useSidebar = () => {
   const [statusState] = useContext(StatusContext);
   const [userConfig, setUserConfig] = useState(null);
+  // Modules hidden by the server according to the user's admin permissions
+  const [permissionConfig, setPermissionConfig] = useState(null);
   const [loading, setLoading] = useState(true);
   const instanceIdRef = useRef(null);
   const hasLoadedOnceRef = useRef(false);
@@ -125,6 +128,11 @@ export const useSidebar = () => {
       }
 
       const res = await API.get('/api/user/self');
+      if (res.data.success) {
+        setPermissionConfig(
+          res.data.data.permissions?.sidebar_modules || null,
+        );
+      }
       if (res.data.success && res.data.data.sidebar_modules) {
         let config;
         // 检查sidebar_modules是字符串还是对象
@@ -241,8 +249,10 @@ export const useSidebar = () => {
       const adminSection = adminConfig[sectionKey];
       const userSection = userConfig[sectionKey];
 
+      const permissionSection = permissionConfig?.[sectionKey];
+
       // 如果管理员禁用了整个区域，则该区域不显示
-      if (!adminSection?.enabled) {
+      if (!adminSection?.enabled || permissionSection === false) {
         result[sectionKey] = { enabled: false };
         return;
       }
@@ -262,13 +272,15 @@ export const useSidebar = () => {
           ? userSection[moduleKey] !== false
           : true;
 
+        const permitted = permissionSection?.[moduleKey] !== false;
+
         result[sectionKey][moduleKey] =
-          adminAllowed && userAllowed && sectionEnabled;
+          adminAllowed && userAllowed && sectionEnabled && permitted;
       });
     });
 
     return result;
-  }, [adminConfig, userConfig]);
+  }, [adminConfig, userConfig, permissionConfig]);
 
   // 检查特定功能是否应该显示
   const isModuleVisible = (sectionKey, moduleKey = null) => {
@@ -24,6 +24,7 @@ import {
   API,
   copy,
   showError,
+  showInfo,
   showSuccess,
   encodeToBase64,
 } from '../../helpers';
@@ -85,7 +86,6 @@ export const useTokensData = (openFluentNotification) => {
 
   // UI state
   const [compactMode, setCompactMode] = useTableCompactMode('tokens');
-  const [showKeys, setShowKeys] = useState({});
 
   // Form state
   const [formApi, setFormApi] = useState(null);
@@ -180,11 +180,16 @@ export const useTokensData = (openFluentNotification) => {
     if (serverAddress === '') {
       serverAddress = window.location.origin;
     }
+    // The token list only carries the public key prefix
+    const apiKey = record.key ? 'sk-' + record.key : '';
+    if (!apiKey) {
+      showInfo(t('完整密钥仅在创建时显示，请在打开的应用中手动填写密钥'));
+    }
     if (url.includes('{cherryConfig}') === true) {
       let cherryConfig = {
         id: 'new-api',
         baseUrl: serverAddress,
-        apiKey: 'sk-' + record.key,
+        apiKey: apiKey,
       };
       let encodedConfig = encodeURIComponent(
         encodeToBase64(JSON.stringify(cherryConfig)),
@@ -193,7 +198,7 @@ export const useTokensData = (openFluentNotification) => {
     } else {
       let encodedServerAddress = encodeURIComponent(serverAddress);
       url = url.replaceAll('{address}', encodedServerAddress);
-      url = url.replaceAll('{key}', 'sk-' + record.key);
+      url = url.replaceAll('{key}', apiKey);
     }
 
     window.open(url, '_blank');
@@ -342,51 +347,6 @@ export const useTokensData = (openFluentNotification) => {
     }
   };
 
-  // Batch copy tokens
-  const batchCopyTokens = (copyType) => {
-    if (selectedKeys.length === 0) {
-      showError(t('请至少选择一个令牌！'));
-      return;
-    }
-
-    Modal.info({
-      title: t('复制令牌'),
-      icon: null,
-      content: t('请选择你的复制方式'),
-      footer: (
-        <div className='flex gap-2'>
-          <button
-            className='px-3 py-1 bg-gray-200 rounded'
-            onClick={async () => {
-              let content = '';
-              for (let i = 0; i < selectedKeys.length; i++) {
-                content +=
-                  selectedKeys[i].name + '    sk-' + selectedKeys[i].key + '\n';
-              }
-              await copyText(content);
-              Modal.destroyAll();
-            }}
-          >
-            {t('名称+密钥')}
-          </button>
-          <button
-            className='px-3 py-1 bg-blue-500 text-white rounded'
-            onClick={async () => {
-              let content = '';
-              for (let i = 0; i < selectedKeys.length; i++) {
-                content += 'sk-' + selectedKeys[i].key + '\n';
-              }
-              await copyText(content);
-              Modal.destroyAll();
-            }}
-          >
-            {t('仅密钥')}
-          </button>
-        </div>
-      ),
-    });
-  };
-
   // Initialize data
   useEffect(() => {
     loadTokens(1, pageSize, { silent: !!initialCacheRef.current })
@@ -419,8 +379,6 @@ export const useTokensData = (openFluentNotification) => {
     // UI state
     compactMode,
     setCompactMode,
-    showKeys,
-    setShowKeys,
 
     // Form state
     formApi,
@@ -441,7 +399,6 @@ export const useTokensData = (openFluentNotification) => {
     rowSelection,
     handleRow,
     batchDeleteTokens,
-    batchCopyTokens,
     syncPageData,
 
     // Translation
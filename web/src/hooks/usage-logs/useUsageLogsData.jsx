@@ -19,7 +19,7 @@ For commercial licensing, please contact support@quantumnous.com
 
 import { useState, useEffect } from 'react';
 import { useTranslation } from 'react-i18next';
-import { Modal } from '@douyinfe/semi-ui';
+import { Button, Modal } from '@douyinfe/semi-ui';
 import {
   API,
   getTodayStartTimestamp,
@@ -121,6 +121,10 @@ export const useLogsData = () => {
   const [channelAffinityUsageCacheTarget, setChannelAffinityUsageCacheTarget] =
     useState(null);
 
+  // Request content log modal state (admin only)
+  const [showContentLogModal, setShowContentLogModal] = useState(false);
+  const [contentLogRequestId, setContentLogRequestId] = useState('');
+
   // Load saved column preferences from localStorage
   useEffect(() => {
     const savedColumns = localStorage.getItem(STORAGE_KEY);
@@ -325,6 +329,11 @@ export const useLogsData = () => {
     setShowChannelAffinityUsageCacheModal(true);
   };
 
+  const openContentLogModal = (requestId) => {
+    setContentLogRequestId(requestId || '');
+    setShowContentLogModal(true);
+  };
+
   // Format logs data
   const setLogsFormat = (logs) => {
     const requestConversionDisplayValue = (conversionChain) => {
@@ -351,9 +360,24 @@ export const useLogsData = () => {
         });
       }
       if (logs[i].request_id) {
+        const requestId = logs[i].request_id;
         expandDataLocal.push({
           key: t('Request ID'),
-          value: logs[i].request_id,
+          value: isAdminUser ? (
+            <span>
+              {requestId}
+              <Button
+                theme='borderless'
+                size='small'
+                style={{ marginLeft: 8 }}
+                onClick={() => openContentLogModal(requestId)}
+              >
+                {t('查看请求内容')}
+              </Button>
+            </span>
+          ) : (
+            requestId
+          ),
         });
       }
       if (other?.ws || other?.audio) {
@@ -450,6 +474,26 @@ export const useLogsData = () => {
             value: other.upstream_model_name,
           });
         }
+        if (other?.fallback_from) {
+          expandDataLocal.push({
+            key: t('降级前模型'),
+            value: other.fallback_from,
+          });
+        }
+        if (other?.price_tier_threshold) {
+          expandDataLocal.push({
+            key: t('长上下文计费档位'),
+            value: t('输入超过 {{threshold}} tokens', {
+              threshold: other.price_tier_threshold,
+            }),
+          });
+        }
+        if (isAdminUser && other?.upstream_cost !== undefined) {
+          expandDataLocal.push({
+            key: t('上游成本'),
+            value: renderQuota(other.upstream_cost, 6),
+          });
+        }
 
         const isViolationFeeLog =
           other?.violation_fee === true ||
@@ -785,6 +829,12 @@ export const useLogsData = () => {
     channelAffinityUsageCacheTarget,
     openChannelAffinityUsageCacheModal,
 
+    // Request content log modal
+    showContentLogModal,
+    setShowContentLogModal,
+    contentLogRequestId,
+    openContentLogModal,
+
     // Functions
     loadLogs,
     handlePageChange,
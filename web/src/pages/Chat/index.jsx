@@ -17,11 +17,12 @@ along with this program. If not, see <https://www.gnu.org/licenses/>.
 For commercial licensing, please contact support@quantumnous.com
 */
 
-import React from 'react';
+import React, { useEffect } from 'react';
 import { useTokenKeys } from '../../hooks/chat/useTokenKeys';
 import { Spin } from '@douyinfe/semi-ui';
 import { useParams } from 'react-router-dom';
 import { useTranslation } from 'react-i18next';
+import { showInfo } from '../../helpers';
 
 const ChatPage = () => {
   const { t } = useTranslation();
@@ -30,7 +31,7 @@ const ChatPage = () => {
 
   const comLink = (key) => {
     // console.log('chatLink:', chatLink);
-    if (!serverAddress || !key) return '';
+    if (!serverAddress) return '';
     let link = '';
     if (id) {
       let chats = localStorage.getItem('chats');
@@ -43,7 +44,7 @@ const ChatPage = () => {
               '{address}',
               encodeURIComponent(serverAddress),
             );
-            link = link.replaceAll('{key}', 'sk-' + key);
+            link = link.replaceAll('{key}', key ? 'sk-' + key : '');
           }
         }
       }
@@ -53,6 +54,13 @@ const ChatPage = () => {
 
   const iframeSrc = keys.length > 0 ? comLink(keys[0]) : '';
 
+  // The token list only carries the public key prefix, so the key has to be entered in the chat app
+  useEffect(() => {
+    if (!isLoading && iframeSrc && !keys[0]) {
+      showInfo(t('完整密钥仅在创建时显示，请在打开的应用中手动填写密钥'));
+    }
+  }, [isLoading, iframeSrc]);
+
   return !isLoading && iframeSrc ? (
     <iframe
       src={iframeSrc}
@@ -46,6 +46,12 @@ const CLAUDE_DEFAULT_MAX_TOKENS = {
   'claude-3-7-sonnet-20250219-thinking': 8192,
 };
 
+const CLAUDE_CACHE_CONTROL_TTL = {
+  default: '5m',
+  'claude-opus-4-5-20251101': '1h',
+  'claude-3-haiku-20240307': 'off',
+};
+
 export default function SettingClaudeModel(props) {
   const { t } = useTranslation();
 
@@ -55,6 +61,9 @@ export default function SettingClaudeModel(props) {
     'claude.thinking_adapter_enabled': true,
     'claude.default_max_tokens': '',
     'claude.thinking_adapter_budget_tokens_percentage': 0.8,
+    'claude.auto_cache_control_enabled': false,
+    'claude.auto_cache_control_ttl': '',
+    'claude.auto_cache_control_min_tokens': 1024,
   });
   const refForm = useRef();
   const [inputsRow, setInputsRow] = useState(inputs);
@@ -214,6 +223,71 @@ export default function SettingClaudeModel(props) {
               </Col>
             </Row>
 
+            <Row>
+              <Col span={16}>
+                <Form.Switch
+                  label={t('自动注入 cache_control')}
+                  field={'claude.auto_cache_control_enabled'}
+                  extraText={t(
+                    'OpenAI 格式请求转换为 Claude 时，为工具定义、系统提示词和对话前缀自动添加缓存断点，渠道设置优先',
+                  )}
+                  onChange={(value) =>
+                    setInputs({
+                      ...inputs,
+                      'claude.auto_cache_control_enabled': value,
+                    })
+                  }
+                />
+              </Col>
+            </Row>
+            <Row>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.TextArea
+                  label={t('模型缓存时长')}
+                  field={'claude.auto_cache_control_ttl'}
+                  placeholder={
+                    t('为一个 JSON 文本，例如：') +
+                    '\n' +
+                    JSON.stringify(CLAUDE_CACHE_CONTROL_TTL, null, 2)
+                  }
+                  extraText={t(
+                    '可选值 5m、1h、off，default 为未单独配置的模型',
+                  )}
+                  autosize={{ minRows: 6, maxRows: 12 }}
+                  trigger='blur'
+                  stopValidateWithError
+                  rules={[
+                    {
+                      validator: (rule, value) => verifyJSON(value),
+                      message: t('不是合法的 JSON 字符串'),
+                    },
+                  ]}
+                  onChange={(value) =>
+                    setInputs({
+                      ...inputs,
+                      'claude.auto_cache_control_ttl': value,
+                    })
+                  }
+                />
+              </Col>
+            </Row>
+            <Row>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.InputNumber
+                  label={t('缓存断点最小 Token 数')}
+                  field={'claude.auto_cache_control_min_tokens'}
+                  extraText={t('断点之前的内容少于该值时不注入')}
+                  min={0}
+                  onChange={(value) =>
+                    setInputs({
+                      ...inputs,
+                      'claude.auto_cache_control_min_tokens': value,
+                    })
+                  }
+                />
+              </Col>
+            </Row>
+
             <Row>
               <Button size='default' onClick={onSubmit}>
                 {t('保存')}
@@ -40,12 +40,40 @@ import {
 
 const { Text } = Typography;
 
+// 以 JSON 数组保存、按行编辑的配置项
+const LIST_KEYS = [
+  'content_log_setting.groups',
+  'content_log_setting.redaction_rules',
+];
+
+const listToLines = (value) => {
+  try {
+    const parsed = JSON.parse(value || '[]');
+    return Array.isArray(parsed) ? parsed.join('\n') : '';
+  } catch (e) {
+    return '';
+  }
+};
+
+const linesToList = (text) =>
+  JSON.stringify(
+    (text || '')
+      .split('\n')
+      .map((s) => s.trim())
+      .filter((s) => s.length > 0),
+  );
+
 export default function SettingsLog(props) {
   const { t } = useTranslation();
   const [loading, setLoading] = useState(false);
   const [loadingCleanHistoryLog, setLoadingCleanHistoryLog] = useState(false);
   const [inputs, setInputs] = useState({
     LogConsumeEnabled: false,
+    'content_log_setting.enabled': false,
+    'content_log_setting.groups': '',
+    'content_log_setting.max_body_kb': 64,
+    'content_log_setting.retention_days': 7,
+    'content_log_setting.redaction_rules': '',
     historyTimestamp: dayjs().subtract(1, 'month').toDate(),
   });
   const refForm = useRef();
@@ -61,6 +89,8 @@ export default function SettingsLog(props) {
       let value = '';
       if (typeof inputs[item.key] === 'boolean') {
         value = String(inputs[item.key]);
+      } else if (LIST_KEYS.includes(item.key)) {
+        value = linesToList(inputs[item.key]);
       } else {
         value = inputs[item.key];
       }
@@ -183,7 +213,9 @@ export default function SettingsLog(props) {
     const currentInputs = {};
     for (let key in props.options) {
       if (Object.keys(inputs).includes(key)) {
-        currentInputs[key] = props.options[key];
+        currentInputs[key] = LIST_KEYS.includes(key)
+          ? listToLines(props.options[key])
+          : props.options[key];
       }
     }
     currentInputs['historyTimestamp'] = inputs.historyTimestamp;
@@ -248,6 +280,88 @@ export default function SettingsLog(props) {
               </Col>
             </Row>
 
+            <Row gutter={16}>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.Switch
+                  field={'content_log_setting.enabled'}
+                  label={t('启用请求内容日志')}
+                  extraText={t(
+                    '记录开启了内容日志的令牌或指定分组的请求体与响应体，仅管理员可查看',
+                  )}
+                  size='default'
+                  checkedText='｜'
+                  uncheckedText='〇'
+                  onChange={(value) => {
+                    setInputs({
+                      ...inputs,
+                      'content_log_setting.enabled': value,
+                    });
+                  }}
+                />
+              </Col>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.InputNumber
+                  field={'content_log_setting.max_body_kb'}
+                  label={t('单条内容最大记录大小')}
+                  extraText={t('请求体与响应体分别计算，超出部分截断')}
+                  suffix='KB'
+                  min={1}
+                  onChange={(value) => {
+                    setInputs({
+                      ...inputs,
+                      'content_log_setting.max_body_kb': value,
+                    });
+                  }}
+                />
+              </Col>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.InputNumber
+                  field={'content_log_setting.retention_days'}
+                  label={t('内容日志保留天数')}
+                  extraText={t('0 表示不自动清理')}
+                  min={0}
+                  onChange={(value) => {
+                    setInputs({
+                      ...inputs,
+                      'content_log_setting.retention_days': value,
+                    });
+                  }}
+                />
+              </Col>
+            </Row>
+            <Row gutter={16}>
+              <Col xs={24} sm={12}>
+                <Form.TextArea
+                  field={'content_log_setting.groups'}
+                  label={t('记录内容的分组')}
+                  extraText={t('一行一个分组，这些分组的所有请求都会记录内容')}
+                  autosize={{ minRows: 3, maxRows: 8 }}
+                  onChange={(value) => {
+                    setInputs({
+                      ...inputs,
+                      'content_log_setting.groups': value,
+                    });
+                  }}
+                />
+              </Col>
+              <Col xs={24} sm={12}>
+                <Form.TextArea
+                  field={'content_log_setting.redaction_rules'}
+                  label={t('脱敏规则')}
+                  extraText={t(
+                    '一行一个正则表达式，匹配的内容会被替换为 [REDACTED]',
+                  )}
+                  autosize={{ minRows: 3, maxRows: 8 }}
+                  onChange={(value) => {
+                    setInputs({
+                      ...inputs,
+                      'content_log_setting.redaction_rules': value,
+                    });
+                  }}
+                />
+              </Col>
+            </Row>
+
             <Row>
               <Button size='default' onClick={onSubmit}>
                 {t('保存日志设置')}
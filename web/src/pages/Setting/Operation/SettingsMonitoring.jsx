@@ -26,6 +26,7 @@ import {
   showSuccess,
   showWarning,
   parseHttpStatusCodeRules,
+  verifyJSON,
 } from '../../../helpers';
 import { useTranslation } from 'react-i18next';
 import HttpStatusCodeRulesInput from '../../../components/settings/HttpStatusCodeRulesInput';
@@ -44,6 +45,27 @@ export default function SettingsMonitoring(props) {
       '100-199,300-399,401-407,409-499,500-503,505-523,525-599',
     'monitor_setting.auto_test_channel_enabled': false,
     'monitor_setting.auto_test_channel_minutes': 10,
+    'channel_health_setting.enabled': false,
+    'channel_health_setting.consecutive_failures': 5,
+    'channel_health_setting.open_success_rate': 0.3,
+    'channel_health_setting.open_seconds': 60,
+    'channel_recovery_setting.enabled': false,
+    'channel_recovery_setting.initial_interval_seconds': 60,
+    'channel_recovery_setting.max_interval_seconds': 3600,
+    'channel_recovery_setting.required_successes': 2,
+    'channel_recovery_setting.event_retention_days': 30,
+    'first_token_setting.timeout_seconds': 0,
+    'first_token_setting.hedge_enabled': false,
+    'first_token_setting.hedge_delay_ms': 3000,
+    'stream_continuation_setting.enabled': false,
+    'stream_continuation_setting.max_continuations': 1,
+    'model_fallback_setting.enabled': false,
+    'model_fallback_setting.group_chains': '',
+    'structured_output_setting.enabled': false,
+    'structured_output_setting.max_retries': 1,
+    'structured_output_setting.buffer_stream': false,
+    'structured_output_setting.failure_mode': 'error',
+    'structured_output_setting.repair_prompt': '',
   });
   const refForm = useRef();
   const [inputsRow, setInputsRow] = useState(inputs);
@@ -73,6 +95,10 @@ export default function SettingsMonitoring(props) {
           : '';
       return showError(`${t('自动重试状态码格式不正确')}${details}`);
     }
+    const groupChains = inputs['model_fallback_setting.group_chains'];
+    if (groupChains?.trim() && !verifyJSON(groupChains)) {
+      return showError(t('模型降级链不是合法的 JSON 字符串'));
+    }
     const requestQueue = updateArray.map((item) => {
       let value = '';
       if (typeof inputs[item.key] === 'boolean') {
@@ -235,6 +261,370 @@ export default function SettingsMonitoring(props) {
                 />
               </Col>
             </Row>
+            <Row gutter={16}>
+              <Col xs={24} sm={12} md={6} lg={6} xl={6}>
+                <Form.Switch
+                  field={'channel_health_setting.enabled'}
+                  label={t('按健康度自适应选择渠道')}
+                  size='default'
+                  checkedText='｜'
+                  uncheckedText='〇'
+                  extraText={t(
+                    '根据渠道在各模型上的滚动成功率与延迟调整权重，并临时熔断异常渠道',
+                  )}
+                  onChange={(value) =>
+                    setInputs({
+                      ...inputs,
+                      'channel_health_setting.enabled': value,
+                    })
+                  }
+                />
+              </Col>
+              <Col xs={24} sm={12} md={6} lg={6} xl={6}>
+                <Form.InputNumber
+                  label={t('连续失败熔断次数')}
+                  step={1}
+                  min={0}
+                  extraText={t('为 0 时不按连续失败熔断')}
+                  field={'channel_health_setting.consecutive_failures'}
+                  onChange={(value) =>
+                    setInputs({
+                      ...inputs,
+                      'channel_health_setting.consecutive_failures':
+                        parseInt(value),
+                    })
+                  }
+                />
+              </Col>
+              <Col xs={24} sm={12} md={6} lg={6} xl={6}>
+                <Form.InputNumber
+                  label={t('熔断成功率阈值')}
+                  step={0.05}
+                  min={0}
+                  max={1}
+                  extraText={t('滚动成功率低于该值时熔断')}
+                  field={'channel_health_setting.open_success_rate'}
+                  onChange={(value) =>
+                    setInputs({
+                      ...inputs,
+                      'channel_health_setting.open_success_rate': value,
+                    })
+                  }
+                />
+              </Col>
+              <Col xs={24} sm={12} md={6} lg={6} xl={6}>
+                <Form.InputNumber
+                  label={t('熔断时长')}
+                  step={1}
+                  min={1}
+                  suffix={t('秒')}
+                  extraText={t('到期后放行单个探测请求，成功则恢复')}
+                  field={'channel_health_setting.open_seconds'}
+                  onChange={(value) =>
+                    setInputs({
+                      ...inputs,
+                      'channel_health_setting.open_seconds': parseInt(value),
+                    })
+                  }
+                />
+              </Col>
+            </Row>
+            <Row gutter={16}>
+              <Col xs={24} sm={12} md={6} lg={6} xl={6}>
+                <Form.Switch
+                  field={'channel_recovery_setting.enabled'}
+                  label={t('自动恢复探测')}
+                  size='default'
+                  checkedText='｜'
+                  uncheckedText='〇'
+                  extraText={t(
+                    '按指数退避单独测试自动禁用的渠道与密钥，连续成功后重新启用',
+                  )}
+                  onChange={(value) =>
+                    setInputs({
+                      ...inputs,
+                      'channel_recovery_setting.enabled': value,
+                    })
+                  }
+                />
+              </Col>
+              <Col xs={24} sm={12} md={6} lg={6} xl={6}>
+                <Form.InputNumber
+                  label={t('首次探测间隔')}
+                  step={10}
+                  min={10}
+                  suffix={t('秒')}
+                  extraText={t('探测失败后间隔翻倍')}
+                  field={'channel_recovery_setting.initial_interval_seconds'}
+                  onChange={(value) =>
+                    setInputs({
+                      ...inputs,
+                      'channel_recovery_setting.initial_interval_seconds':
+                        parseInt(value),
+                    })
+                  }
+                />
+              </Col>
+              <Col xs={24} sm={12} md={6} lg={6} xl={6}>
+                <Form.InputNumber
+                  label={t('最长探测间隔')}
+                  step={60}
+                  min={10}
+                  suffix={t('秒')}
+                  field={'channel_recovery_setting.max_interval_seconds'}
+                  onChange={(value) =>
+                    setInputs({
+                      ...inputs,
+                      'channel_recovery_setting.max_interval_seconds':
+                        parseInt(value),
+                    })
+                  }
+                />
+              </Col>
+              <Col xs={24} sm={12} md={6} lg={6} xl={6}>
+                <Form.InputNumber
+                  label={t('恢复所需连续成功次数')}
+                  step={1}
+                  min={1}
+                  field={'channel_recovery_setting.required_successes'}
+                  onChange={(value) =>
+                    setInputs({
+                      ...inputs,
+                      'channel_recovery_setting.required_successes':
+                        parseInt(value),
+                    })
+                  }
+                />
+              </Col>
+              <Col xs={24} sm={12} md={6} lg={6} xl={6}>
+                <Form.InputNumber
+                  label={t('禁用记录保留天数')}
+                  step={1}
+                  min={0}
+                  suffix={t('天')}
+                  extraText={t(
+                    '超过天数的渠道禁用/恢复记录会被自动清理，0 表示不清理',
+                  )}
+                  field={'channel_recovery_setting.event_retention_days'}
+                  onChange={(value) =>
+                    setInputs({
+                      ...inputs,
+                      'channel_recovery_setting.event_retention_days':
+                        parseInt(value),
+                    })
+                  }
+                />
+              </Col>
+            </Row>
+            <Row gutter={16}>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.InputNumber
+                  label={t('流式首字超时')}
+                  step={1}
+                  min={0}
+                  suffix={t('秒')}
+                  extraText={t(
+                    '流式请求超过该时间仍未返回首个数据块时取消并切换渠道重试，0 表示不限制',
+                  )}
+                  field={'first_token_setting.timeout_seconds'}
+                  onChange={(value) =>
+                    setInputs({
+                      ...inputs,
+                      'first_token_setting.timeout_seconds': parseInt(value),
+                    })
+                  }
+                />
+              </Col>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.Switch
+                  field={'first_token_setting.hedge_enabled'}
+                  label={t('流式对冲请求')}
+                  size='default'
+                  checkedText='｜'
+                  uncheckedText='〇'
+                  extraText={t(
+                    '首个请求迟迟没有输出时再发起一个相同请求，采用先输出的结果，仅对胜出的请求计费',
+                  )}
+                  onChange={(value) =>
+                    setInputs({
+                      ...inputs,
+                      'first_token_setting.hedge_enabled': value,
+                    })
+                  }
+                />
+              </Col>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.InputNumber
+                  label={t('对冲请求延迟')}
+                  step={100}
+                  min={0}
+                  suffix={t('毫秒')}
+                  field={'first_token_setting.hedge_delay_ms'}
+                  onChange={(value) =>
+                    setInputs({
+                      ...inputs,
+                      'first_token_setting.hedge_delay_ms': parseInt(value),
+                    })
+                  }
+                />
+              </Col>
+            </Row>
+            <Row gutter={16}>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.Switch
+                  field={'stream_continuation_setting.enabled'}
+                  label={t('流式中断续写')}
+                  size='default'
+                  checkedText='｜'
+                  uncheckedText='〇'
+                  extraText={t(
+                    '流式输出中途上游中断时，将已输出内容作为预填充切换到同类型渠道继续生成',
+                  )}
+                  onChange={(value) =>
+                    setInputs({
+                      ...inputs,
+                      'stream_continuation_setting.enabled': value,
+                    })
+                  }
+                />
+              </Col>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.InputNumber
+                  label={t('最大续写次数')}
+                  step={1}
+                  min={1}
+                  field={'stream_continuation_setting.max_continuations'}
+                  onChange={(value) =>
+                    setInputs({
+                      ...inputs,
+                      'stream_continuation_setting.max_continuations':
+                        parseInt(value),
+                    })
+                  }
+                />
+              </Col>
+            </Row>
+            <Row gutter={16}>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.Switch
+                  field={'model_fallback_setting.enabled'}
+                  label={t('模型降级链')}
+                  size='default'
+                  checkedText='｜'
+                  uncheckedText='〇'
+                  extraText={t(
+                    '主模型的渠道全部失败或限流时依次改用备选模型重试，按实际使用的模型计费',
+                  )}
+                  onChange={(value) =>
+                    setInputs({
+                      ...inputs,
+                      'model_fallback_setting.enabled': value,
+                    })
+                  }
+                />
+              </Col>
+              <Col xs={24} sm={12} md={16} lg={16} xl={16}>
+                <Form.TextArea
+                  label={t('分组模型降级链')}
+                  placeholder={
+                    '{"default": {"gpt-4o": ["claude-sonnet-4-5", "gemini-2.5-pro"]}}'
+                  }
+                  extraText={t(
+                    '分组 -> 主模型 -> 备选模型列表，分组填 * 时对所有分组生效；令牌上配置的降级链优先',
+                  )}
+                  field={'model_fallback_setting.group_chains'}
+                  autosize={{ minRows: 3, maxRows: 10 }}
+                  onChange={(value) =>
+                    setInputs({
+                      ...inputs,
+                      'model_fallback_setting.group_chains': value,
+                    })
+                  }
+                />
+              </Col>
+            </Row>
+            <Row gutter={16}>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.Switch
+                  field={'structured_output_setting.enabled'}
+                  label={t('结构化输出校验')}
+                  size='default'
+                  checkedText='｜'
+                  uncheckedText='〇'
+                  extraText={t(
+                    '请求指定 json_schema 时按 schema 校验模型输出，不合规时附带修复提示重试',
+                  )}
+                  onChange={(value) =>
+                    setInputs({
+                      ...inputs,
+                      'structured_output_setting.enabled': value,
+                    })
+                  }
+                />
+              </Col>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.InputNumber
+                  label={t('校验失败最大重试次数')}
+                  step={1}
+                  min={0}
+                  field={'structured_output_setting.max_retries'}
+                  onChange={(value) =>
+                    setInputs({
+                      ...inputs,
+                      'structured_output_setting.max_retries': parseInt(value),
+                    })
+                  }
+                />
+              </Col>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.Switch
+                  field={'structured_output_setting.buffer_stream'}
+                  label={t('校验流式请求')}
+                  size='default'
+                  checkedText='｜'
+                  uncheckedText='〇'
+                  extraText={t('流式请求将缓冲完整输出，校验通过后再一次性返回')}
+                  onChange={(value) =>
+                    setInputs({
+                      ...inputs,
+                      'structured_output_setting.buffer_stream': value,
+                    })
+                  }
+                />
+              </Col>
+            </Row>
+            <Row gutter={16}>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.Select
+                  field={'structured_output_setting.failure_mode'}
+                  label={t('重试用尽后')}
+                  optionList={[
+                    { label: t('返回错误'), value: 'error' },
+                    { label: t('返回最后一次输出'), value: 'passthrough' },
+                  ]}
+                  onChange={(value) =>
+                    setInputs({
+                      ...inputs,
+                      'structured_output_setting.failure_mode': value,
+                    })
+                  }
+                />
+              </Col>
+              <Col xs={24} sm={12} md={16} lg={16} xl={16}>
+                <Form.TextArea
+                  label={t('修复提示词')}
+                  extraText={t('%s 将替换为校验错误信息')}
+                  field={'structured_output_setting.repair_prompt'}
+                  autosize={{ minRows: 2, maxRows: 6 }}
+                  onChange={(value) =>
+                    setInputs({
+                      ...inputs,
+                      'structured_output_setting.repair_prompt': value,
+                    })
+                  }
+                />
+              </Col>
+            </Row>
             <Row gutter={16}>
               <Col xs={24} sm={16}>
                 <HttpStatusCodeRulesInput
@@ -45,6 +45,7 @@ export default function ModelRatioSettings(props) {
     CacheRatio: '',
     CreateCacheRatio: '',
     CompletionRatio: '',
+    ModelTieredRatio: '',
     ImageRatio: '',
     AudioRatio: '',
     AudioCompletionRatio: '',
@@ -247,6 +248,32 @@ export default function ModelRatioSettings(props) {
             />
           </Col>
         </Row>
+        <Row gutter={16}>
+          <Col xs={24} sm={16}>
+            <Form.TextArea
+              label={t('长上下文阶梯价格')}
+              extraText={t(
+                '输入 token 数（含缓存）超过阈值时整次请求改用该档位的模型倍率；补全、缓存倍率未填写时沿用基础倍率',
+              )}
+              placeholder={
+                '{"gemini-2.5-pro": [{"threshold": 200000, "model_ratio": 1.25, "completion_ratio": 6}]}'
+              }
+              field={'ModelTieredRatio'}
+              autosize={{ minRows: 6, maxRows: 12 }}
+              trigger='blur'
+              stopValidateWithError
+              rules={[
+                {
+                  validator: (rule, value) => verifyJSON(value),
+                  message: '不是合法的 JSON 字符串',
+                },
+              ]}
+              onChange={(value) =>
+                setInputs({ ...inputs, ModelTieredRatio: value })
+              }
+            />
+          </Col>
+        </Row>
         <Row gutter={16}>
           <Col xs={24} sm={16}>
             <Form.TextArea
@@ -34,6 +34,8 @@ import {
   CreditCard,
   Server,
   Activity,
+  ShieldCheck,
+  ScrollText,
 } from 'lucide-react';
 
 import SystemSetting from '../../components/settings/SystemSetting';
@@ -49,6 +51,8 @@ import DrawingSetting from '../../components/settings/DrawingSetting';
 import PaymentSetting from '../../components/settings/PaymentSetting';
 import ModelDeploymentSetting from '../../components/settings/ModelDeploymentSetting';
 import PerformanceSetting from '../../components/settings/PerformanceSetting';
+import AdminRoleSetting from '../../components/settings/AdminRoleSetting';
+import AuditLogSetting from '../../components/settings/AuditLogSetting';
 
 const Setting = () => {
   const { t } = useTranslation();
@@ -168,6 +172,26 @@ const Setting = () => {
       content: <SystemSetting />,
       itemKey: 'system',
     });
+    panes.push({
+      tab: (
+        <span style={{ display: 'flex', alignItems: 'center', gap: '5px' }}>
+          <ShieldCheck size={18} />
+          {t('管理角色')}
+        </span>
+      ),
+      content: <AdminRoleSetting />,
+      itemKey: 'admin-role',
+    });
+    panes.push({
+      tab: (
+        <span style={{ display: 'flex', alignItems: 'center', gap: '5px' }}>
+          <ScrollText size={18} />
+          {t('审计日志')}
+        </span>
+      ),
+      content: <AuditLogSetting />,
+      itemKey: 'audit-log',
+    });
     panes.push({
       tab: (
         <span style={{ display: 'flex', alignItems: 'center', gap: '5px' }}>